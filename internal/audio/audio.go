@@ -0,0 +1,126 @@
+// Package audio detects audio formats and reads the technical metadata and
+// embedded tags of MP3, WAV and FLAC files without decoding them.
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+)
+
+// Supported audio content types
+const (
+	TypeMP3  = "audio/mpeg"
+	TypeWAV  = "audio/wav"
+	TypeFLAC = "audio/flac"
+)
+
+// sniffLen is the number of leading bytes needed to recognise a format
+const sniffLen = 12
+
+// Parser errors
+var (
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	ErrMalformed         = errors.New("malformed audio file")
+)
+
+// Metadata describes an audio file
+type Metadata struct {
+	ContentType string
+	Duration    float64 // Seconds
+	Bitrate     int     // Bits per second, averaged over the file for VBR audio
+	SampleRate  int     // Hz
+	Channels    int
+
+	// Embedded tags, empty when the file has none
+	Title string
+	Genre string
+
+	layout layout
+}
+
+// layout records where a file's encoded audio sits, for cutting excerpts
+type layout struct {
+	audioStart int64 // First byte of audio frames or samples
+	audioEnd   int64 // Just past the last, before any trailing tag
+
+	header     []byte // Raw WAV "fmt " chunk or FLAC STREAMINFO block body
+	blockAlign int    // WAV bytes per sample frame
+}
+
+// DurationSeconds returns the duration rounded to whole seconds
+func (m *Metadata) DurationSeconds() int {
+	return int(math.Round(m.Duration))
+}
+
+// DetectType identifies MP3, WAV and FLAC audio from a file's leading bytes.
+// It returns an empty string for anything else.
+func DetectType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return TypeMP3 // ID3v2 tag ahead of the MPEG frames
+	case isFrameSync(header):
+		return TypeMP3
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return TypeWAV
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return TypeFLAC
+	default:
+		return ""
+	}
+}
+
+// Sniff detects the type of the audio in r from its leading bytes
+func Sniff(r io.ReaderAt) (string, error) {
+	header := make([]byte, sniffLen)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return DetectType(header[:n]), nil
+}
+
+// Parse detects the format of the size bytes in r and reads their metadata.
+// It returns ErrUnsupportedFormat for anything but MP3, WAV and FLAC, and
+// ErrMalformed when the headers are inconsistent or truncated.
+func Parse(r io.ReaderAt, size int64) (*Metadata, error) {
+	contentType, err := Sniff(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta *Metadata
+	switch contentType {
+	case TypeMP3:
+		meta, err = parseMP3(r, size)
+	case TypeWAV:
+		meta, err = parseWAV(r, size)
+	case TypeFLAC:
+		meta, err = parseFLAC(r, size)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meta.ContentType = contentType
+	if meta.Duration <= 0 || meta.SampleRate <= 0 || meta.Channels <= 0 {
+		return nil, ErrMalformed
+	}
+	return meta, nil
+}
+
+// readAt reads exactly n bytes at off, reporting truncation as ErrMalformed
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := r.ReadAt(buf, off)
+	if read == n {
+		return buf, nil
+	}
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrMalformed
+	}
+	return nil, err
+}
@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDetectType(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"id3v2 tag", []byte("ID3\x03\x00\x00\x00\x00\x00\x00"), TypeMP3},
+		{"mpeg frame", []byte{0xFF, 0xFB, 0x90, 0x00}, TypeMP3},
+		{"mpeg 2.5 frame", []byte{0xFF, 0xE3, 0x90, 0x00}, TypeMP3},
+		{"reserved mpeg version", []byte{0xFF, 0xEB, 0x90, 0x00}, ""},
+		{"layer II frame", []byte{0xFF, 0xFD, 0x90, 0x00}, ""},
+		{"wav", []byte("RIFF\x00\x00\x00\x00WAVE"), TypeWAV},
+		{"riff without wave", []byte("RIFF\x00\x00\x00\x00AVI "), ""},
+		{"riff cut short", []byte("RIFF\x00\x00\x00\x00WAV"), ""},
+		{"flac", []byte("fLaC"), TypeFLAC},
+		{"ogg", []byte("OggS\x00\x02"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectType(tt.header); got != tt.want {
+				t.Errorf("DetectType = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	for _, file := range [][]byte{nil, []byte("OggS\x00\x02"), []byte("not audio at all")} {
+		if _, err := Parse(bytes.NewReader(file), int64(len(file))); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Parse(%q): got %v, want ErrUnsupportedFormat", file, err)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	info := streamInfo(44100, 2, 16, 44100)
+	f.Add(mp3Frames(3))
+	f.Add(concat(id3v2Tag(-1, id3v2Text("TIT2", "Night Drive"), id3v2Text("TCON", "(17)")), mp3Frames(3), id3v1Tag("Night Drive", 35)))
+	f.Add(concat(xingFrame("Xing", 100, true), mp3Frames(2)))
+	f.Add(concat(vbriFrame(100), mp3Frames(2)))
+	f.Add(wavFile(riffChunk("fmt ", -1, wavFormat(2, 44100, 16)), riffChunk("LIST", -1, wavInfo("Night Drive", "House")), riffChunk("data", -1, make([]byte, 64))))
+	f.Add(flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, flacVorbisComment, -1, flacComments("test", "TITLE=Night Drive")), make([]byte, 64)))
+
+	f.Fuzz(func(t *testing.T, file []byte) {
+		size := int64(len(file))
+		meta, err := Parse(bytes.NewReader(file), size)
+		if err != nil {
+			if !errors.Is(err, ErrMalformed) && !errors.Is(err, ErrUnsupportedFormat) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+		if meta.Duration <= 0 || meta.SampleRate <= 0 || meta.Channels <= 0 || meta.Bitrate < 0 {
+			t.Fatalf("implausible metadata: %+v", meta)
+		}
+		if l := meta.layout; l.audioStart < 0 || l.audioStart > l.audioEnd || l.audioEnd > size {
+			t.Fatalf("audio at [%d, %d) outside a %d byte file", l.audioStart, l.audioEnd, size)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Clip is the opening of an audio file cut into a playable file of the same
+// format. The source's tags are replaced by a single comment, which can
+// identify who the clip was made for; it is metadata only, the audio is
+// copied unchanged. Only the new headers are held in memory; the audio is
+// read from the source as the clip is read.
+type Clip struct {
+	ContentType string
+	Duration    float64 // Seconds
+	Size        int64
+
+	header []byte
+	source io.ReaderAt
+	offset int64 // Where the clip's audio starts in source
+}
+
+// Excerpt cuts the first seconds of the size bytes of audio in r into a Clip
+// carrying comment. Files shorter than seconds are returned whole. MP3 and
+// FLAC are cut at the average bitrate, so the length is approximate; players
+// drop the partial frame at the end. Errors are those of Parse.
+func Excerpt(r io.ReaderAt, size int64, seconds float64, comment string) (*Clip, error) {
+	meta, err := Parse(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if seconds > meta.Duration || seconds <= 0 {
+		seconds = meta.Duration
+	}
+	l := meta.layout
+	length := int64(float64(l.audioEnd-l.audioStart) * seconds / meta.Duration)
+
+	var header []byte
+	switch meta.ContentType {
+	case TypeMP3:
+		header = id3Comment(comment)
+	case TypeWAV:
+		length -= length % int64(l.blockAlign)
+		header = wavHeader(l.header, length, comment)
+	case TypeFLAC:
+		header = flacHeader(l.header, comment)
+	}
+
+	return &Clip{
+		ContentType: meta.ContentType,
+		Duration:    seconds,
+		Size:        int64(len(header)) + length,
+		header:      header,
+		source:      r,
+		offset:      l.audioStart,
+	}, nil
+}
+
+// ReadAt reads the clip's bytes starting at off
+func (c *Clip) ReadAt(p []byte, off int64) (int, error) {
+	if off >= c.Size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.Size-off {
+		p = p[:c.Size-off]
+	}
+
+	n := 0
+	if off < int64(len(c.header)) {
+		n = copy(p, c.header[off:])
+	}
+	if n < len(p) {
+		m, err := c.source.ReadAt(p[n:], c.offset+off+int64(n)-int64(len(c.header)))
+		n += m
+		if err != nil && (err != io.EOF || n < len(p)) {
+			return n, err
+		}
+	}
+
+	if off+int64(n) == c.Size {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Reader returns the clip as a seekable stream, for serving byte ranges
+func (c *Clip) Reader() io.ReadSeeker {
+	return io.NewSectionReader(c, 0, c.Size)
+}
+
+// id3Comment builds an ID3v2.4 tag holding a single UTF-8 COMM frame
+func id3Comment(comment string) []byte {
+	var frame bytes.Buffer
+	frame.WriteByte(0x03) // UTF-8
+	frame.WriteString("eng")
+	frame.WriteByte(0x00) // Empty description
+	frame.WriteString(comment)
+
+	var tag bytes.Buffer
+	tag.WriteString("COMM")
+	tag.Write(encodeSynchsafe(uint32(frame.Len())))
+	tag.Write([]byte{0x00, 0x00}) // Frame flags
+	tag.Write(frame.Bytes())
+
+	header := []byte{'I', 'D', '3', 0x04, 0x00, 0x00}
+	return append(append(header, encodeSynchsafe(uint32(tag.Len()))...), tag.Bytes()...)
+}
+
+// encodeSynchsafe encodes a 28 bit synchsafe integer
+func encodeSynchsafe(n uint32) []byte {
+	return []byte{byte(n>>21) & 0x7F, byte(n>>14) & 0x7F, byte(n>>7) & 0x7F, byte(n) & 0x7F}
+}
+
+// wavHeader builds the RIFF header of a WAV file with the given "fmt " chunk
+// body, a LIST/INFO chunk holding the comment (ICMT) and a data chunk of dataSize bytes
+func wavHeader(format []byte, dataSize int64, comment string) []byte {
+	var chunks bytes.Buffer
+	writeRIFFChunk(&chunks, "fmt ", format)
+
+	var info bytes.Buffer
+	info.WriteString("INFO")
+	writeRIFFChunk(&info, "ICMT", append([]byte(comment), 0x00))
+	writeRIFFChunk(&chunks, "LIST", info.Bytes())
+
+	chunks.WriteString("data")
+	binary.Write(&chunks, binary.LittleEndian, uint32(dataSize))
+
+	var header bytes.Buffer
+	header.WriteString("RIFF")
+	binary.Write(&header, binary.LittleEndian, uint32(4+int64(chunks.Len())+dataSize))
+	header.WriteString("WAVE")
+	header.Write(chunks.Bytes())
+	return header.Bytes()
+}
+
+// writeRIFFChunk writes a RIFF chunk, padded to an even size
+func writeRIFFChunk(w *bytes.Buffer, id string, body []byte) {
+	w.WriteString(id)
+	binary.Write(w, binary.LittleEndian, uint32(len(body)))
+	w.Write(body)
+	if len(body)%2 == 1 {
+		w.WriteByte(0x00)
+	}
+}
+
+// flacHeader builds the metadata of a FLAC file from a STREAMINFO block body
+// and a Vorbis comment block holding the comment. The total sample count and
+// MD5 are cleared, which marks them unknown, since the clip has fewer samples.
+func flacHeader(streamInfo []byte, comment string) []byte {
+	info := make([]byte, len(streamInfo))
+	copy(info, streamInfo)
+	info[13] &= 0xF0 // Low 4 bits of the 36 bit total sample count
+	for i := 14; i < 34; i++ {
+		info[i] = 0 // Rest of the sample count, then the MD5
+	}
+
+	var comments bytes.Buffer
+	vendor := "bagr"
+	binary.Write(&comments, binary.LittleEndian, uint32(len(vendor)))
+	comments.WriteString(vendor)
+	binary.Write(&comments, binary.LittleEndian, uint32(1))
+	entry := "COMMENT=" + comment
+	binary.Write(&comments, binary.LittleEndian, uint32(len(entry)))
+	comments.WriteString(entry)
+
+	var header bytes.Buffer
+	header.WriteString("fLaC")
+	writeFLACBlock(&header, flacStreamInfo, info, false)
+	writeFLACBlock(&header, flacVorbisComment, comments.Bytes(), true)
+	return header.Bytes()
+}
+
+// writeFLACBlock writes a metadata block header and body
+func writeFLACBlock(w *bytes.Buffer, blockType byte, body []byte, last bool) {
+	if last {
+		blockType |= 0x80
+	}
+	w.Write([]byte{blockType, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))})
+	w.Write(body)
+}
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+// parseFLAC reads the STREAMINFO block of a FLAC file for the stream format
+// and total sample count, and its Vorbis comment block for tags
+func parseFLAC(r io.ReaderAt, size int64) (*Metadata, error) {
+	meta := &Metadata{}
+
+	var totalSamples uint64
+	seenStreamInfo := false
+	audioStart := int64(-1)
+
+	pos := int64(4) // Past "fLaC"
+	for pos+4 <= size {
+		header, err := readAt(r, pos, 4)
+		if err != nil {
+			return nil, err
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		body := pos + 4
+
+		switch blockType {
+		case flacStreamInfo:
+			if length < 34 {
+				return nil, ErrMalformed
+			}
+			info, err := readAt(r, body, 34)
+			if err != nil {
+				return nil, err
+			}
+			// 20 bits sample rate, 3 bits channels - 1, 5 bits bits per sample - 1, 36 bits total samples
+			packed := binary.BigEndian.Uint64(info[10:18])
+			meta.SampleRate = int(packed >> 44)
+			meta.Channels = int((packed>>41)&0x07) + 1
+			totalSamples = packed & 0x0FFFFFFFFF
+			seenStreamInfo = true
+			meta.layout.header = info
+		case flacVorbisComment:
+			if length <= 1<<20 {
+				comments, err := readAt(r, body, int(length))
+				if err != nil {
+					return nil, err
+				}
+				readVorbisComments(comments, meta)
+			}
+		}
+
+		pos = body + length
+		if last {
+			if pos > size {
+				return nil, ErrMalformed
+			}
+			audioStart = pos
+			break
+		}
+	}
+
+	if !seenStreamInfo || audioStart < 0 || meta.SampleRate == 0 || totalSamples == 0 {
+		return nil, ErrMalformed
+	}
+
+	meta.Duration = float64(totalSamples) / float64(meta.SampleRate)
+	meta.Bitrate = int(float64(size-audioStart) * 8 / meta.Duration)
+	meta.layout.audioStart = audioStart
+	meta.layout.audioEnd = size
+
+	return meta, nil
+}
+
+// readVorbisComments reads TITLE and GENRE from a Vorbis comment block
+func readVorbisComments(block []byte, meta *Metadata) {
+	if len(block) < 4 {
+		return
+	}
+	vendorLen := int(binary.LittleEndian.Uint32(block[0:4]))
+	if 8+vendorLen > len(block) {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(block[4+vendorLen:]))
+	rest := block[8+vendorLen:]
+
+	for i := 0; i < count && len(rest) >= 4; i++ {
+		length := int(binary.LittleEndian.Uint32(rest[0:4]))
+		if 4+length > len(rest) {
+			return
+		}
+		comment := string(rest[4 : 4+length])
+		rest = rest[4+length:]
+
+		key, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			if meta.Title == "" {
+				meta.Title = strings.TrimSpace(value)
+			}
+		case "GENRE":
+			if meta.Genre == "" {
+				meta.Genre = strings.TrimSpace(value)
+			}
+		}
+	}
+}
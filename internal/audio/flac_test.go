@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// flacBlock encodes a metadata block. A length of -1 writes the body's own
+// length.
+func flacBlock(last bool, blockType byte, length int, body []byte) []byte {
+	if length < 0 {
+		length = len(body)
+	}
+	header := []byte{blockType, byte(length >> 16), byte(length >> 8), byte(length)}
+	if last {
+		header[0] |= 0x80
+	}
+	return append(header, body...)
+}
+
+// streamInfo is the body of a STREAMINFO block
+func streamInfo(sampleRate, channels, bitsPerSample int, totalSamples uint64) []byte {
+	info := make([]byte, 34)
+	packed := uint64(sampleRate)<<44 | uint64(channels-1)<<41 | uint64(bitsPerSample-1)<<36 | totalSamples
+	binary.BigEndian.PutUint64(info[10:], packed)
+	return info
+}
+
+// flacComments is the body of a Vorbis comment block
+func flacComments(vendor string, comments ...string) []byte {
+	block := binary.LittleEndian.AppendUint32(nil, uint32(len(vendor)))
+	block = append(block, vendor...)
+	block = binary.LittleEndian.AppendUint32(block, uint32(len(comments)))
+	for _, comment := range comments {
+		block = binary.LittleEndian.AppendUint32(block, uint32(len(comment)))
+		block = append(block, comment...)
+	}
+	return block
+}
+
+// flacFile joins "fLaC", the metadata blocks and the audio frames
+func flacFile(blocks ...[]byte) []byte {
+	return append([]byte("fLaC"), bytes.Join(blocks, nil)...)
+}
+
+func TestParseFLAC(t *testing.T) {
+	info := streamInfo(44100, 2, 16, 3*44100)
+	frames := make([]byte, 3*cdSecond/2)
+
+	tests := []struct {
+		name      string
+		file      []byte
+		wantTitle string
+		wantGenre string
+	}{
+		{"stream info only", flacFile(flacBlock(true, flacStreamInfo, -1, info), frames), "", ""},
+		{"tags", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, flacVorbisComment, -1, flacComments("test", "title=Night Drive", "GENRE=House")), frames), "Night Drive", "House"},
+		{"padding block", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, 1, -1, make([]byte, 100)), frames), "", ""},
+		{"comment without a separator", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, flacVorbisComment, -1, flacComments("test", "junk", "TITLE=Night Drive")), frames), "Night Drive", ""},
+		{"oversized vendor length", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, flacVorbisComment, -1, flacComments("test", "TITLE=Night Drive")[:4]), frames), "", ""},
+		{"comment count past the block", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, flacVorbisComment, -1, append(flacComments("test", "TITLE=Night Drive"), 0xFF)[:12]), frames), "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := Parse(bytes.NewReader(tt.file), int64(len(tt.file)))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if meta.ContentType != TypeFLAC || meta.SampleRate != 44100 || meta.Channels != 2 || meta.Duration != 3 {
+				t.Errorf("metadata = %+v, want 3s of 44.1 kHz stereo FLAC", meta)
+			}
+			if want := int64(len(tt.file) - len(frames)); meta.layout.audioStart != want {
+				t.Errorf("audio starts at %d, want %d", meta.layout.audioStart, want)
+			}
+			if meta.Title != tt.wantTitle || meta.Genre != tt.wantGenre {
+				t.Errorf("tags = %q, %q, want %q, %q", meta.Title, meta.Genre, tt.wantTitle, tt.wantGenre)
+			}
+		})
+	}
+}
+
+func TestParseFLACMalformed(t *testing.T) {
+	info := streamInfo(44100, 2, 16, 3*44100)
+	frames := make([]byte, 1024)
+
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{"no metadata", []byte("fLaC")},
+		{"stream info too small", flacFile(flacBlock(true, flacStreamInfo, 20, info[:20]), frames)},
+		{"stream info cut short", flacFile(flacBlock(true, flacStreamInfo, -1, info))[:4+4+20]},
+		{"no stream info", flacFile(flacBlock(true, flacVorbisComment, -1, flacComments("test")), frames)},
+		{"no last block", flacFile(flacBlock(false, flacStreamInfo, -1, info))},
+		{"zero samples", flacFile(flacBlock(true, flacStreamInfo, -1, streamInfo(44100, 2, 16, 0)), frames)},
+		{"zero sample rate", flacFile(flacBlock(true, flacStreamInfo, -1, streamInfo(0, 2, 16, 3*44100)), frames)},
+		{"comment block past the end of the file", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, flacVorbisComment, 1<<16, flacComments("test")))},
+		{"last block past the end of the file", flacFile(flacBlock(false, flacStreamInfo, -1, info), flacBlock(true, 1, 1<<23, frames))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.file), int64(len(tt.file))); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("got %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxFrameSearch bounds how far past the ID3v2 tag the first MPEG frame is looked for
+const maxFrameSearch = 64 << 10
+
+// MPEG audio versions as encoded in the frame header
+const (
+	mpeg25 = 0x00
+	mpeg2  = 0x02
+	mpeg1  = 0x03
+)
+
+// Layer III bitrates in kbps by bitrate index
+var (
+	mpeg1Bitrates = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mpeg2Bitrates = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// Sample rates in Hz by version and sample rate index
+var sampleRates = map[byte][3]int{
+	mpeg1:  {44100, 48000, 32000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg25: {11025, 12000, 8000},
+}
+
+// frameHeader is a decoded MPEG audio layer III frame header
+type frameHeader struct {
+	version    byte
+	bitrate    int // kbps
+	sampleRate int
+	padding    int
+	mono       bool
+}
+
+// isFrameSync reports whether header starts with an MPEG audio layer III frame
+// header: 11 sync bits followed by a valid version and layer III
+func isFrameSync(header []byte) bool {
+	if len(header) < 2 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := (header[1] >> 3) & 0x03
+	layer := (header[1] >> 1) & 0x03
+	return version != 0x01 && layer == 0x01
+}
+
+// parseFrameHeader decodes a 4 byte frame header, reporting false if it is invalid
+func parseFrameHeader(b []byte) (frameHeader, bool) {
+	if len(b) < 4 || !isFrameSync(b) {
+		return frameHeader{}, false
+	}
+
+	h := frameHeader{version: (b[1] >> 3) & 0x03}
+
+	bitrateIndex := b[2] >> 4
+	sampleRateIndex := (b[2] >> 2) & 0x03
+	if bitrateIndex == 0 || bitrateIndex == 0x0F || sampleRateIndex == 0x03 {
+		return frameHeader{}, false // Free format, bad bitrate or reserved sample rate
+	}
+
+	if h.version == mpeg1 {
+		h.bitrate = mpeg1Bitrates[bitrateIndex]
+	} else {
+		h.bitrate = mpeg2Bitrates[bitrateIndex]
+	}
+	h.sampleRate = sampleRates[h.version][sampleRateIndex]
+	h.padding = int((b[2] >> 1) & 0x01)
+	h.mono = b[3]>>6 == 0x03
+
+	return h, true
+}
+
+// frameLength returns the size of the frame in bytes, header included
+func (h frameHeader) frameLength() int {
+	if h.version == mpeg1 {
+		return 144*h.bitrate*1000/h.sampleRate + h.padding
+	}
+	return 72*h.bitrate*1000/h.sampleRate + h.padding
+}
+
+// samplesPerFrame returns the number of samples per channel in each frame
+func (h frameHeader) samplesPerFrame() int {
+	if h.version == mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// xingOffset returns where a Xing/Info header would start, relative to the frame
+func (h frameHeader) xingOffset() int {
+	switch {
+	case h.version == mpeg1 && !h.mono:
+		return 4 + 32
+	case h.version == mpeg1 || !h.mono:
+		return 4 + 17
+	default:
+		return 4 + 9
+	}
+}
+
+// parseMP3 reads the ID3 tags and the first MPEG frame of an MP3 file. The
+// duration comes from the Xing/Info or VBRI header when the encoder wrote one,
+// and is otherwise derived from the file size and the constant bitrate.
+func parseMP3(r io.ReaderAt, size int64) (*Metadata, error) {
+	meta := &Metadata{}
+
+	audioStart, err := readID3v2(r, size, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	audioEnd := size
+	if size-audioStart >= 128 {
+		if tag, err := readAt(r, size-128, 128); err == nil && bytes.HasPrefix(tag, []byte("TAG")) {
+			audioEnd -= 128
+			readID3v1(tag, meta)
+		}
+	}
+
+	frameStart, header, err := findFirstFrame(r, audioStart, audioEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.SampleRate = header.sampleRate
+	meta.Channels = 2
+	if header.mono {
+		meta.Channels = 1
+	}
+
+	audioBytes := audioEnd - frameStart
+	meta.layout = layout{audioStart: frameStart, audioEnd: audioEnd}
+	if frames := vbrFrameCount(r, frameStart, header); frames > 0 {
+		meta.Duration = float64(frames) * float64(header.samplesPerFrame()) / float64(header.sampleRate)
+		meta.Bitrate = int(float64(audioBytes) * 8 / meta.Duration)
+		// The first frame only carries the VBR header, which describes the whole file
+		meta.layout.audioStart += int64(header.frameLength())
+		if meta.layout.audioStart >= audioEnd {
+			return nil, ErrMalformed
+		}
+	} else {
+		meta.Bitrate = header.bitrate * 1000
+		meta.Duration = float64(audioBytes) * 8 / float64(meta.Bitrate)
+	}
+
+	return meta, nil
+}
+
+// findFirstFrame returns the offset and header of the first MPEG frame at or
+// after start. A candidate only counts if another valid frame follows it, so
+// stray sync bits in leftover tag data are skipped.
+func findFirstFrame(r io.ReaderAt, start, end int64) (int64, frameHeader, error) {
+	searchEnd := start + maxFrameSearch
+	if searchEnd > end {
+		searchEnd = end
+	}
+	if searchEnd-start < 4 {
+		return 0, frameHeader{}, ErrMalformed
+	}
+
+	buf, err := readAt(r, start, int(searchEnd-start))
+	if err != nil {
+		return 0, frameHeader{}, err
+	}
+
+	for i := 0; i+4 <= len(buf); i++ {
+		header, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+
+		next := start + int64(i) + int64(header.frameLength())
+		if next+4 > end {
+			// A single frame file; accept it as is
+			return start + int64(i), header, nil
+		}
+		nextHeader, err := readAt(r, next, 4)
+		if err != nil {
+			return 0, frameHeader{}, err
+		}
+		if _, ok := parseFrameHeader(nextHeader); ok {
+			return start + int64(i), header, nil
+		}
+	}
+
+	return 0, frameHeader{}, ErrMalformed
+}
+
+// vbrFrameCount returns the frame count stored in a Xing/Info or VBRI header
+// in the first frame, or 0 if there is none
+func vbrFrameCount(r io.ReaderAt, frameStart int64, header frameHeader) uint32 {
+	if xing, err := readAt(r, frameStart+int64(header.xingOffset()), 12); err == nil {
+		if bytes.Equal(xing[0:4], []byte("Xing")) || bytes.Equal(xing[0:4], []byte("Info")) {
+			flags := binary.BigEndian.Uint32(xing[4:8])
+			if flags&0x01 != 0 {
+				return binary.BigEndian.Uint32(xing[8:12])
+			}
+			return 0
+		}
+	}
+
+	// The VBRI header always sits 32 bytes after the frame header
+	if vbri, err := readAt(r, frameStart+4+32, 18); err == nil && bytes.Equal(vbri[0:4], []byte("VBRI")) {
+		return binary.BigEndian.Uint32(vbri[14:18])
+	}
+
+	return 0
+}
+
+// readID3v2 reads the title and genre of a leading ID3v2 tag and returns the
+// offset just past it, or 0 if the file has no tag
+func readID3v2(r io.ReaderAt, size int64, meta *Metadata) (int64, error) {
+	header, err := readAt(r, 0, 10)
+	if err != nil || !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0, nil
+	}
+
+	major := header[3]
+	flags := header[5]
+	tagEnd := int64(10 + synchsafe(header[6:10]))
+	if flags&0x10 != 0 {
+		tagEnd += 10 // Footer
+	}
+	if tagEnd > size || major < 2 || major > 4 {
+		return 0, ErrMalformed
+	}
+
+	pos := int64(10)
+	if flags&0x40 != 0 && major >= 3 {
+		ext, err := readAt(r, pos, 4)
+		if err != nil {
+			return 0, err
+		}
+		if major == 4 {
+			pos += int64(synchsafe(ext))
+		} else {
+			pos += 4 + int64(binary.BigEndian.Uint32(ext))
+		}
+	}
+
+	idLen, headerLen := 4, 10
+	titleID, genreID := "TIT2", "TCON"
+	if major == 2 {
+		idLen, headerLen = 3, 6
+		titleID, genreID = "TT2", "TCO"
+	}
+
+	for pos+int64(headerLen) <= tagEnd {
+		fh, err := readAt(r, pos, headerLen)
+		if err != nil {
+			return 0, err
+		}
+		if fh[0] == 0 {
+			break // Padding
+		}
+
+		id := string(fh[:idLen])
+		var frameSize int64
+		var frameFlags uint16
+		switch major {
+		case 2:
+			frameSize = int64(fh[3])<<16 | int64(fh[4])<<8 | int64(fh[5])
+		case 3:
+			frameSize = int64(binary.BigEndian.Uint32(fh[4:8]))
+			frameFlags = binary.BigEndian.Uint16(fh[8:10])
+		default:
+			frameSize = int64(synchsafe(fh[4:8]))
+			frameFlags = binary.BigEndian.Uint16(fh[8:10])
+		}
+
+		dataStart := pos + int64(headerLen)
+		pos = dataStart + frameSize
+		if pos > tagEnd {
+			break
+		}
+		if id != titleID && id != genreID {
+			continue
+		}
+
+		// Skip compressed or encrypted frames; step over a data length indicator
+		if (major == 3 && frameFlags&0x00C0 != 0) || (major == 4 && frameFlags&0x000C != 0) {
+			continue
+		}
+		if major == 4 && frameFlags&0x0001 != 0 {
+			dataStart += 4
+		}
+		if dataStart >= pos || pos-dataStart > 1<<16 {
+			continue
+		}
+
+		data, err := readAt(r, dataStart, int(pos-dataStart))
+		if err != nil {
+			return 0, err
+		}
+		value := decodeID3Text(data)
+		if id == titleID {
+			meta.Title = value
+		} else {
+			meta.Genre = resolveID3Genre(value)
+		}
+	}
+
+	return tagEnd, nil
+}
+
+// readID3v1 fills in tags missing from the ID3v2 tag from a trailing ID3v1 tag
+func readID3v1(tag []byte, meta *Metadata) {
+	if meta.Title == "" {
+		meta.Title = strings.TrimSpace(strings.TrimRight(latin1(tag[3:33]), "\x00"))
+	}
+	if meta.Genre == "" && int(tag[127]) < len(id3Genres) {
+		meta.Genre = id3Genres[tag[127]]
+	}
+}
+
+// decodeID3Text decodes a text frame and returns its first value
+func decodeID3Text(data []byte) string {
+	if len(data) < 2 {
+		return ""
+	}
+
+	var text string
+	switch data[0] {
+	case 0x00:
+		text = latin1(data[1:])
+	case 0x01, 0x02:
+		text = decodeUTF16(data[1:], data[0] == 0x02)
+	default:
+		text = string(data[1:])
+	}
+
+	// ID3v2.4 separates multiple values with NULs
+	if i := strings.IndexByte(text, 0); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// decodeUTF16 decodes UTF-16 text, honouring a byte order mark if present
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFE && b[1] == 0xFF:
+			bigEndian, b = true, b[2:]
+		case b[0] == 0xFF && b[1] == 0xFE:
+			bigEndian, b = false, b[2:]
+		}
+	}
+
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			units = append(units, binary.BigEndian.Uint16(b[i:]))
+		} else {
+			units = append(units, binary.LittleEndian.Uint16(b[i:]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// latin1 decodes ISO-8859-1 text
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// synchsafe decodes a 28 bit synchsafe integer
+func synchsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// resolveID3Genre turns numeric genre references such as "(17)" or "17" into names
+func resolveID3Genre(value string) string {
+	ref := value
+	if strings.HasPrefix(ref, "(") {
+		end := strings.IndexByte(ref, ')')
+		if end < 0 {
+			return value
+		}
+		// "(17)Rock" carries its own refinement
+		if refinement := strings.TrimSpace(ref[end+1:]); refinement != "" {
+			return refinement
+		}
+		ref = ref[1:end]
+	}
+
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n >= 0 && n < len(id3Genres) {
+			return id3Genres[n]
+		}
+		return ""
+	}
+	return value
+}
+
+// id3Genres is the standard ID3v1 genre list
+var id3Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychadelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+}
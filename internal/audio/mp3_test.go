@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// mp3FrameLength is the size of a 128 kbps, 44.1 kHz MPEG-1 layer III frame
+const mp3FrameLength = 417
+
+// mp3Frame returns a silent 128 kbps, 44.1 kHz stereo MPEG-1 layer III frame
+func mp3Frame() []byte {
+	frame := make([]byte, mp3FrameLength)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return frame
+}
+
+// mp3Frames returns n silent frames
+func mp3Frames(n int) []byte {
+	return bytes.Repeat(mp3Frame(), n)
+}
+
+// xingFrame returns a frame carrying a Xing or Info header. The frame count
+// is only flagged present when withFrames is set.
+func xingFrame(id string, frames uint32, withFrames bool) []byte {
+	frame := mp3Frame()
+	copy(frame[36:], id)
+	if withFrames {
+		binary.BigEndian.PutUint32(frame[40:], 0x01)
+	}
+	binary.BigEndian.PutUint32(frame[44:], frames)
+	return frame
+}
+
+// vbriFrame returns a frame carrying a VBRI header
+func vbriFrame(frames uint32) []byte {
+	frame := mp3Frame()
+	copy(frame[36:], "VBRI")
+	binary.BigEndian.PutUint32(frame[36+14:], frames)
+	return frame
+}
+
+// id3v2Tag returns an ID3v2.3 tag holding frames; a size of -1 writes their
+// own length
+func id3v2Tag(size int, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	if size < 0 {
+		size = len(body)
+	}
+	tag := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(tag, body...)
+}
+
+// id3v2Text returns an ID3v2.3 text frame in ISO-8859-1
+func id3v2Text(id, text string) []byte {
+	frame := append([]byte(id), 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(frame[4:], uint32(1+len(text)))
+	frame = append(frame, 0x00)
+	return append(frame, text...)
+}
+
+// id3v1Tag returns a trailing ID3v1 tag
+func id3v1Tag(title string, genre byte) []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:33], title)
+	tag[127] = genre
+	return tag
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestParseMP3(t *testing.T) {
+	cbr := float64(100*mp3FrameLength) * 8 / 128000
+	vbr := float64(100*1152) / 44100
+
+	tests := []struct {
+		name         string
+		file         []byte
+		wantDuration float64
+		wantBitrate  int
+		wantTitle    string
+		wantGenre    string
+	}{
+		{"cbr", mp3Frames(100), cbr, 128000, "", ""},
+		{"id3v2 tag", concat(id3v2Tag(-1, id3v2Text("TIT2", "Night Drive"), id3v2Text("TCON", "(17)")), mp3Frames(100)), cbr, 128000, "Night Drive", "Rock"},
+		{"id3v2 padding", concat(id3v2Tag(-1, id3v2Text("TIT2", "Night Drive"), make([]byte, 64)), mp3Frames(100)), cbr, 128000, "Night Drive", ""},
+		{"id3v1 tag", concat(mp3Frames(100), id3v1Tag("Night Drive", 35)), cbr, 128000, "Night Drive", "House"},
+		{"id3v2 tag wins over id3v1", concat(id3v2Tag(-1, id3v2Text("TIT2", "Night Drive")), mp3Frames(100), id3v1Tag("Old Title", 35)), cbr, 128000, "Night Drive", "House"},
+		{"xing header", concat(xingFrame("Xing", 100, true), mp3Frames(100)), vbr, int(float64(101*mp3FrameLength) * 8 / vbr), "", ""},
+		{"info header without a frame count", concat(xingFrame("Info", 100, false), mp3Frames(99)), cbr, 128000, "", ""},
+		{"vbri header", concat(vbriFrame(100), mp3Frames(100)), vbr, int(float64(101*mp3FrameLength) * 8 / vbr), "", ""},
+		{"stray sync before the frames", concat([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 10), mp3Frames(100)), cbr, 128000, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := Parse(bytes.NewReader(tt.file), int64(len(tt.file)))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if meta.ContentType != TypeMP3 || meta.SampleRate != 44100 || meta.Channels != 2 {
+				t.Errorf("metadata = %+v, want 44.1 kHz stereo MP3", meta)
+			}
+			if math.Abs(meta.Duration-tt.wantDuration) > 1e-9 {
+				t.Errorf("duration = %v, want %v", meta.Duration, tt.wantDuration)
+			}
+			if meta.Bitrate != tt.wantBitrate {
+				t.Errorf("bitrate = %d, want %d", meta.Bitrate, tt.wantBitrate)
+			}
+			if meta.Title != tt.wantTitle || meta.Genre != tt.wantGenre {
+				t.Errorf("tags = %q, %q, want %q, %q", meta.Title, meta.Genre, tt.wantTitle, tt.wantGenre)
+			}
+		})
+	}
+}
+
+func TestParseMP3VBRHeaderIsNotAudio(t *testing.T) {
+	file := concat(id3v2Tag(-1, id3v2Text("TIT2", "Night Drive")), xingFrame("Xing", 100, true), mp3Frames(100))
+	meta, err := Parse(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := int64(len(file) - 100*mp3FrameLength); meta.layout.audioStart != want {
+		t.Errorf("audio starts at %d, want %d past the Xing frame", meta.layout.audioStart, want)
+	}
+}
+
+func TestParseMP3Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{"id3v2 size past the end of the file", concat(id3v2Tag(1<<20, id3v2Text("TIT2", "Night Drive")), mp3Frames(2))},
+		{"id3v2 tag without frames", id3v2Tag(-1, id3v2Text("TIT2", "Night Drive"))},
+		{"garbage after the tag", concat(id3v2Tag(-1, id3v2Text("TIT2", "Night Drive")), bytes.Repeat([]byte{0xFF, 0x00}, 512))},
+		{"frame header cut short", []byte{0xFF, 0xFB, 0x90}},
+		{"reserved sample rate", bytes.Repeat(append([]byte{0xFF, 0xFB, 0x9C, 0x00}, make([]byte, mp3FrameLength-4)...), 2)},
+		{"id3v1 tag only", concat([]byte{0xFF, 0xFB}, id3v1Tag("Night Drive", 35))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.file), int64(len(tt.file))); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("got %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// parseWAV walks the RIFF chunks of a WAV file: "fmt " gives the stream
+// format, the size of "data" gives the duration and a LIST/INFO chunk holds
+// the tags
+func parseWAV(r io.ReaderAt, size int64) (*Metadata, error) {
+	meta := &Metadata{}
+
+	var byteRate int64
+	var dataSize int64 = -1
+
+	pos := int64(12) // Past "RIFF", the RIFF size and "WAVE"
+	for pos+8 <= size {
+		header, err := readAt(r, pos, 8)
+		if err != nil {
+			return nil, err
+		}
+		id := string(header[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		body := pos + 8
+
+		switch id {
+		case "fmt ":
+			if chunkSize < 16 {
+				return nil, ErrMalformed
+			}
+			if chunkSize > 1<<10 {
+				return nil, ErrMalformed
+			}
+			format, err := readAt(r, body, int(chunkSize))
+			if err != nil {
+				return nil, err
+			}
+			meta.Channels = int(binary.LittleEndian.Uint16(format[2:4]))
+			meta.SampleRate = int(binary.LittleEndian.Uint32(format[4:8]))
+			byteRate = int64(binary.LittleEndian.Uint32(format[8:12]))
+			meta.layout.header = format
+			meta.layout.blockAlign = int(binary.LittleEndian.Uint16(format[12:14]))
+		case "data":
+			dataSize = chunkSize
+			// Streaming writers leave the size unset; the data then runs to the end of the file
+			if dataSize == 0 || dataSize == 0xFFFFFFFF || body+dataSize > size {
+				dataSize = size - body
+			}
+			meta.layout.audioStart = body
+			meta.layout.audioEnd = body + dataSize
+		case "LIST":
+			if chunkSize >= 4 && chunkSize <= 1<<16 {
+				list, err := readAt(r, body, int(chunkSize))
+				if err != nil {
+					return nil, err
+				}
+				if bytes.Equal(list[0:4], []byte("INFO")) {
+					readRIFFInfo(list[4:], meta)
+				}
+			}
+		}
+
+		// Chunks are padded to an even size
+		pos = body + chunkSize + chunkSize%2
+	}
+
+	if byteRate <= 0 || dataSize < 0 || meta.layout.blockAlign <= 0 {
+		return nil, ErrMalformed
+	}
+
+	meta.Bitrate = int(byteRate * 8)
+	meta.Duration = float64(dataSize) / float64(byteRate)
+
+	return meta, nil
+}
+
+// readRIFFInfo reads the title (INAM) and genre (IGNR) from LIST/INFO subchunks
+func readRIFFInfo(info []byte, meta *Metadata) {
+	for len(info) >= 8 {
+		id := string(info[0:4])
+		size := int(binary.LittleEndian.Uint32(info[4:8]))
+		if 8+size > len(info) {
+			return
+		}
+
+		value := strings.TrimSpace(strings.TrimRight(string(info[8:8+size]), "\x00"))
+		switch id {
+		case "INAM":
+			meta.Title = value
+		case "IGNR":
+			meta.Genre = value
+		}
+
+		next := 8 + size + size%2
+		if next > len(info) {
+			return
+		}
+		info = info[next:]
+	}
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// riffChunk encodes a RIFF chunk, padded to an even size. A size of -1 writes
+// the body's own length.
+func riffChunk(id string, size int64, body []byte) []byte {
+	if size < 0 {
+		size = int64(len(body))
+	}
+	chunk := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(size))
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// wavFormat is the body of a PCM "fmt " chunk
+func wavFormat(channels, sampleRate, bitsPerSample int) []byte {
+	blockAlign := channels * bitsPerSample / 8
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:], 1) // PCM
+	binary.LittleEndian.PutUint16(format[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(format[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(format[8:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(format[12:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(format[14:], uint16(bitsPerSample))
+	return format
+}
+
+// wavInfo is the body of a LIST/INFO chunk with a title and genre
+func wavInfo(title, genre string) []byte {
+	info := []byte("INFO")
+	info = append(info, riffChunk("INAM", -1, append([]byte(title), 0))...)
+	return append(info, riffChunk("IGNR", -1, append([]byte(genre), 0))...)
+}
+
+// wavFile wraps chunks in a RIFF/WAVE header
+func wavFile(chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+	file := append([]byte("RIFF"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(file[4:], uint32(4+len(body)))
+	file = append(file, "WAVE"...)
+	return append(file, body...)
+}
+
+// cdSecond is one second of 16-bit stereo audio at 44.1 kHz
+const cdSecond = 44100 * 4
+
+func TestParseWAV(t *testing.T) {
+	format := riffChunk("fmt ", -1, wavFormat(2, 44100, 16))
+	samples := make([]byte, 2*cdSecond)
+
+	tests := []struct {
+		name         string
+		file         []byte
+		wantDuration float64
+		wantTitle    string
+	}{
+		{"pcm", wavFile(format, riffChunk("data", -1, samples)), 2, ""},
+		{"tags", wavFile(format, riffChunk("LIST", -1, wavInfo("Night Drive", "House")), riffChunk("data", -1, samples)), 2, "Night Drive"},
+		{"streamed without a data size", wavFile(format, riffChunk("data", 0xFFFFFFFF, samples)), 2, ""},
+		{"data size past the end of the file", wavFile(format, riffChunk("data", 10*cdSecond, samples)), 2, ""},
+		{"oversized LIST chunk after the data", wavFile(format, riffChunk("data", -1, samples), riffChunk("LIST", 1<<30, wavInfo("Night Drive", "House"))), 2, ""},
+		{"odd-sized chunk is padded", wavFile(format, riffChunk("junk", -1, []byte{1, 2, 3}), riffChunk("data", -1, samples)), 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := Parse(bytes.NewReader(tt.file), int64(len(tt.file)))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if meta.ContentType != TypeWAV || meta.SampleRate != 44100 || meta.Channels != 2 || meta.Bitrate != 1411200 {
+				t.Errorf("metadata = %+v, want 44.1 kHz stereo WAV at 1411200 bps", meta)
+			}
+			if meta.Duration != tt.wantDuration {
+				t.Errorf("duration = %v, want %v", meta.Duration, tt.wantDuration)
+			}
+			if meta.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", meta.Title, tt.wantTitle)
+			}
+			if tt.wantTitle != "" && meta.Genre != "House" {
+				t.Errorf("genre = %q, want %q", meta.Genre, "House")
+			}
+		})
+	}
+}
+
+func TestParseWAVMalformed(t *testing.T) {
+	format := riffChunk("fmt ", -1, wavFormat(2, 44100, 16))
+	data := riffChunk("data", -1, make([]byte, cdSecond))
+	truncatedFormat := wavFile(format, data)[:12+8+10]
+	noBlockAlign := wavFormat(2, 44100, 16)
+	binary.LittleEndian.PutUint16(noBlockAlign[12:], 0)
+
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{"no data chunk", wavFile(format)},
+		{"no fmt chunk", wavFile(data)},
+		{"fmt chunk cut short", truncatedFormat},
+		{"fmt chunk too small", wavFile(riffChunk("fmt ", -1, wavFormat(2, 44100, 16)[:8]), data)},
+		{"oversized fmt chunk", wavFile(riffChunk("fmt ", 1<<20, wavFormat(2, 44100, 16)), data)},
+		{"zero byte rate", wavFile(riffChunk("fmt ", -1, wavFormat(2, 0, 16)), data)},
+		{"zero block align", wavFile(riffChunk("fmt ", -1, noBlockAlign), data)},
+		{"empty data", wavFile(format, riffChunk("data", -1, nil))},
+		{"header only", wavFile()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.file), int64(len(tt.file))); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("got %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
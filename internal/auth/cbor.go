@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// cborMaxDepth bounds how deeply CBOR items may nest, so a hostile
+// attestation object can't exhaust the stack
+const cborMaxDepth = 16
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes the first CBOR item in data, returning it and the number
+// of bytes it took. It covers the subset WebAuthn uses (RFC 8949 with
+// definite lengths only): integers as int64, byte and text strings, arrays
+// as []interface{}, maps as map[interface{}]interface{}, booleans and null.
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, int, error) {
+	if depth > cborMaxDepth {
+		return nil, 0, errors.New("cbor: nested too deeply")
+	}
+	if len(data) == 0 {
+		return nil, 0, errCBORTruncated
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	argument, n, err := cborArgument(data, info)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case 0: // Unsigned integer
+		if argument > 1<<63-1 {
+			return nil, 0, errors.New("cbor: integer overflows int64")
+		}
+		return int64(argument), n, nil
+	case 1: // Negative integer
+		if argument > 1<<63-1 {
+			return nil, 0, errors.New("cbor: integer overflows int64")
+		}
+		return -1 - int64(argument), n, nil
+	case 2, 3: // Byte string, text string
+		if argument > uint64(len(data)-n) {
+			return nil, 0, errCBORTruncated
+		}
+		end := n + int(argument)
+		if major == 2 {
+			return append([]byte(nil), data[n:end]...), end, nil
+		}
+		return string(data[n:end]), end, nil
+	case 4: // Array
+		if argument > uint64(len(data)) {
+			return nil, 0, errCBORTruncated
+		}
+		items := make([]interface{}, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			item, size, err := decodeCBORItem(data[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			n += size
+		}
+		return items, n, nil
+	case 5: // Map
+		if argument > uint64(len(data)) {
+			return nil, 0, errCBORTruncated
+		}
+		items := make(map[interface{}]interface{}, argument)
+		for i := uint64(0); i < argument; i++ {
+			key, size, err := decodeCBORItem(data[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			n += size
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			value, size, err := decodeCBORItem(data[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items[key] = value
+			n += size
+		}
+		return items, n, nil
+	case 7: // Simple values
+		switch info {
+		case 20:
+			return false, n, nil
+		case 21:
+			return true, n, nil
+		case 22:
+			return nil, n, nil
+		}
+		return nil, 0, fmt.Errorf("cbor: unsupported simple value %d", info)
+	default: // Tags
+		return nil, 0, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+// cborArgument reads the argument that follows an initial byte, returning it
+// and the number of bytes the initial byte and argument took
+func cborArgument(data []byte, info byte) (uint64, int, error) {
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24:
+		if len(data) < 2 {
+			return 0, 0, errCBORTruncated
+		}
+		return uint64(data[1]), 2, nil
+	case info == 25:
+		if len(data) < 3 {
+			return 0, 0, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint16(data[1:])), 3, nil
+	case info == 26:
+		if len(data) < 5 {
+			return 0, 0, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint32(data[1:])), 5, nil
+	case info == 27:
+		if len(data) < 9 {
+			return 0, 0, errCBORTruncated
+		}
+		return binary.BigEndian.Uint64(data[1:]), 9, nil
+	default:
+		return 0, 0, errors.New("cbor: indefinite lengths are not supported")
+	}
+}
@@ -166,6 +166,36 @@ func (e *EmailService) SendWelcomeEmail(to, username, role string) error {
 	return e.sendEmail(to, subject, body)
 }
 
+// SendAccountLockedEmail tells the user their account was locked after too
+// many failed logins
+func (e *EmailService) SendAccountLockedEmail(to, username string, lockedUntil time.Time) error {
+	subject := "Your Account Has Been Locked - BAGR Auction System"
+
+	data := map[string]interface{}{
+		"Username":    username,
+		"LockedUntil": lockedUntil.UTC().Format("January 2, 2006 at 15:04 MST"),
+		"CurrentYear": time.Now().Year(),
+		"LogoBase64":  getLogoBase64(),
+	}
+
+	body, err := e.renderTemplate("account_locked", data)
+	if err != nil {
+		return fmt.Errorf("failed to render account locked template: %w", err)
+	}
+
+	// In test mode, just log the lockout details
+	if e.testMode {
+		fmt.Printf("\n=== ACCOUNT LOCKED (TEST MODE) ===\n")
+		fmt.Printf("To: %s\n", to)
+		fmt.Printf("Subject: %s\n", subject)
+		fmt.Printf("Locked Until: %s\n", data["LockedUntil"])
+		fmt.Printf("==================================\n\n")
+		return nil
+	}
+
+	return e.sendEmail(to, subject, body)
+}
+
 // sendEmail sends an email using Microsoft Graph API
 func (e *EmailService) sendEmail(to, subject, body string) error {
 	logger := utils.GetLogger()
@@ -714,6 +744,94 @@ func getEmailTemplate(templateName string) string {
         </div>
     </div>
 </body>
+</html>`,
+		"account_locked": `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Account Locked - BAGR</title>
+    <style>
+        body { 
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; 
+            line-height: 1.6; 
+            color: #2d3748; 
+            margin: 0; 
+            padding: 0; 
+            background-color: #f7fafc;
+        }
+        .container { 
+            max-width: 600px; 
+            margin: 20px auto; 
+            background: white; 
+            border-radius: 12px; 
+            overflow: hidden; 
+            box-shadow: 0 10px 25px rgba(0,0,0,0.1);
+        }
+        .header { 
+            background: #000000; 
+            color: white; 
+            padding: 30px 20px; 
+            text-align: center; 
+        }
+        .logo { 
+            max-width: 200px; 
+            height: auto; 
+            margin: 0 auto 15px;
+            display: block;
+        }
+        .content { 
+            padding: 40px 30px; 
+        }
+        .content h2 { 
+            color: #1a202c; 
+            font-size: 22px; 
+            margin-bottom: 20px; 
+            font-weight: 600;
+        }
+        .content p { 
+            margin-bottom: 16px; 
+            color: #4a5568;
+        }
+        .footer { 
+            text-align: center; 
+            margin-top: 30px; 
+            color: #718096; 
+            font-size: 14px; 
+            padding: 20px 30px;
+            background: #f7fafc;
+        }
+        .security-note {
+            background: #fff5f5;
+            border: 1px solid #fed7d7;
+            border-radius: 6px;
+            padding: 15px;
+            margin: 20px 0;
+            color: #c53030;
+            font-size: 14px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <img src="https://bagr-profile-images.s3.amazonaws.com/BAGR-logo.png" alt="BAGR Logo" class="logo">
+        </div>
+        <div class="content">
+            <h2>Your Account Has Been Locked</h2>
+            <p>Hello <strong>{{.Username}}</strong>,</p>
+            <p>We noticed several failed attempts to log in to your BAGR account, so we have temporarily locked it to keep it safe.</p>
+            <p>You will be able to log in again after <strong>{{.LockedUntil}}</strong>.</p>
+            <div class="security-note">
+                <strong>Security Note:</strong> If these attempts weren't you, someone may be trying to guess your password. Once the lock ends, we recommend resetting your password and turning on two-factor authentication.
+            </div>
+        </div>
+        <div class="footer">
+            <p>&copy; {{.CurrentYear}} BAGR Auction System. All rights reserved.</p>
+            <p>Connecting Music Creators Worldwide</p>
+        </div>
+    </div>
+</body>
 </html>`,
 	}
 
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRows is a handler's answer to a statement: the rows a query returns, or
+// the number of rows a statement changed
+type fakeRows struct {
+	columns  []string
+	values   [][]driver.Value
+	affected int64
+}
+
+// fakeHandler answers one statement, given with its whitespace collapsed
+type fakeHandler func(query string, args []driver.Value) (*fakeRows, error)
+
+// The fake driver runs the service's SQL against in-memory tables kept by the
+// test's handler. Transactions are neither isolated nor rolled back, and row
+// locks are ignored.
+var (
+	fakeDBMu       sync.Mutex
+	fakeDBHandlers = map[string]fakeHandler{}
+)
+
+func init() {
+	sql.Register("authfake", fakeDriver{})
+}
+
+// newFakeDB opens a database whose statements are answered by handler
+func newFakeDB(t *testing.T, handler fakeHandler) *sql.DB {
+	t.Helper()
+	fakeDBMu.Lock()
+	fakeDBHandlers[t.Name()] = handler
+	fakeDBMu.Unlock()
+
+	db, err := sql.Open("authfake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDBMu.Lock()
+		delete(fakeDBHandlers, t.Name())
+		fakeDBMu.Unlock()
+	})
+	return db
+}
+
+// unexpectedQuery is the error handlers return for statements they don't know
+func unexpectedQuery(query string) error {
+	return fmt.Errorf("unexpected query: %s", query)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBMu.Lock()
+	defer fakeDBMu.Unlock()
+	handler, ok := fakeDBHandlers[name]
+	if !ok {
+		return nil, fmt.Errorf("no fake database %q", name)
+	}
+	return &fakeConn{handler: handler}, nil
+}
+
+type fakeConn struct {
+	handler fakeHandler
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	rows, err := s.conn.handler(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		return driver.RowsAffected(0), nil
+	}
+	return driver.RowsAffected(rows.affected), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.conn.handler(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = &fakeRows{}
+	}
+	return &fakeCursor{rows: rows}, nil
+}
+
+type fakeCursor struct {
+	rows *fakeRows
+	next int
+}
+
+func (c *fakeCursor) Columns() []string { return c.rows.columns }
+func (c *fakeCursor) Close() error      { return nil }
+
+func (c *fakeCursor) Next(dest []driver.Value) error {
+	if c.next >= len(c.rows.values) {
+		return io.EOF
+	}
+	copy(dest, c.rows.values[c.next])
+	c.next++
+	return nil
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -86,15 +88,36 @@ func (h *AuthHandlers) Login(c *gin.Context) {
 	}
 
 	// Login user
-	response, err := h.authService.LoginUser(&req)
+	response, challenge, err := h.authService.LoginUser(&req, c.ClientIP())
 	if err != nil {
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			loginLockedResponse(c, locked)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusUnauthorized, "LOGIN_FAILED", "Login failed", err.Error())
 		return
 	}
+	if challenge != nil {
+		utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", challenge)
+		return
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
 }
 
+// loginLockedResponse tells the client when it may try to log in again
+func loginLockedResponse(c *gin.Context, locked *LoginLockedError) {
+	retryAfter := locked.RetryAfterSeconds()
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	code := "TOO_MANY_ATTEMPTS"
+	if locked.AccountLocked {
+		code = "ACCOUNT_LOCKED"
+	}
+	utils.ErrorResponse(c, http.StatusTooManyRequests, code, locked.Error(), fmt.Sprintf("Retry after %d seconds", retryAfter))
+}
+
 // VerifyEmail handles email verification
 // GET /api/v1/auth/verify?token=xxx
 func (h *AuthHandlers) VerifyEmail(c *gin.Context) {
@@ -206,6 +229,10 @@ func (h *AuthHandlers) RefreshToken(c *gin.Context) {
 	// Refresh token
 	response, err := h.authService.RefreshToken(req.RefreshToken)
 	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "Refresh token reuse detected", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusUnauthorized, "TOKEN_REFRESH_FAILED", "Token refresh failed", err.Error())
 		return
 	}
@@ -270,6 +297,14 @@ func (h *AuthHandlers) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	// Deactivating the account logs it out everywhere
+	if req.Status != nil && *req.Status != models.UserStatusActive {
+		if err := h.authService.RevokeAllSessions(uid, "status_"+string(*req.Status)); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "PROFILE_UPDATE_FAILED", "Profile update failed", err.Error())
+			return
+		}
+	}
+
 	// Get updated user
 	user, err := h.authService.getUserByID(uid)
 	if err != nil {
@@ -280,18 +315,59 @@ func (h *AuthHandlers) UpdateProfile(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Profile updated successfully", user.ToResponse())
 }
 
-// Logout handles user logout
+// Logout handles user logout by deleting the session behind the access token,
+// so none of its refresh tokens can be used again
 // POST /api/v1/auth/logout
 func (h *AuthHandlers) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is handled client-side
-	// by removing the token from storage
-	// We could implement a token blacklist here if needed
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	sessionID := c.GetString("session_id")
+	if err := h.authService.Logout(uid, sessionID); err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SESSION", "Token is not bound to a session", "Log in again to get a token that can be logged out")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "LOGOUT_FAILED", "Logout failed", err.Error())
+		return
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Logout successful", gin.H{
 		"message": "You have been successfully logged out. Please remove your tokens from client storage.",
 	})
 }
 
+// LogoutAll handles logging out of every device by revoking all of the
+// user's access tokens and sessions
+// POST /api/v1/auth/logout-all
+func (h *AuthHandlers) LogoutAll(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	if err := h.authService.RevokeAllSessions(uid, "logout_all"); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "LOGOUT_FAILED", "Logout failed", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Logged out of all devices", gin.H{
+		"message": "All of your sessions have been ended. Log in again to continue.",
+	})
+}
+
+// JWKS serves the public keys access tokens are signed with, as a plain JSON
+// Web Key Set so standard JWT libraries can fetch it
+// GET /.well-known/jwks.json
+func (h *AuthHandlers) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.GetJWTService().JWKS())
+}
+
 // GetRoles handles getting available user roles
 // GET /api/v1/auth/roles
 func (h *AuthHandlers) GetRoles(c *gin.Context) {
@@ -387,6 +463,13 @@ func (a *AuthService) updateUserProfile(userID int, req *models.UpdateUserReques
 	return err
 }
 
+// userIDFromContext returns the user ID set by the JWT middleware
+func userIDFromContext(c *gin.Context) (int, bool) {
+	userID, _ := c.Get("user_id")
+	uid, ok := userID.(int)
+	return uid, ok
+}
+
 // joinStrings joins a slice of strings with a separator
 func joinStrings(strs []string, sep string) string {
 	if len(strs) == 0 {
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -10,12 +12,17 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ErrTokenRevoked is returned for access tokens on the revocation denylist
+var ErrTokenRevoked = errors.New("token has been revoked")
+
 // JWTService handles JWT token operations
 type JWTService struct {
 	accessSecret  []byte
 	refreshSecret []byte
+	accessKeys    *KeySet
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
+	revocations   RevocationStore
 }
 
 // Claims represents the JWT claims
@@ -23,22 +30,51 @@ type Claims struct {
 	UserID    int             `json:"user_id"`
 	Email     string          `json:"email"`
 	Role      models.UserRole `json:"role"`
-	TokenType string          `json:"token_type"` // "access" or "refresh"
+	TokenType string          `json:"token_type"`    // "access" or "refresh"
+	SessionID string          `json:"sid,omitempty"` // Session family shared by every token rotated from one login
 	jwt.RegisteredClaims
 }
 
-// NewJWTService creates a new JWT service
-func NewJWTService(accessSecret, refreshSecret string) *JWTService {
+// TokenPair is a signed access and refresh token issued together. The
+// refresh token's ID (jti) is the key of its row in the sessions table.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessTokenID    string
+	RefreshTokenID   string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// NewJWTService creates a new JWT service. Access tokens are signed with
+// accessKeys so other services can verify them from the JWKS, or with the
+// HMAC access secret when accessKeys is nil. Refresh tokens never leave this
+// service and are always HMAC signed. Access tokens are checked against
+// revocations when validated; pass nil to skip the check.
+func NewJWTService(accessSecret, refreshSecret string, accessKeys *KeySet, revocations RevocationStore) *JWTService {
 	return &JWTService{
 		accessSecret:  []byte(accessSecret),
 		refreshSecret: []byte(refreshSecret),
+		accessKeys:    accessKeys,
 		accessExpiry:  24 * time.Hour,     // 24 hours as requested
 		refreshExpiry: 7 * 24 * time.Hour, // 7 days for refresh tokens
+		revocations:   revocations,
 	}
 }
 
-// GenerateTokenPair generates both access and refresh tokens
-func (j *JWTService) GenerateTokenPair(user *models.User) (string, string, time.Time, error) {
+// GenerateTokenPair generates both access and refresh tokens for a session.
+// Every pair gets new token IDs, so a refresh token can be used once and an
+// access token can be revoked on its own.
+func (j *JWTService) GenerateTokenPair(user *models.User, sessionID string) (*TokenPair, error) {
+	accessTokenID, err := newTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token ID: %w", err)
+	}
+	refreshTokenID, err := newTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token ID: %w", err)
+	}
+
 	now := time.Now()
 	accessExpiry := now.Add(j.accessExpiry)
 	refreshExpiry := now.Add(j.refreshExpiry)
@@ -49,7 +85,9 @@ func (j *JWTService) GenerateTokenPair(user *models.User) (string, string, time.
 		Email:     user.Email,
 		Role:      user.Role,
 		TokenType: "access",
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        accessTokenID,
 			ExpiresAt: jwt.NewNumericDate(accessExpiry),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -58,10 +96,9 @@ func (j *JWTService) GenerateTokenPair(user *models.User) (string, string, time.
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(j.accessSecret)
+	accessTokenString, err := j.signAccessToken(accessClaims)
 	if err != nil {
-		return "", "", time.Time{}, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	// Generate refresh token
@@ -70,7 +107,9 @@ func (j *JWTService) GenerateTokenPair(user *models.User) (string, string, time.
 		Email:     user.Email,
 		Role:      user.Role,
 		TokenType: "refresh",
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshTokenID,
 			ExpiresAt: jwt.NewNumericDate(refreshExpiry),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -82,31 +121,77 @@ func (j *JWTService) GenerateTokenPair(user *models.User) (string, string, time.
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 	refreshTokenString, err := refreshToken.SignedString(j.refreshSecret)
 	if err != nil {
-		return "", "", time.Time{}, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	return accessTokenString, refreshTokenString, accessExpiry, nil
+	return &TokenPair{
+		AccessToken:      accessTokenString,
+		RefreshToken:     refreshTokenString,
+		AccessTokenID:    accessTokenID,
+		RefreshTokenID:   refreshTokenID,
+		AccessExpiresAt:  accessExpiry,
+		RefreshExpiresAt: refreshExpiry,
+	}, nil
 }
 
-// ValidateAccessToken validates an access token
+// ValidateAccessToken validates an access token and checks it hasn't been revoked
 func (j *JWTService) ValidateAccessToken(tokenString string) (*Claims, error) {
-	return j.validateToken(tokenString, j.accessSecret, "access")
+	var claims *Claims
+	var err error
+	if j.accessKeys != nil {
+		claims, err = j.validateToken(tokenString, j.accessKeys.keyFunc, j.accessKeys.validMethods(), "access")
+	} else {
+		claims, err = j.validateToken(tokenString, hmacKey(j.accessSecret), []string{"HS256"}, "access")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens without an ID can't be revoked, so they aren't accepted
+	if claims.ID == "" {
+		return nil, errors.New("token has no ID")
+	}
+	if j.revocations != nil && j.revocations.IsRevoked(claims.ID) {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
 }
 
 // ValidateRefreshToken validates a refresh token
 func (j *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return j.validateToken(tokenString, j.refreshSecret, "refresh")
+	return j.validateToken(tokenString, hmacKey(j.refreshSecret), []string{"HS256"}, "refresh")
+}
+
+// signAccessToken signs access token claims with the access key set, or
+// the access secret when there is none
+func (j *JWTService) signAccessToken(claims *Claims) (string, error) {
+	if j.accessKeys != nil {
+		return j.accessKeys.sign(claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.accessSecret)
+}
+
+// JWKS returns the public keys access tokens can be verified with. It is
+// empty when access tokens are HMAC signed.
+func (j *JWTService) JWKS() *JWKS {
+	return j.accessKeys.JWKS()
 }
 
-// validateToken validates a JWT token with the given secret and expected type
-func (j *JWTService) validateToken(tokenString string, secret []byte, expectedType string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+// hmacKey returns a key function for tokens HMAC signed with secret
+func hmacKey(secret []byte) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return secret, nil
-	})
+	}
+}
 
+// validateToken validates a JWT token signed with one of methods, using
+// keyFunc to find the key, and checks its type
+func (j *JWTService) validateToken(tokenString string, keyFunc jwt.Keyfunc, methods []string, expectedType string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyFunc, jwt.WithValidMethods(methods))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -129,40 +214,6 @@ func (j *JWTService) validateToken(tokenString string, secret []byte, expectedTy
 	return claims, nil
 }
 
-// RefreshAccessToken generates a new access token from a refresh token
-func (j *JWTService) RefreshAccessToken(refreshTokenString string) (string, time.Time, error) {
-	claims, err := j.ValidateRefreshToken(refreshTokenString)
-	if err != nil {
-		return "", time.Time{}, fmt.Errorf("invalid refresh token: %w", err)
-	}
-
-	// Create new access token with same user info
-	now := time.Now()
-	accessExpiry := now.Add(j.accessExpiry)
-
-	accessClaims := &Claims{
-		UserID:    claims.UserID,
-		Email:     claims.Email,
-		Role:      claims.Role,
-		TokenType: "access",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(accessExpiry),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    "bagr-auction-system",
-			Subject:   fmt.Sprintf("user:%d", claims.UserID),
-		},
-	}
-
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(j.accessSecret)
-	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to generate new access token: %w", err)
-	}
-
-	return accessTokenString, accessExpiry, nil
-}
-
 // ExtractUserFromToken extracts user information from a token
 func (j *JWTService) ExtractUserFromToken(tokenString string) (*models.User, error) {
 	claims, err := j.ValidateAccessToken(tokenString)
@@ -183,5 +234,11 @@ func (j *JWTService) IsTokenExpired(tokenString string) bool {
 	return err != nil
 }
 
-
-
+// newTokenID generates a random identifier for sessions and refresh tokens
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for signing or verification
+const minRSAKeyBits = 2048
+
+// KeySet holds the asymmetric keys access tokens are signed and verified
+// with. Tokens name their key in the kid header, the key's RFC 7638
+// thumbprint, so retired keys can keep verifying tokens they signed while a
+// new key signs everything issued from now on.
+type KeySet struct {
+	signingID     string
+	signingMethod jwt.SigningMethod
+	signingKey    crypto.Signer
+
+	verification map[string]crypto.PublicKey
+	kids         []string // Verification key IDs in load order, signing key first
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // EC or OKP curve
+	X   string `json:"x,omitempty"`   // EC x coordinate or Ed25519 public key
+	Y   string `json:"y,omitempty"`   // EC y coordinate
+}
+
+// JWKS is a JSON Web Key Set, as served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeySet loads a PEM private key to sign access tokens with, RSA (RS256)
+// or Ed25519 (EdDSA), and PEM public keys of earlier signing keys that should
+// still be accepted. It returns nil when no signing key is configured.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	if signingKeyFile == "" {
+		if len(verificationKeyFiles) > 0 {
+			return nil, errors.New("verification keys are configured without a signing key")
+		}
+		return nil, nil
+	}
+
+	signingKey, err := readPrivateKey(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &KeySet{verification: make(map[string]crypto.PublicKey)}
+	keys.signingMethod, err = signingMethodFor(signingKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", signingKeyFile, err)
+	}
+	keys.signingKey = signingKey
+	keys.signingID = keys.add(signingKey.Public())
+
+	for _, path := range verificationKeyFiles {
+		publicKey, err := readPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := signingMethodFor(publicKey); err != nil {
+			return nil, fmt.Errorf("verification key %s: %w", path, err)
+		}
+		keys.add(publicKey)
+	}
+
+	return keys, nil
+}
+
+// add adds a verification key and returns its key ID
+func (k *KeySet) add(publicKey crypto.PublicKey) string {
+	kid := thumbprint(publicKey)
+	if _, ok := k.verification[kid]; !ok {
+		k.verification[kid] = publicKey
+		k.kids = append(k.kids, kid)
+	}
+	return kid
+}
+
+// sign signs claims with the signing key, naming it in the kid header
+func (k *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signingMethod, claims)
+	token.Header["kid"] = k.signingID
+	return token.SignedString(k.signingKey)
+}
+
+// keyFunc finds the verification key named by a token's kid header, making
+// sure the token was signed with that key's algorithm
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	publicKey, ok := k.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	method, _ := signingMethodFor(publicKey)
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return publicKey, nil
+}
+
+// validMethods lists the algorithms of the verification keys
+func (k *KeySet) validMethods() []string {
+	var methods []string
+	seen := make(map[string]bool)
+	for _, kid := range k.kids {
+		method, _ := signingMethodFor(k.verification[kid])
+		if !seen[method.Alg()] {
+			seen[method.Alg()] = true
+			methods = append(methods, method.Alg())
+		}
+	}
+	return methods
+}
+
+// JWKS returns the verification keys as a JSON Web Key Set
+func (k *KeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+	if k == nil {
+		return jwks
+	}
+
+	for _, kid := range k.kids {
+		method, _ := signingMethodFor(k.verification[kid])
+		jwk := JWK{Kid: kid, Use: "sig", Alg: method.Alg()}
+		switch publicKey := k.verification[kid].(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// signingMethodFor returns the JWT algorithm used with a public key
+func signingMethodFor(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", publicKey)
+	}
+}
+
+// thumbprint returns the RFC 7638 JWK thumbprint of a public key
+func thumbprint(publicKey crypto.PublicKey) string {
+	var canonical string
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		)
+	case ed25519.PublicKey:
+		canonical = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`,
+			base64.RawURLEncoding.EncodeToString(key),
+		)
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// readPEM reads the first PEM block of a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// readPrivateKey reads a PKCS#8 or PKCS#1 PEM private key
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key file %s holds a %q block, not a private key", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T in %s", key, path)
+	}
+	return signer, nil
+}
+
+// readPublicKey reads a PKIX or PKCS#1 PEM public key
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key crypto.PublicKey
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key file %s holds a %q block, not a public key", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,590 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// MFA errors
+var (
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrMFAChallengeInvalid = errors.New("two-factor challenge is invalid or expired; log in again")
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrTOTPNotSetUp        = errors.New("two-factor setup has not been started")
+	ErrMFARequiredByRole   = errors.New("two-factor authentication is required for your role")
+)
+
+const (
+	mfaChallengeTTL    = 5 * time.Minute
+	mfaMaxAttempts     = 5  // Wrong codes before a challenge is used up
+	recoveryCodeCount  = 10 // Codes issued at a time
+	recoveryCodeLength = 10 // Characters per code, shown as two groups of five
+)
+
+// recoveryCodeAlphabet avoids characters that are easy to misread
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz123456789"
+
+// totpState is a user's TOTP enrollment
+type totpState struct {
+	Secret   *string // Set when setup starts; in use once Enabled
+	Enabled  bool
+	LastStep int64 // Newest time step accepted, so a code can't be used twice
+}
+
+// mfaChallenge is a login waiting for its second factor
+type mfaChallenge struct {
+	ID        string // SHA-256 of the challenge token
+	UserID    int
+	ExpiresAt time.Time
+	Attempts  int
+	UsedAt    *time.Time
+}
+
+// usable reports whether the challenge can still complete a login
+func (c *mfaChallenge) usable(now time.Time) bool {
+	return c.UsedAt == nil && now.Before(c.ExpiresAt) && c.Attempts < mfaMaxAttempts
+}
+
+// mfaChallengeFor starts a second-factor challenge when user has TOTP enabled
+// or their role requires it. It returns nil when the password is enough.
+func (a *AuthService) mfaChallengeFor(user *models.User) (*models.MFAChallengeResponse, error) {
+	state, err := a.getTOTPState(a.db, user.ID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	required, err := a.mfaRequiredForRole(user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor policy: %w", err)
+	}
+	if !state.Enabled && !required {
+		return nil, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	expiresAt := time.Now().Add(mfaChallengeTTL)
+
+	query := `
+		INSERT INTO mfa_challenges (id, user_id, expires_at)
+		VALUES ($1, $2, $3)`
+
+	if _, err := a.db.Exec(query, hashChallengeToken(token), user.ID, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to store two-factor challenge: %w", err)
+	}
+
+	return &models.MFAChallengeResponse{
+		MFARequired:        true,
+		ChallengeToken:     token,
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: !state.Enabled,
+	}, nil
+}
+
+// SetupTOTPForChallenge starts the TOTP enrollment a user's role requires
+// before their login can finish
+func (a *AuthService) SetupTOTPForChallenge(challengeToken string) (*models.TOTPSetupResponse, error) {
+	challenge, err := a.getMFAChallenge(a.db, hashChallengeToken(challengeToken), false)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMFAChallengeInvalid
+		}
+		return nil, fmt.Errorf("failed to get two-factor challenge: %w", err)
+	}
+	if !challenge.usable(time.Now()) {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	user, err := a.getUserByID(challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return a.startTOTPSetup(user)
+}
+
+// VerifyMFAChallenge finishes a login with a TOTP or recovery code. When the
+// login was waiting on a required enrollment, the code confirms it and the
+// response carries the new recovery codes. Wrong codes count as failed logins
+// of the account and clientIP, as a new challenge can be had for every five.
+func (a *AuthService) VerifyMFAChallenge(req *models.MFAVerifyRequest, clientIP string) (*models.AuthResponse, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the challenge so parallel guesses can't get past the attempt limit
+	challenge, err := a.getMFAChallenge(tx, hashChallengeToken(req.ChallengeToken), true)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMFAChallengeInvalid
+		}
+		return nil, fmt.Errorf("failed to get two-factor challenge: %w", err)
+	}
+	if !challenge.usable(time.Now()) {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	user, err := a.getUserByID(challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, errors.New("account is not active")
+	}
+	account := strings.ToLower(strings.TrimSpace(user.Email))
+	if err := a.checkLoginThrottle(account, clientIP); err != nil {
+		return nil, err
+	}
+
+	state, err := a.getTOTPState(tx, user.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+
+	var recoveryCodes []string
+	if state.Enabled {
+		err = a.verifySecondFactor(tx, user.ID, state, req.Code)
+	} else {
+		recoveryCodes, err = a.enableTOTP(tx, user.ID, state, req.Code)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		if err := a.recordMFAFailure(tx, challenge.ID); err != nil {
+			return nil, fmt.Errorf("failed to record two-factor failure: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to record two-factor failure: %w", err)
+		}
+		if locked := a.recordLoginFailure(user, account, clientIP); locked != nil {
+			return nil, locked
+		}
+		return nil, ErrInvalidMFACode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.markMFAChallengeUsed(tx, challenge.ID); err != nil {
+		return nil, fmt.Errorf("failed to complete two-factor challenge: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to complete two-factor challenge: %w", err)
+	}
+	a.clearLoginFailures(account)
+
+	if err := a.updateLastLogin(user.ID); err != nil {
+		// Log error but don't fail login
+		utils.GetLogger().WithError(err).Warn("Failed to update last login time")
+	}
+
+	response, err := a.startSession(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+	response.RecoveryCodes = recoveryCodes
+
+	return response, nil
+}
+
+// GetMFAStatus describes a user's two-factor setup
+func (a *AuthService) GetMFAStatus(userID int) (*models.MFAStatusResponse, error) {
+	user, err := a.getUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	state, err := a.getTOTPState(a.db, userID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	required, err := a.mfaRequiredForRole(user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor policy: %w", err)
+	}
+
+	status := &models.MFAStatusResponse{TOTPEnabled: state.Enabled, RequiredByRole: required}
+	query := "SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL"
+	if err := a.db.QueryRow(query, userID).Scan(&status.RecoveryCodesRemaining); err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return status, nil
+}
+
+// SetupTOTP starts TOTP enrollment for a logged-in user. The secret is only
+// used once ConfirmTOTP accepts a code generated from it.
+func (a *AuthService) SetupTOTP(userID int) (*models.TOTPSetupResponse, error) {
+	user, err := a.getUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return a.startTOTPSetup(user)
+}
+
+// ConfirmTOTP enables TOTP with a code from the secret given by SetupTOTP and
+// returns the user's recovery codes
+func (a *AuthService) ConfirmTOTP(userID int, code string) ([]string, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	state, err := a.getTOTPState(tx, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	if state.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	recoveryCodes, err := a.enableTOTP(tx, userID, state, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	utils.GetLogger().WithField("user_id", userID).Info("Two-factor authentication enabled")
+	return recoveryCodes, nil
+}
+
+// DisableTOTP turns TOTP off after checking a current TOTP or recovery code.
+// Users whose role requires 2FA can't turn it off.
+func (a *AuthService) DisableTOTP(userID int, code string) error {
+	user, err := a.getUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	required, err := a.mfaRequiredForRole(user.Role)
+	if err != nil {
+		return fmt.Errorf("failed to get two-factor policy: %w", err)
+	}
+	if required {
+		return ErrMFARequiredByRole
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	state, err := a.getTOTPState(tx, userID, true)
+	if err != nil {
+		return fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	if !state.Enabled {
+		return ErrTOTPNotEnabled
+	}
+	if err := a.verifySecondFactor(tx, userID, state, code); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0, updated_at = $1
+		WHERE id = $2`
+
+	if _, err := tx.Exec(query, time.Now(), userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	utils.GetLogger().WithField("user_id", userID).Info("Two-factor authentication disabled")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking a
+// current TOTP or recovery code
+func (a *AuthService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	state, err := a.getTOTPState(tx, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	if !state.Enabled {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err := a.verifySecondFactor(tx, userID, state, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := a.replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	return recoveryCodes, nil
+}
+
+// GetMFARequiredRoles lists the roles that must use two-factor authentication
+func (a *AuthService) GetMFARequiredRoles() ([]models.UserRole, error) {
+	rows, err := a.db.Query("SELECT role FROM mfa_required_roles ORDER BY role")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor policy: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.UserRole{}
+	for rows.Next() {
+		var role models.UserRole
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("failed to scan two-factor policy: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// SetMFARequiredRoles replaces the roles that must use two-factor
+// authentication. Users of those roles without TOTP have to enroll at their
+// next login.
+func (a *AuthService) SetMFARequiredRoles(roles []models.UserRole) ([]models.UserRole, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM mfa_required_roles"); err != nil {
+		return nil, fmt.Errorf("failed to update two-factor policy: %w", err)
+	}
+	for _, role := range roles {
+		query := "INSERT INTO mfa_required_roles (role) VALUES ($1) ON CONFLICT (role) DO NOTHING"
+		if _, err := tx.Exec(query, role); err != nil {
+			return nil, fmt.Errorf("failed to update two-factor policy: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update two-factor policy: %w", err)
+	}
+
+	utils.GetLogger().WithField("roles", roles).Info("Two-factor policy updated")
+	return a.GetMFARequiredRoles()
+}
+
+// startTOTPSetup stores a new, unconfirmed TOTP secret for user
+func (a *AuthService) startTOTPSetup(user *models.User) (*models.TOTPSetupResponse, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	query := "UPDATE users SET totp_secret = $1, updated_at = $2 WHERE id = $3 AND totp_enabled = FALSE"
+	result, err := a.db.Exec(query, secret, time.Now(), user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	return &models.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: totpURI(secret, user.Email),
+	}, nil
+}
+
+// enableTOTP confirms a pending TOTP secret with a code and issues recovery codes
+func (a *AuthService) enableTOTP(db sqlExecutor, userID int, state *totpState, code string) ([]string, error) {
+	if state.Secret == nil {
+		return nil, ErrTOTPNotSetUp
+	}
+	step, ok := validateTOTP(*state.Secret, normalizeMFACode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	query := "UPDATE users SET totp_enabled = TRUE, totp_last_step = $1, updated_at = $2 WHERE id = $3"
+	if _, err := db.Exec(query, step, time.Now(), userID); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return a.replaceRecoveryCodes(db, userID)
+}
+
+// verifySecondFactor checks a TOTP code, refusing one already used, or uses
+// up a recovery code
+func (a *AuthService) verifySecondFactor(db sqlExecutor, userID int, state *totpState, code string) error {
+	code = normalizeMFACode(code)
+	if len(code) != totpDigits {
+		return a.useRecoveryCode(db, userID, code)
+	}
+
+	step, ok := validateTOTP(*state.Secret, code, time.Now())
+	if !ok || step <= state.LastStep {
+		return ErrInvalidMFACode
+	}
+
+	query := "UPDATE users SET totp_last_step = $1 WHERE id = $2"
+	if _, err := db.Exec(query, step, userID); err != nil {
+		return fmt.Errorf("failed to record TOTP code: %w", err)
+	}
+	return nil
+}
+
+// useRecoveryCode marks the matching unused recovery code as used
+func (a *AuthService) useRecoveryCode(db sqlExecutor, userID int, code string) error {
+	if len(code) != recoveryCodeLength {
+		return ErrInvalidMFACode
+	}
+
+	rows, err := db.Query("SELECT id, code_hash FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID)
+	if err != nil {
+		return fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+
+	matched := 0
+	for rows.Next() {
+		var id int
+		var codeHash string
+		if err := rows.Scan(&id, &codeHash); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan recovery code: %w", err)
+		}
+		if matched == 0 && a.passwordService.VerifyPassword(codeHash, code) == nil {
+			matched = id
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+	if matched == 0 {
+		return ErrInvalidMFACode
+	}
+
+	if _, err := db.Exec("UPDATE recovery_codes SET used_at = $1 WHERE id = $2", time.Now(), matched); err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	utils.GetLogger().WithField("user_id", userID).Info("Recovery code used")
+	return nil
+}
+
+// replaceRecoveryCodes issues a new set of recovery codes, stored hashed
+func (a *AuthService) replaceRecoveryCodes(db sqlExecutor, userID int) ([]string, error) {
+	if _, err := db.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codeHash, err := a.passwordService.HashSecret(code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		if _, err := db.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, codeHash); err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %w", err)
+		}
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+	}
+
+	return codes, nil
+}
+
+// getTOTPState reads a user's TOTP enrollment, locking the user's row when
+// forUpdate is set so concurrent codes are checked one at a time
+func (a *AuthService) getTOTPState(db sqlExecutor, userID int, forUpdate bool) (*totpState, error) {
+	state := &totpState{}
+	query := "SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	err := db.QueryRow(query, userID).Scan(&state.Secret, &state.Enabled, &state.LastStep)
+	return state, err
+}
+
+// mfaRequiredForRole reports whether users of role must use two-factor authentication
+func (a *AuthService) mfaRequiredForRole(role models.UserRole) (bool, error) {
+	var required bool
+	err := a.db.QueryRow("SELECT EXISTS (SELECT 1 FROM mfa_required_roles WHERE role = $1)", role).Scan(&required)
+	return required, err
+}
+
+// getMFAChallenge reads the challenge with the hashed token id, locking it when
+// forUpdate is set. Returns sql.ErrNoRows for unknown challenges.
+func (a *AuthService) getMFAChallenge(db sqlExecutor, id string, forUpdate bool) (*mfaChallenge, error) {
+	challenge := &mfaChallenge{}
+	query := "SELECT id, user_id, expires_at, attempts, used_at FROM mfa_challenges WHERE id = $1"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	err := db.QueryRow(query, id).Scan(
+		&challenge.ID, &challenge.UserID, &challenge.ExpiresAt, &challenge.Attempts, &challenge.UsedAt,
+	)
+	return challenge, err
+}
+
+// recordMFAFailure counts a wrong code against a challenge
+func (a *AuthService) recordMFAFailure(tx *sql.Tx, id string) error {
+	query := "UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1"
+	_, err := tx.Exec(query, id)
+	return err
+}
+
+// markMFAChallengeUsed uses up a challenge once its login has completed
+func (a *AuthService) markMFAChallengeUsed(tx *sql.Tx, id string) error {
+	query := "UPDATE mfa_challenges SET used_at = $1 WHERE id = $2"
+	_, err := tx.Exec(query, time.Now(), id)
+	return err
+}
+
+// hashChallengeToken hashes a challenge token for storage, so a database
+// leak doesn't expose tokens that are still usable
+func hashChallengeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCode generates one random recovery code
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// normalizeMFACode strips the spaces and dashes users type in codes
+func normalizeMFACode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyMFA completes a login that returned an MFA challenge
+// POST /api/v1/auth/mfa/verify
+func (h *AuthHandlers) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	response, err := h.authService.VerifyMFAChallenge(&req, c.ClientIP())
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
+
+// SetupMFAForLogin starts the TOTP enrollment a login challenge requires
+// POST /api/v1/auth/mfa/setup
+func (h *AuthHandlers) SetupMFAForLogin(c *gin.Context) {
+	var req models.MFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	setup, err := h.authService.SetupTOTPForChallenge(req.ChallengeToken)
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Scan the code with your authenticator app, then verify a code to finish logging in", setup)
+}
+
+// GetMFAStatus handles getting the user's two-factor setup
+// GET /api/v1/auth/mfa
+func (h *AuthHandlers) GetMFAStatus(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	status, err := h.authService.GetMFAStatus(uid)
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor status retrieved successfully", status)
+}
+
+// SetupTOTP handles starting TOTP enrollment
+// POST /api/v1/auth/mfa/totp
+func (h *AuthHandlers) SetupTOTP(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	setup, err := h.authService.SetupTOTP(uid)
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Scan the code with your authenticator app, then confirm a code to enable it", setup)
+}
+
+// ConfirmTOTP handles enabling TOTP with a first code
+// POST /api/v1/auth/mfa/totp/confirm
+func (h *AuthHandlers) ConfirmTOTP(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	recoveryCodes, err := h.authService.ConfirmTOTP(uid, req.Code)
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled. Store your recovery codes somewhere safe; they won't be shown again.", models.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// DisableTOTP handles turning TOTP off
+// POST /api/v1/auth/mfa/totp/disable
+func (h *AuthHandlers) DisableTOTP(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	if err := h.authService.DisableTOTP(uid, req.Code); err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes handles replacing the user's recovery codes
+// POST /api/v1/auth/mfa/recovery-codes
+func (h *AuthHandlers) RegenerateRecoveryCodes(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	recoveryCodes, err := h.authService.RegenerateRecoveryCodes(uid, req.Code)
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Recovery codes replaced. Your old codes no longer work.", models.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// GetMFARequiredRoles handles listing the roles that must use 2FA
+// GET /api/v1/auth/mfa/required-roles
+func (h *AuthHandlers) GetMFARequiredRoles(c *gin.Context) {
+	roles, err := h.authService.GetMFARequiredRoles()
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor policy retrieved successfully", models.MFARequiredRoles{Roles: roles})
+}
+
+// SetMFARequiredRoles handles replacing the roles that must use 2FA (admin only)
+// PUT /api/v1/auth/mfa/required-roles
+func (h *AuthHandlers) SetMFARequiredRoles(c *gin.Context) {
+	var req models.MFARequiredRoles
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	roles, err := h.authService.SetMFARequiredRoles(req.Roles)
+	if err != nil {
+		mfaErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor policy updated successfully", models.MFARequiredRoles{Roles: roles})
+}
+
+// mfaErrorResponse maps two-factor errors to responses
+func mfaErrorResponse(c *gin.Context, err error) {
+	var locked *LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		loginLockedResponse(c, locked)
+	case errors.Is(err, ErrInvalidMFACode):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_MFA_CODE", "Invalid two-factor code", err.Error())
+	case errors.Is(err, ErrMFAChallengeInvalid):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "MFA_CHALLENGE_INVALID", "Two-factor challenge is invalid or expired", err.Error())
+	case errors.Is(err, ErrTOTPAlreadyEnabled), errors.Is(err, ErrTOTPNotEnabled), errors.Is(err, ErrTOTPNotSetUp):
+		utils.ErrorResponse(c, http.StatusConflict, "MFA_STATE_CONFLICT", err.Error(), "")
+	case errors.Is(err, ErrMFARequiredByRole):
+		utils.ErrorResponse(c, http.StatusForbidden, "MFA_REQUIRED_BY_ROLE", err.Error(), "")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "MFA_FAILED", "Two-factor request failed", err.Error())
+	}
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMFATables keeps one user's TOTP step, recovery codes and login
+// challenges for the fake database
+type fakeMFATables struct {
+	lastStep      int64
+	recoveryCodes []*fakeRecoveryCode
+	challenges    map[string]*mfaChallenge
+}
+
+type fakeRecoveryCode struct {
+	id     int64
+	hash   string
+	usedAt *time.Time
+}
+
+func (f *fakeMFATables) handle(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case strings.HasPrefix(query, "UPDATE users SET totp_last_step = $1"):
+		f.lastStep = args[0].(int64)
+		return &fakeRows{affected: 1}, nil
+
+	case strings.HasPrefix(query, "DELETE FROM recovery_codes WHERE user_id = $1"):
+		f.recoveryCodes = nil
+		return &fakeRows{}, nil
+	case strings.HasPrefix(query, "INSERT INTO recovery_codes"):
+		f.recoveryCodes = append(f.recoveryCodes, &fakeRecoveryCode{id: int64(len(f.recoveryCodes) + 1), hash: args[1].(string)})
+		return &fakeRows{affected: 1}, nil
+	case strings.HasPrefix(query, "SELECT id, code_hash FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL"):
+		rows := &fakeRows{columns: []string{"id", "code_hash"}}
+		for _, code := range f.recoveryCodes {
+			if code.usedAt == nil {
+				rows.values = append(rows.values, []driver.Value{code.id, code.hash})
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "UPDATE recovery_codes SET used_at = $1 WHERE id = $2"):
+		for _, code := range f.recoveryCodes {
+			if code.id == args[1].(int64) {
+				usedAt := args[0].(time.Time)
+				code.usedAt = &usedAt
+				return &fakeRows{affected: 1}, nil
+			}
+		}
+		return &fakeRows{}, nil
+
+	case strings.HasPrefix(query, "SELECT id, user_id, expires_at, attempts, used_at FROM mfa_challenges WHERE id = $1"):
+		rows := &fakeRows{columns: []string{"id", "user_id", "expires_at", "attempts", "used_at"}}
+		if c, ok := f.challenges[args[0].(string)]; ok {
+			var usedAt driver.Value
+			if c.UsedAt != nil {
+				usedAt = *c.UsedAt
+			}
+			rows.values = append(rows.values, []driver.Value{c.ID, int64(c.UserID), c.ExpiresAt, int64(c.Attempts), usedAt})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1"):
+		if c, ok := f.challenges[args[0].(string)]; ok {
+			c.Attempts++
+			return &fakeRows{affected: 1}, nil
+		}
+		return &fakeRows{}, nil
+	}
+	return nil, unexpectedQuery(query)
+}
+
+// newMFATestService returns a service whose database is tables
+func newMFATestService(t *testing.T, tables *fakeMFATables) *AuthService {
+	return &AuthService{
+		db:              newFakeDB(t, tables.handle),
+		passwordService: NewPasswordService(),
+	}
+}
+
+func TestVerifySecondFactorRefusesReplay(t *testing.T) {
+	tables := &fakeMFATables{}
+	service := newMFATestService(t, tables)
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := rfc6238Secret
+	state := &totpState{Secret: &secret, Enabled: true}
+	current := time.Now().Unix() / totpPeriod
+
+	if err := service.verifySecondFactor(service.db, 1, state, totpCode(key, current)); err != nil {
+		t.Fatalf("current code rejected: %v", err)
+	}
+	if tables.lastStep < current {
+		t.Fatalf("last step = %d, want at least %d", tables.lastStep, current)
+	}
+
+	// The same code, or an older one still inside the window, can't be used again
+	state.LastStep = tables.lastStep
+	for name, step := range map[string]int64{"same code": state.LastStep, "earlier code": state.LastStep - 1} {
+		if err := service.verifySecondFactor(service.db, 1, state, totpCode(key, step)); !errors.Is(err, ErrInvalidMFACode) {
+			t.Errorf("%s: got %v, want ErrInvalidMFACode", name, err)
+		}
+	}
+
+	if err := service.verifySecondFactor(service.db, 1, state, totpCode(key, current+1)); err != nil {
+		t.Errorf("next step's code rejected: %v", err)
+	}
+}
+
+func TestRecoveryCodesWorkOnce(t *testing.T) {
+	tables := &fakeMFATables{}
+	service := newMFATestService(t, tables)
+	secret := rfc6238Secret
+	state := &totpState{Secret: &secret, Enabled: true}
+
+	codes, err := service.replaceRecoveryCodes(service.db, 1)
+	if err != nil {
+		t.Fatalf("replaceRecoveryCodes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("issued %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	if err := service.verifySecondFactor(service.db, 1, state, codes[0]); err != nil {
+		t.Fatalf("recovery code rejected: %v", err)
+	}
+	if err := service.verifySecondFactor(service.db, 1, state, codes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("used recovery code: got %v, want ErrInvalidMFACode", err)
+	}
+
+	// Codes are typed without care for case, dashes or spaces
+	typed := strings.ToUpper(strings.ReplaceAll(codes[1], "-", " "))
+	if err := service.verifySecondFactor(service.db, 1, state, typed); err != nil {
+		t.Errorf("recovery code typed as %q rejected: %v", typed, err)
+	}
+
+	// Issuing new codes retires the old ones
+	if _, err := service.replaceRecoveryCodes(service.db, 1); err != nil {
+		t.Fatalf("replaceRecoveryCodes: %v", err)
+	}
+	if err := service.verifySecondFactor(service.db, 1, state, codes[2]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replaced recovery code: got %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestMFAChallengeAttemptLimit(t *testing.T) {
+	id := hashChallengeToken("challenge-token")
+	tables := &fakeMFATables{challenges: map[string]*mfaChallenge{
+		id: {ID: id, UserID: 1, ExpiresAt: time.Now().Add(mfaChallengeTTL)},
+	}}
+	service := newMFATestService(t, tables)
+
+	for attempt := 1; attempt <= mfaMaxAttempts; attempt++ {
+		challenge, err := service.getMFAChallenge(service.db, id, true)
+		if err != nil {
+			t.Fatalf("getMFAChallenge: %v", err)
+		}
+		if !challenge.usable(time.Now()) {
+			t.Fatalf("challenge unusable after %d wrong codes", attempt-1)
+		}
+
+		tx, err := service.db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.recordMFAFailure(tx, id); err != nil {
+			t.Fatalf("recordMFAFailure: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	challenge, err := service.getMFAChallenge(service.db, id, true)
+	if err != nil {
+		t.Fatalf("getMFAChallenge: %v", err)
+	}
+	if challenge.usable(time.Now()) {
+		t.Errorf("challenge usable after %d wrong codes", challenge.Attempts)
+	}
+}
+
+func TestMFAChallengeUsable(t *testing.T) {
+	now := time.Now()
+	used := now.Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		challenge mfaChallenge
+		want      bool
+	}{
+		{"fresh", mfaChallenge{ExpiresAt: now.Add(time.Minute)}, true},
+		{"one attempt left", mfaChallenge{ExpiresAt: now.Add(time.Minute), Attempts: mfaMaxAttempts - 1}, true},
+		{"attempts used up", mfaChallenge{ExpiresAt: now.Add(time.Minute), Attempts: mfaMaxAttempts}, false},
+		{"expired", mfaChallenge{ExpiresAt: now}, false},
+		{"already used", mfaChallenge{ExpiresAt: now.Add(time.Minute), UsedAt: &used}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.challenge.usable(now); got != tt.want {
+				t.Errorf("usable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrOIDCTokenInvalid is returned when a provider's tokens fail validation
+var ErrOIDCTokenInvalid = errors.New("identity provider returned an invalid token")
+
+const (
+	oidcHTTPTimeout      = 10 * time.Second
+	oidcJWKSRefreshEvery = time.Minute // Least time between JWKS reloads for unknown key IDs
+	oidcClockSkew        = time.Minute
+	oidcMaxResponseSize  = 1 << 20
+)
+
+// OIDCProviderConfig is one OpenID Connect provider's client registration
+type OIDCProviderConfig struct {
+	Name         string // Used in the login URLs and stored with linked identities
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	ResponseMode string // Empty for the default query response mode
+	RedirectURL  string // This service's callback for the provider
+}
+
+// oidcDiscovery is the part of a provider's discovery document the client uses
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcIdentity is the user an ID token vouches for
+type oidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// idTokenClaims are the ID token claims the client reads
+type idTokenClaims struct {
+	Nonce         string      `json:"nonce"`
+	AuthorizedBy  string      `json:"azp"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // Apple sends "true" as a string
+	GivenName     string      `json:"given_name"`
+	FamilyName    string      `json:"family_name"`
+	jwt.RegisteredClaims
+}
+
+// oidcProvider is an OpenID Connect client for one provider. Discovery and
+// the provider's signing keys are fetched on first use and cached.
+type oidcProvider struct {
+	config OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func newOIDCProvider(config OIDCProviderConfig) *oidcProvider {
+	return &oidcProvider{
+		config: config,
+		client: &http.Client{Timeout: oidcHTTPTimeout},
+	}
+}
+
+// authorizationURL builds the URL users are sent to to log in at the
+// provider, using PKCE with the S256 challenge of codeVerifier
+func (p *oidcProvider) authorizationURL(state, nonce, codeVerifier, loginHint string) (string, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if p.config.ResponseMode != "" {
+		query.Set("response_mode", p.config.ResponseMode)
+	}
+	if loginHint != "" {
+		query.Set("login_hint", loginHint)
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// exchangeCode redeems an authorization code and validates the ID token
+// that comes back
+func (p *oidcProvider) exchangeCode(code, codeVerifier, nonce string) (*oidcIdentity, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	resp, err := p.client.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("%w: token request failed: %s %s", ErrOIDCTokenInvalid, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no ID token in token response", ErrOIDCTokenInvalid)
+	}
+
+	return p.verifyIDToken(tokens.IDToken, nonce)
+}
+
+// verifyIDToken checks an ID token's signature against the provider's keys,
+// its issuer, audience, lifetime and nonce
+func (p *oidcProvider) verifyIDToken(rawToken, nonce string) (*oidcIdentity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrOIDCTokenInvalid)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrOIDCTokenInvalid)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.config.ClientID {
+		return nil, fmt.Errorf("%w: token was issued to another client", ErrOIDCTokenInvalid)
+	}
+
+	identity := &oidcIdentity{
+		Subject:    claims.Subject,
+		Email:      strings.ToLower(strings.TrimSpace(claims.Email)),
+		GivenName:  claims.GivenName,
+		FamilyName: claims.FamilyName,
+	}
+	switch verified := claims.EmailVerified.(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	return identity, nil
+}
+
+// keyFunc finds the provider key named by a token's kid header, reloading the
+// provider's keys when the kid is new, since providers rotate their keys
+func (p *oidcProvider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.keys[kid]
+	if !ok && time.Since(p.keysFetchedAt) >= oidcJWKSRefreshEvery {
+		if err := p.loadKeys(); err != nil {
+			return nil, err
+		}
+		key, ok = p.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var expected string
+	switch key.(type) {
+	case *rsa.PublicKey:
+		expected = "RS256"
+	case *ecdsa.PublicKey:
+		expected = "ES256"
+	case ed25519.PublicKey:
+		expected = "EdDSA"
+	}
+	if token.Method.Alg() != expected {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key, nil
+}
+
+// getDiscovery returns the provider's discovery document, fetching it the
+// first time
+func (p *oidcProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	discovery := &oidcDiscovery{}
+	if err := p.getJSON(p.config.Issuer+"/.well-known/openid-configuration", discovery); err != nil {
+		return nil, fmt.Errorf("failed to get %s discovery document: %w", p.config.Name, err)
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("%s discovery document is for issuer %q", p.config.Name, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%s discovery document is missing endpoints", p.config.Name)
+	}
+
+	p.discovery = discovery
+	return discovery, nil
+}
+
+// loadKeys reloads the provider's signing keys. Keys of unsupported types
+// are skipped. The caller holds p.mu.
+func (p *oidcProvider) loadKeys() error {
+	if p.discovery == nil {
+		return errors.New("discovery document not loaded")
+	}
+	p.keysFetchedAt = time.Now()
+
+	var jwks struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := p.getJSON(p.discovery.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("failed to get %s signing keys: %w", p.config.Name, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+	return nil
+}
+
+// getJSON fetches and decodes a JSON document
+func (p *oidcProvider) getJSON(url string, v interface{}) error {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(v)
+}
+
+// publicKey parses an RSA, P-256 or Ed25519 JWK
+func (k JWK) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch {
+	case k.Kty == "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		return key, nil
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid P-256 key")
+		}
+		return key, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// newOIDCSecret generates a random state, nonce or PKCE code verifier
+func newOIDCSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
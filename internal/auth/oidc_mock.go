@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const mockOIDCCodeTTL = time.Minute
+
+// MockOIDCProvider is an OpenID provider for development and tests. It logs
+// in whoever the authorization request's login_hint names without asking
+// for a password, or shows a form asking for an email when there is none.
+// Unknown emails log in as a new user with a verified email; AddUser sets up
+// other cases. Client secrets are not checked, PKCE is.
+//
+// In tests, serve it with httptest using the server's URL as the issuer.
+type MockOIDCProvider struct {
+	issuer string
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu    sync.Mutex
+	users map[string]MockOIDCUser // By email
+	codes map[string]*mockOIDCCode
+}
+
+// MockOIDCUser is a user of the mock provider
+type MockOIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// mockOIDCCode is an issued authorization code
+type mockOIDCCode struct {
+	user          MockOIDCUser
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+// NewMockOIDCProvider creates a mock provider serving issuer, the URL its
+// handler is reachable at
+func NewMockOIDCProvider(issuer string) (*MockOIDCProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mock provider key: %w", err)
+	}
+
+	return &MockOIDCProvider{
+		issuer: strings.TrimRight(issuer, "/"),
+		key:    key,
+		kid:    thumbprint(&key.PublicKey),
+		users:  make(map[string]MockOIDCUser),
+		codes:  make(map[string]*mockOIDCCode),
+	}, nil
+}
+
+// AddUser adds or replaces a user, keyed by email
+func (m *MockOIDCProvider) AddUser(user MockOIDCUser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[strings.ToLower(user.Email)] = user
+}
+
+// ServeHTTP serves discovery, authorization, token and JWKS endpoints under
+// the issuer's path
+func (m *MockOIDCProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+		m.writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                m.issuer,
+			"authorization_endpoint":                m.issuer + "/authorize",
+			"token_endpoint":                        m.issuer + "/token",
+			"jwks_uri":                              m.issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	case strings.HasSuffix(r.URL.Path, "/authorize"):
+		m.authorize(w, r)
+	case strings.HasSuffix(r.URL.Path, "/token") && r.Method == http.MethodPost:
+		m.token(w, r)
+	case strings.HasSuffix(r.URL.Path, "/jwks"):
+		m.writeJSON(w, http.StatusOK, JWKS{Keys: []JWK{{
+			Kty: "RSA",
+			Kid: m.kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1}),
+		}}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// mockLoginForm asks for the email to log in as, keeping the other parameters
+var mockLoginForm = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html><body>
+<h1>Mock OpenID provider</h1>
+<form method="get">
+{{range $name, $values := .}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<label>Log in as <input type="email" name="login_hint" required></label>
+<button type="submit">Continue</button>
+</form>
+</body></html>`))
+
+// mockFormPost returns the authorization response as an auto-submitting form
+var mockFormPost = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html><body onload="document.forms[0].submit()">
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="code" value="{{.Code}}">
+<input type="hidden" name="state" value="{{.State}}">
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body></html>`))
+
+// authorize issues a code for the login_hint user
+func (m *MockOIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if _, err := url.ParseRequestURI(redirectURI); err != nil || query.Get("client_id") == "" {
+		http.Error(w, "client_id and redirect_uri are required", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "only the code flow with S256 PKCE is supported", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(query.Get("login_hint")))
+	if email == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mockLoginForm.Execute(w, query)
+		return
+	}
+
+	m.mu.Lock()
+	user, ok := m.users[email]
+	if !ok {
+		sum := sha256.Sum256([]byte(email))
+		user = MockOIDCUser{
+			Subject:       "mock-" + base64.RawURLEncoding.EncodeToString(sum[:12]),
+			Email:         email,
+			EmailVerified: true,
+		}
+	}
+	code, err := newOIDCSecret()
+	if err != nil {
+		m.mu.Unlock()
+		http.Error(w, "failed to generate code", http.StatusInternalServerError)
+		return
+	}
+	m.codes[code] = &mockOIDCCode{
+		user:          user,
+		clientID:      query.Get("client_id"),
+		redirectURI:   redirectURI,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		expiresAt:     time.Now().Add(mockOIDCCodeTTL),
+	}
+	m.mu.Unlock()
+
+	if query.Get("response_mode") == "form_post" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		mockFormPost.Execute(w, map[string]string{"Action": redirectURI, "Code": code, "State": query.Get("state")})
+		return
+	}
+
+	callback, _ := url.Parse(redirectURI)
+	params := callback.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	callback.RawQuery = params.Encode()
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+// token redeems a code for a signed ID token
+func (m *MockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		m.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	m.mu.Lock()
+	code, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || time.Now().After(code.expiresAt) ||
+		code.clientID != r.PostForm.Get("client_id") ||
+		code.redirectURI != r.PostForm.Get("redirect_uri") ||
+		code.codeChallenge != base64.RawURLEncoding.EncodeToString(verifier[:]) {
+		m.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            m.issuer,
+		"sub":            code.user.Subject,
+		"aud":            code.clientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          code.nonce,
+		"email":          code.user.Email,
+		"email_verified": code.user.EmailVerified,
+		"given_name":     code.user.GivenName,
+		"family_name":    code.user.FamilyName,
+	})
+	token.Header["kid"] = m.kid
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		m.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	m.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (m *MockOIDCProvider) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"bagr-backend/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOIDCClientID    = "bagr"
+	testOIDCRedirectURL = "https://bagr.app/api/v1/auth/oidc/mock/callback"
+)
+
+// newTestOIDCProvider serves a mock provider and returns a client for it
+func newTestOIDCProvider(t *testing.T) (*oidcProvider, *MockOIDCProvider) {
+	t.Helper()
+	var mock *MockOIDCProvider
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mock.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	mock, err := NewMockOIDCProvider(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := newOIDCProvider(OIDCProviderConfig{
+		Name:        "mock",
+		Issuer:      server.URL,
+		ClientID:    testOIDCClientID,
+		Scopes:      []string{"openid", "email", "profile"},
+		RedirectURL: testOIDCRedirectURL,
+	})
+	return provider, mock
+}
+
+// authorize logs in as email at the provider and returns the authorization
+// code it redirects back with
+func authorize(t *testing.T, provider *oidcProvider, nonce, codeVerifier, email string) string {
+	t.Helper()
+	authURL, err := provider.authorizationURL("state", nonce, codeVerifier, email)
+	if err != nil {
+		t.Fatalf("authorizationURL: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization returned %d, Location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if callback.Query().Get("state") != "state" {
+		t.Fatalf("state = %q, want %q", callback.Query().Get("state"), "state")
+	}
+	return callback.Query().Get("code")
+}
+
+// signIDToken signs claims with the mock provider's key, for tokens the
+// mock wouldn't issue
+func signIDToken(t *testing.T, mock *MockOIDCProvider, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = mock.kid
+	signed, err := token.SignedString(mock.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOIDCExchangeCode(t *testing.T) {
+	provider, mock := newTestOIDCProvider(t)
+	mock.AddUser(MockOIDCUser{
+		Subject:       "subject-1",
+		Email:         "Artist@Example.com",
+		EmailVerified: true,
+		GivenName:     "Ada",
+	})
+
+	code := authorize(t, provider, "nonce", "verifier", "artist@example.com")
+	identity, err := provider.exchangeCode(code, "verifier", "nonce")
+	if err != nil {
+		t.Fatalf("exchangeCode: %v", err)
+	}
+	if identity.Subject != "subject-1" || identity.Email != "artist@example.com" || !identity.EmailVerified || identity.GivenName != "Ada" {
+		t.Errorf("identity = %+v", identity)
+	}
+}
+
+func TestOIDCExchangeCodeNonceMismatch(t *testing.T) {
+	provider, _ := newTestOIDCProvider(t)
+
+	// The ID token carries the nonce of the authorization request; a
+	// callback replayed into another login attempt has a different one
+	code := authorize(t, provider, "nonce", "verifier", "fan@example.com")
+	if _, err := provider.exchangeCode(code, "verifier", "other-nonce"); !errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Fatalf("got %v, want ErrOIDCTokenInvalid", err)
+	}
+}
+
+func TestOIDCExchangeCodeWrongVerifier(t *testing.T) {
+	provider, _ := newTestOIDCProvider(t)
+
+	code := authorize(t, provider, "nonce", "verifier", "fan@example.com")
+	if _, err := provider.exchangeCode(code, "other-verifier", "nonce"); !errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Fatalf("got %v, want ErrOIDCTokenInvalid", err)
+	}
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	provider, mock := newTestOIDCProvider(t)
+	if _, err := provider.getDiscovery(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss":            mock.issuer,
+			"sub":            "subject-1",
+			"aud":            testOIDCClientID,
+			"exp":            now.Add(time.Hour).Unix(),
+			"iat":            now.Unix(),
+			"nonce":          "nonce",
+			"email":          "fan@example.com",
+			"email_verified": true,
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name         string
+		claims       jwt.MapClaims
+		nonce        string
+		wantInvalid  bool
+		wantVerified bool
+	}{
+		{"valid", claims(nil), "nonce", false, true},
+		{"nonce mismatch", claims(nil), "other-nonce", true, false},
+		{"no nonce in token", claims(jwt.MapClaims{"nonce": nil}), "nonce", true, false},
+		{"no nonce expected", claims(jwt.MapClaims{"nonce": ""}), "", true, false},
+		{"other issuer", claims(jwt.MapClaims{"iss": "https://evil.example"}), "nonce", true, false},
+		{"other audience", claims(jwt.MapClaims{"aud": "other-client"}), "nonce", true, false},
+		{"issued to another client", claims(jwt.MapClaims{"aud": []string{testOIDCClientID, "other-client"}, "azp": "other-client"}), "nonce", true, false},
+		{"expired", claims(jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()}), "nonce", true, false},
+		{"no subject", claims(jwt.MapClaims{"sub": nil}), "nonce", true, false},
+		{"email unverified", claims(jwt.MapClaims{"email_verified": false}), "nonce", false, false},
+		{"email verified as string", claims(jwt.MapClaims{"email_verified": "true"}), "nonce", false, true},
+		{"email unverified as string", claims(jwt.MapClaims{"email_verified": "false"}), "nonce", false, false},
+		{"email verification missing", claims(jwt.MapClaims{"email_verified": nil}), "nonce", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := provider.verifyIDToken(signIDToken(t, mock, tt.claims), tt.nonce)
+			if tt.wantInvalid {
+				if !errors.Is(err, ErrOIDCTokenInvalid) {
+					t.Fatalf("got %v, want ErrOIDCTokenInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if identity.EmailVerified != tt.wantVerified {
+				t.Errorf("email verified = %v, want %v", identity.EmailVerified, tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyIDTokenForeignKey(t *testing.T) {
+	provider, mock := newTestOIDCProvider(t)
+	if _, err := provider.getDiscovery(); err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewMockOIDCProvider(mock.issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token signed by a key the provider doesn't publish, even with the
+	// provider's kid, is refused
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   mock.issuer,
+		"sub":   "subject-1",
+		"aud":   testOIDCClientID,
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nonce": "nonce",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = mock.kid
+	signed, err := token.SignedString(other.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.verifyIDToken(signed, "nonce"); !errors.Is(err, ErrOIDCTokenInvalid) {
+		t.Fatalf("got %v, want ErrOIDCTokenInvalid", err)
+	}
+}
+
+func TestOIDCUnverifiedEmailIsNotMatched(t *testing.T) {
+	provider, mock := newTestOIDCProvider(t)
+	mock.AddUser(MockOIDCUser{Subject: "subject-2", Email: "artist@example.com", EmailVerified: false})
+
+	code := authorize(t, provider, "nonce", "verifier", "artist@example.com")
+	identity, err := provider.exchangeCode(code, "verifier", "nonce")
+	if err != nil {
+		t.Fatalf("exchangeCode: %v", err)
+	}
+	if identity.EmailVerified {
+		t.Fatal("identity email verified, want unverified")
+	}
+
+	// Neither the existing account nor a new one may be had with an email
+	// the provider hasn't verified
+	existing := &models.User{Email: "artist@example.com", EmailVerified: true}
+	if err := checkEmailMatch(identity, existing); !errors.Is(err, ErrOIDCEmailUnverified) {
+		t.Errorf("existing account: got %v, want ErrOIDCEmailUnverified", err)
+	}
+	if err := checkEmailMatch(identity, nil); !errors.Is(err, ErrOIDCEmailUnverified) {
+		t.Errorf("new account: got %v, want ErrOIDCEmailUnverified", err)
+	}
+}
+
+func TestCheckEmailMatch(t *testing.T) {
+	verified := &oidcIdentity{Subject: "subject-1", Email: "fan@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name     string
+		identity *oidcIdentity
+		user     *models.User
+		wantErr  error
+	}{
+		{"new user", verified, nil, nil},
+		{"verified account", verified, &models.User{Email: "fan@example.com", EmailVerified: true}, nil},
+		{"unverified account", verified, &models.User{Email: "fan@example.com", EmailVerified: false}, ErrOIDCAccountUnverified},
+		{"unverified identity", &oidcIdentity{Subject: "subject-1", Email: "fan@example.com"}, nil, ErrOIDCEmailUnverified},
+		{"no email", &oidcIdentity{Subject: "subject-1", EmailVerified: true}, nil, ErrOIDCEmailUnverified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkEmailMatch(tt.identity, tt.user); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
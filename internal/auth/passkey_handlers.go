@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BeginPasskeyRegistration handles starting to register a passkey
+// POST /api/v1/auth/webauthn/register/begin
+func (h *AuthHandlers) BeginPasskeyRegistration(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	options, err := h.authService.BeginPasskeyRegistration(uid)
+	if err != nil {
+		passkeyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Create the passkey with these options, then finish the registration", options)
+}
+
+// FinishPasskeyRegistration handles storing a passkey created by the browser
+// POST /api/v1/auth/webauthn/register/finish
+func (h *AuthHandlers) FinishPasskeyRegistration(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	var req models.PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	passkey, err := h.authService.FinishPasskeyRegistration(uid, &req)
+	if err != nil {
+		passkeyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Passkey registered", passkey)
+}
+
+// ListPasskeys handles listing the user's passkeys
+// GET /api/v1/auth/webauthn/credentials
+func (h *AuthHandlers) ListPasskeys(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	passkeys, err := h.authService.ListPasskeys(uid)
+	if err != nil {
+		passkeyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Passkeys retrieved", passkeys)
+}
+
+// DeletePasskey handles removing one of the user's passkeys
+// DELETE /api/v1/auth/webauthn/credentials/:id
+func (h *AuthHandlers) DeletePasskey(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	passkeyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || passkeyID <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ID", "Invalid passkey ID", "")
+		return
+	}
+
+	if err := h.authService.DeletePasskey(uid, passkeyID); err != nil {
+		passkeyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Passkey deleted", nil)
+}
+
+// BeginPasskeyLogin handles starting a passkey login
+// POST /api/v1/auth/webauthn/login/begin
+func (h *AuthHandlers) BeginPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginBeginRequest
+	// The body is optional: without an email any passkey for this site works
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+			return
+		}
+	}
+
+	options, err := h.authService.BeginPasskeyLogin(&req)
+	if err != nil {
+		passkeyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sign the challenge with a passkey, then finish the login", options)
+}
+
+// FinishPasskeyLogin handles logging in with a passkey assertion
+// POST /api/v1/auth/webauthn/login/finish
+func (h *AuthHandlers) FinishPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	response, err := h.authService.FinishPasskeyLogin(&req)
+	if err != nil {
+		passkeyErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
+
+// passkeyErrorResponse maps passkey errors to responses
+func passkeyErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidPasskey):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_PASSKEY", "Passkey verification failed", err.Error())
+	case errors.Is(err, ErrPasskeyChallengeInvalid):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "PASSKEY_CHALLENGE_INVALID", "Passkey challenge is invalid or expired", err.Error())
+	case errors.Is(err, ErrPasskeyAlreadyRegistered):
+		utils.ErrorResponse(c, http.StatusConflict, "PASSKEY_ALREADY_REGISTERED", err.Error(), "")
+	case errors.Is(err, ErrPasskeyNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "PASSKEY_NOT_FOUND", err.Error(), "")
+	case errors.Is(err, ErrPasskeyAccountInactive), errors.Is(err, ErrPasskeyEmailUnverified):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "LOGIN_FAILED", "Login failed", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "PASSKEY_FAILED", "Passkey request failed", err.Error())
+	}
+}
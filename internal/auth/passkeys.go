@@ -0,0 +1,457 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// Passkey errors
+var (
+	ErrPasskeyChallengeInvalid  = errors.New("passkey challenge is invalid or expired; start again")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+	ErrPasskeyAlreadyRegistered = errors.New("passkey is already registered")
+	ErrPasskeyAccountInactive   = errors.New("account is not active")
+	ErrPasskeyEmailUnverified   = errors.New("please verify your email before logging in")
+)
+
+// Passkey ceremonies, as stored with their challenges
+const (
+	ceremonyRegister = "register"
+	ceremonyLogin    = "login"
+)
+
+const (
+	passkeyChallengeTTL  = 5 * time.Minute
+	passkeyUserHandleLen = 32   // Bytes of the random user handle
+	maxCredentialIDLen   = 1023 // Longest credential ID WebAuthn allows
+	defaultPasskeyName   = "Passkey"
+)
+
+// passkeyAlgorithms are the accepted key algorithms, in order of preference
+var passkeyAlgorithms = []int{coseAlgES256, coseAlgEdDSA, coseAlgRS256}
+
+// passkeyCredential is a stored WebAuthn credential
+type passkeyCredential struct {
+	ID        int
+	UserID    int
+	PublicKey []byte // COSE_Key
+	SignCount int64
+}
+
+// BeginPasskeyRegistration starts registering a passkey for a logged-in user.
+// Passkeys must verify the user (PIN or biometrics), which is why a passkey
+// login doesn't also ask for a TOTP code.
+func (a *AuthService) BeginPasskeyRegistration(userID int) (*models.PasskeyCreationOptions, error) {
+	user, err := a.getUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	userHandle, err := a.passkeyUserHandle(userID)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := a.passkeyDescriptors(userID)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := a.newPasskeyChallenge(&userID, ceremonyRegister)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &models.PasskeyCreationOptions{
+		Challenge: challenge,
+		RP:        models.PasskeyRelyingParty{ID: a.webauthn.RPID, Name: a.webauthn.RPName},
+		User: models.PasskeyUser{
+			ID:          base64.RawURLEncoding.EncodeToString(userHandle),
+			Name:        user.Email,
+			DisplayName: user.Username,
+		},
+		Timeout:            passkeyChallengeTTL.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: models.PasskeyAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+	for _, alg := range passkeyAlgorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, models.PasskeyCredentialParameter{Type: "public-key", Alg: alg})
+	}
+
+	return options, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's response to
+// BeginPasskeyRegistration and stores the new credential
+func (a *AuthService) FinishPasskeyRegistration(userID int, req *models.PasskeyRegisterRequest) (*models.Passkey, error) {
+	response := req.Credential.Response
+	clientDataJSON, err := decodeBase64URL(response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	attestationObject, err := decodeBase64URL(response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	credentialID, err := decodeBase64URL(req.Credential.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := a.webauthn.parseClientData(clientDataJSON, "webauthn.create")
+	if err != nil {
+		return nil, err
+	}
+	challengeUserID, err := a.consumePasskeyChallenge(challenge, ceremonyRegister)
+	if err != nil {
+		return nil, err
+	}
+	if challengeUserID == nil || *challengeUserID != userID {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+
+	rawAuthData, err := parseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := a.webauthn.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, fmt.Errorf("%w: no credential was created", ErrInvalidPasskey)
+	}
+	if len(authData.CredentialID) > maxCredentialIDLen || !bytes.Equal(authData.CredentialID, credentialID) {
+		return nil, fmt.Errorf("%w: credential ID does not match", ErrInvalidPasskey)
+	}
+	_, algorithm, err := parseCOSEKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey := &models.Passkey{Name: req.Name}
+	if passkey.Name == "" {
+		passkey.Name = defaultPasskeyName
+	}
+
+	query := `
+		INSERT INTO webauthn_credentials (user_id, credential_id, public_key, algorithm, sign_count, name)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (credential_id) DO NOTHING
+		RETURNING id, created_at`
+
+	err = a.db.QueryRow(query,
+		userID, authData.CredentialID, authData.PublicKey, algorithm, authData.SignCount, passkey.Name,
+	).Scan(&passkey.ID, &passkey.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPasskeyAlreadyRegistered
+		}
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id":    userID,
+		"passkey_id": passkey.ID,
+	}).Info("Passkey registered")
+
+	return passkey, nil
+}
+
+// ListPasskeys lists a user's passkeys
+func (a *AuthService) ListPasskeys(userID int) ([]models.Passkey, error) {
+	query := `
+		SELECT id, name, created_at, last_used_at
+		FROM webauthn_credentials WHERE user_id = $1
+		ORDER BY created_at`
+
+	rows, err := a.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	defer rows.Close()
+
+	passkeys := []models.Passkey{}
+	for rows.Next() {
+		var passkey models.Passkey
+		if err := rows.Scan(&passkey.ID, &passkey.Name, &passkey.CreatedAt, &passkey.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan passkey: %w", err)
+		}
+		passkeys = append(passkeys, passkey)
+	}
+	return passkeys, rows.Err()
+}
+
+// DeletePasskey removes one of a user's passkeys
+func (a *AuthService) DeletePasskey(userID, passkeyID int) error {
+	result, err := a.db.Exec("DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2", passkeyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrPasskeyNotFound
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id":    userID,
+		"passkey_id": passkeyID,
+	}).Info("Passkey deleted")
+	return nil
+}
+
+// BeginPasskeyLogin starts a passkey login. With an email the user's passkeys
+// are listed for the browser to choose from; an unknown email gets an empty
+// list, the same as a user without passkeys, so it can't be used to probe
+// for accounts.
+func (a *AuthService) BeginPasskeyLogin(req *models.PasskeyLoginBeginRequest) (*models.PasskeyRequestOptions, error) {
+	options := &models.PasskeyRequestOptions{
+		RPID:             a.webauthn.RPID,
+		Timeout:          passkeyChallengeTTL.Milliseconds(),
+		AllowCredentials: []models.PasskeyCredentialDescriptor{},
+		UserVerification: "required",
+	}
+
+	var userID *int
+	if req.Email != "" {
+		user, err := a.getUserByEmail(req.Email)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if err == nil {
+			userID = &user.ID
+			options.AllowCredentials, err = a.passkeyDescriptors(user.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	challenge, err := a.newPasskeyChallenge(userID, ceremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+	options.Challenge = challenge
+
+	return options, nil
+}
+
+// FinishPasskeyLogin verifies a passkey assertion and logs its owner in
+func (a *AuthService) FinishPasskeyLogin(req *models.PasskeyLoginRequest) (*models.AuthResponse, error) {
+	clientDataJSON, err := decodeBase64URL(req.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	rawAuthData, err := decodeBase64URL(req.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := decodeBase64URL(req.Response.Signature)
+	if err != nil {
+		return nil, err
+	}
+	credentialID, err := decodeBase64URL(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := a.webauthn.parseClientData(clientDataJSON, "webauthn.get")
+	if err != nil {
+		return nil, err
+	}
+	challengeUserID, err := a.consumePasskeyChallenge(challenge, ceremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	authData, err := a.webauthn.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the credential so parallel logins see each other's sign counts
+	credential, err := a.getPasskeyCredential(tx, credentialID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: unknown credential", ErrInvalidPasskey)
+		}
+		return nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+	if challengeUserID != nil && *challengeUserID != credential.UserID {
+		return nil, fmt.Errorf("%w: credential belongs to another account", ErrInvalidPasskey)
+	}
+	if req.Response.UserHandle != "" {
+		userHandle, err := decodeBase64URL(req.Response.UserHandle)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := a.passkeyUserHandle(credential.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(userHandle, stored) {
+			return nil, fmt.Errorf("%w: user handle does not match", ErrInvalidPasskey)
+		}
+	}
+
+	if err := verifyAssertion(credential.PublicKey, rawAuthData, clientDataJSON, signature); err != nil {
+		return nil, err
+	}
+
+	if err := checkSignCount(credential.SignCount, authData.SignCount); err != nil {
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"user_id":    credential.UserID,
+			"passkey_id": credential.ID,
+		}).Warn("Passkey signature counter went backwards; possible cloned authenticator")
+		return nil, err
+	}
+	signCount := int64(authData.SignCount)
+
+	query := "UPDATE webauthn_credentials SET sign_count = $1, last_used_at = $2 WHERE id = $3"
+	if _, err := tx.Exec(query, signCount, time.Now(), credential.ID); err != nil {
+		return nil, fmt.Errorf("failed to update passkey: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update passkey: %w", err)
+	}
+
+	user, err := a.getUserByID(credential.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, ErrPasskeyAccountInactive
+	}
+	if !user.EmailVerified {
+		return nil, ErrPasskeyEmailUnverified
+	}
+
+	if err := a.updateLastLogin(user.ID); err != nil {
+		// Log error but don't fail login
+		utils.GetLogger().WithError(err).Warn("Failed to update last login time")
+	}
+
+	response, err := a.startSession(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+
+	return response, nil
+}
+
+// newPasskeyChallenge stores a random challenge for one ceremony. Login
+// challenges started without an email have no user.
+func (a *AuthService) newPasskeyChallenge(userID *int, ceremony string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate passkey challenge: %w", err)
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(b)
+
+	// Challenges nobody finished pile up otherwise
+	if _, err := a.db.Exec("DELETE FROM webauthn_challenges WHERE expires_at < $1", time.Now()); err != nil {
+		utils.GetLogger().WithError(err).Warn("Failed to delete expired passkey challenges")
+	}
+
+	query := `
+		INSERT INTO webauthn_challenges (challenge, user_id, ceremony, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	if _, err := a.db.Exec(query, challenge, userID, ceremony, time.Now().Add(passkeyChallengeTTL)); err != nil {
+		return "", fmt.Errorf("failed to store passkey challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// consumePasskeyChallenge deletes a challenge so it can only be answered once,
+// returning the user it was issued for
+func (a *AuthService) consumePasskeyChallenge(challenge, ceremony string) (*int, error) {
+	var userID *int
+	var expiresAt time.Time
+	query := `
+		DELETE FROM webauthn_challenges
+		WHERE challenge = $1 AND ceremony = $2
+		RETURNING user_id, expires_at`
+
+	err := a.db.QueryRow(query, challenge, ceremony).Scan(&userID, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPasskeyChallengeInvalid
+		}
+		return nil, fmt.Errorf("failed to get passkey challenge: %w", err)
+	}
+	if time.Now().After(expiresAt) {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+	return userID, nil
+}
+
+// passkeyUserHandle returns the user's WebAuthn user handle, creating it the
+// first time. It is random rather than the user ID so authenticators don't
+// learn anything about the account.
+func (a *AuthService) passkeyUserHandle(userID int) ([]byte, error) {
+	handle := make([]byte, passkeyUserHandleLen)
+	if _, err := rand.Read(handle); err != nil {
+		return nil, fmt.Errorf("failed to generate user handle: %w", err)
+	}
+
+	query := `
+		UPDATE users SET webauthn_user_id = COALESCE(webauthn_user_id, $1)
+		WHERE id = $2
+		RETURNING webauthn_user_id`
+
+	if err := a.db.QueryRow(query, handle, userID).Scan(&handle); err != nil {
+		return nil, fmt.Errorf("failed to get user handle: %w", err)
+	}
+	return handle, nil
+}
+
+// passkeyDescriptors lists a user's credentials for the browser
+func (a *AuthService) passkeyDescriptors(userID int) ([]models.PasskeyCredentialDescriptor, error) {
+	rows, err := a.db.Query("SELECT credential_id FROM webauthn_credentials WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	defer rows.Close()
+
+	descriptors := []models.PasskeyCredentialDescriptor{}
+	for rows.Next() {
+		var credentialID []byte
+		if err := rows.Scan(&credentialID); err != nil {
+			return nil, fmt.Errorf("failed to scan passkey: %w", err)
+		}
+		descriptors = append(descriptors, models.PasskeyCredentialDescriptor{
+			Type: "public-key",
+			ID:   base64.RawURLEncoding.EncodeToString(credentialID),
+		})
+	}
+	return descriptors, rows.Err()
+}
+
+func (a *AuthService) getPasskeyCredential(tx *sql.Tx, credentialID []byte) (*passkeyCredential, error) {
+	credential := &passkeyCredential{}
+	query := `
+		SELECT id, user_id, public_key, sign_count
+		FROM webauthn_credentials WHERE credential_id = $1
+		FOR UPDATE`
+
+	err := tx.QueryRow(query, credentialID).Scan(
+		&credential.ID, &credential.UserID, &credential.PublicKey, &credential.SignCount,
+	)
+	return credential, err
+}
@@ -45,6 +45,17 @@ func (p *PasswordService) HashPassword(password string) (string, error) {
 	return string(hashedBytes), nil
 }
 
+// HashSecret hashes a generated secret, such as a recovery code, using
+// bcrypt. Unlike HashPassword it doesn't apply the password strength rules.
+func (p *PasswordService) HashSecret(secret string) (string, error) {
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashedBytes), nil
+}
+
 // VerifyPassword verifies a password against its hash
 func (p *PasswordService) VerifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"bagr-backend/internal/utils"
+)
+
+// RevokedToken is an access token that must be refused until it expires
+type RevokedToken struct {
+	ID        string // The token's jti claim
+	UserID    int
+	ExpiresAt time.Time
+	Reason    string
+}
+
+// RevocationStore is the denylist of revoked access tokens. IsRevoked is
+// called on every authenticated request, so it must not block on the network.
+type RevocationStore interface {
+	Revoke(ctx context.Context, tokens []RevokedToken) error
+	IsRevoked(tokenID string) bool
+}
+
+// syncOverlap is how far back each sync looks past the newest revocation it
+// has seen, to pick up revocations committed slightly out of order
+const syncOverlap = time.Minute
+
+// PostgresRevocationStore keeps the denylist in Postgres and answers lookups
+// from an in-memory copy. The copy is synced periodically, so revocations
+// made by other replicas take effect within one sync interval.
+type PostgresRevocationStore struct {
+	db       *sql.DB
+	interval time.Duration
+
+	mu       sync.RWMutex
+	revoked  map[string]time.Time // jti -> token expiry
+	lastSeen time.Time            // Newest revoked_at loaded so far
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPostgresRevocationStore creates a revocation store that syncs every interval
+func NewPostgresRevocationStore(db *sql.DB, interval time.Duration) *PostgresRevocationStore {
+	return &PostgresRevocationStore{
+		db:       db,
+		interval: interval,
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+// Start loads the current denylist and keeps it in sync in the background
+func (s *PostgresRevocationStore) Start() error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return nil // Already running
+	}
+	s.mu.Unlock()
+
+	if err := s.Sync(context.Background()); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	done := s.done
+	s.mu.Unlock()
+
+	go s.loop(ctx, done)
+
+	utils.GetLogger().WithField("interval", s.interval.String()).Info("Token revocation sync started")
+	return nil
+}
+
+// Stop stops the background sync and waits for it to end or for ctx to
+// expire, whichever comes first
+func (s *PostgresRevocationStore) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		utils.GetLogger().Info("Token revocation sync stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("token revocation sync did not stop in time: %w", ctx.Err())
+	}
+}
+
+// loop syncs the denylist on every tick until ctx is cancelled
+func (s *PostgresRevocationStore) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			utils.GetLogger().WithError(err).Error("Token revocation sync failed")
+		}
+	}
+}
+
+// Revoke adds tokens to the denylist
+func (s *PostgresRevocationStore) Revoke(ctx context.Context, tokens []RevokedToken) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING`
+
+	for _, token := range tokens {
+		if _, err := tx.ExecContext(ctx, query, token.ID, token.UserID, token.ExpiresAt, token.Reason); err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to revoke token")
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit revoked tokens: %w", err)
+	}
+
+	s.mu.Lock()
+	for _, token := range tokens {
+		s.revoked[token.ID] = token.ExpiresAt
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked reports whether the token with the given jti has been revoked
+func (s *PostgresRevocationStore) IsRevoked(tokenID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[tokenID]
+	return ok
+}
+
+// Sync loads revocations made since the last sync and forgets tokens that
+// have expired, deleting them from the table too
+func (s *PostgresRevocationStore) Sync(ctx context.Context) error {
+	now := time.Now()
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < $1", now); err != nil {
+		utils.GetLogger().WithError(err).Warn("Failed to delete expired revoked tokens")
+	}
+
+	s.mu.RLock()
+	since := s.lastSeen.Add(-syncOverlap)
+	s.mu.RUnlock()
+
+	query := `
+		SELECT jti, expires_at, revoked_at
+		FROM revoked_tokens
+		WHERE revoked_at > $1 AND expires_at > $2`
+
+	rows, err := s.db.QueryContext(ctx, query, since, now)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to load revoked tokens")
+		return fmt.Errorf("failed to load revoked tokens: %w", err)
+	}
+	defer rows.Close()
+
+	loaded := make(map[string]time.Time)
+	lastSeen := time.Time{}
+	for rows.Next() {
+		var jti string
+		var expiresAt, revokedAt time.Time
+		if err := rows.Scan(&jti, &expiresAt, &revokedAt); err != nil {
+			return fmt.Errorf("failed to scan revoked token: %w", err)
+		}
+		loaded[jti] = expiresAt
+		if revokedAt.After(lastSeen) {
+			lastSeen = revokedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load revoked tokens: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jti, expiresAt := range loaded {
+		s.revoked[jti] = expiresAt
+	}
+	for jti, expiresAt := range s.revoked {
+		if expiresAt.Before(now) {
+			delete(s.revoked, jti)
+		}
+	}
+	if lastSeen.After(s.lastSeen) {
+		s.lastSeen = lastSeen
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"bagr-backend/internal/models"
@@ -16,15 +17,28 @@ type AuthService struct {
 	jwtService      *JWTService
 	passwordService *PasswordService
 	emailService    *EmailService
+	webauthn        WebAuthnConfig
+	oidcProviders   map[string]*oidcProvider
+	loginThrottle   LoginThrottleConfig
+	now             func() time.Time // Clock the login throttle runs on
 }
 
 // NewAuthService creates a new authentication service
-func NewAuthService(db *sql.DB, jwtService *JWTService, passwordService *PasswordService, emailService *EmailService) *AuthService {
+func NewAuthService(db *sql.DB, jwtService *JWTService, passwordService *PasswordService, emailService *EmailService, webauthn WebAuthnConfig, oidcProviders []OIDCProviderConfig, loginThrottle LoginThrottleConfig) *AuthService {
+	providers := make(map[string]*oidcProvider, len(oidcProviders))
+	for _, config := range oidcProviders {
+		providers[config.Name] = newOIDCProvider(config)
+	}
+
 	return &AuthService{
 		db:              db,
 		jwtService:      jwtService,
 		passwordService: passwordService,
 		emailService:    emailService,
+		webauthn:        webauthn,
+		oidcProviders:   providers,
+		loginThrottle:   loginThrottle,
+		now:             time.Now,
 	}
 }
 
@@ -135,7 +149,7 @@ func (a *AuthService) RegisterUser(req *models.CreateUserRequest) (*models.AuthR
 
 	// Generate tokens
 	logger.Debug("Generating JWT tokens")
-	accessToken, refreshToken, expiresAt, err := a.jwtService.GenerateTokenPair(user)
+	response, err := a.startSession(user)
 	if err != nil {
 		logger.WithError(err).Error("Failed to generate JWT tokens")
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
@@ -148,40 +162,62 @@ func (a *AuthService) RegisterUser(req *models.CreateUserRequest) (*models.AuthR
 		"role":    user.Role,
 	}).Info("User registration completed successfully")
 
-	return &models.AuthResponse{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    expiresAt,
-	}, nil
+	return response, nil
 }
 
-// LoginUser handles user login
-func (a *AuthService) LoginUser(req *models.LoginRequest) (*models.AuthResponse, error) {
+// LoginUser handles user login. When the user has to pass a second factor it
+// returns an MFA challenge instead of tokens. Failed passwords are counted
+// against the account and clientIP; once either is throttled it returns a
+// *LoginLockedError.
+func (a *AuthService) LoginUser(req *models.LoginRequest, clientIP string) (*models.AuthResponse, *models.MFAChallengeResponse, error) {
+	// Refuse guesses while the account or address is locked out or backing off
+	account := strings.ToLower(strings.TrimSpace(req.Email))
+	if err := a.checkLoginThrottle(account, clientIP); err != nil {
+		return nil, nil, err
+	}
+
 	// Get user by email
 	user, err := a.getUserByEmail(req.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("invalid email or password")
+			if locked := a.recordLoginFailure(nil, account, clientIP); locked != nil {
+				return nil, nil, locked
+			}
+			return nil, nil, errors.New("invalid email or password")
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Check if user is active
 	if user.Status != models.UserStatusActive {
-		return nil, errors.New("account is not active")
+		return nil, nil, errors.New("account is not active")
 	}
 
 	// Verify password
 	err = a.passwordService.VerifyPassword(user.PasswordHash, req.Password)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		if locked := a.recordLoginFailure(user, account, clientIP); locked != nil {
+			return nil, nil, locked
+		}
+		return nil, nil, errors.New("invalid email or password")
 	}
 
 	// Check if email is verified
 	if !user.EmailVerified {
-		return nil, errors.New("please verify your email before logging in")
+		return nil, nil, errors.New("please verify your email before logging in")
+	}
+
+	// Hold the login until the second factor is checked
+	challenge, err := a.mfaChallengeFor(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		// Failures are kept until the second factor passes too, so wrong
+		// codes keep counting toward the lockout
+		return nil, challenge, nil
 	}
+	a.clearLoginFailures(account)
 
 	// Update last login time
 	err = a.updateLastLogin(user.ID)
@@ -191,17 +227,12 @@ func (a *AuthService) LoginUser(req *models.LoginRequest) (*models.AuthResponse,
 	}
 
 	// Generate tokens
-	accessToken, refreshToken, expiresAt, err := a.jwtService.GenerateTokenPair(user)
+	response, err := a.startSession(user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	return &models.AuthResponse{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    expiresAt,
-	}, nil
+	return response, nil, nil
 }
 
 // VerifyEmail handles email verification
@@ -299,6 +330,12 @@ func (a *AuthService) ResetPassword(req *models.ResetPasswordRequest) error {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	// Log out every device that used the old password. The reset token stays
+	// unused on failure, so the link can be retried.
+	if err := a.RevokeAllSessions(userID, "password_reset"); err != nil {
+		return err
+	}
+
 	// Mark reset token as used
 	err = a.markResetTokenUsed(req.Token)
 	if err != nil {
@@ -310,13 +347,55 @@ func (a *AuthService) ResetPassword(req *models.ResetPasswordRequest) error {
 	return nil
 }
 
-// RefreshToken handles token refresh
+// RefreshToken exchanges a refresh token for a new token pair. Each refresh
+// token can be used once; replaying one that was already rotated revokes
+// every token in its session, since either copy may be in an attacker's hands.
 func (a *AuthService) RefreshToken(refreshToken string) (*models.AuthResponse, error) {
 	// Validate refresh token
 	claims, err := a.jwtService.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
+	if claims.ID == "" || claims.SessionID == "" {
+		return nil, fmt.Errorf("invalid refresh token: %w", ErrSessionNotFound)
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the session so concurrent refreshes with the same token can't both rotate it
+	current, err := a.getSessionForUpdate(tx, claims.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if current.UserID != claims.UserID || current.FamilyID != claims.SessionID {
+		return nil, ErrSessionNotFound
+	}
+
+	if current.RotatedAt != nil || current.RevokedAt != nil {
+		if err := a.revokeSessionFamily(tx, current.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+
+		if err := a.revokeAccessTokens("refresh_token_reuse", "family_id = $2", current.FamilyID); err != nil {
+			utils.GetLogger().WithError(err).WithField("session_id", current.FamilyID).Error("Failed to revoke access tokens of reused session")
+		}
+
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"user_id":    current.UserID,
+			"session_id": current.FamilyID,
+		}).Warn("Refresh token reused; session revoked")
+		return nil, ErrRefreshTokenReused
+	}
 
 	// Get user
 	user, err := a.getUserByID(claims.UserID)
@@ -329,18 +408,51 @@ func (a *AuthService) RefreshToken(refreshToken string) (*models.AuthResponse, e
 		return nil, errors.New("account is not active")
 	}
 
-	// Generate new access token
-	accessToken, expiresAt, err := a.jwtService.RefreshAccessToken(refreshToken)
+	// Rotate the refresh token
+	if err := a.markSessionRotated(tx, current.ID); err != nil {
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+	response, err := a.issueTokens(tx, user, current.FamilyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit session: %w", err)
+	}
+
+	return response, nil
+}
 
-	return &models.AuthResponse{
-		User:         user.ToResponse(),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken, // Keep the same refresh token
-		ExpiresAt:    expiresAt,
-	}, nil
+// Logout ends a session, revoking its access tokens and deleting every
+// refresh token rotated from its login
+func (a *AuthService) Logout(userID int, sessionID string) error {
+	if sessionID == "" {
+		return ErrSessionNotFound
+	}
+	if err := a.revokeAccessTokens("logout", "user_id = $2 AND family_id = $3", userID, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	if err := a.deleteSessionFamily(userID, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllSessions logs a user out on every device: their access tokens are
+// revoked and their refresh tokens deleted. reason is recorded on the denylist.
+func (a *AuthService) RevokeAllSessions(userID int, reason string) error {
+	if err := a.revokeAccessTokens(reason, "user_id = $2", userID); err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	if err := a.deleteUserSessions(userID); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id": userID,
+		"reason":  reason,
+	}).Info("All sessions revoked")
+	return nil
 }
 
 // Helper methods for database operations
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// Session errors
+var (
+	ErrSessionNotFound    = errors.New("session not found or logged out")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used; the session has been revoked")
+)
+
+// session is one refresh token in the sessions table. Each refresh rotates the
+// token into a new row of the same family; the family is the login session.
+type session struct {
+	ID        string
+	FamilyID  string
+	UserID    int
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+// sqlExecutor is the part of *sql.DB and *sql.Tx the session queries use
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// issueTokens signs a token pair for user in the session family and records
+// its refresh token, along with the access token's ID so it can be revoked
+func (a *AuthService) issueTokens(db sqlExecutor, user *models.User, familyID string) (*models.AuthResponse, error) {
+	pair, err := a.jwtService.GenerateTokenPair(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO sessions (id, family_id, user_id, expires_at, access_token_id, access_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = db.Exec(query,
+		pair.RefreshTokenID, familyID, user.ID, pair.RefreshExpiresAt,
+		pair.AccessTokenID, pair.AccessExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
+
+	return &models.AuthResponse{
+		User:         user.ToResponse(),
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresAt:    pair.AccessExpiresAt,
+	}, nil
+}
+
+// startSession starts a new session family for user and issues its first tokens
+func (a *AuthService) startSession(user *models.User) (*models.AuthResponse, error) {
+	familyID, err := newTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	// Expired sessions can no longer be replayed, so this is a good time to drop them
+	if _, err := a.db.Exec("DELETE FROM sessions WHERE user_id = $1 AND expires_at < $2", user.ID, time.Now()); err != nil {
+		utils.GetLogger().WithError(err).WithField("user_id", user.ID).Warn("Failed to delete expired sessions")
+	}
+
+	return a.issueTokens(a.db, user, familyID)
+}
+
+// getSessionForUpdate reads the session of a refresh token ID and locks it for
+// the rest of tx. Returns sql.ErrNoRows for unknown or deleted sessions.
+func (a *AuthService) getSessionForUpdate(tx *sql.Tx, id string) (*session, error) {
+	s := &session{}
+	query := `
+		SELECT id, family_id, user_id, expires_at, rotated_at, revoked_at
+		FROM sessions WHERE id = $1
+		FOR UPDATE`
+
+	err := tx.QueryRow(query, id).Scan(
+		&s.ID, &s.FamilyID, &s.UserID, &s.ExpiresAt, &s.RotatedAt, &s.RevokedAt,
+	)
+
+	return s, err
+}
+
+// markSessionRotated records that a refresh token was exchanged, so using it
+// again is detected as reuse
+func (a *AuthService) markSessionRotated(tx *sql.Tx, id string) error {
+	query := "UPDATE sessions SET rotated_at = $1 WHERE id = $2"
+	_, err := tx.Exec(query, time.Now(), id)
+	return err
+}
+
+// revokeSessionFamily revokes every refresh token of a login session
+func (a *AuthService) revokeSessionFamily(tx *sql.Tx, familyID string) error {
+	query := "UPDATE sessions SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL"
+	_, err := tx.Exec(query, time.Now(), familyID)
+	return err
+}
+
+// deleteSessionFamily deletes a user's login session with all its refresh tokens
+func (a *AuthService) deleteSessionFamily(userID int, familyID string) error {
+	query := "DELETE FROM sessions WHERE user_id = $1 AND family_id = $2"
+	_, err := a.db.Exec(query, userID, familyID)
+	return err
+}
+
+// deleteUserSessions deletes every session of a user, logging them out everywhere
+func (a *AuthService) deleteUserSessions(userID int) error {
+	query := "DELETE FROM sessions WHERE user_id = $1"
+	_, err := a.db.Exec(query, userID)
+	return err
+}
+
+// revokeAccessTokens denylists the unexpired access tokens issued to the
+// sessions matching where, whose placeholders start at $2
+func (a *AuthService) revokeAccessTokens(reason, where string, args ...interface{}) error {
+	if a.jwtService.revocations == nil {
+		return nil
+	}
+
+	query := `
+		SELECT access_token_id, user_id, access_expires_at
+		FROM sessions
+		WHERE access_expires_at > $1 AND ` + where
+
+	rows, err := a.db.Query(query, append([]interface{}{time.Now()}, args...)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var tokens []RevokedToken
+	for rows.Next() {
+		token := RevokedToken{Reason: reason}
+		if err := rows.Scan(&token.ID, &token.UserID, &token.ExpiresAt); err != nil {
+			return err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return a.jwtService.revocations.Revoke(context.Background(), tokens)
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bagr-backend/internal/models"
+)
+
+// fakeSessionTables keeps the sessions table and one active user
+type fakeSessionTables struct {
+	user     *models.User
+	sessions map[string]*fakeSession
+}
+
+type fakeSession struct {
+	session
+	accessTokenID   string
+	accessExpiresAt time.Time
+}
+
+func (f *fakeSessionTables) handle(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case strings.HasPrefix(query, "DELETE FROM sessions WHERE user_id = $1 AND expires_at < $2"):
+		return &fakeRows{}, nil
+	case strings.HasPrefix(query, "INSERT INTO sessions"):
+		s := &fakeSession{
+			session: session{
+				ID:        args[0].(string),
+				FamilyID:  args[1].(string),
+				UserID:    int(args[2].(int64)),
+				ExpiresAt: args[3].(time.Time),
+			},
+			accessTokenID:   args[4].(string),
+			accessExpiresAt: args[5].(time.Time),
+		}
+		f.sessions[s.ID] = s
+		return &fakeRows{affected: 1}, nil
+	case strings.HasPrefix(query, "SELECT id, family_id, user_id, expires_at, rotated_at, revoked_at FROM sessions WHERE id = $1"):
+		rows := &fakeRows{columns: []string{"id", "family_id", "user_id", "expires_at", "rotated_at", "revoked_at"}}
+		if s, ok := f.sessions[args[0].(string)]; ok {
+			rows.values = append(rows.values, []driver.Value{s.ID, s.FamilyID, int64(s.UserID), s.ExpiresAt, timeValue(s.RotatedAt), timeValue(s.RevokedAt)})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "UPDATE sessions SET rotated_at = $1 WHERE id = $2"):
+		rotatedAt := args[0].(time.Time)
+		f.sessions[args[1].(string)].RotatedAt = &rotatedAt
+		return &fakeRows{affected: 1}, nil
+	case strings.HasPrefix(query, "UPDATE sessions SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL"):
+		rows := &fakeRows{}
+		for _, s := range f.sessions {
+			if s.FamilyID == args[1].(string) && s.RevokedAt == nil {
+				revokedAt := args[0].(time.Time)
+				s.RevokedAt = &revokedAt
+				rows.affected++
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT access_token_id, user_id, access_expires_at FROM sessions WHERE access_expires_at > $1 AND family_id = $2"):
+		rows := &fakeRows{columns: []string{"access_token_id", "user_id", "access_expires_at"}}
+		for _, s := range f.sessions {
+			if s.FamilyID == args[1].(string) && s.accessExpiresAt.After(args[0].(time.Time)) {
+				rows.values = append(rows.values, []driver.Value{s.accessTokenID, int64(s.UserID), s.accessExpiresAt})
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT id, email, username, first_name, last_name, password_hash, role, status,"):
+		u := f.user
+		return &fakeRows{
+			columns: []string{"id", "email", "username", "first_name", "last_name", "password_hash", "role", "status",
+				"email_verified", "verification_token", "reset_token", "reset_token_expires", "last_login_at", "created_at", "updated_at"},
+			values: [][]driver.Value{{
+				int64(u.ID), u.Email, u.Username, u.FirstName, u.LastName, u.PasswordHash, string(u.Role), string(u.Status),
+				u.EmailVerified, nil, nil, nil, nil, u.CreatedAt, u.UpdatedAt,
+			}},
+		}, nil
+	}
+	return nil, unexpectedQuery(query)
+}
+
+// timeValue is t as a column value, NULL when t is nil
+func timeValue(t *time.Time) driver.Value {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// fakeRevocations records the access tokens revoked through it
+type fakeRevocations struct {
+	revoked map[string]bool
+}
+
+func (r *fakeRevocations) Revoke(ctx context.Context, tokens []RevokedToken) error {
+	for _, token := range tokens {
+		r.revoked[token.ID] = true
+	}
+	return nil
+}
+
+func (r *fakeRevocations) IsRevoked(tokenID string) bool {
+	return r.revoked[tokenID]
+}
+
+// newSessionTestService returns a service whose database is tables
+func newSessionTestService(t *testing.T, tables *fakeSessionTables, revocations *fakeRevocations) *AuthService {
+	return &AuthService{
+		db:         newFakeDB(t, tables.handle),
+		jwtService: NewJWTService("access-secret", "refresh-secret", nil, revocations),
+	}
+}
+
+func newSessionTestTables() *fakeSessionTables {
+	return &fakeSessionTables{
+		user: &models.User{
+			ID:       1,
+			Email:    "fan@example.com",
+			Username: "fan",
+			Role:     models.UserRoleFan,
+			Status:   models.UserStatusActive,
+		},
+		sessions: map[string]*fakeSession{},
+	}
+}
+
+// familyOf returns the session family a refresh token belongs to
+func familyOf(t *testing.T, service *AuthService, refreshToken string) (tokenID, familyID string) {
+	t.Helper()
+	claims, err := service.jwtService.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken: %v", err)
+	}
+	return claims.ID, claims.SessionID
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	tables := newSessionTestTables()
+	service := newSessionTestService(t, tables, &fakeRevocations{revoked: map[string]bool{}})
+
+	login, err := service.startSession(tables.user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	firstID, family := familyOf(t, service, login.RefreshToken)
+
+	refreshed, err := service.RefreshToken(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken || refreshed.AccessToken == login.AccessToken {
+		t.Error("refresh reissued the same tokens")
+	}
+	secondID, secondFamily := familyOf(t, service, refreshed.RefreshToken)
+	if secondFamily != family || secondID == firstID {
+		t.Errorf("rotated token is %s in family %s, want a new token in family %s", secondID, secondFamily, family)
+	}
+	if tables.sessions[firstID].RotatedAt == nil {
+		t.Error("exchanged refresh token not marked rotated")
+	}
+
+	// The rotated token keeps working
+	if _, err := service.RefreshToken(refreshed.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	tables := newSessionTestTables()
+	revocations := &fakeRevocations{revoked: map[string]bool{}}
+	service := newSessionTestService(t, tables, revocations)
+
+	login, err := service.startSession(tables.user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	_, family := familyOf(t, service, login.RefreshToken)
+	other, err := service.startSession(tables.user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+
+	refreshed, err := service.RefreshToken(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+
+	// Someone replaying the exchanged token ends the whole login session
+	if _, err := service.RefreshToken(login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused refresh token: got %v, want ErrRefreshTokenReused", err)
+	}
+
+	// Every refresh token of the family is revoked, along with the access
+	// tokens issued with them
+	for _, s := range tables.sessions {
+		if s.FamilyID != family {
+			continue
+		}
+		if s.RevokedAt == nil {
+			t.Errorf("session %s of the reused family not revoked", s.ID)
+		}
+		if !revocations.IsRevoked(s.accessTokenID) {
+			t.Errorf("access token %s of the reused family not revoked", s.accessTokenID)
+		}
+	}
+
+	if _, err := service.jwtService.ValidateAccessToken(refreshed.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("newest access token of the reused family: got %v, want ErrTokenRevoked", err)
+	}
+
+	// The legitimate holder's newest token is revoked with the family
+	if _, err := service.RefreshToken(refreshed.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("newest token of a revoked family: got %v, want ErrRefreshTokenReused", err)
+	}
+
+	// Other logins of the same user are left alone
+	if _, err := service.RefreshToken(other.RefreshToken); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+	if _, err := service.jwtService.ValidateAccessToken(other.AccessToken); err != nil {
+		t.Errorf("other session's access token: %v", err)
+	}
+}
+
+func TestRefreshTokenUnknownSession(t *testing.T) {
+	tables := newSessionTestTables()
+	service := newSessionTestService(t, tables, &fakeRevocations{revoked: map[string]bool{}})
+
+	login, err := service.startSession(tables.user)
+	if err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	// Logging out deletes the family's sessions
+	tables.sessions = map[string]*fakeSession{}
+
+	if _, err := service.RefreshToken(login.RefreshToken); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("got %v, want ErrSessionNotFound", err)
+	}
+}
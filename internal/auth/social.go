@@ -0,0 +1,521 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// Social login errors
+var (
+	ErrOIDCProviderNotFound  = errors.New("unknown login provider")
+	ErrOIDCStateInvalid      = errors.New("login attempt is invalid or expired; start again")
+	ErrOIDCSignupRole        = errors.New("that role can't be chosen when signing up with a provider")
+	ErrOIDCEmailUnverified   = errors.New("the provider has not verified your email address")
+	ErrOIDCAccountUnverified = errors.New("an account with this email exists but its email is not verified; verify it and log in to link this provider")
+	ErrOIDCIdentityTaken     = errors.New("this provider account is linked to another user")
+	ErrOIDCProviderLinked    = errors.New("another account of this provider is already linked")
+	ErrOIDCLinkInvalid       = errors.New("link code is invalid or expired")
+	ErrOIDCIdentityNotFound  = errors.New("linked account not found")
+	ErrOIDCAccountInactive   = errors.New("account is not active")
+)
+
+const (
+	oidcStateTTL       = 10 * time.Minute
+	oidcLinkTTL        = 5 * time.Minute
+	oidcUsernameMaxLen = 40
+)
+
+// oidcSignupRoles are the roles users may choose when signing up through a
+// provider; staff roles are only given by admins
+var oidcSignupRoles = map[models.UserRole]bool{
+	models.UserRoleArtist:   true,
+	models.UserRoleBuyer:    true,
+	models.UserRoleProducer: true,
+	models.UserRoleFan:      true,
+}
+
+// OIDCCallbackResult is the outcome of a provider callback: tokens, an MFA
+// challenge when the user has a second factor, or a pending link
+type OIDCCallbackResult struct {
+	Auth        *models.AuthResponse
+	Challenge   *models.MFAChallengeResponse
+	PendingLink *models.OIDCLinkPendingResponse
+}
+
+// oidcState is an authorization request waiting for its callback
+type oidcState struct {
+	CodeVerifier string
+	Nonce        string
+	UserID       *int // Set when a logged-in user is linking a provider
+	Role         models.UserRole
+	ExpiresAt    time.Time
+}
+
+// OIDCProviders lists the enabled providers by name
+func (a *AuthService) OIDCProviders() []string {
+	names := make([]string, 0, len(a.oidcProviders))
+	for name := range a.oidcProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOIDCLogin starts logging in, or signing up as role, with a provider
+// and returns the URL to send the user to
+func (a *AuthService) BeginOIDCLogin(providerName string, role models.UserRole, loginHint string) (string, error) {
+	if role == "" {
+		role = models.UserRoleFan
+	}
+	if !oidcSignupRoles[role] {
+		return "", ErrOIDCSignupRole
+	}
+	return a.beginOIDC(providerName, nil, role, loginHint)
+}
+
+// BeginOIDCLink starts linking a provider account to a logged-in user
+func (a *AuthService) BeginOIDCLink(userID int, providerName string) (string, error) {
+	return a.beginOIDC(providerName, &userID, "", "")
+}
+
+// CompleteOIDC handles a provider callback. The authorization code is
+// redeemed with the PKCE verifier saved for state and the ID token checked;
+// then the provider account logs its user in or, for a new verified email,
+// signs up a user. An existing account with the same verified email is
+// linked to the provider account.
+func (a *AuthService) CompleteOIDC(providerName, state, code string) (*OIDCCallbackResult, error) {
+	provider, ok := a.oidcProviders[providerName]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	pending, err := a.consumeOIDCState(providerName, state)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := provider.exchangeCode(code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending.UserID != nil {
+		link, err := a.storePendingLink(*pending.UserID, providerName, identity)
+		if err != nil {
+			return nil, err
+		}
+		return &OIDCCallbackResult{PendingLink: link}, nil
+	}
+
+	user, err := a.userForIdentity(providerName, identity, pending.Role)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, ErrOIDCAccountInactive
+	}
+
+	// The provider vouches for the password, not for the second factor
+	challenge, err := a.mfaChallengeFor(user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &OIDCCallbackResult{Challenge: challenge}, nil
+	}
+
+	if err := a.updateLastLogin(user.ID); err != nil {
+		// Log error but don't fail login
+		utils.GetLogger().WithError(err).Warn("Failed to update last login time")
+	}
+
+	response, err := a.startSession(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+	return &OIDCCallbackResult{Auth: response}, nil
+}
+
+// ConfirmOIDCLink links the provider account of a pending link, which must
+// have been started by the same user
+func (a *AuthService) ConfirmOIDCLink(userID int, linkCode string) (*models.ExternalIdentity, error) {
+	var provider, subject, email string
+	var expiresAt time.Time
+	query := `
+		DELETE FROM oidc_pending_links
+		WHERE code_hash = $1 AND user_id = $2
+		RETURNING provider, subject, email, expires_at`
+
+	err := a.db.QueryRow(query, hashChallengeToken(linkCode), userID).Scan(&provider, &subject, &email, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOIDCLinkInvalid
+		}
+		return nil, fmt.Errorf("failed to get pending link: %w", err)
+	}
+	if time.Now().After(expiresAt) {
+		return nil, ErrOIDCLinkInvalid
+	}
+
+	linkedUserID, err := a.identityUserID(provider, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked account: %w", err)
+	}
+	if linkedUserID != 0 && linkedUserID != userID {
+		return nil, ErrOIDCIdentityTaken
+	}
+	if linkedUserID == 0 {
+		if err := a.insertIdentity(userID, provider, subject, email); err != nil {
+			return nil, err
+		}
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"user_id":  userID,
+			"provider": provider,
+		}).Info("Provider account linked")
+	}
+
+	identities, err := a.ListIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return &identity, nil
+		}
+	}
+	return nil, ErrOIDCIdentityNotFound
+}
+
+// ListIdentities lists the provider accounts linked to a user
+func (a *AuthService) ListIdentities(userID int) ([]models.ExternalIdentity, error) {
+	query := `
+		SELECT id, provider, email, created_at, last_login_at
+		FROM external_identities WHERE user_id = $1
+		ORDER BY provider`
+
+	rows, err := a.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked accounts: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []models.ExternalIdentity{}
+	for rows.Next() {
+		var identity models.ExternalIdentity
+		if err := rows.Scan(&identity.ID, &identity.Provider, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt); err != nil {
+			return nil, fmt.Errorf("failed to scan linked account: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// UnlinkIdentity removes a linked provider account. Users who signed up
+// through a provider have no password they know; they can set one with the
+// forgot password flow before unlinking their last provider.
+func (a *AuthService) UnlinkIdentity(userID, identityID int) error {
+	result, err := a.db.Exec("DELETE FROM external_identities WHERE id = $1 AND user_id = $2", identityID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink account: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrOIDCIdentityNotFound
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id":     userID,
+		"identity_id": identityID,
+	}).Info("Provider account unlinked")
+	return nil
+}
+
+// beginOIDC stores a new authorization request and builds its URL
+func (a *AuthService) beginOIDC(providerName string, userID *int, role models.UserRole, loginHint string) (string, error) {
+	provider, ok := a.oidcProviders[providerName]
+	if !ok {
+		return "", ErrOIDCProviderNotFound
+	}
+
+	secrets := make([]string, 3)
+	for i := range secrets {
+		secret, err := newOIDCSecret()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate login state: %w", err)
+		}
+		secrets[i] = secret
+	}
+	state, nonce, codeVerifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := provider.authorizationURL(state, nonce, codeVerifier, loginHint)
+	if err != nil {
+		return "", err
+	}
+
+	// Requests nobody finished pile up otherwise
+	if _, err := a.db.Exec("DELETE FROM oidc_states WHERE expires_at < $1", time.Now()); err != nil {
+		utils.GetLogger().WithError(err).Warn("Failed to delete expired login states")
+	}
+
+	var storedRole *models.UserRole
+	if role != "" {
+		storedRole = &role
+	}
+	query := `
+		INSERT INTO oidc_states (state_hash, provider, code_verifier, nonce, user_id, role, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = a.db.Exec(query,
+		hashChallengeToken(state), providerName, codeVerifier, nonce, userID, storedRole, time.Now().Add(oidcStateTTL),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to store login state: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// consumeOIDCState deletes an authorization request so its callback can only
+// be handled once
+func (a *AuthService) consumeOIDCState(providerName, state string) (*oidcState, error) {
+	pending := &oidcState{}
+	var role *string
+	query := `
+		DELETE FROM oidc_states
+		WHERE state_hash = $1 AND provider = $2
+		RETURNING code_verifier, nonce, user_id, role, expires_at`
+
+	err := a.db.QueryRow(query, hashChallengeToken(state), providerName).Scan(
+		&pending.CodeVerifier, &pending.Nonce, &pending.UserID, &role, &pending.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOIDCStateInvalid
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+	if role != nil {
+		pending.Role = models.UserRole(*role)
+	}
+	return pending, nil
+}
+
+// storePendingLink saves a provider account for the linking user to confirm
+func (a *AuthService) storePendingLink(userID int, providerName string, identity *oidcIdentity) (*models.OIDCLinkPendingResponse, error) {
+	code, err := newOIDCSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate link code: %w", err)
+	}
+	expiresAt := time.Now().Add(oidcLinkTTL)
+
+	if _, err := a.db.Exec("DELETE FROM oidc_pending_links WHERE expires_at < $1", time.Now()); err != nil {
+		utils.GetLogger().WithError(err).Warn("Failed to delete expired pending links")
+	}
+
+	query := `
+		INSERT INTO oidc_pending_links (code_hash, user_id, provider, subject, email, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = a.db.Exec(query, hashChallengeToken(code), userID, providerName, identity.Subject, identity.Email, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store pending link: %w", err)
+	}
+
+	return &models.OIDCLinkPendingResponse{LinkCode: code, ExpiresAt: expiresAt}, nil
+}
+
+// userForIdentity finds the user a provider account logs in as, linking it
+// to the account with the same verified email or signing up a new user
+func (a *AuthService) userForIdentity(providerName string, identity *oidcIdentity, role models.UserRole) (*models.User, error) {
+	userID, err := a.identityUserID(providerName, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked account: %w", err)
+	}
+	if userID != 0 {
+		query := "UPDATE external_identities SET email = $1, last_login_at = $2 WHERE provider = $3 AND subject = $4"
+		if _, err := a.db.Exec(query, identity.Email, time.Now(), providerName, identity.Subject); err != nil {
+			utils.GetLogger().WithError(err).Warn("Failed to update linked account")
+		}
+		user, err := a.getUserByID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		return user, nil
+	}
+
+	if err := checkEmailMatch(identity, nil); err != nil {
+		return nil, err
+	}
+
+	user, err := a.getUserByEmail(identity.Email)
+	switch {
+	case err == sql.ErrNoRows:
+		user, err = a.createOIDCUser(identity, role)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	default:
+		if err := checkEmailMatch(identity, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.insertIdentity(user.ID, providerName, identity.Subject, identity.Email); err != nil {
+		return nil, err
+	}
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id":  user.ID,
+		"provider": providerName,
+	}).Info("Provider account linked")
+
+	return user, nil
+}
+
+// checkEmailMatch decides whether a provider account not linked to anyone
+// may log in as user, the account with the same email, or sign up when user
+// is nil. Only an email the provider has verified may match or create an
+// account.
+func checkEmailMatch(identity *oidcIdentity, user *models.User) error {
+	if identity.Email == "" || !identity.EmailVerified {
+		return ErrOIDCEmailUnverified
+	}
+	if user != nil && !user.EmailVerified {
+		// Anyone can register an email they don't own; linking would hand
+		// the owner's provider login to whoever set that account's password
+		return ErrOIDCAccountUnverified
+	}
+	return nil
+}
+
+// createOIDCUser signs up the user of a provider account. Their email is
+// verified by the provider, and they get a random password nobody knows.
+func (a *AuthService) createOIDCUser(identity *oidcIdentity, role models.UserRole) (*models.User, error) {
+	username, err := a.oidcUsername(identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := newOIDCSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	passwordHash, err := a.passwordService.HashSecret(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	if role == "" {
+		role = models.UserRoleFan
+	}
+
+	user := &models.User{
+		Email:         identity.Email,
+		Username:      username,
+		FirstName:     identity.GivenName,
+		LastName:      identity.FamilyName,
+		PasswordHash:  passwordHash,
+		Role:          role,
+		Status:        models.UserStatusActive,
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	user.ID, err = a.insertUser(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"role":    user.Role,
+	}).Info("User signed up through a login provider")
+
+	if err := a.emailService.SendWelcomeEmail(user.Email, user.Username, string(user.Role)); err != nil {
+		// Log error but don't fail signup
+		utils.GetLogger().WithError(err).Warn("Failed to send welcome email")
+	}
+
+	return user, nil
+}
+
+// oidcUsername derives a free username from an email's local part
+func (a *AuthService) oidcUsername(email string) (string, error) {
+	local := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+	base := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return -1
+	}, local)
+	if len(base) > oidcUsernameMaxLen {
+		base = base[:oidcUsernameMaxLen]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	username := base
+	for attempt := 0; attempt < 5; attempt++ {
+		exists, err := a.userExistsByUsername(username)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username existence: %w", err)
+		}
+		if !exists {
+			return username, nil
+		}
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate username: %w", err)
+		}
+		username = fmt.Sprintf("%s_%04d", base, suffix.Int64())
+	}
+	return "", errors.New("failed to find a free username")
+}
+
+// identityUserID returns the user a provider account is linked to, or 0
+func (a *AuthService) identityUserID(providerName, subject string) (int, error) {
+	var userID int
+	query := "SELECT user_id FROM external_identities WHERE provider = $1 AND subject = $2"
+	err := a.db.QueryRow(query, providerName, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// insertIdentity links a provider account to a user. A user has at most one
+// account per provider.
+func (a *AuthService) insertIdentity(userID int, providerName, subject, email string) error {
+	query := `
+		INSERT INTO external_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`
+
+	result, err := a.db.Exec(query, userID, providerName, subject, email, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to link account: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		linkedUserID, err := a.identityUserID(providerName, subject)
+		if err == nil && linkedUserID != 0 && linkedUserID != userID {
+			return ErrOIDCIdentityTaken
+		}
+		if err == nil && linkedUserID == userID {
+			return nil
+		}
+		return ErrOIDCProviderLinked
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListOIDCProviders handles listing the enabled social login providers
+// GET /api/v1/auth/oidc/providers
+func (h *AuthHandlers) ListOIDCProviders(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Login providers retrieved", models.OIDCProvidersResponse{
+		Providers: h.authService.OIDCProviders(),
+	})
+}
+
+// BeginOIDCLogin handles sending the user to a provider to log in or sign up
+// GET /api/v1/auth/oidc/:provider/authorize?role=artist&login_hint=xxx
+func (h *AuthHandlers) BeginOIDCLogin(c *gin.Context) {
+	authURL, err := h.authService.BeginOIDCLogin(c.Param("provider"), models.UserRole(c.Query("role")), c.Query("login_hint"))
+	if err != nil {
+		oidcErrorResponse(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback handles the provider sending the user back. Apple posts the
+// response as a form; other providers use the query string.
+// GET|POST /api/v1/auth/oidc/:provider/callback
+func (h *AuthHandlers) OIDCCallback(c *gin.Context) {
+	if providerError := c.Request.FormValue("error"); providerError != "" {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "OIDC_AUTHORIZATION_DENIED", "The provider did not authorize the login", providerError)
+		return
+	}
+
+	state := c.Request.FormValue("state")
+	code := c.Request.FormValue("code")
+	if state == "" || code == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", "state and code are required")
+		return
+	}
+
+	result, err := h.authService.CompleteOIDC(c.Param("provider"), state, code)
+	if err != nil {
+		oidcErrorResponse(c, err)
+		return
+	}
+
+	switch {
+	case result.PendingLink != nil:
+		utils.SuccessResponse(c, http.StatusOK, "Confirm the link code to finish linking the account", result.PendingLink)
+	case result.Challenge != nil:
+		utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", result.Challenge)
+	default:
+		utils.SuccessResponse(c, http.StatusOK, "Login successful", result.Auth)
+	}
+}
+
+// BeginOIDCLink handles starting to link a provider account
+// POST /api/v1/auth/oidc/:provider/link
+func (h *AuthHandlers) BeginOIDCLink(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	authURL, err := h.authService.BeginOIDCLink(uid, c.Param("provider"))
+	if err != nil {
+		oidcErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Send the user to the provider to link their account", models.OIDCAuthorizationResponse{
+		AuthorizationURL: authURL,
+	})
+}
+
+// ConfirmOIDCLink handles finishing a provider account link
+// POST /api/v1/auth/oidc/link/confirm
+func (h *AuthHandlers) ConfirmOIDCLink(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	var req models.OIDCLinkConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request data", err.Error())
+		return
+	}
+
+	identity, err := h.authService.ConfirmOIDCLink(uid, req.LinkCode)
+	if err != nil {
+		oidcErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account linked", identity)
+}
+
+// ListOIDCIdentities handles listing the user's linked provider accounts
+// GET /api/v1/auth/oidc/identities
+func (h *AuthHandlers) ListOIDCIdentities(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	identities, err := h.authService.ListIdentities(uid)
+	if err != nil {
+		oidcErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Linked accounts retrieved", identities)
+}
+
+// UnlinkOIDCIdentity handles removing a linked provider account
+// DELETE /api/v1/auth/oidc/identities/:id
+func (h *AuthHandlers) UnlinkOIDCIdentity(c *gin.Context) {
+	uid, ok := userIDFromContext(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized", "User ID not found in token")
+		return
+	}
+
+	identityID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || identityID <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ID", "Invalid linked account ID", "")
+		return
+	}
+
+	if err := h.authService.UnlinkIdentity(uid, identityID); err != nil {
+		oidcErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account unlinked", nil)
+}
+
+// oidcErrorResponse maps social login errors to responses
+func oidcErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOIDCProviderNotFound), errors.Is(err, ErrOIDCIdentityNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "NOT_FOUND", err.Error(), "")
+	case errors.Is(err, ErrOIDCSignupRole):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ROLE", err.Error(), "")
+	case errors.Is(err, ErrOIDCStateInvalid), errors.Is(err, ErrOIDCLinkInvalid):
+		utils.ErrorResponse(c, http.StatusBadRequest, "OIDC_STATE_INVALID", err.Error(), "")
+	case errors.Is(err, ErrOIDCTokenInvalid):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "OIDC_TOKEN_INVALID", "The provider's login could not be verified", err.Error())
+	case errors.Is(err, ErrOIDCEmailUnverified), errors.Is(err, ErrOIDCAccountInactive):
+		utils.ErrorResponse(c, http.StatusUnauthorized, "LOGIN_FAILED", "Login failed", err.Error())
+	case errors.Is(err, ErrOIDCAccountUnverified), errors.Is(err, ErrOIDCIdentityTaken), errors.Is(err, ErrOIDCProviderLinked):
+		utils.ErrorResponse(c, http.StatusConflict, "OIDC_ACCOUNT_CONFLICT", err.Error(), "")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "OIDC_FAILED", "Login with the provider failed", err.Error())
+	}
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// Scopes failed logins are counted in
+const (
+	loginScopeAccount = "account"
+	loginScopeIP      = "ip"
+)
+
+// loginMaxBackoffShift keeps the backoff's doubling from overflowing
+const loginMaxBackoffShift = 30
+
+// LoginThrottleConfig limits password and two-factor code guessing on
+// LoginUser and VerifyMFAChallenge
+type LoginThrottleConfig struct {
+	MaxFailures        int           // Failed logins before an account is locked
+	IPMaxFailures      int           // Failed logins from one IP address before it is locked out
+	FailureWindow      time.Duration // Failures older than this are forgotten
+	LockoutDuration    time.Duration // Length of the first lockout; each lockout in a row doubles it
+	MaxLockoutDuration time.Duration
+}
+
+// LoginLockedError is returned by LoginUser and VerifyMFAChallenge while the
+// account or the client's
+// IP address is locked out, or while the account waits out the backoff after
+// a failed login
+type LoginLockedError struct {
+	AccountLocked bool // The account itself is locked, rather than backing off or throttled by IP
+	RetryAfter    time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	if e.AccountLocked {
+		return "account is temporarily locked after too many failed logins"
+	}
+	return "too many failed logins; wait before trying again"
+}
+
+// RetryAfterSeconds is RetryAfter rounded up to whole seconds, as sent in the
+// Retry-After header
+func (e *LoginLockedError) RetryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// checkLoginThrottle refuses a login while the account or IP address is
+// locked, or before the account's backoff since its last failure has passed
+func (a *AuthService) checkLoginThrottle(account, clientIP string) error {
+	query := `
+		SELECT scope, failures, last_failure_at, locked_until
+		FROM login_attempts
+		WHERE (scope = $1 AND key = $2) OR (scope = $3 AND key = $4)`
+
+	rows, err := a.db.Query(query, loginScopeAccount, account, loginScopeIP, clientIP)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to check failed logins")
+		return fmt.Errorf("failed to check failed logins: %w", err)
+	}
+	defer rows.Close()
+
+	now := a.now()
+	var locked *LoginLockedError
+	for rows.Next() {
+		var scope string
+		var failures int
+		var lastFailureAt time.Time
+		var lockedUntil sql.NullTime
+		if err := rows.Scan(&scope, &failures, &lastFailureAt, &lockedUntil); err != nil {
+			return fmt.Errorf("failed to scan failed logins: %w", err)
+		}
+
+		var wait time.Duration
+		accountLocked := false
+		switch {
+		case lockedUntil.Valid && lockedUntil.Time.After(now):
+			wait = lockedUntil.Time.Sub(now)
+			accountLocked = scope == loginScopeAccount
+		case scope == loginScopeAccount && failures > 0 && lastFailureAt.After(now.Add(-a.loginThrottle.FailureWindow)):
+			wait = lastFailureAt.Add(a.loginBackoff(failures)).Sub(now)
+		}
+		if wait <= 0 {
+			continue
+		}
+
+		// A locked account is reported over anything else, as it's what the
+		// user gets emailed about
+		if locked == nil || (accountLocked && !locked.AccountLocked) || (accountLocked == locked.AccountLocked && wait > locked.RetryAfter) {
+			locked = &LoginLockedError{AccountLocked: accountLocked, RetryAfter: wait}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read failed logins: %w", err)
+	}
+
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against the account and the IP
+// address, locking either once it reaches its threshold. The user is nil for
+// emails nobody has, which are counted the same so lockouts don't reveal
+// which accounts exist. Returns the lockout this failure started, if any.
+func (a *AuthService) recordLoginFailure(user *models.User, account, clientIP string) *LoginLockedError {
+	logger := utils.GetLogger()
+	now := a.now()
+
+	var locked *LoginLockedError
+	if clientIP != "" {
+		lockedUntil, err := a.countLoginFailure(loginScopeIP, clientIP, a.loginThrottle.IPMaxFailures, now)
+		if err != nil {
+			logger.WithError(err).Error("Failed to record failed login for IP address")
+		} else if lockedUntil != nil {
+			logger.WithFields(map[string]interface{}{
+				"ip":           clientIP,
+				"locked_until": lockedUntil,
+			}).Warn("IP address locked out after too many failed logins")
+			locked = &LoginLockedError{RetryAfter: lockedUntil.Sub(now)}
+		}
+	}
+
+	lockedUntil, err := a.countLoginFailure(loginScopeAccount, account, a.loginThrottle.MaxFailures, now)
+	if err != nil {
+		logger.WithError(err).Error("Failed to record failed login for account")
+		return locked
+	}
+	if lockedUntil == nil {
+		return locked
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"email":        account,
+		"known":        user != nil,
+		"locked_until": lockedUntil,
+	}).Warn("Account locked after too many failed logins")
+
+	if user != nil {
+		if err := a.emailService.SendAccountLockedEmail(user.Email, user.Username, *lockedUntil); err != nil {
+			// Log error but don't fail the lockout
+			logger.WithError(err).Warn("Failed to send account locked email")
+		}
+	}
+
+	return &LoginLockedError{AccountLocked: true, RetryAfter: lockedUntil.Sub(now)}
+}
+
+// countLoginFailure adds a failure to one scope's counter and locks it when it
+// reaches maxFailures. Returns when the lockout ends if this failure started it.
+func (a *AuthService) countLoginFailure(scope, key string, maxFailures int, now time.Time) (*time.Time, error) {
+	query := `
+		INSERT INTO login_attempts (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < $4 THEN 1 ELSE login_attempts.failures + 1 END,
+			lockouts = CASE WHEN login_attempts.last_failure_at < $5 THEN 0 ELSE login_attempts.lockouts END,
+			last_failure_at = $3
+		RETURNING failures, lockouts`
+
+	var failures, lockouts int
+	err := a.db.QueryRow(query, scope, key, now,
+		now.Add(-a.loginThrottle.FailureWindow), now.Add(-a.loginQuietPeriod()),
+	).Scan(&failures, &lockouts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	if failures < maxFailures {
+		return nil, nil
+	}
+
+	// Only the failure that takes the counter past the threshold locks it,
+	// so concurrent guesses don't send several emails
+	lockedUntil := now.Add(a.lockoutDuration(lockouts))
+	result, err := a.db.Exec(`
+		UPDATE login_attempts
+		SET failures = 0, lockouts = lockouts + 1, locked_until = $3
+		WHERE scope = $1 AND key = $2 AND failures >= $4`,
+		scope, key, lockedUntil, maxFailures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock out: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return nil, err
+	}
+
+	return &lockedUntil, nil
+}
+
+// clearLoginFailures forgets an account's failed logins once a login has
+// passed every factor. Failures from the IP address are kept, as one address
+// guessing at many accounts may get some right.
+func (a *AuthService) clearLoginFailures(account string) {
+	logger := utils.GetLogger()
+
+	if _, err := a.db.Exec("DELETE FROM login_attempts WHERE scope = $1 AND key = $2", loginScopeAccount, account); err != nil {
+		logger.WithError(err).Warn("Failed to clear failed logins")
+	}
+
+	// Counters nobody has failed against for a while would reset anyway
+	if _, err := a.db.Exec("DELETE FROM login_attempts WHERE last_failure_at < $1", a.now().Add(-a.loginQuietPeriod())); err != nil {
+		logger.WithError(err).Warn("Failed to delete stale failed logins")
+	}
+}
+
+// loginBackoff is how long an account waits after its nth failure in a row:
+// 1s, 2s, 4s and so on, up to the lockout duration
+func (a *AuthService) loginBackoff(failures int) time.Duration {
+	shift := failures - 1
+	if shift > loginMaxBackoffShift {
+		shift = loginMaxBackoffShift
+	}
+	backoff := time.Second << shift
+	if backoff > a.loginThrottle.LockoutDuration {
+		backoff = a.loginThrottle.LockoutDuration
+	}
+	return backoff
+}
+
+// lockoutDuration doubles the lockout for each earlier lockout in a row
+func (a *AuthService) lockoutDuration(lockouts int) time.Duration {
+	duration := a.loginThrottle.LockoutDuration
+	for i := 0; i < lockouts && duration < a.loginThrottle.MaxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > a.loginThrottle.MaxLockoutDuration {
+		duration = a.loginThrottle.MaxLockoutDuration
+	}
+	return duration
+}
+
+// loginQuietPeriod is how long without failures before lockouts in a row are
+// forgotten. It outlasts the longest lockout so a guesser resuming right after
+// one doesn't start over at the shortest.
+func (a *AuthService) loginQuietPeriod() time.Duration {
+	return a.loginThrottle.MaxLockoutDuration + a.loginThrottle.FailureWindow
+}
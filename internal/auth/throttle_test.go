@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLoginAttempts keeps the login_attempts table
+type fakeLoginAttempts struct {
+	rows map[[2]string]*fakeLoginAttempt
+}
+
+type fakeLoginAttempt struct {
+	failures      int64
+	lockouts      int64
+	lastFailureAt time.Time
+	lockedUntil   *time.Time
+}
+
+func (f *fakeLoginAttempts) handle(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case strings.HasPrefix(query, "SELECT scope, failures, last_failure_at, locked_until FROM login_attempts"):
+		rows := &fakeRows{columns: []string{"scope", "failures", "last_failure_at", "locked_until"}}
+		for _, id := range [][2]string{{args[0].(string), args[1].(string)}, {args[2].(string), args[3].(string)}} {
+			if row, ok := f.rows[id]; ok {
+				rows.values = append(rows.values, []driver.Value{id[0], row.failures, row.lastFailureAt, timeValue(row.lockedUntil)})
+			}
+		}
+		return rows, nil
+
+	case strings.HasPrefix(query, "INSERT INTO login_attempts"):
+		id := [2]string{args[0].(string), args[1].(string)}
+		now, windowStart, quietStart := args[2].(time.Time), args[3].(time.Time), args[4].(time.Time)
+		row, ok := f.rows[id]
+		if !ok {
+			row = &fakeLoginAttempt{}
+			f.rows[id] = row
+		}
+		switch {
+		case !ok || row.lastFailureAt.Before(windowStart):
+			row.failures = 1
+		default:
+			row.failures++
+		}
+		if ok && row.lastFailureAt.Before(quietStart) {
+			row.lockouts = 0
+		}
+		row.lastFailureAt = now
+		return &fakeRows{columns: []string{"failures", "lockouts"}, values: [][]driver.Value{{row.failures, row.lockouts}}}, nil
+
+	case strings.HasPrefix(query, "UPDATE login_attempts SET failures = 0, lockouts = lockouts + 1, locked_until = $3"):
+		row, ok := f.rows[[2]string{args[0].(string), args[1].(string)}]
+		if !ok || row.failures < args[3].(int64) {
+			return &fakeRows{}, nil
+		}
+		lockedUntil := args[2].(time.Time)
+		row.failures, row.lockedUntil = 0, &lockedUntil
+		row.lockouts++
+		return &fakeRows{affected: 1}, nil
+
+	case strings.HasPrefix(query, "DELETE FROM login_attempts WHERE scope = $1 AND key = $2"):
+		delete(f.rows, [2]string{args[0].(string), args[1].(string)})
+		return &fakeRows{affected: 1}, nil
+	case strings.HasPrefix(query, "DELETE FROM login_attempts WHERE last_failure_at < $1"):
+		for id, row := range f.rows {
+			if row.lastFailureAt.Before(args[0].(time.Time)) {
+				delete(f.rows, id)
+			}
+		}
+		return &fakeRows{}, nil
+	}
+	return nil, unexpectedQuery(query)
+}
+
+var testLoginThrottle = LoginThrottleConfig{
+	MaxFailures:        3,
+	IPMaxFailures:      5,
+	FailureWindow:      15 * time.Minute,
+	LockoutDuration:    time.Minute,
+	MaxLockoutDuration: 4 * time.Minute,
+}
+
+// throttleTest is a service with a login throttle on a clock the test moves
+type throttleTest struct {
+	t        *testing.T
+	service  *AuthService
+	attempts *fakeLoginAttempts
+	now      time.Time
+}
+
+func newThrottleTest(t *testing.T) *throttleTest {
+	tt := &throttleTest{
+		t:        t,
+		attempts: &fakeLoginAttempts{rows: map[[2]string]*fakeLoginAttempt{}},
+		now:      time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tt.service = &AuthService{
+		db:            newFakeDB(t, tt.attempts.handle),
+		loginThrottle: testLoginThrottle,
+		now:           func() time.Time { return tt.now },
+	}
+	return tt
+}
+
+func (tt *throttleTest) advance(d time.Duration) {
+	tt.now = tt.now.Add(d)
+}
+
+// fail records a failed login of an unknown account, so no email is sent
+func (tt *throttleTest) fail(account, clientIP string) *LoginLockedError {
+	return tt.service.recordLoginFailure(nil, account, clientIP)
+}
+
+// check returns the lockout checkLoginThrottle reports, or nil
+func (tt *throttleTest) check(account, clientIP string) *LoginLockedError {
+	tt.t.Helper()
+	err := tt.service.checkLoginThrottle(account, clientIP)
+	if err == nil {
+		return nil
+	}
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) {
+		tt.t.Fatalf("checkLoginThrottle: %v", err)
+	}
+	return locked
+}
+
+func TestLoginBackoff(t *testing.T) {
+	service := &AuthService{loginThrottle: testLoginThrottle}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute}, // Capped at the lockout duration
+		{1000, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := service.loginBackoff(tt.failures); got != tt.want {
+			t.Errorf("loginBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	lockouts := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for i, want := range lockouts {
+		if got := service.lockoutDuration(i); got != want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	tt := newThrottleTest(t)
+	const account, ip = "fan@example.com", "203.0.113.1"
+
+	if locked := tt.check(account, ip); locked != nil {
+		t.Fatalf("fresh account throttled: %+v", locked)
+	}
+
+	// Each failure doubles the wait before the next attempt
+	for i, wait := range []time.Duration{time.Second, 2 * time.Second} {
+		if locked := tt.fail(account, ip); locked != nil {
+			t.Fatalf("failure %d locked the account", i+1)
+		}
+		locked := tt.check(account, ip)
+		if locked == nil || locked.AccountLocked || locked.RetryAfter != wait {
+			t.Fatalf("after failure %d: got %+v, want a %v backoff", i+1, locked, wait)
+		}
+		tt.advance(wait - time.Millisecond)
+		if tt.check(account, ip) == nil {
+			t.Fatalf("after failure %d: backoff over early", i+1)
+		}
+		tt.advance(time.Millisecond)
+		if locked := tt.check(account, ip); locked != nil {
+			t.Fatalf("after failure %d: still throttled after %v: %+v", i+1, wait, locked)
+		}
+	}
+
+	// Failures older than the window are forgotten
+	tt.advance(testLoginThrottle.FailureWindow)
+	tt.fail(account, ip)
+	if locked := tt.check(account, ip); locked == nil || locked.RetryAfter != time.Second {
+		t.Errorf("failure after the window: got %+v, want a 1s backoff", locked)
+	}
+}
+
+func TestLoginThrottleLockout(t *testing.T) {
+	tt := newThrottleTest(t)
+	const account = "fan@example.com"
+
+	lockout := func(want time.Duration) {
+		t.Helper()
+		for i := 1; i < testLoginThrottle.MaxFailures; i++ {
+			if locked := tt.fail(account, ""); locked != nil {
+				t.Fatalf("failure %d locked the account", i)
+			}
+			tt.advance(time.Minute)
+		}
+		locked := tt.fail(account, "")
+		if locked == nil || !locked.AccountLocked || locked.RetryAfter != want {
+			t.Fatalf("failure %d: got %+v, want a %v account lockout", testLoginThrottle.MaxFailures, locked, want)
+		}
+		if checked := tt.check(account, "203.0.113.9"); checked == nil || !checked.AccountLocked || checked.RetryAfter != want {
+			t.Fatalf("locked account checked: got %+v, want a %v account lockout", checked, want)
+		}
+		tt.advance(want)
+		if checked := tt.check(account, ""); checked != nil {
+			t.Fatalf("still locked after the lockout: %+v", checked)
+		}
+	}
+
+	// Lockouts in a row double, up to the maximum
+	lockout(time.Minute)
+	lockout(2 * time.Minute)
+	lockout(4 * time.Minute)
+	lockout(4 * time.Minute)
+
+	// A quiet period starts lockouts over
+	tt.advance(testLoginThrottle.MaxLockoutDuration + testLoginThrottle.FailureWindow + time.Minute)
+	lockout(time.Minute)
+}
+
+func TestLoginThrottleCountsIPAndAccount(t *testing.T) {
+	tt := newThrottleTest(t)
+	const ip, otherIP = "203.0.113.1", "198.51.100.7"
+
+	// One address guessing at many accounts is locked out by IP; each
+	// account has only failed once
+	var locked *LoginLockedError
+	for i := 0; i < testLoginThrottle.IPMaxFailures; i++ {
+		locked = tt.fail(string(rune('a'+i))+"@example.com", ip)
+	}
+	if locked == nil || locked.AccountLocked || locked.RetryAfter != testLoginThrottle.LockoutDuration {
+		t.Fatalf("failure %d from one IP: got %+v, want an IP lockout", testLoginThrottle.IPMaxFailures, locked)
+	}
+	tt.advance(time.Second)
+
+	if checked := tt.check("new@example.com", ip); checked == nil || checked.AccountLocked {
+		t.Errorf("new account from the locked IP: got %+v, want an IP lockout", checked)
+	}
+	if checked := tt.check("new@example.com", otherIP); checked != nil {
+		t.Errorf("new account from another IP throttled: %+v", checked)
+	}
+	if checked := tt.check("a@example.com", otherIP); checked != nil {
+		t.Errorf("account with one failure throttled from another IP after its backoff: %+v", checked)
+	}
+
+	// One account guessed at from many addresses is locked as an account
+	for i := 0; i < testLoginThrottle.MaxFailures; i++ {
+		locked = tt.fail("target@example.com", string(rune('a'+i))+".example")
+	}
+	if locked == nil || !locked.AccountLocked {
+		t.Fatalf("failure %d on one account: got %+v, want an account lockout", testLoginThrottle.MaxFailures, locked)
+	}
+	if checked := tt.check("target@example.com", otherIP); checked == nil || !checked.AccountLocked {
+		t.Errorf("locked account from a new IP: got %+v, want an account lockout", checked)
+	}
+}
+
+func TestClearLoginFailures(t *testing.T) {
+	tt := newThrottleTest(t)
+	const account, ip = "fan@example.com", "203.0.113.1"
+
+	tt.fail(account, ip)
+	tt.fail(account, ip)
+	tt.service.clearLoginFailures(account)
+
+	if locked := tt.check(account, ip); locked != nil {
+		t.Fatalf("throttled after a successful login: %+v", locked)
+	}
+	tt.fail(account, ip)
+	if locked := tt.check(account, ip); locked == nil || locked.RetryAfter != time.Second {
+		t.Errorf("failure after a successful login: got %+v, want the first backoff", locked)
+	}
+
+	// The IP address's failures are kept
+	if row := tt.attempts.rows[[2]string{loginScopeIP, ip}]; row == nil || row.failures != 3 {
+		t.Errorf("IP failures = %+v, want 3 kept", row)
+	}
+}
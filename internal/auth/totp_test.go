@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists eight-digit codes; six-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", rfc6238Secret, totpCode(key, current), true, current},
+		{"one step behind", rfc6238Secret, totpCode(key, current-1), true, current - 1},
+		{"one step ahead", rfc6238Secret, totpCode(key, current+1), true, current + 1},
+		{"two steps behind", rfc6238Secret, totpCode(key, current-2), false, 0},
+		{"two steps ahead", rfc6238Secret, totpCode(key, current+2), false, 0},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", totpCode(key, current), true, current},
+		{"wrong length", rfc6238Secret, totpCode(key, current)[:5], false, 0},
+		{"malformed secret", "not base32!", totpCode(key, current), false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := validateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("validateTOTP = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// WebAuthnConfig identifies this service to passkey authenticators
+type WebAuthnConfig struct {
+	RPID    string   // Domain passkeys are bound to
+	RPName  string   // Name authenticators show to the user
+	Origins []string // Web origins allowed to run ceremonies
+}
+
+// ErrInvalidPasskey is returned for any WebAuthn response that fails verification
+var ErrInvalidPasskey = errors.New("passkey verification failed")
+
+// COSE algorithm identifiers of the supported credential keys
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// Authenticator data flags
+const (
+	authDataUserPresent  = 0x01
+	authDataUserVerified = 0x04
+	authDataAttested     = 0x40
+)
+
+// clientData is the part of clientDataJSON the server checks
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// authenticatorData is parsed authenticator data. CredentialID and
+// PublicKey are only set when a credential was just created.
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	PublicKey    []byte // COSE_Key
+}
+
+// parseClientData checks clientDataJSON was made for ceremonyType by one of
+// the allowed origins and returns its challenge
+func (c WebAuthnConfig) parseClientData(raw []byte, ceremonyType string) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", fmt.Errorf("%w: malformed client data", ErrInvalidPasskey)
+	}
+	if data.Type != ceremonyType {
+		return "", fmt.Errorf("%w: client data is for %q, not %q", ErrInvalidPasskey, data.Type, ceremonyType)
+	}
+	if data.CrossOrigin {
+		return "", fmt.Errorf("%w: cross-origin ceremonies are not allowed", ErrInvalidPasskey)
+	}
+
+	allowed := false
+	for _, origin := range c.Origins {
+		allowed = allowed || data.Origin == origin
+	}
+	if !allowed {
+		return "", fmt.Errorf("%w: origin %q is not allowed", ErrInvalidPasskey, data.Origin)
+	}
+
+	return data.Challenge, nil
+}
+
+// parseAuthenticatorData parses authenticator data and checks it is for this
+// relying party and that the user was present and verified
+func (c WebAuthnConfig) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data is too short", ErrInvalidPasskey)
+	}
+
+	data := &authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(c.RPID))
+	if string(data.RPIDHash) != string(rpIDHash[:]) {
+		return nil, fmt.Errorf("%w: credential is for another relying party", ErrInvalidPasskey)
+	}
+	if data.Flags&authDataUserPresent == 0 || data.Flags&authDataUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user was not verified by the authenticator", ErrInvalidPasskey)
+	}
+
+	if data.Flags&authDataAttested != 0 {
+		// AAGUID (16 bytes), credential ID length (2 bytes), credential ID, COSE key
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: attested credential data is too short", ErrInvalidPasskey)
+		}
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLength {
+			return nil, fmt.Errorf("%w: attested credential data is too short", ErrInvalidPasskey)
+		}
+		data.CredentialID = rest[:idLength]
+
+		_, keyLength, err := decodeCBOR(rest[idLength:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed credential public key", ErrInvalidPasskey)
+		}
+		data.PublicKey = rest[idLength : idLength+keyLength]
+	}
+
+	return data, nil
+}
+
+// parseAttestationObject returns the authenticator data of an attestation
+// object. Registration asks for no attestation, so the attestation
+// statement is not verified: the credential is trusted as the user's
+// because only the logged-in user can register it.
+func parseAttestationObject(raw []byte) ([]byte, error) {
+	decoded, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrInvalidPasskey)
+	}
+	object, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrInvalidPasskey)
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestation object has no authenticator data", ErrInvalidPasskey)
+	}
+	return authData, nil
+}
+
+// parseCOSEKey parses a COSE_Key into a public key and its COSE algorithm
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+	decoded, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: malformed credential public key", ErrInvalidPasskey)
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: malformed credential public key", ErrInvalidPasskey)
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	switch {
+	case alg == coseAlgES256 && kty == 2:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, fmt.Errorf("%w: invalid P-256 key", ErrInvalidPasskey)
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, 0, fmt.Errorf("%w: invalid P-256 key", ErrInvalidPasskey)
+		}
+		return publicKey, alg, nil
+	case alg == coseAlgEdDSA && kty == 1:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, fmt.Errorf("%w: invalid Ed25519 key", ErrInvalidPasskey)
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case alg == coseAlgRS256 && kty == 3:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return nil, 0, fmt.Errorf("%w: invalid RSA key", ErrInvalidPasskey)
+		}
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if publicKey.N.BitLen() < minRSAKeyBits {
+			return nil, 0, fmt.Errorf("%w: RSA key is too small", ErrInvalidPasskey)
+		}
+		return publicKey, alg, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unsupported key algorithm %d", ErrInvalidPasskey, alg)
+	}
+}
+
+// verifyAssertion checks an assertion signature, made over the authenticator
+// data followed by the SHA-256 of clientDataJSON
+func verifyAssertion(coseKey, authData, clientDataJSON, signature []byte) error {
+	publicKey, _, err := parseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+	digest := sha256.Sum256(signed)
+
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, signed, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", ErrInvalidPasskey)
+	}
+	return nil
+}
+
+// checkSignCount checks an assertion's signature counter against the stored
+// one. Authenticators that count signatures must always count up; a count
+// that doesn't means the credential has probably been cloned. Those that
+// don't count always report zero.
+func checkSignCount(stored int64, received uint32) error {
+	if (received != 0 || stored != 0) && int64(received) <= stored {
+		return fmt.Errorf("%w: signature counter went backwards", ErrInvalidPasskey)
+	}
+	return nil
+}
+
+// decodeBase64URL decodes the unpadded base64url WebAuthn uses for binary
+// fields, tolerating padding
+func decodeBase64URL(value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed base64url value", ErrInvalidPasskey)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var testWebAuthn = WebAuthnConfig{
+	RPID:    "bagr.app",
+	RPName:  "BAGR",
+	Origins: []string{"https://bagr.app"},
+}
+
+// cborMap is a CBOR map written as alternating keys and values, so tests
+// encode it in a fixed order
+type cborMap []interface{}
+
+// encodeCBOR encodes the few CBOR types the tests build
+func encodeCBOR(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	switch v := v.(type) {
+	case int:
+		if v >= 0 {
+			return cborHead(0, uint64(v))
+		}
+		return cborHead(1, uint64(-1-v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case cborMap:
+		out := cborHead(5, uint64(len(v)/2))
+		for _, item := range v {
+			out = append(out, encodeCBOR(t, item)...)
+		}
+		return out
+	default:
+		t.Fatalf("cannot encode %T as CBOR", v)
+		return nil
+	}
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		head := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(head[1:], uint16(n))
+		return head
+	default:
+		head := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(n))
+		return head
+	}
+}
+
+// testAuthenticator is a software ES256 passkey authenticator
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{key: key, credentialID: []byte("test-credential")}
+}
+
+func (a *testAuthenticator) coseKey(t *testing.T) []byte {
+	return encodeCBOR(t, cborMap{
+		1, 2, // kty: EC2
+		3, coseAlgES256,
+		-1, 1, // crv: P-256
+		-2, a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3, a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+// authenticatorData builds authenticator data for rpID, with attested
+// credential data when attested is set
+func (a *testAuthenticator) authenticatorData(t *testing.T, rpID string, flags byte, signCount uint32, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	if attested {
+		flags |= authDataAttested
+	}
+	data = append(data, flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = append(data, byte(len(a.credentialID)>>8), byte(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey(t)...)
+	}
+	return data
+}
+
+// sign signs an assertion over authData and clientDataJSON
+func (a *testAuthenticator) sign(t *testing.T, authData, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func testClientData(t *testing.T, ceremonyType, origin string, crossOrigin bool) []byte {
+	raw, err := json.Marshal(clientData{
+		Type:        ceremonyType,
+		Challenge:   base64.RawURLEncoding.EncodeToString([]byte("challenge")),
+		Origin:      origin,
+		CrossOrigin: crossOrigin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParseClientData(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         []byte
+		wantInvalid bool
+	}{
+		{"allowed origin", testClientData(t, "webauthn.get", "https://bagr.app", false), false},
+		{"other origin", testClientData(t, "webauthn.get", "https://bagr.app.evil.example", false), true},
+		{"http origin", testClientData(t, "webauthn.get", "http://bagr.app", false), true},
+		{"other ceremony", testClientData(t, "webauthn.create", "https://bagr.app", false), true},
+		{"cross origin", testClientData(t, "webauthn.get", "https://bagr.app", true), true},
+		{"malformed", []byte("{"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge, err := testWebAuthn.parseClientData(tt.raw, "webauthn.get")
+			if tt.wantInvalid {
+				if !errors.Is(err, ErrInvalidPasskey) {
+					t.Fatalf("got %v, want ErrInvalidPasskey", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := base64.RawURLEncoding.EncodeToString([]byte("challenge")); challenge != want {
+				t.Errorf("challenge = %q, want %q", challenge, want)
+			}
+		})
+	}
+}
+
+func TestParseAuthenticatorData(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	verified := byte(authDataUserPresent | authDataUserVerified)
+
+	tests := []struct {
+		name        string
+		raw         []byte
+		wantInvalid bool
+	}{
+		{"this relying party", authenticator.authenticatorData(t, "bagr.app", verified, 1, false), false},
+		{"other relying party", authenticator.authenticatorData(t, "evil.example", verified, 1, false), true},
+		{"parent domain", authenticator.authenticatorData(t, "app", verified, 1, false), true},
+		{"user not verified", authenticator.authenticatorData(t, "bagr.app", authDataUserPresent, 1, false), true},
+		{"user not present", authenticator.authenticatorData(t, "bagr.app", authDataUserVerified, 1, false), true},
+		{"too short", make([]byte, 36), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := testWebAuthn.parseAuthenticatorData(tt.raw)
+			if tt.wantInvalid {
+				if !errors.Is(err, ErrInvalidPasskey) {
+					t.Fatalf("got %v, want ErrInvalidPasskey", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if data.SignCount != 1 {
+				t.Errorf("sign count = %d, want 1", data.SignCount)
+			}
+		})
+	}
+}
+
+func TestParseAttestationObject(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	authData := authenticator.authenticatorData(t, "bagr.app", authDataUserPresent|authDataUserVerified, 0, true)
+	attestation := encodeCBOR(t, cborMap{
+		"fmt", "none",
+		"attStmt", cborMap{},
+		"authData", authData,
+	})
+
+	raw, err := parseAttestationObject(attestation)
+	if err != nil {
+		t.Fatalf("parseAttestationObject: %v", err)
+	}
+	data, err := testWebAuthn.parseAuthenticatorData(raw)
+	if err != nil {
+		t.Fatalf("parseAuthenticatorData: %v", err)
+	}
+	if !bytes.Equal(data.CredentialID, authenticator.credentialID) {
+		t.Errorf("credential ID = %q, want %q", data.CredentialID, authenticator.credentialID)
+	}
+
+	publicKey, alg, err := parseCOSEKey(data.PublicKey)
+	if err != nil {
+		t.Fatalf("parseCOSEKey: %v", err)
+	}
+	if alg != coseAlgES256 {
+		t.Errorf("algorithm = %d, want %d", alg, coseAlgES256)
+	}
+	if !authenticator.key.PublicKey.Equal(publicKey) {
+		t.Error("parsed public key does not match the authenticator's")
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+	coseKey := authenticator.coseKey(t)
+	clientDataJSON := testClientData(t, "webauthn.get", "https://bagr.app", false)
+	authData := authenticator.authenticatorData(t, "bagr.app", authDataUserPresent|authDataUserVerified, 7, false)
+	signature := authenticator.sign(t, authData, clientDataJSON)
+
+	if err := verifyAssertion(coseKey, authData, clientDataJSON, signature); err != nil {
+		t.Fatalf("valid assertion rejected: %v", err)
+	}
+
+	// A replayed signature doesn't cover a raised counter or other client data
+	raised := authenticator.authenticatorData(t, "bagr.app", authDataUserPresent|authDataUserVerified, 8, false)
+	if err := verifyAssertion(coseKey, raised, clientDataJSON, signature); !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("tampered authenticator data: got %v, want ErrInvalidPasskey", err)
+	}
+	otherClientData := testClientData(t, "webauthn.get", "https://evil.example", false)
+	if err := verifyAssertion(coseKey, authData, otherClientData, signature); !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("tampered client data: got %v, want ErrInvalidPasskey", err)
+	}
+
+	// Signatures by another key are refused
+	other := newTestAuthenticator(t)
+	if err := verifyAssertion(coseKey, authData, clientDataJSON, other.sign(t, authData, clientDataJSON)); !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("other key: got %v, want ErrInvalidPasskey", err)
+	}
+}
+
+func TestVerifyAssertionEdDSA(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coseKey := encodeCBOR(t, cborMap{1, 1, 3, coseAlgEdDSA, -1, 6, -2, []byte(publicKey)})
+
+	authData := (&testAuthenticator{}).authenticatorData(t, "bagr.app", authDataUserPresent|authDataUserVerified, 0, false)
+	clientDataJSON := testClientData(t, "webauthn.get", "https://bagr.app", false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature := ed25519.Sign(privateKey, append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	if err := verifyAssertion(coseKey, authData, clientDataJSON, signature); err != nil {
+		t.Fatalf("valid assertion rejected: %v", err)
+	}
+	signature[0] ^= 0xff
+	if err := verifyAssertion(coseKey, authData, clientDataJSON, signature); !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("bad signature: got %v, want ErrInvalidPasskey", err)
+	}
+}
+
+func TestCheckSignCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   int64
+		received uint32
+		wantErr  bool
+	}{
+		{"authenticator without counter", 0, 0, false},
+		{"first use", 0, 1, false},
+		{"counts up", 5, 6, false},
+		{"skips ahead", 5, 100, false},
+		{"repeated", 5, 5, true},
+		{"went backwards", 5, 3, true},
+		{"reset to zero", 5, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSignCount(tt.stored, tt.received)
+			if tt.wantErr && !errors.Is(err, ErrInvalidPasskey) {
+				t.Errorf("got %v, want ErrInvalidPasskey", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
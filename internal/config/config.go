@@ -1,14 +1,25 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+
+	"bagr-backend/internal/models"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
 )
 
+// Development JWT secrets, refused in production
+const (
+	defaultJWTAccessSecret  = "your-access-secret-key-change-in-production"
+	defaultJWTRefreshSecret = "your-refresh-secret-key-change-in-production"
+)
+
 // Config holds all configuration for the application
 type Config struct {
 	Server   ServerConfig   `yaml:"server"`
@@ -18,6 +29,14 @@ type Config struct {
 	JWT      JWTConfig      `yaml:"jwt"`
 	Email    EmailConfig    `yaml:"email"`
 	S3       S3Config       `yaml:"s3"`
+	Auction  AuctionConfig  `yaml:"auction"`
+	Realtime RealtimeConfig `yaml:"realtime"`
+	Media    MediaConfig    `yaml:"media"`
+	Payments PaymentsConfig `yaml:"payments"`
+	Currency CurrencyConfig `yaml:"currency"`
+	WebAuthn WebAuthnConfig `yaml:"webauthn"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+	Login    LoginConfig    `yaml:"login"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -26,6 +45,19 @@ type ServerConfig struct {
 	Port         string `yaml:"port" env:"SERVER_PORT"`
 	ReadTimeout  int    `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
 	WriteTimeout int    `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header gives the client IP. Without any, the client IP
+	// is the connection's address. From the environment it is read as a comma
+	// separated list.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+
+	// AllowedOrigins are the browser origins, such as "https://bagr.app", that
+	// may call the API cross-origin and open the live auction feed. Without
+	// any, CORS allows every origin and live feed WebSockets are only accepted
+	// from the API's own origin. From the environment it is read as a comma
+	// separated list.
+	AllowedOrigins []string `yaml:"allowed_origins" env:"SERVER_ALLOWED_ORIGINS"`
 }
 
 // DatabaseConfig holds database configuration
@@ -56,6 +88,18 @@ type AppConfig struct {
 type JWTConfig struct {
 	AccessSecret  string `yaml:"access_secret" env:"JWT_ACCESS_SECRET"`
 	RefreshSecret string `yaml:"refresh_secret" env:"JWT_REFRESH_SECRET"`
+
+	// Asymmetric signing for access tokens: a PEM RSA (RS256) or Ed25519 (EdDSA)
+	// private key, plus the public keys of retired signing keys that should still
+	// verify tokens until they expire. Without a signing key, access tokens are
+	// signed with AccessSecret. From the environment the verification keys are a
+	// comma separated list of files.
+	SigningKeyFile       string   `yaml:"signing_key_file" env:"JWT_SIGNING_KEY_FILE"`
+	VerificationKeyFiles []string `yaml:"verification_key_files" env:"JWT_VERIFICATION_KEY_FILES"`
+
+	// Seconds between loads of the token revocation denylist, which bounds how
+	// long a token revoked on another replica keeps working here
+	RevocationSyncInterval int `yaml:"revocation_sync_interval" env:"JWT_REVOCATION_SYNC_INTERVAL"`
 }
 
 // EmailConfig holds email configuration
@@ -68,13 +112,162 @@ type EmailConfig struct {
 	TestMode     bool   `yaml:"test_mode" env:"EMAIL_TEST_MODE"`
 }
 
-// S3Config holds AWS S3 configuration
+// S3Config holds object storage configuration
 type S3Config struct {
+	Driver          string `yaml:"driver" env:"S3_DRIVER"` // "s3" or "local" (files on disk, for development)
 	Region          string `yaml:"region" env:"S3_REGION"`
 	Bucket          string `yaml:"bucket" env:"S3_BUCKET"`
 	AccessKeyID     string `yaml:"access_key_id" env:"S3_ACCESS_KEY_ID"`
 	SecretAccessKey string `yaml:"secret_access_key" env:"S3_SECRET_ACCESS_KEY"`
 	BaseURL         string `yaml:"base_url" env:"S3_BASE_URL"`
+
+	// Local driver settings; files are served by the API under /storage
+	LocalPath       string `yaml:"local_path" env:"S3_LOCAL_PATH"`
+	LocalBaseURL    string `yaml:"local_base_url" env:"S3_LOCAL_BASE_URL"`       // External URL of /storage
+	LocalSigningKey string `yaml:"local_signing_key" env:"S3_LOCAL_SIGNING_KEY"` // Signs upload and download URLs; random per process when unset
+}
+
+// AuctionConfig holds auction engine configuration
+type AuctionConfig struct {
+	SchedulerInterval  int `yaml:"scheduler_interval" env:"AUCTION_SCHEDULER_INTERVAL"`     // Seconds between lifecycle runs
+	SchedulerBatchSize int `yaml:"scheduler_batch_size" env:"AUCTION_SCHEDULER_BATCH_SIZE"` // Auctions closed per run
+
+	// Soft close defaults for new auctions (minutes); a max of 0 means no cap on extensions
+	SoftCloseWindow        int `yaml:"soft_close_window_minutes" env:"AUCTION_SOFT_CLOSE_WINDOW"`
+	SoftCloseExtension     int `yaml:"soft_close_extension_minutes" env:"AUCTION_SOFT_CLOSE_EXTENSION"`
+	SoftCloseMaxExtensions int `yaml:"soft_close_max_extensions" env:"AUCTION_SOFT_CLOSE_MAX_EXTENSIONS"`
+
+	// BidIncrements is the default bid increment ladder, also used for proxy bidding steps.
+	// From the environment it is read as "up_to:increment" pairs, e.g. "20:1,100:5,25".
+	BidIncrements []BidIncrementBand `yaml:"bid_increments" env:"AUCTION_BID_INCREMENTS"`
+}
+
+// RealtimeConfig holds live auction feed configuration
+type RealtimeConfig struct {
+	Broker            string `yaml:"broker" env:"REALTIME_BROKER"`                         // "local" or "postgres" (fans out across replicas)
+	HeartbeatInterval int    `yaml:"heartbeat_interval" env:"REALTIME_HEARTBEAT_INTERVAL"` // Seconds between keep-alive messages
+}
+
+// MediaConfig holds media upload configuration
+type MediaConfig struct {
+	MaxAudioSizeMB int `yaml:"max_audio_size_mb" env:"MEDIA_MAX_AUDIO_SIZE_MB"` // Largest accepted track upload
+	MaxImageSizeMB int `yaml:"max_image_size_mb" env:"MEDIA_MAX_IMAGE_SIZE_MB"` // Largest accepted profile image upload
+
+	// Lifetimes of presigned S3 URLs (seconds)
+	UploadURLExpiry   int `yaml:"upload_url_expiry" env:"MEDIA_UPLOAD_URL_EXPIRY"`
+	DownloadURLExpiry int `yaml:"download_url_expiry" env:"MEDIA_DOWNLOAD_URL_EXPIRY"`
+
+	// Auction previews stream only the opening PreviewSeconds of a track,
+	// through signed links valid for PreviewTokenTTL seconds. The links are
+	// signed with PreviewSigningKey, random per process when unset.
+	PreviewSeconds    int    `yaml:"preview_seconds" env:"MEDIA_PREVIEW_SECONDS"`
+	PreviewTokenTTL   int    `yaml:"preview_token_ttl" env:"MEDIA_PREVIEW_TOKEN_TTL"`
+	PreviewSigningKey string `yaml:"preview_signing_key" env:"MEDIA_PREVIEW_SIGNING_KEY"`
+}
+
+// PaymentsConfig holds payment provider and escrow configuration
+type PaymentsConfig struct {
+	Provider        string `yaml:"provider" env:"PAYMENTS_PROVIDER"`             // "fake" (in memory, for development) or "stripe"
+	StripeSecretKey string `yaml:"stripe_secret_key" env:"STRIPE_SECRET_KEY"`    // Stripe API secret key
+	WebhookSecret   string `yaml:"webhook_secret" env:"PAYMENTS_WEBHOOK_SECRET"` // Verifies provider webhook signatures; random per process for "fake" when unset
+
+	// Escrow capture worker
+	WorkerInterval  int `yaml:"worker_interval" env:"PAYMENTS_WORKER_INTERVAL"`     // Seconds between capture runs
+	WorkerBatchSize int `yaml:"worker_batch_size" env:"PAYMENTS_WORKER_BATCH_SIZE"` // Max escrows captured per run
+	MaxAttempts     int `yaml:"max_attempts" env:"PAYMENTS_MAX_ATTEMPTS"`           // Capture attempts before an escrow fails
+	CommissionBps   int `yaml:"commission_bps" env:"PAYMENTS_COMMISSION_BPS"`       // Platform commission on each sale, in basis points
+}
+
+// CurrencyConfig holds auction currency and display conversion configuration
+type CurrencyConfig struct {
+	Default string `yaml:"default" env:"CURRENCY_DEFAULT"` // Currency of auctions created without one
+
+	// Supported lists the ISO 4217 codes auctions may be priced in. Amounts
+	// are kept in hundredths, so only currencies with two decimal places fit.
+	// From the environment it is read as a comma separated list.
+	Supported []string `yaml:"supported" env:"CURRENCY_SUPPORTED"`
+
+	// RatesFile is an exchange rate table loaded at startup, in the JSON
+	// format of PUT /fx/rates. Rates only convert amounts for display.
+	RatesFile string `yaml:"rates_file" env:"CURRENCY_RATES_FILE"`
+}
+
+// WebAuthnConfig holds passkey configuration
+type WebAuthnConfig struct {
+	RPID   string `yaml:"rp_id" env:"WEBAUTHN_RP_ID"`     // Domain passkeys are bound to, e.g. "bagr.app"
+	RPName string `yaml:"rp_name" env:"WEBAUTHN_RP_NAME"` // Name authenticators show to the user
+
+	// Origins are the web origins allowed to run passkey ceremonies, e.g.
+	// "https://bagr.app". From the environment it is read as a comma
+	// separated list.
+	Origins []string `yaml:"origins" env:"WEBAUTHN_ORIGINS"`
+}
+
+// OIDCConfig holds social login configuration
+type OIDCConfig struct {
+	// CallbackBaseURL is the external URL of this API; providers send users
+	// back to {CallbackBaseURL}/api/v1/auth/oidc/{provider}/callback
+	CallbackBaseURL string `yaml:"callback_base_url" env:"OIDC_CALLBACK_BASE_URL"`
+
+	// Mock serves a mock OpenID provider at /mock-oidc and enables it as the
+	// "mock" provider, for development without real provider credentials.
+	// Refused in production.
+	Mock bool `yaml:"mock" env:"OIDC_MOCK"`
+
+	// Providers by name, as used in the login URLs. "google" and "apple"
+	// default to their issuers; other names need an issuer. Providers
+	// without a client ID are disabled.
+	Providers map[string]OIDCProviderConfig `yaml:"providers"`
+}
+
+// OIDCProviderConfig holds one OpenID Connect provider's client registration
+type OIDCProviderConfig struct {
+	Issuer       string   `yaml:"issuer"` // Discovery is read from {Issuer}/.well-known/openid-configuration
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"` // For Apple, the signed client secret JWT
+	Scopes       []string `yaml:"scopes"`
+	ResponseMode string   `yaml:"response_mode"` // "form_post" for Apple, which posts the callback
+}
+
+// LoginConfig holds password login throttling configuration. After each
+// failed login an account waits 1s, 2s, 4s and so on before it may try again.
+type LoginConfig struct {
+	MaxFailures   int `yaml:"max_failures" env:"LOGIN_MAX_FAILURES"`       // Failed logins before an account is locked
+	IPMaxFailures int `yaml:"ip_max_failures" env:"LOGIN_IP_MAX_FAILURES"` // Failed logins from one IP address before it is locked out
+	FailureWindow int `yaml:"failure_window" env:"LOGIN_FAILURE_WINDOW"`   // Seconds before a failed login is forgotten
+
+	// Seconds an account or IP address stays locked. Each lockout in a row
+	// doubles the last, up to MaxLockoutDuration.
+	LockoutDuration    int `yaml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION"`
+	MaxLockoutDuration int `yaml:"max_lockout_duration" env:"LOGIN_MAX_LOCKOUT_DURATION"`
+}
+
+// BidIncrementBand is one step of the bid increment ladder: bids on a current
+// price below UpTo must rise by Increment. An UpTo of 0 covers every higher price.
+type BidIncrementBand struct {
+	UpTo      float64 `yaml:"up_to"`
+	Increment float64 `yaml:"increment"`
+}
+
+// BidIncrementTable converts the bid increment ladder into its model form.
+// The configuration is in currency units; the ladder applies to every
+// currency.
+func (c AuctionConfig) BidIncrementTable() models.BidIncrementTable {
+	table := make(models.BidIncrementTable, 0, len(c.BidIncrements))
+	for _, band := range c.BidIncrements {
+		entry := models.BidIncrementBand{Increment: models.NewMoney(toCents(band.Increment), "")}
+		if band.UpTo > 0 {
+			upTo := models.NewMoney(toCents(band.UpTo), "")
+			entry.UpTo = &upTo
+		}
+		table = append(table, entry)
+	}
+	return table
+}
+
+// toCents converts a configured amount in currency units to whole cents
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
 }
 
 // Load loads configuration from file and environment variables
@@ -92,11 +285,17 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	// Override with environment variables
-	loadFromEnv(config)
+	if err := loadFromEnv(config); err != nil {
+		return nil, fmt.Errorf("failed to load config from environment: %w", err)
+	}
 
 	// Set defaults
 	setDefaults(config)
 
+	if err := validate(config); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
@@ -113,7 +312,7 @@ func loadFromFile(config *Config, path string) error {
 }
 
 // loadFromEnv loads configuration from environment variables
-func loadFromEnv(config *Config) {
+func loadFromEnv(config *Config) error {
 	// Server config
 	if host := os.Getenv("SERVER_HOST"); host != "" {
 		config.Server.Host = host
@@ -131,6 +330,22 @@ func loadFromEnv(config *Config) {
 			config.Server.WriteTimeout = val
 		}
 	}
+	if proxies := os.Getenv("SERVER_TRUSTED_PROXIES"); proxies != "" {
+		config.Server.TrustedProxies = nil
+		for _, proxy := range strings.Split(proxies, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				config.Server.TrustedProxies = append(config.Server.TrustedProxies, proxy)
+			}
+		}
+	}
+	if origins := os.Getenv("SERVER_ALLOWED_ORIGINS"); origins != "" {
+		config.Server.AllowedOrigins = nil
+		for _, origin := range strings.Split(origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.Server.AllowedOrigins = append(config.Server.AllowedOrigins, origin)
+			}
+		}
+	}
 
 	// Database config
 	if host := os.Getenv("DB_HOST"); host != "" {
@@ -183,6 +398,22 @@ func loadFromEnv(config *Config) {
 	if refreshSecret := os.Getenv("JWT_REFRESH_SECRET"); refreshSecret != "" {
 		config.JWT.RefreshSecret = refreshSecret
 	}
+	if keyFile := os.Getenv("JWT_SIGNING_KEY_FILE"); keyFile != "" {
+		config.JWT.SigningKeyFile = keyFile
+	}
+	if keyFiles := os.Getenv("JWT_VERIFICATION_KEY_FILES"); keyFiles != "" {
+		config.JWT.VerificationKeyFiles = nil
+		for _, keyFile := range strings.Split(keyFiles, ",") {
+			if keyFile = strings.TrimSpace(keyFile); keyFile != "" {
+				config.JWT.VerificationKeyFiles = append(config.JWT.VerificationKeyFiles, keyFile)
+			}
+		}
+	}
+	if interval := os.Getenv("JWT_REVOCATION_SYNC_INTERVAL"); interval != "" {
+		if val, err := strconv.Atoi(interval); err == nil {
+			config.JWT.RevocationSyncInterval = val
+		}
+	}
 
 	// Email config
 	if clientID := os.Getenv("EMAIL_CLIENT_ID"); clientID != "" {
@@ -207,6 +438,9 @@ func loadFromEnv(config *Config) {
 	}
 
 	// S3 config
+	if driver := os.Getenv("S3_DRIVER"); driver != "" {
+		config.S3.Driver = driver
+	}
 	if region := os.Getenv("S3_REGION"); region != "" {
 		config.S3.Region = region
 	}
@@ -222,6 +456,241 @@ func loadFromEnv(config *Config) {
 	if baseURL := os.Getenv("S3_BASE_URL"); baseURL != "" {
 		config.S3.BaseURL = baseURL
 	}
+	if localPath := os.Getenv("S3_LOCAL_PATH"); localPath != "" {
+		config.S3.LocalPath = localPath
+	}
+	if localBaseURL := os.Getenv("S3_LOCAL_BASE_URL"); localBaseURL != "" {
+		config.S3.LocalBaseURL = localBaseURL
+	}
+	if signingKey := os.Getenv("S3_LOCAL_SIGNING_KEY"); signingKey != "" {
+		config.S3.LocalSigningKey = signingKey
+	}
+
+	// Auction config
+	if interval := os.Getenv("AUCTION_SCHEDULER_INTERVAL"); interval != "" {
+		if val, err := strconv.Atoi(interval); err == nil {
+			config.Auction.SchedulerInterval = val
+		}
+	}
+	if batchSize := os.Getenv("AUCTION_SCHEDULER_BATCH_SIZE"); batchSize != "" {
+		if val, err := strconv.Atoi(batchSize); err == nil {
+			config.Auction.SchedulerBatchSize = val
+		}
+	}
+	if window := os.Getenv("AUCTION_SOFT_CLOSE_WINDOW"); window != "" {
+		if val, err := strconv.Atoi(window); err == nil {
+			config.Auction.SoftCloseWindow = val
+		}
+	}
+	if extension := os.Getenv("AUCTION_SOFT_CLOSE_EXTENSION"); extension != "" {
+		if val, err := strconv.Atoi(extension); err == nil {
+			config.Auction.SoftCloseExtension = val
+		}
+	}
+	if maxExtensions := os.Getenv("AUCTION_SOFT_CLOSE_MAX_EXTENSIONS"); maxExtensions != "" {
+		if val, err := strconv.Atoi(maxExtensions); err == nil {
+			config.Auction.SoftCloseMaxExtensions = val
+		}
+	}
+	if increments := os.Getenv("AUCTION_BID_INCREMENTS"); increments != "" {
+		bands, err := parseBidIncrements(increments)
+		if err != nil {
+			return err
+		}
+		config.Auction.BidIncrements = bands
+	}
+
+	// Realtime config
+	if broker := os.Getenv("REALTIME_BROKER"); broker != "" {
+		config.Realtime.Broker = broker
+	}
+	if heartbeat := os.Getenv("REALTIME_HEARTBEAT_INTERVAL"); heartbeat != "" {
+		if val, err := strconv.Atoi(heartbeat); err == nil {
+			config.Realtime.HeartbeatInterval = val
+		}
+	}
+
+	// Media config
+	if maxSize := os.Getenv("MEDIA_MAX_AUDIO_SIZE_MB"); maxSize != "" {
+		if val, err := strconv.Atoi(maxSize); err == nil {
+			config.Media.MaxAudioSizeMB = val
+		}
+	}
+	if maxSize := os.Getenv("MEDIA_MAX_IMAGE_SIZE_MB"); maxSize != "" {
+		if val, err := strconv.Atoi(maxSize); err == nil {
+			config.Media.MaxImageSizeMB = val
+		}
+	}
+	if expiry := os.Getenv("MEDIA_UPLOAD_URL_EXPIRY"); expiry != "" {
+		if val, err := strconv.Atoi(expiry); err == nil {
+			config.Media.UploadURLExpiry = val
+		}
+	}
+	if expiry := os.Getenv("MEDIA_DOWNLOAD_URL_EXPIRY"); expiry != "" {
+		if val, err := strconv.Atoi(expiry); err == nil {
+			config.Media.DownloadURLExpiry = val
+		}
+	}
+	if seconds := os.Getenv("MEDIA_PREVIEW_SECONDS"); seconds != "" {
+		if val, err := strconv.Atoi(seconds); err == nil {
+			config.Media.PreviewSeconds = val
+		}
+	}
+	if ttl := os.Getenv("MEDIA_PREVIEW_TOKEN_TTL"); ttl != "" {
+		if val, err := strconv.Atoi(ttl); err == nil {
+			config.Media.PreviewTokenTTL = val
+		}
+	}
+	if signingKey := os.Getenv("MEDIA_PREVIEW_SIGNING_KEY"); signingKey != "" {
+		config.Media.PreviewSigningKey = signingKey
+	}
+
+	// Payments config
+	if provider := os.Getenv("PAYMENTS_PROVIDER"); provider != "" {
+		config.Payments.Provider = provider
+	}
+	if secretKey := os.Getenv("STRIPE_SECRET_KEY"); secretKey != "" {
+		config.Payments.StripeSecretKey = secretKey
+	}
+	if webhookSecret := os.Getenv("PAYMENTS_WEBHOOK_SECRET"); webhookSecret != "" {
+		config.Payments.WebhookSecret = webhookSecret
+	}
+	if interval := os.Getenv("PAYMENTS_WORKER_INTERVAL"); interval != "" {
+		if val, err := strconv.Atoi(interval); err == nil {
+			config.Payments.WorkerInterval = val
+		}
+	}
+	if batchSize := os.Getenv("PAYMENTS_WORKER_BATCH_SIZE"); batchSize != "" {
+		if val, err := strconv.Atoi(batchSize); err == nil {
+			config.Payments.WorkerBatchSize = val
+		}
+	}
+	if attempts := os.Getenv("PAYMENTS_MAX_ATTEMPTS"); attempts != "" {
+		if val, err := strconv.Atoi(attempts); err == nil {
+			config.Payments.MaxAttempts = val
+		}
+	}
+	if commission := os.Getenv("PAYMENTS_COMMISSION_BPS"); commission != "" {
+		if val, err := strconv.Atoi(commission); err == nil {
+			config.Payments.CommissionBps = val
+		}
+	}
+
+	// Currency config
+	if currency := os.Getenv("CURRENCY_DEFAULT"); currency != "" {
+		config.Currency.Default = currency
+	}
+	if supported := os.Getenv("CURRENCY_SUPPORTED"); supported != "" {
+		config.Currency.Supported = strings.Split(supported, ",")
+	}
+	if ratesFile := os.Getenv("CURRENCY_RATES_FILE"); ratesFile != "" {
+		config.Currency.RatesFile = ratesFile
+	}
+
+	// WebAuthn config
+	if rpID := os.Getenv("WEBAUTHN_RP_ID"); rpID != "" {
+		config.WebAuthn.RPID = rpID
+	}
+	if rpName := os.Getenv("WEBAUTHN_RP_NAME"); rpName != "" {
+		config.WebAuthn.RPName = rpName
+	}
+	if origins := os.Getenv("WEBAUTHN_ORIGINS"); origins != "" {
+		config.WebAuthn.Origins = strings.Split(origins, ",")
+	}
+
+	// OIDC config
+	if baseURL := os.Getenv("OIDC_CALLBACK_BASE_URL"); baseURL != "" {
+		config.OIDC.CallbackBaseURL = baseURL
+	}
+	if mock := os.Getenv("OIDC_MOCK"); mock != "" {
+		if val, err := strconv.ParseBool(mock); err == nil {
+			config.OIDC.Mock = val
+		}
+	}
+	// Each provider is configured by OIDC_<NAME>_ISSUER, _CLIENT_ID and _CLIENT_SECRET
+	for _, name := range []string{"google", "apple", "generic"} {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		issuer := os.Getenv(prefix + "ISSUER")
+		clientID := os.Getenv(prefix + "CLIENT_ID")
+		clientSecret := os.Getenv(prefix + "CLIENT_SECRET")
+		if issuer == "" && clientID == "" && clientSecret == "" {
+			continue
+		}
+		if config.OIDC.Providers == nil {
+			config.OIDC.Providers = make(map[string]OIDCProviderConfig)
+		}
+		provider := config.OIDC.Providers[name]
+		if issuer != "" {
+			provider.Issuer = issuer
+		}
+		if clientID != "" {
+			provider.ClientID = clientID
+		}
+		if clientSecret != "" {
+			provider.ClientSecret = clientSecret
+		}
+		config.OIDC.Providers[name] = provider
+	}
+
+	// Login config
+	if maxFailures := os.Getenv("LOGIN_MAX_FAILURES"); maxFailures != "" {
+		if val, err := strconv.Atoi(maxFailures); err == nil {
+			config.Login.MaxFailures = val
+		}
+	}
+	if ipMaxFailures := os.Getenv("LOGIN_IP_MAX_FAILURES"); ipMaxFailures != "" {
+		if val, err := strconv.Atoi(ipMaxFailures); err == nil {
+			config.Login.IPMaxFailures = val
+		}
+	}
+	if window := os.Getenv("LOGIN_FAILURE_WINDOW"); window != "" {
+		if val, err := strconv.Atoi(window); err == nil {
+			config.Login.FailureWindow = val
+		}
+	}
+	if duration := os.Getenv("LOGIN_LOCKOUT_DURATION"); duration != "" {
+		if val, err := strconv.Atoi(duration); err == nil {
+			config.Login.LockoutDuration = val
+		}
+	}
+	if duration := os.Getenv("LOGIN_MAX_LOCKOUT_DURATION"); duration != "" {
+		if val, err := strconv.Atoi(duration); err == nil {
+			config.Login.MaxLockoutDuration = val
+		}
+	}
+
+	return nil
+}
+
+// parseBidIncrements parses a comma separated list of "up_to:increment" pairs;
+// a bare increment is an open-ended band
+func parseBidIncrements(value string) ([]BidIncrementBand, error) {
+	var bands []BidIncrementBand
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var band BidIncrementBand
+		increment := part
+		if upTo, rest, found := strings.Cut(part, ":"); found {
+			val, err := strconv.ParseFloat(strings.TrimSpace(upTo), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bid increment band %q: %w", part, err)
+			}
+			band.UpTo = val
+			increment = rest
+		}
+		val, err := strconv.ParseFloat(strings.TrimSpace(increment), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bid increment band %q: %w", part, err)
+		}
+		band.Increment = val
+
+		bands = append(bands, band)
+	}
+	return bands, nil
 }
 
 // setDefaults sets default values for configuration
@@ -265,10 +734,13 @@ func setDefaults(config *Config) {
 
 	// JWT defaults
 	if config.JWT.AccessSecret == "" {
-		config.JWT.AccessSecret = "your-access-secret-key-change-in-production"
+		config.JWT.AccessSecret = defaultJWTAccessSecret
 	}
 	if config.JWT.RefreshSecret == "" {
-		config.JWT.RefreshSecret = "your-refresh-secret-key-change-in-production"
+		config.JWT.RefreshSecret = defaultJWTRefreshSecret
+	}
+	if config.JWT.RevocationSyncInterval <= 0 {
+		config.JWT.RevocationSyncInterval = 10
 	}
 
 	// Email defaults
@@ -288,6 +760,223 @@ func setDefaults(config *Config) {
 	if config.S3.Bucket == "" {
 		config.S3.Bucket = "bagr-profile-images"
 	}
+	if config.S3.Driver == "" {
+		config.S3.Driver = "s3"
+	}
+	if config.S3.LocalPath == "" {
+		config.S3.LocalPath = "./storage"
+	}
+	if config.S3.LocalBaseURL == "" {
+		config.S3.LocalBaseURL = fmt.Sprintf("http://localhost:%s/storage", config.Server.Port)
+	}
+
+	// Auction defaults
+	if config.Auction.SchedulerInterval <= 0 {
+		config.Auction.SchedulerInterval = 10
+	}
+	if config.Auction.SchedulerBatchSize <= 0 {
+		config.Auction.SchedulerBatchSize = 100
+	}
+	if len(config.Auction.BidIncrements) == 0 {
+		config.Auction.BidIncrements = []BidIncrementBand{
+			{UpTo: 20, Increment: 1},
+			{UpTo: 100, Increment: 5},
+			{UpTo: 500, Increment: 10},
+			{UpTo: 1000, Increment: 25},
+			{Increment: 50},
+		}
+	}
+
+	if config.Realtime.Broker == "" {
+		config.Realtime.Broker = "local"
+	}
+	if config.Realtime.HeartbeatInterval <= 0 {
+		config.Realtime.HeartbeatInterval = 25
+	}
+
+	if config.Media.MaxAudioSizeMB <= 0 {
+		config.Media.MaxAudioSizeMB = 200
+	}
+	if config.Media.MaxImageSizeMB <= 0 {
+		config.Media.MaxImageSizeMB = 10
+	}
+	if config.Media.UploadURLExpiry <= 0 {
+		config.Media.UploadURLExpiry = 900
+	}
+	if config.Media.DownloadURLExpiry <= 0 {
+		config.Media.DownloadURLExpiry = 300
+	}
+	if config.Media.PreviewSeconds <= 0 {
+		config.Media.PreviewSeconds = 30
+	}
+	if config.Media.PreviewTokenTTL <= 0 {
+		config.Media.PreviewTokenTTL = 600
+	}
+
+	if config.Payments.Provider == "" {
+		config.Payments.Provider = "fake"
+	}
+	if config.Payments.WorkerInterval <= 0 {
+		config.Payments.WorkerInterval = 10
+	}
+	if config.Payments.WorkerBatchSize <= 0 {
+		config.Payments.WorkerBatchSize = 50
+	}
+	if config.Payments.MaxAttempts <= 0 {
+		config.Payments.MaxAttempts = 5
+	}
+	if config.Payments.CommissionBps <= 0 || config.Payments.CommissionBps > 10000 {
+		config.Payments.CommissionBps = 1000
+	}
+
+	config.Currency.Default = strings.ToUpper(strings.TrimSpace(config.Currency.Default))
+	if config.Currency.Default == "" {
+		config.Currency.Default = "USD"
+	}
+	if len(config.Currency.Supported) == 0 {
+		config.Currency.Supported = []string{"USD", "EUR", "GBP", "CAD", "AUD"}
+	}
+	supported := make([]string, 0, len(config.Currency.Supported)+1)
+	hasDefault := false
+	for _, currency := range config.Currency.Supported {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if currency == "" {
+			continue
+		}
+		hasDefault = hasDefault || currency == config.Currency.Default
+		supported = append(supported, currency)
+	}
+	if !hasDefault {
+		supported = append(supported, config.Currency.Default)
+	}
+	config.Currency.Supported = supported
+
+	// WebAuthn defaults
+	if config.WebAuthn.RPID == "" {
+		config.WebAuthn.RPID = "localhost"
+	}
+	if config.WebAuthn.RPName == "" {
+		config.WebAuthn.RPName = "BAGR"
+	}
+	origins := make([]string, 0, len(config.WebAuthn.Origins))
+	for _, origin := range config.WebAuthn.Origins {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{"http://localhost:3000"}
+	}
+	config.WebAuthn.Origins = origins
+
+	// OIDC defaults
+	if config.OIDC.CallbackBaseURL == "" {
+		config.OIDC.CallbackBaseURL = fmt.Sprintf("http://localhost:%s", config.Server.Port)
+	}
+	config.OIDC.CallbackBaseURL = strings.TrimRight(config.OIDC.CallbackBaseURL, "/")
+	for name, provider := range config.OIDC.Providers {
+		switch name {
+		case "google":
+			if provider.Issuer == "" {
+				provider.Issuer = "https://accounts.google.com"
+			}
+		case "apple":
+			if provider.Issuer == "" {
+				provider.Issuer = "https://appleid.apple.com"
+			}
+			if len(provider.Scopes) == 0 {
+				provider.Scopes = []string{"openid", "email", "name"}
+			}
+			if provider.ResponseMode == "" {
+				provider.ResponseMode = "form_post"
+			}
+		}
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+		provider.Issuer = strings.TrimRight(provider.Issuer, "/")
+		config.OIDC.Providers[name] = provider
+	}
+
+	// Login defaults
+	if config.Login.MaxFailures <= 0 {
+		config.Login.MaxFailures = 5
+	}
+	if config.Login.IPMaxFailures <= 0 {
+		config.Login.IPMaxFailures = 50
+	}
+	if config.Login.FailureWindow <= 0 {
+		config.Login.FailureWindow = 900 // 15 minutes
+	}
+	if config.Login.LockoutDuration <= 0 {
+		config.Login.LockoutDuration = 900 // 15 minutes
+	}
+	if config.Login.MaxLockoutDuration <= 0 {
+		config.Login.MaxLockoutDuration = 86400 // 24 hours
+	}
+	if config.Login.MaxLockoutDuration < config.Login.LockoutDuration {
+		config.Login.MaxLockoutDuration = config.Login.LockoutDuration
+	}
+}
+
+// validate refuses development settings in production: the default JWT
+// secrets, the fake payment provider, and missing or shared webhook and
+// signing secrets, any of which would let clients forge tokens, payment
+// events or signed links
+func validate(config *Config) error {
+	// A broken ladder would let bids match the current price
+	increments := config.Auction.BidIncrementTable()
+	if len(increments) == 0 {
+		return errors.New("auction.bid_increments must have at least one band")
+	}
+	if err := increments.Validate(); err != nil {
+		return fmt.Errorf("invalid auction.bid_increments: %w", err)
+	}
+
+	if config.App.Environment != "production" {
+		return nil
+	}
+
+	if config.JWT.AccessSecret == defaultJWTAccessSecret || config.JWT.RefreshSecret == defaultJWTRefreshSecret {
+		return errors.New("jwt.access_secret and jwt.refresh_secret must be set in production")
+	}
+	if config.OIDC.Mock {
+		return errors.New("the mock login provider can't be used in production; unset oidc.mock")
+	}
+	if config.Payments.Provider == "fake" {
+		return errors.New("the fake payment provider can't be used in production; set payments.provider")
+	}
+	if config.Payments.WebhookSecret == "" {
+		return errors.New("payments.webhook_secret must be set in production")
+	}
+	if config.Payments.WebhookSecret == config.JWT.AccessSecret || config.Payments.WebhookSecret == config.JWT.RefreshSecret {
+		return errors.New("payments.webhook_secret must not be a JWT secret")
+	}
+	if err := validateSigningKey("media.preview_signing_key", config.Media.PreviewSigningKey, config); err != nil {
+		return err
+	}
+	if config.S3.Driver == "local" {
+		if err := validateSigningKey("s3.local_signing_key", config.S3.LocalSigningKey, config); err != nil {
+			return err
+		}
+		if config.S3.LocalSigningKey == config.Media.PreviewSigningKey {
+			return errors.New("s3.local_signing_key must not be the preview signing key")
+		}
+	}
+
+	return nil
+}
+
+// validateSigningKey requires a signing key of its own in production, so
+// signed links and tokens can't be forged with each other's keys
+func validateSigningKey(setting, key string, config *Config) error {
+	if key == "" {
+		return fmt.Errorf("%s must be set in production", setting)
+	}
+	if key == config.JWT.AccessSecret || key == config.JWT.RefreshSecret || key == config.Payments.WebhookSecret {
+		return fmt.Errorf("%s must not be a JWT secret or the payments webhook secret", setting)
+	}
+	return nil
 }
 
 // GetDatabaseURL returns the database connection URL
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateBidIncrements(t *testing.T) {
+	tests := []struct {
+		name    string
+		bands   []BidIncrementBand
+		wantErr string
+	}{
+		{"default ladder", []BidIncrementBand{{UpTo: 20, Increment: 1}, {UpTo: 100, Increment: 5}, {Increment: 10}}, ""},
+		{"single open band", []BidIncrementBand{{Increment: 1}}, ""},
+		{"empty", nil, "at least one band"},
+		{"zero increment", []BidIncrementBand{{UpTo: 20, Increment: 0}, {Increment: 5}}, "increment must be positive"},
+		{"increment below a cent", []BidIncrementBand{{Increment: 0.001}}, "increment must be positive"},
+		{"negative increment", []BidIncrementBand{{Increment: -1}}, "increment must be positive"},
+		{"unsorted", []BidIncrementBand{{UpTo: 100, Increment: 5}, {UpTo: 20, Increment: 1}, {Increment: 10}}, "greater than the previous band"},
+		{"repeated band", []BidIncrementBand{{UpTo: 20, Increment: 1}, {UpTo: 20, Increment: 5}, {Increment: 10}}, "greater than the previous band"},
+		{"open band before the last", []BidIncrementBand{{Increment: 1}, {UpTo: 100, Increment: 5}}, "only the last band"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			setDefaults(config)
+			config.Auction.BidIncrements = tt.bands
+
+			err := validate(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRefusesMalformedBidIncrements(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not a number", "20:one,5"},
+		{"unsorted", "100:5,20:1,10"},
+		{"zero increment", "20:0,5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AUCTION_BID_INCREMENTS", tt.value)
+			if _, err := Load(""); err == nil {
+				t.Fatalf("AUCTION_BID_INCREMENTS=%q accepted", tt.value)
+			}
+		})
+	}
+
+	t.Setenv("AUCTION_BID_INCREMENTS", "20:1,100:5,25")
+	config, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Auction.BidIncrements) != 3 || config.Auction.BidIncrements[2] != (BidIncrementBand{Increment: 25}) {
+		t.Errorf("bid increments = %+v", config.Auction.BidIncrements)
+	}
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AuctionController handles auction-related endpoints
+type AuctionController struct {
+	auctionService *services.AuctionService
+	fxService      *services.FXService
+}
+
+// NewAuctionController creates a new auction controller
+func NewAuctionController(auctionService *services.AuctionService, fxService *services.FXService) *AuctionController {
+	return &AuctionController{
+		auctionService: auctionService,
+		fxService:      fxService,
+	}
+}
+
+// CreateAuction handles auction creation
+// @Summary Create a new auction
+// @Description List a track for auction; the authenticated user becomes the seller
+// @Tags auctions
+// @Accept json
+// @Produce json
+// @Param auction body models.CreateAuctionRequest true "Auction creation data"
+// @Success 201 {object} models.AuctionResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions [post]
+func (ac *AuctionController) CreateAuction(c *gin.Context) {
+	sellerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAuctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	auction, err := ac.auctionService.CreateAuction(c.Request.Context(), sellerID, &req)
+	if err != nil {
+		ac.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Auction created successfully", auction.ToResponse())
+}
+
+// GetAuction handles getting an auction by ID
+// @Summary Get auction by ID
+// @Description Get an auction by its ID. With display_currency, its amounts are also shown converted into that currency.
+// @Tags auctions
+// @Accept json
+// @Produce json
+// @Param id path int true "Auction ID"
+// @Param display_currency query string false "Currency to show converted amounts in, e.g. EUR"
+// @Success 200 {object} models.AuctionResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions/{id} [get]
+func (ac *AuctionController) GetAuction(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "auction")
+	if !ok {
+		return
+	}
+
+	converter, ok := displayConverter(c, ac.fxService)
+	if !ok {
+		return
+	}
+
+	auction, err := ac.auctionService.GetAuction(c.Request.Context(), id)
+	if err != nil {
+		ac.handleError(c, err)
+		return
+	}
+
+	response := auction.ToResponse()
+	response.Display = converter.AuctionPrices(auction)
+
+	utils.SuccessResponse(c, http.StatusOK, "Auction retrieved successfully", response)
+}
+
+// UpdateAuction handles auction updates
+// @Summary Update auction
+// @Description Update an auction owned by the authenticated seller
+// @Tags auctions
+// @Accept json
+// @Produce json
+// @Param id path int true "Auction ID"
+// @Param auction body models.UpdateAuctionRequest true "Auction update data"
+// @Success 200 {object} models.AuctionResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions/{id} [put]
+func (ac *AuctionController) UpdateAuction(c *gin.Context) {
+	sellerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "auction")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateAuctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	auction, err := ac.auctionService.UpdateAuction(c.Request.Context(), id, sellerID, &req)
+	if err != nil {
+		ac.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Auction updated successfully", auction.ToResponse())
+}
+
+// DeleteAuction handles auction cancellation
+// @Summary Cancel auction
+// @Description Cancel an auction owned by the authenticated seller
+// @Tags auctions
+// @Accept json
+// @Produce json
+// @Param id path int true "Auction ID"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions/{id} [delete]
+func (ac *AuctionController) DeleteAuction(c *gin.Context) {
+	sellerID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "auction")
+	if !ok {
+		return
+	}
+
+	if err := ac.auctionService.DeleteAuction(c.Request.Context(), id, sellerID); err != nil {
+		ac.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Auction cancelled successfully", nil)
+}
+
+// ListAuctions handles listing auctions with pagination
+// @Summary List auctions
+// @Description Get a paginated list of auctions, optionally only active ones or those of a seller
+// @Tags auctions
+// @Accept json
+// @Produce json
+// @Param status query string false "Set to 'active' to only return auctions open for bidding"
+// @Param seller_id query int false "Only return auctions created by this seller"
+// @Param display_currency query string false "Currency to show converted amounts in, e.g. EUR"
+// @Param limit query int false "Number of auctions to return (default: 10, max: 100)"
+// @Param offset query int false "Number of auctions to skip (default: 0)"
+// @Success 200 {array} models.AuctionResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions [get]
+func (ac *AuctionController) ListAuctions(c *gin.Context) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	converter, ok := displayConverter(c, ac.fxService)
+	if !ok {
+		return
+	}
+
+	var auctions []*models.Auction
+	var err error
+
+	switch {
+	case c.Query("seller_id") != "":
+		sellerID, convErr := strconv.Atoi(c.Query("seller_id"))
+		if convErr != nil || sellerID < 1 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_SELLER_ID", "Invalid seller_id parameter", "seller_id must be a valid integer")
+			return
+		}
+		auctions, err = ac.auctionService.ListSellerAuctions(c.Request.Context(), sellerID, limit, offset)
+	case c.Query("status") == string(models.AuctionStatusActive):
+		auctions, err = ac.auctionService.ListActiveAuctions(c.Request.Context(), limit, offset)
+	default:
+		auctions, err = ac.auctionService.ListAuctions(c.Request.Context(), limit, offset)
+	}
+
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	// Convert to response format
+	auctionResponses := make([]*models.AuctionResponse, len(auctions))
+	for i, auction := range auctions {
+		auctionResponses[i] = auction.ToResponse()
+		auctionResponses[i].Display = converter.AuctionPrices(auction)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Auctions retrieved successfully", auctionResponses)
+}
+
+// handleError maps auction service errors to HTTP responses
+func (ac *AuctionController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAuctionNotFound):
+		utils.NotFoundResponse(c, "Auction")
+	case errors.Is(err, services.ErrNotAuctionSeller), errors.Is(err, services.ErrNotTrackArtist):
+		utils.ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", err.Error(), "")
+	case errors.Is(err, services.ErrTrackNotFound):
+		utils.NotFoundResponse(c, "Track")
+	case errors.Is(err, services.ErrTrackNotSellable):
+		utils.ErrorResponse(c, http.StatusConflict, "TRACK_NOT_SELLABLE", err.Error(), "")
+	case errors.Is(err, services.ErrAuctionNotEditable):
+		utils.ErrorResponse(c, http.StatusConflict, "AUCTION_LOCKED", err.Error(), "")
+	case errors.Is(err, services.ErrInvalidAuctionWindow),
+		errors.Is(err, services.ErrInvalidStartPrice),
+		errors.Is(err, services.ErrInvalidReservePrice),
+		errors.Is(err, services.ErrInvalidMaxExtensions),
+		errors.Is(err, services.ErrInvalidStatusChange),
+		errors.Is(err, services.ErrUnsupportedCurrency):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_AUCTION", err.Error(), "")
+	case errors.Is(err, services.ErrInvalidBidIncrements):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_AUCTION", services.ErrInvalidBidIncrements.Error(), err.Error())
+	default:
+		utils.InternalErrorResponse(c, err)
+	}
+}
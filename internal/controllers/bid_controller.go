@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// BidController handles bid-related endpoints
+type BidController struct {
+	bidService *services.BidService
+	fxService  *services.FXService
+}
+
+// NewBidController creates a new bid controller
+func NewBidController(bidService *services.BidService, fxService *services.FXService) *BidController {
+	return &BidController{
+		bidService: bidService,
+		fxService:  fxService,
+	}
+}
+
+// PlaceBid handles placing a bid
+// @Summary Place a bid
+// @Description Place a bid on an active auction as the authenticated user
+// @Tags bids
+// @Accept json
+// @Produce json
+// @Param bid body models.CreateBidRequest true "Bid data"
+// @Success 201 {object} models.BidResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /bids [post]
+func (bc *BidController) PlaceBid(c *gin.Context) {
+	bidderID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateBidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	bid, err := bc.bidService.PlaceBid(c.Request.Context(), bidderID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAuctionNotFound):
+			utils.NotFoundResponse(c, "Auction")
+		case errors.Is(err, services.ErrSellerCannotBid):
+			utils.ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", err.Error(), "")
+		case errors.Is(err, services.ErrAuctionNotActive):
+			utils.ErrorResponse(c, http.StatusConflict, "AUCTION_NOT_ACTIVE", err.Error(), "")
+		case errors.Is(err, services.ErrInvalidMaxAmount):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_BID", err.Error(), "")
+		case errors.Is(err, services.ErrBidTooLow):
+			utils.ErrorResponse(c, http.StatusConflict, "BID_TOO_LOW", services.ErrBidTooLow.Error(), err.Error())
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Bid placed successfully", bid.ToResponse())
+}
+
+// ListMyBids handles listing the authenticated user's bids
+// @Summary List my bids
+// @Description Get a paginated list of bids placed by the authenticated user
+// @Tags bids
+// @Accept json
+// @Produce json
+// @Param limit query int false "Number of bids to return (default: 10, max: 100)"
+// @Param offset query int false "Number of bids to skip (default: 0)"
+// @Param display_currency query string false "Currency to show converted amounts in, e.g. EUR"
+// @Success 200 {array} models.BidResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /bids [get]
+func (bc *BidController) ListMyBids(c *gin.Context) {
+	bidderID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	converter, ok := displayConverter(c, bc.fxService)
+	if !ok {
+		return
+	}
+
+	bids, err := bc.bidService.GetBidderBids(c.Request.Context(), bidderID, limit, offset)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bids retrieved successfully", toBidResponses(bids, converter))
+}
+
+// ListAuctionBids handles listing the bids of an auction
+// @Summary List auction bids
+// @Description Get a paginated list of an auction's bids, highest first
+// @Tags bids
+// @Accept json
+// @Produce json
+// @Param id path int true "Auction ID"
+// @Param limit query int false "Number of bids to return (default: 10, max: 100)"
+// @Param offset query int false "Number of bids to skip (default: 0)"
+// @Param display_currency query string false "Currency to show converted amounts in, e.g. EUR"
+// @Success 200 {array} models.BidResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions/{id}/bids [get]
+func (bc *BidController) ListAuctionBids(c *gin.Context) {
+	auctionID, ok := parseIDParam(c, "id", "auction")
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	converter, ok := displayConverter(c, bc.fxService)
+	if !ok {
+		return
+	}
+
+	bids, err := bc.bidService.GetAuctionBids(c.Request.Context(), auctionID, limit, offset)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bids retrieved successfully", toBidResponses(bids, converter))
+}
+
+// toBidResponses converts bids to their response format, adding display
+// amounts when a converter is given
+func toBidResponses(bids []*models.Bid, converter *services.CurrencyConverter) []*models.BidResponse {
+	bidResponses := make([]*models.BidResponse, len(bids))
+	for i, bid := range bids {
+		bidResponses[i] = bid.ToResponse()
+		bidResponses[i].Display = converter.Amount(bid.Amount)
+	}
+	return bidResponses
+}
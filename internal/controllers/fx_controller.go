@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// FXController handles display exchange rate endpoints
+type FXController struct {
+	fxService *services.FXService
+}
+
+// NewFXController creates a new FX controller
+func NewFXController(fxService *services.FXService) *FXController {
+	return &FXController{
+		fxService: fxService,
+	}
+}
+
+// GetRates handles retrieving the exchange rate table
+// @Summary Get exchange rates
+// @Description Get the exchange rates used to show amounts in other currencies, relative to the base currency. They are for display only; bids are placed and paid in the auction's currency.
+// @Tags fx
+// @Produce json
+// @Success 200 {object} models.ExchangeRateTable
+// @Failure 500 {object} utils.APIResponse
+// @Router /fx/rates [get]
+func (fc *FXController) GetRates(c *gin.Context) {
+	table, err := fc.fxService.GetRates(c.Request.Context())
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Exchange rates retrieved successfully", table)
+}
+
+// SetRates handles replacing the exchange rate table
+// @Summary Replace exchange rates
+// @Description Replace the whole exchange rate table (admin only). Rates are decimal strings giving the units of each currency worth one unit of the base currency.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param request body models.ExchangeRateTable true "Exchange rate table"
+// @Success 200 {object} models.ExchangeRateTable
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /fx/rates [put]
+func (fc *FXController) SetRates(c *gin.Context) {
+	var req models.ExchangeRateTable
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	table, err := fc.fxService.SetRates(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExchangeRates) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_EXCHANGE_RATES", services.ErrInvalidExchangeRates.Error(), err.Error())
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Exchange rates updated successfully", table)
+}
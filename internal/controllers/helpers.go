@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the authenticated user's ID set by the JWT middleware.
+// It writes an error response and returns false if the ID is missing.
+func currentUserID(c *gin.Context) (int, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.UnauthorizedResponse(c)
+		return 0, false
+	}
+
+	uid, ok := userID.(int)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "INVALID_USER_ID", "Invalid user ID", "User ID is not a valid integer")
+		return 0, false
+	}
+
+	return uid, true
+}
+
+// currentUserRole returns the authenticated user's role set by the JWT middleware
+func currentUserRole(c *gin.Context) string {
+	return c.GetString("user_role")
+}
+
+// parseIDParam parses a positive integer path parameter.
+// It writes an error response and returns false if the parameter is invalid.
+func parseIDParam(c *gin.Context, name, resource string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_ID", "Invalid "+resource+" ID", "ID must be a valid integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// parsePagination parses the limit and offset query parameters.
+// It writes an error response and returns false if either is invalid.
+func parsePagination(c *gin.Context) (int, int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_LIMIT", "Invalid limit parameter", "Limit must be a positive integer")
+		return 0, 0, false
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_OFFSET", "Invalid offset parameter", "Offset must be a non-negative integer")
+		return 0, 0, false
+	}
+
+	return limit, offset, true
+}
+
+// displayConverter returns a converter into the currency named by the
+// display_currency query parameter, or nil when the parameter is absent.
+// It writes an error response and returns false if there is no rate for it.
+func displayConverter(c *gin.Context, fxService *services.FXService) (*services.CurrencyConverter, bool) {
+	currency := c.Query("display_currency")
+	if currency == "" {
+		return nil, true
+	}
+
+	converter, err := fxService.Converter(c.Request.Context(), currency)
+	if err != nil {
+		if errors.Is(err, services.ErrNoExchangeRate) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "UNSUPPORTED_CURRENCY", "Unsupported display currency", err.Error())
+			return nil, false
+		}
+		utils.InternalErrorResponse(c, err)
+		return nil, false
+	}
+	return converter, true
+}
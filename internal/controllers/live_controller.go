@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/realtime"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// liveWriteTimeout bounds how long a single message may take to reach a live client
+const liveWriteTimeout = 10 * time.Second
+
+// Live feed authentication for browsers, which can't set an Authorization
+// header on EventSource or WebSocket requests: EventSource clients send their
+// access token in the query, WebSocket clients as a subprotocol next to
+// LiveSubprotocol, e.g. new WebSocket(url, ["bagr.live", "bearer." + token])
+const (
+	LiveTokenQueryParam        = "access_token"
+	LiveSubprotocol            = "bagr.live"
+	liveTokenSubprotocolPrefix = "bearer."
+)
+
+// LiveController handles the real-time auction feed
+type LiveController struct {
+	auctionService *services.AuctionService
+	hub            *realtime.Hub
+	allowedOrigins []string
+	upgrader       websocket.Upgrader
+}
+
+// NewLiveController creates a new live feed controller. WebSockets are
+// accepted from allowedOrigins, or only from the API's own origin when there
+// are none.
+func NewLiveController(auctionService *services.AuctionService, hub *realtime.Hub, allowedOrigins []string) *LiveController {
+	lc := &LiveController{
+		auctionService: auctionService,
+		hub:            hub,
+		allowedOrigins: allowedOrigins,
+	}
+	lc.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{LiveSubprotocol},
+	}
+	if len(allowedOrigins) > 0 {
+		lc.upgrader.CheckOrigin = lc.checkOrigin
+	}
+	return lc
+}
+
+// AuctionFeed handles streaming an auction's live updates
+// @Summary Live auction feed
+// @Description Stream new bids, outbid notices, end time extensions and the final result of an auction.
+// @Description Connect with a WebSocket upgrade, or with Accept: text/event-stream for Server-Sent Events.
+// @Description The first message is a snapshot of the auction; the feed ends after it if the auction is already closed, or after the auction_closed event.
+// @Description Outbid notices are only sent to the authenticated bidder they concern. Browsers pass the access token in the access_token query parameter (EventSource) or as a "bearer.<token>" subprotocol next to "bagr.live" (WebSocket).
+// @Tags auctions
+// @Produce json
+// @Produce text/event-stream
+// @Param id path int true "Auction ID"
+// @Param access_token query string false "Access token, for clients that can't set the Authorization header"
+// @Success 101 "Switching Protocols"
+// @Success 200 {object} realtime.Event
+// @Failure 400 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /auctions/{id}/live [get]
+func (lc *LiveController) AuctionFeed(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "auction")
+	if !ok {
+		return
+	}
+
+	auction, err := lc.auctionService.GetAuction(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrAuctionNotFound) {
+			utils.NotFoundResponse(c, "Auction")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	snapshot, err := realtime.NewEvent(realtime.EventSnapshot, auction.ID, auction.ToResponse())
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	// Subscribe before sending the snapshot so no event falls in between
+	sub := lc.hub.Subscribe(auction.ID)
+	defer sub.Close()
+
+	// Authentication is optional; it only decides who receives outbid notices
+	userID := 0
+	if uid, exists := c.Get("user_id"); exists {
+		userID, _ = uid.(int)
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		lc.serveWebSocket(c, sub, snapshot, userID, auction.IsClosed())
+		return
+	}
+	lc.serveSSE(c, sub, snapshot, userID, auction.IsClosed())
+}
+
+// serveWebSocket streams events as JSON text messages, pinging the client
+// every heartbeat and closing once the auction has closed. Only the snapshot
+// is sent when the auction had already ended.
+func (lc *LiveController) serveWebSocket(c *gin.Context, sub *realtime.Subscription, snapshot realtime.Event, userID int, ended bool) {
+	conn, err := lc.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		utils.GetLogger().WithError(err).Warn("Failed to upgrade live feed connection")
+		return
+	}
+	defer conn.Close()
+
+	heartbeat := lc.hub.Heartbeat()
+
+	// The feed is one-way; read only to process pongs and notice the client leaving
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event realtime.Event) error {
+		conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return conn.WriteJSON(event)
+	}
+
+	if err := send(snapshot); err != nil {
+		return
+	}
+	if ended {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "auction closed"),
+			time.Now().Add(liveWriteTimeout))
+		return
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if !deliverTo(event, userID) {
+				continue
+			}
+			if err := send(event); err != nil {
+				return
+			}
+			if event.Type == realtime.EventAuctionClosed {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "auction closed"),
+					time.Now().Add(liveWriteTimeout))
+				return
+			}
+		}
+	}
+}
+
+// serveSSE streams events as Server-Sent Events named after their type,
+// sending a comment every heartbeat and ending once the auction has closed.
+// Only the snapshot is sent when the auction had already ended.
+func (lc *LiveController) serveSSE(c *gin.Context, sub *realtime.Subscription, snapshot realtime.Event, userID int, ended bool) {
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		utils.GetLogger().WithError(err).Warn("Failed to clear write deadline for live feed")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(event realtime.Event) error {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	if err := send(snapshot); err != nil || ended {
+		return
+	}
+
+	ticker := time.NewTicker(lc.hub.Heartbeat())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if !deliverTo(event, userID) {
+				continue
+			}
+			if err := send(event); err != nil {
+				return
+			}
+			if event.Type == realtime.EventAuctionClosed {
+				return
+			}
+		}
+	}
+}
+
+// deliverTo reports whether an event should be sent to userID.
+// Events addressed to a single user are only sent to that user.
+func deliverTo(event realtime.Event, userID int) bool {
+	return event.UserID == 0 || event.UserID == userID
+}
+
+// checkOrigin accepts WebSockets from the allowed origins. Requests without
+// an Origin header don't come from a browser page and are accepted.
+func (lc *LiveController) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || OriginAllowed(origin, lc.allowedOrigins)
+}
+
+// OriginAllowed reports whether a browser origin is one of allowedOrigins
+func OriginAllowed(origin string, allowedOrigins []string) bool {
+	origin = strings.TrimRight(origin, "/")
+	for _, allowed := range allowedOrigins {
+		if origin != "" && strings.EqualFold(origin, strings.TrimRight(allowed, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// LiveSubprotocolToken returns the access token of a WebSocket request's
+// "bearer.<token>" subprotocol, if any
+func LiveSubprotocolToken(r *http.Request) string {
+	for _, protocol := range websocket.Subprotocols(r) {
+		if token, ok := strings.CutPrefix(protocol, liveTokenSubprotocolPrefix); ok {
+			return token
+		}
+	}
+	return ""
+}
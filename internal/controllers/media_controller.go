@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// MediaController handles direct-to-S3 media upload endpoints
+type MediaController struct {
+	mediaService *services.MediaService
+}
+
+// NewMediaController creates a new media controller
+func NewMediaController(mediaService *services.MediaService) *MediaController {
+	return &MediaController{
+		mediaService: mediaService,
+	}
+}
+
+// CreateUpload handles issuing a presigned upload URL
+// @Summary Request an upload URL
+// @Description Issue a presigned S3 PUT URL for track audio or a profile image. The client uploads the file
+// @Description straight to S3 with the returned method and headers, then calls the completion endpoint.
+// @Description The content type and size are signed into the URL, so S3 rejects any other file.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param upload body models.CreateMediaUploadRequest true "Upload details"
+// @Success 201 {object} models.MediaUploadResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /media/uploads [post]
+func (mc *MediaController) CreateUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateMediaUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	upload, presigned, err := mc.mediaService.CreateUpload(c.Request.Context(), userID, currentUserRole(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUploadForbidden):
+			utils.ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", err.Error(), "")
+		case errors.Is(err, services.ErrUnsupportedAudio):
+			utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_AUDIO", err.Error(), "")
+		case errors.Is(err, services.ErrUnsupportedImage):
+			utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_IMAGE", err.Error(), "")
+		case errors.Is(err, services.ErrAudioTooLarge):
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "AUDIO_TOO_LARGE", services.ErrAudioTooLarge.Error(), err.Error())
+		case errors.Is(err, services.ErrImageTooLarge):
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", services.ErrImageTooLarge.Error(), err.Error())
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Upload URL issued", &models.MediaUploadResponse{
+		ID:        upload.ID,
+		Kind:      upload.Kind,
+		Status:    upload.Status,
+		UploadURL: presigned.URL,
+		Method:    presigned.Method,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
+	})
+}
+
+// CompleteUpload handles completing a direct upload
+// @Summary Complete an upload
+// @Description Verify that the file was uploaded to S3 with the requested size and content type, then attach it.
+// @Description Track audio creates a draft track, checked like a multipart track upload; the body takes the same
+// @Description optional title, genre, duration and description. A profile image replaces the user's image and needs no body.
+// @Description If the file has not arrived yet or the track details are rejected, the upload can be completed again.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Upload ID"
+// @Param track body models.UploadTrackRequest false "Track details for track audio"
+// @Success 200 {object} models.CompleteMediaUploadResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /media/uploads/{id}/complete [post]
+func (mc *MediaController) CompleteUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	uploadID, ok := parseIDParam(c, "id", "upload")
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req models.UploadTrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	completed, err := mc.mediaService.CompleteUpload(c.Request.Context(), userID, uploadID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUploadNotFound):
+			utils.NotFoundResponse(c, "Upload")
+		case errors.Is(err, services.ErrUploadNotPending):
+			utils.ErrorResponse(c, http.StatusConflict, "UPLOAD_NOT_PENDING", err.Error(), "")
+		case errors.Is(err, services.ErrUploadNotReceived):
+			utils.ErrorResponse(c, http.StatusConflict, "UPLOAD_NOT_RECEIVED", err.Error(), "")
+		case errors.Is(err, services.ErrUploadMismatch):
+			utils.ErrorResponse(c, http.StatusBadRequest, "UPLOAD_MISMATCH", services.ErrUploadMismatch.Error(), err.Error())
+		default:
+			if !trackAudioErrorResponse(c, err) {
+				utils.InternalErrorResponse(c, err)
+			}
+		}
+		return
+	}
+
+	response := &models.CompleteMediaUploadResponse{
+		ID:       completed.Upload.ID,
+		Kind:     completed.Upload.Kind,
+		Status:   completed.Upload.Status,
+		ImageURL: completed.ImageURL,
+	}
+	if completed.Track != nil {
+		response.Track = completed.Track.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Upload completed successfully", response)
+}
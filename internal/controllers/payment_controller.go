@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/payments"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBytes caps the size of a payment provider webhook body
+const maxWebhookBytes = 1 << 20
+
+// PaymentController handles payment method, escrow and provider webhook endpoints
+type PaymentController struct {
+	escrowService *services.EscrowService
+}
+
+// NewPaymentController creates a new payment controller
+func NewPaymentController(escrowService *services.EscrowService) *PaymentController {
+	return &PaymentController{
+		escrowService: escrowService,
+	}
+}
+
+// SetPaymentMethod handles saving the authenticated user's payment method
+// @Summary Set my payment method
+// @Description Save the payment method charged when the authenticated user wins an auction, replacing any previous one. The value is a reference issued by the payment provider (e.g. a Stripe PaymentMethod ID), never raw card details.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param request body models.SetPaymentMethodRequest true "Payment method"
+// @Success 200 {object} models.PaymentMethod
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /payments/method [put]
+func (pc *PaymentController) SetPaymentMethod(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetPaymentMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	method, err := pc.escrowService.SetPaymentMethod(c.Request.Context(), userID, req.PaymentMethod)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment method saved", method)
+}
+
+// GetPaymentMethod handles retrieving the authenticated user's payment method
+// @Summary Get my payment method
+// @Description Get the payment method charged when the authenticated user wins an auction
+// @Tags payments
+// @Produce json
+// @Success 200 {object} models.PaymentMethod
+// @Failure 401 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /payments/method [get]
+func (pc *PaymentController) GetPaymentMethod(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	method, err := pc.escrowService.GetPaymentMethod(c.Request.Context(), userID)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment method retrieved successfully", method)
+}
+
+// HandleWebhook handles events sent by the payment provider
+// @Summary Payment provider webhook
+// @Description Receive a signed event from the payment provider. Refunds and disputes made at the provider are applied to the escrow of the payment; redelivered events are acknowledged without being applied twice.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /payments/webhook [post]
+func (pc *PaymentController) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_WEBHOOK", "Failed to read webhook body", err.Error())
+		return
+	}
+
+	if err := pc.escrowService.HandleWebhook(c.Request.Context(), payload, c.Request.Header); err != nil {
+		if errors.Is(err, payments.ErrInvalidWebhook) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_WEBHOOK", err.Error(), "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook received", nil)
+}
+
+// ListEscrows handles listing the authenticated user's escrows
+// @Summary List my escrows
+// @Description Get a paginated list of the payments the authenticated user has made or is owed for auctions, newest first
+// @Tags payments
+// @Produce json
+// @Param limit query int false "Number of escrows to return (default: 10, max: 100)"
+// @Param offset query int false "Number of escrows to skip (default: 0)"
+// @Success 200 {array} models.EscrowResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /escrows [get]
+func (pc *PaymentController) ListEscrows(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	escrows, err := pc.escrowService.ListEscrows(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	responses := make([]*models.EscrowResponse, len(escrows))
+	for i, escrow := range escrows {
+		responses[i] = escrow.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Escrows retrieved successfully", responses)
+}
+
+// GetEscrow handles retrieving an escrow
+// @Summary Get escrow
+// @Description Get an escrow the authenticated user is the buyer or seller of
+// @Tags payments
+// @Produce json
+// @Param id path int true "Escrow ID"
+// @Success 200 {object} models.EscrowResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /escrows/{id} [get]
+func (pc *PaymentController) GetEscrow(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "escrow")
+	if !ok {
+		return
+	}
+
+	escrow, err := pc.escrowService.GetEscrow(c.Request.Context(), userID, currentUserRole(c), id)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Escrow retrieved successfully", escrow.ToResponse())
+}
+
+// RetryPayment handles retrying a failed payment
+// @Summary Retry a failed payment
+// @Description Queue a failed escrow payment for another attempt with the buyer's current payment method
+// @Tags payments
+// @Produce json
+// @Param id path int true "Escrow ID"
+// @Success 200 {object} models.EscrowResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /escrows/{id}/retry [post]
+func (pc *PaymentController) RetryPayment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "escrow")
+	if !ok {
+		return
+	}
+
+	escrow, err := pc.escrowService.RetryPayment(c.Request.Context(), userID, id)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment queued for retry", escrow.ToResponse())
+}
+
+// ConfirmDelivery handles the buyer confirming delivery of a purchase
+// @Summary Confirm delivery
+// @Description Confirm that the purchased track was delivered, releasing the captured payment to the seller
+// @Tags payments
+// @Produce json
+// @Param id path int true "Escrow ID"
+// @Success 200 {object} models.EscrowResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /escrows/{id}/confirm [post]
+func (pc *PaymentController) ConfirmDelivery(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "escrow")
+	if !ok {
+		return
+	}
+
+	escrow, err := pc.escrowService.ConfirmDelivery(c.Request.Context(), userID, id)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment released to seller", escrow.ToResponse())
+}
+
+// RefundEscrow handles refunding an escrow to its buyer
+// @Summary Refund escrow
+// @Description Refund a captured or disputed escrow payment to the buyer (admin only)
+// @Tags payments
+// @Produce json
+// @Param id path int true "Escrow ID"
+// @Success 200 {object} models.EscrowResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /escrows/{id}/refund [post]
+func (pc *PaymentController) RefundEscrow(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "escrow")
+	if !ok {
+		return
+	}
+
+	escrow, err := pc.escrowService.Refund(c.Request.Context(), id)
+	if err != nil {
+		pc.handleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment refunded to buyer", escrow.ToResponse())
+}
+
+// handleError maps escrow service errors to HTTP responses
+func (pc *PaymentController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEscrowNotFound):
+		utils.NotFoundResponse(c, "Escrow")
+	case errors.Is(err, services.ErrNoPaymentMethod):
+		utils.ErrorResponse(c, http.StatusNotFound, "NO_PAYMENT_METHOD", err.Error(), "")
+	case errors.Is(err, services.ErrEscrowNotCaptured),
+		errors.Is(err, services.ErrEscrowNotFailed),
+		errors.Is(err, services.ErrEscrowNotRefundable):
+		utils.ErrorResponse(c, http.StatusConflict, "INVALID_ESCROW_STATUS", err.Error(), "")
+	default:
+		utils.InternalErrorResponse(c, err)
+	}
+}
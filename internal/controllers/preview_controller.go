@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// previewPath is where preview tokens are streamed from, relative to the API host
+const previewPath = "/api/v1/previews/"
+
+// PreviewController handles auction track preview endpoints
+type PreviewController struct {
+	previewService *services.PreviewService
+}
+
+// NewPreviewController creates a new preview controller
+func NewPreviewController(previewService *services.PreviewService) *PreviewController {
+	return &PreviewController{
+		previewService: previewService,
+	}
+}
+
+// CreatePreview handles issuing a preview link for a track
+// @Summary Get a track preview link
+// @Description Issue a short-lived link that streams the opening seconds of a track (media.preview_seconds), tagged with the requesting user.
+// @Description Any user may preview a track in a draft or active auction; the track's artist and admins may always preview it.
+// @Tags tracks
+// @Produce json
+// @Param id path int true "Track ID"
+// @Success 200 {object} models.MediaURLResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /tracks/{id}/preview [post]
+func (pc *PreviewController) CreatePreview(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	trackID, ok := parseIDParam(c, "id", "track")
+	if !ok {
+		return
+	}
+
+	preview, err := pc.previewService.IssueToken(c.Request.Context(), userID, currentUserRole(c), trackID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTrackNotFound):
+			utils.NotFoundResponse(c, "Track")
+		case errors.Is(err, services.ErrPreviewUnavailable):
+			utils.ErrorResponse(c, http.StatusForbidden, "PREVIEW_UNAVAILABLE", err.Error(), "")
+		case errors.Is(err, services.ErrTrackAudioUnavailable):
+			utils.ErrorResponse(c, http.StatusNotFound, "AUDIO_UNAVAILABLE", err.Error(), "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Preview link issued", &models.MediaURLResponse{
+		URL:       previewPath + preview.Token,
+		ExpiresAt: preview.ExpiresAt,
+	})
+}
+
+// StreamPreview handles streaming a track preview
+// @Summary Stream a track preview
+// @Description Stream the preview a preview link grants. No other authentication is needed, so the link can be used directly as an audio source. Range requests are supported.
+// @Tags tracks
+// @Produce audio/mpeg,audio/wav,audio/flac
+// @Param token path string true "Preview token"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /previews/{token} [get]
+func (pc *PreviewController) StreamPreview(c *gin.Context) {
+	clip, err := pc.previewService.OpenPreview(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPreviewToken):
+			utils.ErrorResponse(c, http.StatusForbidden, "INVALID_PREVIEW_TOKEN", err.Error(), "")
+		case errors.Is(err, services.ErrTrackNotFound), errors.Is(err, services.ErrTrackAudioUnavailable):
+			utils.NotFoundResponse(c, "Preview")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.Header("Content-Type", clip.ContentType)
+	c.Header("Content-Disposition", "inline")
+	c.Header("Cache-Control", "private, no-store")
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, clip.Reader())
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// downloadPath is where download tokens are redeemed, relative to the API host
+const downloadPath = "/api/v1/downloads/"
+
+// PurchaseController handles endpoints for tracks bought at auction
+type PurchaseController struct {
+	purchaseService *services.PurchaseService
+}
+
+// NewPurchaseController creates a new purchase controller
+func NewPurchaseController(purchaseService *services.PurchaseService) *PurchaseController {
+	return &PurchaseController{
+		purchaseService: purchaseService,
+	}
+}
+
+// ListPurchases handles listing the authenticated user's purchases
+// @Summary List my purchases
+// @Description Get a paginated list of the tracks the authenticated user has won at auction, most recent first
+// @Tags purchases
+// @Produce json
+// @Param limit query int false "Number of purchases to return (default: 10, max: 100)"
+// @Param offset query int false "Number of purchases to skip (default: 0)"
+// @Success 200 {array} models.PurchaseResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /purchases [get]
+func (pc *PurchaseController) ListPurchases(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	purchases, err := pc.purchaseService.ListPurchases(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	responses := make([]*models.PurchaseResponse, len(purchases))
+	for i, purchase := range purchases {
+		responses[i] = purchase.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchases retrieved successfully", responses)
+}
+
+// CreateDownload handles issuing a download link for a purchased track
+// @Summary Get a purchased track download link
+// @Description Issue a one-time link to download the master file of a purchased track. The link expires after media.download_url_expiry seconds and works once. Requires the winning bid's payment to have been captured.
+// @Tags purchases
+// @Produce json
+// @Param id path int true "Purchase ID"
+// @Success 200 {object} models.MediaURLResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 402 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /purchases/{id}/download [post]
+func (pc *PurchaseController) CreateDownload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	purchaseID, ok := parseIDParam(c, "id", "purchase")
+	if !ok {
+		return
+	}
+
+	link, err := pc.purchaseService.CreateDownload(c.Request.Context(), userID, purchaseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPurchaseNotFound):
+			utils.NotFoundResponse(c, "Purchase")
+		case errors.Is(err, services.ErrPaymentRequired):
+			utils.ErrorResponse(c, http.StatusPaymentRequired, "PAYMENT_REQUIRED", err.Error(), "")
+		case errors.Is(err, services.ErrTrackNotFound):
+			utils.NotFoundResponse(c, "Track")
+		case errors.Is(err, services.ErrTrackAudioUnavailable):
+			utils.ErrorResponse(c, http.StatusNotFound, "AUDIO_UNAVAILABLE", err.Error(), "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Download link issued", &models.MediaURLResponse{
+		URL:       downloadPath + link.Token,
+		ExpiresAt: link.ExpiresAt,
+	})
+}
+
+// RedeemDownload handles a one-time download link
+// @Summary Download a purchased track
+// @Description Use up a download link and redirect to a short-lived URL for the track's master file. No other authentication is needed; a link only works once.
+// @Tags purchases
+// @Param token path string true "Download token"
+// @Success 302
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /downloads/{token} [get]
+func (pc *PurchaseController) RedeemDownload(c *gin.Context) {
+	download, err := pc.purchaseService.RedeemDownload(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDownloadToken):
+			utils.ErrorResponse(c, http.StatusForbidden, "INVALID_DOWNLOAD_TOKEN", err.Error(), "")
+		case errors.Is(err, services.ErrTrackNotFound), errors.Is(err, services.ErrTrackAudioUnavailable):
+			utils.NotFoundResponse(c, "Track")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, download.URL)
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// StorageController serves the signed upload and download URLs of the local
+// disk storage driver, standing in for S3 during development
+type StorageController struct {
+	storage *services.LocalStorage
+}
+
+// NewStorageController creates a new storage controller
+func NewStorageController(storage *services.LocalStorage) *StorageController {
+	return &StorageController{
+		storage: storage,
+	}
+}
+
+// PublicDir returns the directory of public objects to serve as static files
+func (sc *StorageController) PublicDir() string {
+	return sc.storage.PublicDir()
+}
+
+// Upload handles an upload to a presigned local storage URL
+// @Summary Upload to local storage
+// @Description Store the request body at a presigned upload URL issued by the local storage driver.
+// @Description The Content-Type and Content-Length must match the ones the URL was signed for.
+// @Tags storage
+// @Accept */*
+// @Produce json
+// @Param key path string true "Object key"
+// @Success 200 {object} utils.APIResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /storage/upload/{key} [put]
+func (sc *StorageController) Upload(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	err := sc.storage.ReceiveUpload(c.Request.Context(), key, c.Request.URL.Query(),
+		c.GetHeader("Content-Type"), c.Request.ContentLength, c.Request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSignature):
+			utils.ErrorResponse(c, http.StatusForbidden, "INVALID_SIGNATURE", err.Error(), "")
+		case errors.Is(err, services.ErrInvalidKey):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_KEY", err.Error(), "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Object stored successfully", nil)
+}
+
+// Download handles a presigned local storage download URL
+// @Summary Download from local storage
+// @Description Serve a private object at a presigned download URL issued by the local storage driver. Range requests are supported.
+// @Tags storage
+// @Produce octet-stream
+// @Param key path string true "Object key"
+// @Success 200 {file} file
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Router /storage/private/{key} [get]
+func (sc *StorageController) Download(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	path, contentType, err := sc.storage.ResolveDownload(key, c.Request.URL.Query())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSignature):
+			utils.ErrorResponse(c, http.StatusForbidden, "INVALID_SIGNATURE", err.Error(), "")
+		case errors.Is(err, services.ErrObjectNotFound), errors.Is(err, services.ErrInvalidKey):
+			utils.NotFoundResponse(c, "Object")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.File(path)
+}
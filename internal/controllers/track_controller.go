@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TrackController handles track-related endpoints
+type TrackController struct {
+	trackService *services.TrackService
+}
+
+// NewTrackController creates a new track controller
+func NewTrackController(trackService *services.TrackService) *TrackController {
+	return &TrackController{
+		trackService: trackService,
+	}
+}
+
+// multipartOverhead is the room left for form fields and multipart framing on top of the audio size limit
+const multipartOverhead = 1 << 20
+
+// UploadTrack handles uploading a track's audio file
+// @Summary Upload a track
+// @Description Upload an MP3, WAV or FLAC file and create a draft track owned by the authenticated artist.
+// @Description The format, duration, bitrate, sample rate and channels are read from the file itself, not the declared content type.
+// @Description Title and genre default to the file's tags; a declared duration must match the file's within 2 seconds.
+// @Tags tracks
+// @Accept multipart/form-data
+// @Produce json
+// @Param audio formData file true "Audio file"
+// @Param title formData string false "Track title (defaults to the file's title tag)"
+// @Param genre formData string false "Track genre (defaults to the file's genre tag)"
+// @Param duration formData int false "Declared duration in seconds, checked against the file"
+// @Param description formData string false "Track description"
+// @Success 201 {object} models.TrackResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 413 {object} utils.APIResponse
+// @Failure 415 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /tracks/upload [post]
+func (tc *TrackController) UploadTrack(c *gin.Context) {
+	artistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tc.trackService.MaxAudioSize()+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("audio")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "AUDIO_TOO_LARGE", services.ErrAudioTooLarge.Error(), "")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "MISSING_AUDIO", "No audio file provided", err.Error())
+		return
+	}
+	defer file.Close()
+
+	var req models.UploadTrackRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	track, err := tc.trackService.UploadTrack(c.Request.Context(), artistID, &req, file, header.Size)
+	if err != nil {
+		if !trackAudioErrorResponse(c, err) {
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Track uploaded successfully", track.ToResponse())
+}
+
+// trackAudioErrorResponse writes the response for an error from checking a
+// track's audio file and reports whether err was one
+func trackAudioErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrAudioTooLarge):
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "AUDIO_TOO_LARGE", services.ErrAudioTooLarge.Error(), err.Error())
+	case errors.Is(err, services.ErrUnsupportedAudio):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_AUDIO", err.Error(), "")
+	case errors.Is(err, services.ErrAudioTypeMismatch):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "AUDIO_TYPE_MISMATCH", services.ErrAudioTypeMismatch.Error(), err.Error())
+	case errors.Is(err, services.ErrEmptyAudio):
+		utils.ErrorResponse(c, http.StatusBadRequest, "EMPTY_AUDIO", err.Error(), "")
+	case errors.Is(err, services.ErrInvalidAudio):
+		utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_AUDIO", err.Error(), "")
+	case errors.Is(err, services.ErrDurationMismatch):
+		utils.ErrorResponse(c, http.StatusBadRequest, "DURATION_MISMATCH", services.ErrDurationMismatch.Error(), err.Error())
+	case errors.Is(err, services.ErrMissingTrackDetails):
+		utils.ErrorResponse(c, http.StatusBadRequest, "MISSING_TRACK_DETAILS", err.Error(), "")
+	default:
+		return false
+	}
+	return true
+}
+
+// GetAudioURL handles issuing a download URL for a track's private audio
+// @Summary Get a track audio download URL
+// @Description Issue a short-lived presigned S3 URL for a track's audio file. Only the track's artist, admins and the winner of an auction for the track, once their payment is captured, may download it.
+// @Tags tracks
+// @Produce json
+// @Param id path int true "Track ID"
+// @Success 200 {object} models.MediaURLResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 402 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /tracks/{id}/audio [get]
+func (tc *TrackController) GetAudioURL(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	trackID, ok := parseIDParam(c, "id", "track")
+	if !ok {
+		return
+	}
+
+	download, err := tc.trackService.AudioDownloadURL(c.Request.Context(), userID, currentUserRole(c), trackID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTrackNotFound):
+			utils.NotFoundResponse(c, "Track")
+		case errors.Is(err, services.ErrTrackAccessDenied):
+			utils.ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", err.Error(), "")
+		case errors.Is(err, services.ErrPaymentRequired):
+			utils.ErrorResponse(c, http.StatusPaymentRequired, "PAYMENT_REQUIRED", err.Error(), "")
+		case errors.Is(err, services.ErrTrackAudioUnavailable):
+			utils.ErrorResponse(c, http.StatusNotFound, "AUDIO_UNAVAILABLE", err.Error(), "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Download URL issued", &models.MediaURLResponse{
+		URL:       download.URL,
+		ExpiresAt: download.ExpiresAt,
+	})
+}
+
+// PublishTrack handles publishing a draft track
+// @Summary Publish a track
+// @Description Make one of the authenticated artist's draft tracks active, so it appears in search. The track's audio must have been verified on upload and still be stored.
+// @Tags tracks
+// @Produce json
+// @Param id path int true "Track ID"
+// @Success 200 {object} models.TrackResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 404 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /tracks/{id}/publish [post]
+func (tc *TrackController) PublishTrack(c *gin.Context) {
+	artistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	trackID, ok := parseIDParam(c, "id", "track")
+	if !ok {
+		return
+	}
+
+	track, err := tc.trackService.PublishTrack(c.Request.Context(), artistID, trackID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTrackNotFound):
+			utils.NotFoundResponse(c, "Track")
+		case errors.Is(err, services.ErrTrackPublishDenied):
+			utils.ErrorResponse(c, http.StatusForbidden, "FORBIDDEN", err.Error(), "")
+		case errors.Is(err, services.ErrTrackNotDraft):
+			utils.ErrorResponse(c, http.StatusConflict, "TRACK_NOT_DRAFT", err.Error(), "")
+		case errors.Is(err, services.ErrTrackAudioUnavailable):
+			utils.ErrorResponse(c, http.StatusConflict, "AUDIO_UNAVAILABLE", err.Error(), "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Track published successfully", track.ToResponse())
+}
+
+// SearchTracks handles full-text track search
+// @Summary Search tracks
+// @Description Search active tracks by title, genre and description. Every word is prefix-matched and results are ordered by relevance (0 to 1).
+// @Tags tracks
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text"
+// @Param genre query []string false "Only return tracks in these genres (repeat or comma-separate)"
+// @Param limit query int false "Number of tracks to return (default: 10, max: 100)"
+// @Param offset query int false "Number of tracks to skip (default: 0)"
+// @Success 200 {array} models.TrackSearchResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /tracks/search [get]
+func (tc *TrackController) SearchTracks(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "MISSING_QUERY", "Search query is required", "Provide the q query parameter")
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	var filter models.TrackSearchFilter
+	for _, value := range c.QueryArray("genre") {
+		filter.Genres = append(filter.Genres, strings.Split(value, ",")...)
+	}
+
+	results, err := tc.trackService.SearchTracks(c.Request.Context(), query, filter, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrEmptySearchQuery) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_QUERY", err.Error(), "")
+			return
+		}
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	// Convert to response format
+	trackResponses := make([]*models.TrackSearchResponse, len(results))
+	for i, result := range results {
+		trackResponses[i] = result.ToResponse()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tracks retrieved successfully", trackResponses)
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/services"
+	"bagr-backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// WalletController handles seller balance and payout endpoints
+type WalletController struct {
+	ledgerService *services.LedgerService
+}
+
+// NewWalletController creates a new wallet controller
+func NewWalletController(ledgerService *services.LedgerService) *WalletController {
+	return &WalletController{
+		ledgerService: ledgerService,
+	}
+}
+
+// GetWallet handles retrieving the authenticated user's wallet
+// @Summary Get my wallet
+// @Description Get what the platform owes the authenticated seller in each currency, as decimal amounts, and a paginated history of the earnings, clawbacks and payouts behind it, newest first
+// @Tags wallet
+// @Produce json
+// @Param limit query int false "Number of transactions to return (default: 10, max: 100)"
+// @Param offset query int false "Number of transactions to skip (default: 0)"
+// @Success 200 {object} models.WalletResponse
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /wallet [get]
+func (wc *WalletController) GetWallet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	wallet, err := wc.ledgerService.GetWallet(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		utils.InternalErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Wallet retrieved successfully", wallet)
+}
+
+// RecordPayout handles recording a payout to a seller
+// @Summary Record a payout
+// @Description Record that part of a seller's balance was paid out to them (admin only). The payout cannot exceed the balance.
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param request body models.PayoutRequest true "Payout"
+// @Success 201 {object} models.LedgerEntry
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 403 {object} utils.APIResponse
+// @Failure 409 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /payouts [post]
+func (wc *WalletController) RecordPayout(c *gin.Context) {
+	var req models.PayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	entry, err := wc.ledgerService.RecordPayout(c.Request.Context(), req.UserID, req.Amount.WithCurrency(req.Currency))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPayout):
+			utils.ErrorResponse(c, http.StatusBadRequest, "INVALID_PAYOUT", err.Error(), "")
+		case errors.Is(err, services.ErrInsufficientFunds):
+			utils.ErrorResponse(c, http.StatusConflict, "INSUFFICIENT_FUNDS", err.Error(), "")
+		default:
+			utils.InternalErrorResponse(c, err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Payout recorded", entry)
+}
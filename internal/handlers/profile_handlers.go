@@ -14,15 +14,15 @@ import (
 // ProfileHandlers handles profile-related HTTP requests
 type ProfileHandlers struct {
 	profileService *services.ProfileService
-	s3Service      *services.S3Service
+	storage        services.Storage
 	logger         *logrus.Logger
 }
 
 // NewProfileHandlers creates a new profile handlers instance
-func NewProfileHandlers(profileService *services.ProfileService, s3Service *services.S3Service, logger *logrus.Logger) *ProfileHandlers {
+func NewProfileHandlers(profileService *services.ProfileService, storage services.Storage, logger *logrus.Logger) *ProfileHandlers {
 	return &ProfileHandlers{
 		profileService: profileService,
-		s3Service:      s3Service,
+		storage:        storage,
 		logger:         logger,
 	}
 }
@@ -187,7 +187,7 @@ func (h *ProfileHandlers) UploadProfileImage(c *gin.Context) {
 
 	// Validate file type
 	contentType := header.Header.Get("Content-Type")
-	if !h.s3Service.ValidateImageType(contentType) {
+	if !services.ValidateImageType(contentType) {
 		h.logger.WithField("content_type", contentType).Error("Invalid image type")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -196,8 +196,9 @@ func (h *ProfileHandlers) UploadProfileImage(c *gin.Context) {
 		return
 	}
 
-	// Upload to S3
-	imageURL, err := h.s3Service.UploadProfileImage(c.Request.Context(), userIDInt, file, contentType)
+	// Upload to storage; profile images are public
+	key := services.ProfileImageKey(userIDInt, contentType)
+	err = h.storage.Put(c.Request.Context(), key, file, header.Size, contentType, true)
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", userIDInt).Error("Failed to upload profile image")
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -209,6 +210,7 @@ func (h *ProfileHandlers) UploadProfileImage(c *gin.Context) {
 	}
 
 	// Update profile with new image URL
+	imageURL := h.storage.URL(key)
 	err = h.profileService.UpdateProfileImage(userIDInt, imageURL)
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", userIDInt).Error("Failed to update profile image URL")
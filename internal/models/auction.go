@@ -1,6 +1,10 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -11,13 +15,27 @@ type Auction struct {
 	SellerID    int           `json:"seller_id" db:"seller_id"`
 	Title       string        `json:"title" db:"title"`
 	Description string        `json:"description" db:"description"`
-	StartPrice  float64       `json:"start_price" db:"start_price"`
-	ReservePrice *float64     `json:"reserve_price,omitempty" db:"reserve_price"`
-	CurrentBid  *float64      `json:"current_bid,omitempty" db:"current_bid"`
+	Currency    string        `json:"currency" db:"currency"` // ISO 4217 code of every amount of the auction
+	StartPrice  Money         `json:"start_price" db:"start_price"`
+	ReservePrice *Money       `json:"reserve_price,omitempty" db:"reserve_price"`
+	CurrentBid  *Money        `json:"current_bid,omitempty" db:"current_bid"`
 	BidCount    int           `json:"bid_count" db:"bid_count"`
 	Status      AuctionStatus `json:"status" db:"status"`
 	StartTime   time.Time     `json:"start_time" db:"start_time"`
 	EndTime     time.Time     `json:"end_time" db:"end_time"`
+
+	// Soft close: a bid within the final SoftCloseWindow minutes pushes EndTime
+	// out by SoftCloseExtension minutes, at most SoftCloseMaxExtensions times (nil = no cap)
+	SoftCloseWindow        int  `json:"soft_close_window_minutes" db:"soft_close_window_minutes"`
+	SoftCloseExtension     int  `json:"soft_close_extension_minutes" db:"soft_close_extension_minutes"`
+	SoftCloseMaxExtensions *int `json:"soft_close_max_extensions,omitempty" db:"soft_close_max_extensions"`
+	ExtensionCount         int  `json:"extension_count" db:"extension_count"`
+
+	// BidIncrements overrides the server's bid increment ladder for this auction (nil = server default)
+	BidIncrements BidIncrementTable `json:"bid_increments,omitempty" db:"bid_increments"`
+	// NextMinimumBid is the lowest acceptable next bid, filled in by the services
+	NextMinimumBid Money `json:"next_minimum_bid" db:"-"`
+
 	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
 	
@@ -43,21 +61,99 @@ type CreateAuctionRequest struct {
 	TrackID      int       `json:"track_id" binding:"required"`
 	Title        string    `json:"title" binding:"required,min=1,max=200"`
 	Description  string    `json:"description" binding:"required,min=1,max=1000"`
-	StartPrice   float64   `json:"start_price" binding:"required,min=0"`
-	ReservePrice *float64  `json:"reserve_price,omitempty" binding:"omitempty,min=0"`
+	Currency     string    `json:"currency,omitempty" binding:"omitempty,len=3"` // Defaults to the server's default currency
+	StartPrice   Money     `json:"start_price"`
+	ReservePrice *Money    `json:"reserve_price,omitempty"`
 	StartTime    time.Time `json:"start_time" binding:"required"`
 	EndTime      time.Time `json:"end_time" binding:"required"`
+
+	// Soft close settings, defaulting to the server configuration when omitted
+	SoftCloseWindow        *int `json:"soft_close_window_minutes,omitempty" binding:"omitempty,min=0,max=60"`
+	SoftCloseExtension     *int `json:"soft_close_extension_minutes,omitempty" binding:"omitempty,min=0,max=60"`
+	SoftCloseMaxExtensions *int `json:"soft_close_max_extensions,omitempty" binding:"omitempty,min=0"`
+
+	// Optional bid increment ladder, defaulting to the server configuration when omitted
+	BidIncrements BidIncrementTable `json:"bid_increments,omitempty" binding:"omitempty,dive"`
 }
 
 // UpdateAuctionRequest represents the request payload for updating an auction
 type UpdateAuctionRequest struct {
 	Title        *string        `json:"title,omitempty" binding:"omitempty,min=1,max=200"`
 	Description  *string        `json:"description,omitempty" binding:"omitempty,min=1,max=1000"`
-	StartPrice   *float64       `json:"start_price,omitempty" binding:"omitempty,min=0"`
-	ReservePrice *float64       `json:"reserve_price,omitempty" binding:"omitempty,min=0"`
+	Currency     *string        `json:"currency,omitempty" binding:"omitempty,len=3"`
+	StartPrice   *Money         `json:"start_price,omitempty"`
+	ReservePrice Nullable[Money] `json:"reserve_price"` // null removes the reserve
 	Status       *AuctionStatus `json:"status,omitempty" binding:"omitempty,oneof=draft active completed cancelled expired"`
 	StartTime    *time.Time     `json:"start_time,omitempty"`
 	EndTime      *time.Time     `json:"end_time,omitempty"`
+
+	SoftCloseWindow        *int `json:"soft_close_window_minutes,omitempty" binding:"omitempty,min=0,max=60"`
+	SoftCloseExtension     *int `json:"soft_close_extension_minutes,omitempty" binding:"omitempty,min=0,max=60"`
+	SoftCloseMaxExtensions Nullable[int] `json:"soft_close_max_extensions"` // null removes the cap
+
+	// An empty list clears the override and falls back to the server ladder
+	BidIncrements BidIncrementTable `json:"bid_increments,omitempty" binding:"omitempty,dive"`
+}
+
+// AuctionResponse represents the response payload for auction data
+type AuctionResponse struct {
+	ID           int           `json:"id"`
+	TrackID      int           `json:"track_id"`
+	SellerID     int           `json:"seller_id"`
+	Title        string        `json:"title"`
+	Description  string        `json:"description"`
+	Currency     string        `json:"currency"`
+	StartPrice   Money         `json:"start_price"`
+	ReservePrice *Money        `json:"reserve_price,omitempty"`
+	CurrentBid   *Money        `json:"current_bid,omitempty"`
+	BidCount     int           `json:"bid_count"`
+	Status       AuctionStatus `json:"status"`
+	StartTime    time.Time     `json:"start_time"`
+	EndTime      time.Time     `json:"end_time"`
+
+	SoftCloseWindow        int  `json:"soft_close_window_minutes"`
+	SoftCloseExtension     int  `json:"soft_close_extension_minutes"`
+	SoftCloseMaxExtensions *int `json:"soft_close_max_extensions,omitempty"`
+	ExtensionCount         int  `json:"extension_count"`
+
+	BidIncrements  BidIncrementTable `json:"bid_increments,omitempty"`
+	NextMinimumBid Money             `json:"next_minimum_bid"`
+
+	// Display holds the amounts converted into the currency requested with display_currency
+	Display *DisplayPrices `json:"display,omitempty"`
+
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// ToResponse converts Auction to AuctionResponse
+func (a *Auction) ToResponse() *AuctionResponse {
+	return &AuctionResponse{
+		ID:           a.ID,
+		TrackID:      a.TrackID,
+		SellerID:     a.SellerID,
+		Title:        a.Title,
+		Description:  a.Description,
+		Currency:     a.Currency,
+		StartPrice:   a.StartPrice,
+		ReservePrice: a.ReservePrice,
+		CurrentBid:   a.CurrentBid,
+		BidCount:     a.BidCount,
+		Status:       a.Status,
+		StartTime:    a.StartTime,
+		EndTime:      a.EndTime,
+
+		SoftCloseWindow:        a.SoftCloseWindow,
+		SoftCloseExtension:     a.SoftCloseExtension,
+		SoftCloseMaxExtensions: a.SoftCloseMaxExtensions,
+		ExtensionCount:         a.ExtensionCount,
+
+		BidIncrements:  a.BidIncrements,
+		NextMinimumBid: a.NextMinimumBid,
+
+		CreatedAt:    a.CreatedAt,
+		UpdatedAt:    a.UpdatedAt,
+	}
 }
 
 // IsActive returns true if the auction is currently active
@@ -73,6 +169,17 @@ func (a *Auction) IsExpired() bool {
 	return time.Now().After(a.EndTime)
 }
 
+// IsClosed returns true once the auction has been completed, cancelled or
+// expired, after which it takes no more bids
+func (a *Auction) IsClosed() bool {
+	switch a.Status {
+	case AuctionStatusCompleted, AuctionStatusCancelled, AuctionStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
 // HasReserveMet returns true if the current bid meets the reserve price
 func (a *Auction) HasReserveMet() bool {
 	if a.ReservePrice == nil {
@@ -81,5 +188,130 @@ func (a *Auction) HasReserveMet() bool {
 	if a.CurrentBid == nil {
 		return false // No bids yet
 	}
-	return *a.CurrentBid >= *a.ReservePrice
+	return !a.CurrentBid.LessThan(*a.ReservePrice)
+}
+
+// SoftCloseEndTime returns the end time after applying the soft close rule to a
+// bid placed at bidTime, and whether the auction was extended
+func (a *Auction) SoftCloseEndTime(bidTime time.Time) (time.Time, bool) {
+	if a.SoftCloseWindow <= 0 || a.SoftCloseExtension <= 0 {
+		return a.EndTime, false
+	}
+	if a.SoftCloseMaxExtensions != nil && a.ExtensionCount >= *a.SoftCloseMaxExtensions {
+		return a.EndTime, false
+	}
+
+	windowStart := a.EndTime.Add(-time.Duration(a.SoftCloseWindow) * time.Minute)
+	if bidTime.Before(windowStart) {
+		return a.EndTime, false
+	}
+
+	return a.EndTime.Add(time.Duration(a.SoftCloseExtension) * time.Minute), true
+}
+
+// BidIncrement returns the raise required over amount, using the auction's own
+// ladder when it has one and defaults otherwise
+func (a *Auction) BidIncrement(amount Money, defaults BidIncrementTable) Money {
+	if len(a.BidIncrements) > 0 {
+		return a.BidIncrements.IncrementFor(amount)
+	}
+	return defaults.IncrementFor(amount)
+}
+
+// MinimumBid returns the lowest amount the next bid may have: the start price
+// for the first bid, otherwise the current bid plus its increment
+func (a *Auction) MinimumBid(defaults BidIncrementTable) Money {
+	if a.CurrentBid == nil {
+		return a.StartPrice
+	}
+	return a.CurrentBid.Add(a.BidIncrement(*a.CurrentBid, defaults))
+}
+
+// SetCurrency tags every amount of the auction with its currency, e.g. after
+// the amounts were read from their own columns
+func (a *Auction) SetCurrency(currency string) {
+	a.Currency = currency
+	a.StartPrice.Currency = currency
+	if a.ReservePrice != nil {
+		a.ReservePrice.Currency = currency
+	}
+	if a.CurrentBid != nil {
+		a.CurrentBid.Currency = currency
+	}
+	a.NextMinimumBid.Currency = currency
+}
+
+// BidIncrementBand is one step of a bid increment ladder: bids on a current
+// price below UpTo must rise by at least Increment. The last band leaves UpTo
+// unset to cover every higher price. Bands are in the auction's currency.
+type BidIncrementBand struct {
+	UpTo      *Money `json:"up_to,omitempty"`
+	Increment Money  `json:"increment"`
+}
+
+// BidIncrementTable is a bid increment ladder ordered by price band
+type BidIncrementTable []BidIncrementBand
+
+// IncrementFor returns the increment of the band containing amount, in amount's currency
+func (t BidIncrementTable) IncrementFor(amount Money) Money {
+	for _, band := range t {
+		if band.UpTo == nil || amount.LessThan(*band.UpTo) {
+			return band.Increment.WithCurrency(amount.Currency)
+		}
+	}
+	if len(t) == 0 {
+		return NewMoney(0, amount.Currency)
+	}
+	return t[len(t)-1].Increment.WithCurrency(amount.Currency)
+}
+
+// Validate checks that bands are in ascending order, that only the last band is
+// open-ended and that every increment is positive
+func (t BidIncrementTable) Validate() error {
+	var previous Money
+	for i, band := range t {
+		if !band.Increment.IsPositive() {
+			return fmt.Errorf("band %d: increment must be positive", i+1)
+		}
+		if band.UpTo == nil {
+			if i != len(t)-1 {
+				return fmt.Errorf("band %d: only the last band may omit up_to", i+1)
+			}
+			continue
+		}
+		if !previous.LessThan(*band.UpTo) {
+			return fmt.Errorf("band %d: up_to must be greater than the previous band", i+1)
+		}
+		previous = *band.UpTo
+	}
+	return nil
+}
+
+// Value stores the ladder as JSONB; an empty ladder is stored as NULL
+func (t BidIncrementTable) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the ladder from a JSONB column
+func (t *BidIncrementTable) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for bid increment table")
+	}
+	return json.Unmarshal(data, t)
 }
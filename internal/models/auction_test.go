@@ -0,0 +1,148 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSoftCloseEndTime(t *testing.T) {
+	end := time.Date(2026, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		window        int
+		extension     int
+		maxExtensions *int
+		count         int
+		bidTime       time.Time
+		wantExtended  bool
+	}{
+		{"before the window", 5, 2, nil, 0, end.Add(-6 * time.Minute), false},
+		{"at the window start", 5, 2, nil, 0, end.Add(-5 * time.Minute), true},
+		{"inside the window", 5, 2, nil, 0, end.Add(-time.Second), true},
+		{"uncapped after many extensions", 5, 2, nil, 50, end.Add(-time.Minute), true},
+		{"below the cap", 5, 2, intPtr(3), 2, end.Add(-time.Minute), true},
+		{"cap reached", 5, 2, intPtr(3), 3, end.Add(-time.Minute), false},
+		{"cap of zero", 5, 2, intPtr(0), 0, end.Add(-time.Minute), false},
+		{"no window", 0, 2, nil, 0, end.Add(-time.Second), false},
+		{"no extension", 5, 0, nil, 0, end.Add(-time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := &Auction{
+				EndTime:                end,
+				SoftCloseWindow:        tt.window,
+				SoftCloseExtension:     tt.extension,
+				SoftCloseMaxExtensions: tt.maxExtensions,
+				ExtensionCount:         tt.count,
+			}
+
+			endTime, extended := auction.SoftCloseEndTime(tt.bidTime)
+			if extended != tt.wantExtended {
+				t.Fatalf("extended = %v, want %v", extended, tt.wantExtended)
+			}
+			want := end
+			if tt.wantExtended {
+				want = end.Add(time.Duration(tt.extension) * time.Minute)
+			}
+			if !endTime.Equal(want) {
+				t.Errorf("end time = %v, want %v", endTime, want)
+			}
+		})
+	}
+}
+
+func usd(cents int64) *Money {
+	amount := NewMoney(cents, "USD")
+	return &amount
+}
+
+var testLadder = BidIncrementTable{
+	{UpTo: usd(2000), Increment: *usd(100)},
+	{UpTo: usd(10000), Increment: *usd(500)},
+	{Increment: *usd(1000)},
+}
+
+func TestIncrementFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		ladder BidIncrementTable
+		amount int64
+		want   int64
+	}{
+		{"bottom of the first band", testLadder, 0, 100},
+		{"just below a band edge", testLadder, 1999, 100},
+		{"at a band edge", testLadder, 2000, 500},
+		{"inside a band", testLadder, 5000, 500},
+		{"at the last edge", testLadder, 10000, 1000},
+		{"open-ended band", testLadder, 1000000, 1000},
+		{"no open-ended band", testLadder[:2], 50000, 500},
+		{"empty ladder", nil, 5000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.ladder.IncrementFor(*usd(tt.amount))
+			if got.Cents != tt.want || got.Currency != "USD" {
+				t.Errorf("IncrementFor(%d) = %s %s, want %d cents", tt.amount, got, got.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimumBid(t *testing.T) {
+	override := BidIncrementTable{{Increment: *usd(25)}}
+
+	tests := []struct {
+		name       string
+		currentBid *Money
+		ladder     BidIncrementTable
+		want       int64
+	}{
+		{"first bid is the start price", nil, nil, 1000},
+		{"default ladder below an edge", usd(1950), nil, 2050},
+		{"default ladder at an edge", usd(2000), nil, 2500},
+		{"auction's own ladder", usd(2000), override, 2025},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auction := &Auction{StartPrice: *usd(1000), CurrentBid: tt.currentBid, BidIncrements: tt.ladder}
+			if got := auction.MinimumBid(testLadder); got.Cents != tt.want {
+				t.Errorf("MinimumBid = %s, want %d cents", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBidIncrementTableValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ladder  BidIncrementTable
+		wantErr bool
+	}{
+		{"ladder", testLadder, false},
+		{"single open band", BidIncrementTable{{Increment: *usd(50)}}, false},
+		{"bands only", BidIncrementTable{{UpTo: usd(2000), Increment: *usd(100)}}, false},
+		{"empty", nil, false},
+		{"zero increment", BidIncrementTable{{UpTo: usd(2000), Increment: *usd(0)}, {Increment: *usd(100)}}, true},
+		{"negative increment", BidIncrementTable{{Increment: *usd(-100)}}, true},
+		{"unsorted", BidIncrementTable{{UpTo: usd(10000), Increment: *usd(500)}, {UpTo: usd(2000), Increment: *usd(100)}}, true},
+		{"repeated edge", BidIncrementTable{{UpTo: usd(2000), Increment: *usd(100)}, {UpTo: usd(2000), Increment: *usd(500)}}, true},
+		{"zero edge", BidIncrementTable{{UpTo: usd(0), Increment: *usd(100)}}, true},
+		{"open band before the last", BidIncrementTable{{Increment: *usd(100)}, {UpTo: usd(2000), Increment: *usd(500)}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ladder.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("invalid ladder accepted")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
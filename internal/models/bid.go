@@ -6,13 +6,15 @@ import (
 
 // Bid represents a bid in an auction
 type Bid struct {
-	ID        int       `json:"id" db:"id"`
-	AuctionID int       `json:"auction_id" db:"auction_id"`
-	BidderID  int       `json:"bidder_id" db:"bidder_id"`
-	Amount    float64   `json:"amount" db:"amount"`
-	Status    BidStatus `json:"status" db:"status"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          int       `json:"id" db:"id"`
+	AuctionID   int       `json:"auction_id" db:"auction_id"`
+	BidderID    int       `json:"bidder_id" db:"bidder_id"`
+	Amount      Money     `json:"amount" db:"amount"`
+	Currency    string    `json:"currency" db:"currency"`
+	Status      BidStatus `json:"status" db:"status"`
+	IsAutomatic bool      `json:"is_automatic" db:"is_automatic"` // Placed by the proxy bidding engine
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	
 	// Related entities (loaded via joins)
 	Auction *Auction `json:"auction,omitempty"`
@@ -29,32 +31,59 @@ const (
 	BidStatusCancelled BidStatus = "cancelled"
 )
 
+// ProxyBid represents a bidder's hidden maximum on an auction.
+// The proxy bidding engine raises the bidder's visible bid up to MaxAmount when they are outbid.
+type ProxyBid struct {
+	ID        int       `json:"id" db:"id"`
+	AuctionID int       `json:"auction_id" db:"auction_id"`
+	BidderID  int       `json:"bidder_id" db:"bidder_id"`
+	MaxAmount Money     `json:"max_amount" db:"max_amount"`
+	Currency  string    `json:"currency" db:"currency"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
 // CreateBidRequest represents the request payload for creating a bid
 type CreateBidRequest struct {
-	AuctionID int     `json:"auction_id" binding:"required"`
-	Amount    float64 `json:"amount" binding:"required,min=0"`
+	AuctionID int    `json:"auction_id" binding:"required"`
+	Amount    Money  `json:"amount"`               // In the auction's currency
+	MaxAmount *Money `json:"max_amount,omitempty"` // Optional proxy bidding ceiling
 }
 
 // BidResponse represents the response payload for bid data
 type BidResponse struct {
-	ID        int       `json:"id"`
-	AuctionID int       `json:"auction_id"`
-	BidderID  int       `json:"bidder_id"`
-	Amount    float64   `json:"amount"`
-	Status    BidStatus `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int       `json:"id"`
+	AuctionID   int       `json:"auction_id"`
+	BidderID    int       `json:"bidder_id"`
+	Amount      Money     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Status      BidStatus `json:"status"`
+	IsAutomatic bool      `json:"is_automatic"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// AuctionEndTime is set when the auction was loaded with the bid, e.g. after a soft close extension
+	AuctionEndTime *time.Time `json:"auction_end_time,omitempty"`
+
+	// Display holds the amount converted into the currency requested with display_currency
+	Display *DisplayAmount `json:"display,omitempty"`
 }
 
 // ToResponse converts Bid to BidResponse
 func (b *Bid) ToResponse() *BidResponse {
-	return &BidResponse{
-		ID:        b.ID,
-		AuctionID: b.AuctionID,
-		BidderID:  b.BidderID,
-		Amount:    b.Amount,
-		Status:    b.Status,
-		CreatedAt: b.CreatedAt,
-		UpdatedAt: b.UpdatedAt,
+	response := &BidResponse{
+		ID:          b.ID,
+		AuctionID:   b.AuctionID,
+		BidderID:    b.BidderID,
+		Amount:      b.Amount,
+		Currency:    b.Currency,
+		Status:      b.Status,
+		IsAutomatic: b.IsAutomatic,
+		CreatedAt:   b.CreatedAt,
+		UpdatedAt:   b.UpdatedAt,
+	}
+	if b.Auction != nil {
+		response.AuctionEndTime = &b.Auction.EndTime
 	}
+	return response
 }
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+)
+
+// Escrow holds the payment for a completed auction's winning bid. The
+// buyer's payment is captured when the auction closes and released to the
+// seller once the buyer confirms delivery.
+type Escrow struct {
+	ID            int          `json:"id" db:"id"`
+	AuctionID     int          `json:"auction_id" db:"auction_id"`
+	BidID         int          `json:"bid_id" db:"bid_id"`
+	BuyerID       int          `json:"buyer_id" db:"buyer_id"`
+	SellerID      int          `json:"seller_id" db:"seller_id"`
+	Amount        Money        `json:"amount" db:"amount"`
+	Currency      string       `json:"currency" db:"currency"`
+	Status        EscrowStatus `json:"status" db:"status"`
+	Provider      *string      `json:"provider,omitempty" db:"provider"`
+	PaymentRef    *string      `json:"payment_ref,omitempty" db:"payment_ref"` // Provider payment ID once authorized
+	FailureReason *string      `json:"failure_reason,omitempty" db:"failure_reason"`
+	Attempts      int          `json:"attempts" db:"attempts"`
+	CapturedAt    *time.Time   `json:"captured_at,omitempty" db:"captured_at"`
+	ReleasedAt    *time.Time   `json:"released_at,omitempty" db:"released_at"`
+	RefundedAt    *time.Time   `json:"refunded_at,omitempty" db:"refunded_at"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// EscrowStatus represents escrow status
+type EscrowStatus string
+
+const (
+	EscrowStatusPending    EscrowStatus = "pending"    // Waiting for the payment to be captured
+	EscrowStatusProcessing EscrowStatus = "processing" // Capture in progress
+	EscrowStatusCaptured   EscrowStatus = "captured"   // Funds held by the platform
+	EscrowStatusReleased   EscrowStatus = "released"   // Delivery confirmed, funds owed to the seller
+	EscrowStatusRefunded   EscrowStatus = "refunded"
+	EscrowStatusDisputed   EscrowStatus = "disputed" // Buyer disputed the charge; release is frozen
+	EscrowStatusFailed     EscrowStatus = "failed"   // Payment declined or gave up; the buyer can retry
+)
+
+// IsPaid reports whether the buyer's payment has been taken and not returned
+func (e *Escrow) IsPaid() bool {
+	return e.Status == EscrowStatusCaptured || e.Status == EscrowStatusReleased
+}
+
+// EscrowResponse represents an escrow as shown to its buyer and seller
+type EscrowResponse struct {
+	ID            int          `json:"id"`
+	AuctionID     int          `json:"auction_id"`
+	BidID         int          `json:"bid_id"`
+	BuyerID       int          `json:"buyer_id"`
+	SellerID      int          `json:"seller_id"`
+	Amount        Money        `json:"amount"`
+	Currency      string       `json:"currency"`
+	Status        EscrowStatus `json:"status"`
+	FailureReason *string      `json:"failure_reason,omitempty"`
+	CapturedAt    *time.Time   `json:"captured_at,omitempty"`
+	ReleasedAt    *time.Time   `json:"released_at,omitempty"`
+	RefundedAt    *time.Time   `json:"refunded_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// ToResponse converts Escrow to EscrowResponse
+func (e *Escrow) ToResponse() *EscrowResponse {
+	return &EscrowResponse{
+		ID:            e.ID,
+		AuctionID:     e.AuctionID,
+		BidID:         e.BidID,
+		BuyerID:       e.BuyerID,
+		SellerID:      e.SellerID,
+		Amount:        e.Amount,
+		Currency:      e.Currency,
+		Status:        e.Status,
+		FailureReason: e.FailureReason,
+		CapturedAt:    e.CapturedAt,
+		ReleasedAt:    e.ReleasedAt,
+		RefundedAt:    e.RefundedAt,
+		CreatedAt:     e.CreatedAt,
+		UpdatedAt:     e.UpdatedAt,
+	}
+}
+
+// SetPaymentMethodRequest represents the request payload for saving the
+// payment method charged when the user wins an auction
+type SetPaymentMethodRequest struct {
+	PaymentMethod string `json:"payment_method" binding:"required,max=255"` // Provider reference, e.g. a Stripe PaymentMethod ID
+}
+
+// PaymentMethod is the payment method on file for a user
+type PaymentMethod struct {
+	UserID    int       `json:"user_id" db:"user_id"`
+	Provider  string    `json:"provider" db:"provider"`
+	MethodRef string    `json:"payment_method" db:"method_ref"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// ExchangeRate is the number of units of Currency worth one unit of
+// BaseCurrency. Rates are kept as exact decimal strings.
+type ExchangeRate struct {
+	Currency     string    `json:"currency" db:"currency"`
+	BaseCurrency string    `json:"base_currency" db:"base_currency"`
+	Rate         string    `json:"rate" db:"rate"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ExchangeRateTable is the full set of display exchange rates, relative to
+// one base currency, e.g. {"base": "USD", "rates": {"EUR": "0.92"}}. It is
+// both the body of PUT /fx/rates and the format of the rates file.
+type ExchangeRateTable struct {
+	Base      string            `json:"base" binding:"required,len=3"`
+	Rates     map[string]string `json:"rates" binding:"required,min=1"`
+	UpdatedAt *time.Time        `json:"updated_at,omitempty"`
+}
+
+// DisplayPrices are an auction's amounts converted into a currency the client
+// asked for. They are indicative only: bids are placed, compared and paid in
+// the auction's own currency.
+type DisplayPrices struct {
+	Currency       string    `json:"currency"`
+	Rate           string    `json:"rate"` // Units of Currency per unit of the auction currency
+	StartPrice     Money     `json:"start_price"`
+	ReservePrice   *Money    `json:"reserve_price,omitempty"`
+	CurrentBid     *Money    `json:"current_bid,omitempty"`
+	NextMinimumBid Money     `json:"next_minimum_bid"`
+	RatesAsOf      time.Time `json:"rates_as_of"`
+}
+
+// DisplayAmount is a single amount converted into a currency the client asked for
+type DisplayAmount struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	Amount    Money     `json:"amount"`
+	RatesAsOf time.Time `json:"rates_as_of"`
+}
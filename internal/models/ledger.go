@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+// LedgerAccountType represents the kind of a ledger account
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeAsset     LedgerAccountType = "asset"     // Debit-normal: money the platform holds
+	LedgerAccountTypeLiability LedgerAccountType = "liability" // Credit-normal: money the platform owes
+	LedgerAccountTypeRevenue   LedgerAccountType = "revenue"   // Credit-normal: money the platform earned
+)
+
+// Ledger account codes
+const (
+	LedgerAccountPlatformCash       = "platform_cash"       // Money held at the payment provider
+	LedgerAccountEscrowHolding      = "escrow_holding"      // Captured payments awaiting delivery
+	LedgerAccountPlatformCommission = "platform_commission" // Commission earned on sales
+	LedgerAccountSellerPayable      = "seller_payable"      // A seller's earnings not yet paid out
+)
+
+// LedgerAccount is an account of the double-entry ledger. Platform accounts
+// have no user; each seller has a payable account per currency.
+type LedgerAccount struct {
+	ID        int               `json:"id" db:"id"`
+	Code      string            `json:"code" db:"code"`
+	Type      LedgerAccountType `json:"type" db:"type"`
+	UserID    *int              `json:"user_id,omitempty" db:"user_id"`
+	Currency  string            `json:"currency" db:"currency"`
+	CreatedAt time.Time         `json:"created_at" db:"created_at"`
+}
+
+// LedgerEntryKind represents the money movement a journal entry records
+type LedgerEntryKind string
+
+const (
+	LedgerEntryKindCharge  LedgerEntryKind = "charge"  // Buyer payment captured into escrow
+	LedgerEntryKindRelease LedgerEntryKind = "release" // Escrow split into seller earnings and commission
+	LedgerEntryKindRefund  LedgerEntryKind = "refund"  // Payment returned to the buyer
+	LedgerEntryKindPayout  LedgerEntryKind = "payout"  // Seller earnings paid out
+)
+
+// LedgerEntry is a journal entry. Its postings sum to zero in each currency.
+type LedgerEntry struct {
+	ID          int              `json:"id" db:"id"`
+	Kind        LedgerEntryKind  `json:"kind" db:"kind"`
+	Reference   string           `json:"reference" db:"reference"` // Unique per recorded event
+	Description string           `json:"description" db:"description"`
+	EscrowID    *int             `json:"escrow_id,omitempty" db:"escrow_id"`
+	Postings    []*LedgerPosting `json:"postings"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+}
+
+// LedgerPosting is a line of a journal entry
+type LedgerPosting struct {
+	ID        int    `json:"id" db:"id"`
+	EntryID   int    `json:"entry_id" db:"entry_id"`
+	AccountID int    `json:"account_id" db:"account_id"`
+	Amount    Money  `json:"amount" db:"amount"` // Positive debits, negative credits; stored in minor units
+	Currency  string `json:"currency" db:"currency"`
+}
+
+// WalletBalance is what the platform owes a seller in one currency
+type WalletBalance struct {
+	Balance  Money  `json:"balance"`
+	Currency string `json:"currency"`
+}
+
+// WalletTransaction is a movement of a seller's earnings
+type WalletTransaction struct {
+	EntryID     int             `json:"entry_id"`
+	Kind        LedgerEntryKind `json:"kind"`
+	Description string          `json:"description"`
+	EscrowID    *int            `json:"escrow_id,omitempty"`
+	Amount      Money           `json:"amount"` // Positive when earned, negative when paid out or clawed back
+	Currency    string          `json:"currency"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// WalletResponse represents a user's balances and their recent movements
+type WalletResponse struct {
+	Balances     []*WalletBalance     `json:"balances"`
+	Transactions []*WalletTransaction `json:"transactions"`
+}
+
+// PayoutRequest represents the request payload for recording a payout of a seller's earnings
+type PayoutRequest struct {
+	UserID   int    `json:"user_id" binding:"required,min=1"`
+	Amount   Money  `json:"amount"`
+	Currency string `json:"currency" binding:"required,len=3"`
+}
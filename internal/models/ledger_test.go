@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWalletJSON(t *testing.T) {
+	wallet := WalletResponse{
+		Balances: []*WalletBalance{{Balance: NewMoney(179900, "USD"), Currency: "USD"}},
+		Transactions: []*WalletTransaction{
+			{EntryID: 2, Kind: LedgerEntryKindPayout, Description: "Payout to seller", Amount: NewMoney(-5050, "USD"), Currency: "USD"},
+		},
+	}
+
+	data, err := json.Marshal(wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"balances":[{"balance":"1799.00","currency":"USD"}],"transactions":[{"entry_id":2,"kind":"payout","description":"Payout to seller","amount":"-50.50","currency":"USD","created_at":"0001-01-01T00:00:00Z"}]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestPayoutRequestJSON(t *testing.T) {
+	var req PayoutRequest
+	if err := json.Unmarshal([]byte(`{"user_id":5,"amount":"100.25","currency":"USD"}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Amount.Cents != 10025 {
+		t.Errorf("amount = %d cents, want 10025", req.Amount.Cents)
+	}
+}
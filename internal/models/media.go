@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// MediaUpload represents a direct-to-S3 upload through a presigned URL.
+// It is created when the URL is issued and completed once the object has
+// been verified and attached to a track or profile.
+type MediaUpload struct {
+	ID          int               `json:"id" db:"id"`
+	UserID      int               `json:"user_id" db:"user_id"`
+	Kind        MediaUploadKind   `json:"kind" db:"kind"`
+	ObjectKey   string            `json:"object_key" db:"object_key"`
+	ContentType string            `json:"content_type" db:"content_type"`
+	Size        int64             `json:"size" db:"size_bytes"`
+	Status      MediaUploadStatus `json:"status" db:"status"`
+	TrackID     *int              `json:"track_id,omitempty" db:"track_id"`
+	ExpiresAt   time.Time         `json:"expires_at" db:"expires_at"` // When the upload URL stops working
+	CompletedAt *time.Time        `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// MediaUploadKind is what an uploaded object will be attached to
+type MediaUploadKind string
+
+const (
+	MediaUploadTrackAudio   MediaUploadKind = "track_audio"
+	MediaUploadProfileImage MediaUploadKind = "profile_image"
+)
+
+// MediaUploadStatus represents media upload status
+type MediaUploadStatus string
+
+const (
+	MediaUploadStatusPending    MediaUploadStatus = "pending"    // URL issued, waiting for the client
+	MediaUploadStatusProcessing MediaUploadStatus = "processing" // Completion in progress
+	MediaUploadStatusCompleted  MediaUploadStatus = "completed"
+	MediaUploadStatusRejected   MediaUploadStatus = "rejected" // Object failed verification and was deleted
+)
+
+// CreateMediaUploadRequest represents the request payload for requesting a presigned upload URL
+type CreateMediaUploadRequest struct {
+	Kind        MediaUploadKind `json:"kind" binding:"required,oneof=track_audio profile_image"`
+	ContentType string          `json:"content_type" binding:"required"`
+	Size        int64           `json:"size" binding:"required,min=1"` // Exact size of the file in bytes
+}
+
+// MediaUploadResponse represents the response payload for a newly issued upload URL.
+// The client PUTs the file to UploadURL with every header in Headers, then
+// calls the completion endpoint.
+type MediaUploadResponse struct {
+	ID        int               `json:"id"`
+	Kind      MediaUploadKind   `json:"kind"`
+	Status    MediaUploadStatus `json:"status"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// CompleteMediaUploadResponse represents the response payload for a completed upload
+type CompleteMediaUploadResponse struct {
+	ID       int               `json:"id"`
+	Kind     MediaUploadKind   `json:"kind"`
+	Status   MediaUploadStatus `json:"status"`
+	Track    *TrackResponse    `json:"track,omitempty"`     // Set for track audio
+	ImageURL string            `json:"image_url,omitempty"` // Set for profile images
+}
+
+// MediaURLResponse represents a short-lived presigned download URL
+type MediaURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
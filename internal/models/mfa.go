@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// MFAChallengeResponse is returned by login instead of an AuthResponse when
+// the user has to pass a second factor. The challenge token is exchanged for
+// tokens at POST /auth/mfa/verify.
+type MFAChallengeResponse struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+
+	// EnrollmentRequired is set when the user's role requires 2FA but they
+	// haven't enabled it yet: they must call POST /auth/mfa/setup first
+	EnrollmentRequired bool `json:"enrollment_required"`
+}
+
+// MFAVerifyRequest completes a login with a TOTP or recovery code
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// MFASetupRequest starts a TOTP enrollment required during login
+type MFASetupRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// MFACodeRequest carries a TOTP code or, where accepted, a recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPSetupResponse is a new TOTP secret awaiting confirmation. OTPAuthURL is
+// the payload to show as a QR code.
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// RecoveryCodesResponse lists new recovery codes; they are shown this once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatusResponse describes a user's two-factor setup
+type MFAStatusResponse struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RequiredByRole         bool `json:"required_by_role"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// MFARequiredRoles are the roles that must use two-factor authentication
+type MFARequiredRoles struct {
+	Roles []UserRole `json:"roles" binding:"required,dive,oneof=admin artist buyer moderator producer fan"`
+}
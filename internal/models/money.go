@@ -0,0 +1,221 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts stored before currencies were recorded
+const DefaultCurrency = "USD"
+
+// MaxMoneyCents is the largest amount the DECIMAL(10,2) amount columns hold,
+// 99,999,999.99
+const MaxMoneyCents = 9999999999
+
+// Money errors
+var (
+	// ErrInvalidAmount is returned when an amount is not a decimal number of whole cents
+	ErrInvalidAmount = errors.New("amount must be a decimal number with at most two decimal places")
+	// ErrAmountTooLarge is returned when an amount doesn't fit the amount columns
+	ErrAmountTooLarge = errors.New("amount must be at most 99999999.99")
+)
+
+// Money is an exact amount of money: a whole number of cents (hundredths
+// of the currency unit) in an ISO 4217 currency. Arithmetic never goes
+// through floating point, so amounts compare exactly.
+//
+// In JSON an amount is a decimal string such as "12.50"; its currency is
+// carried by the enclosing object. Numbers are accepted on input for older
+// clients, but are parsed from their text rather than as floats.
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+// NewMoney returns an amount of cents in a currency
+func NewMoney(cents int64, currency string) Money {
+	return Money{Cents: cents, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount such as "12.5" or "-3.25" in a currency
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	// Digits past the cents are only allowed when they are zeros, as in "1.500"
+	if len(fraction) > 2 && strings.Trim(fraction[2:], "0") == "" {
+		fraction = fraction[:2]
+	}
+	if whole == "" || len(fraction) > 2 || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (1<<63-1)/100-1 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	cents := units * 100
+	if fraction != "" {
+		part, _ := strconv.ParseInt((fraction + "0")[:2], 10, 64)
+		cents += part
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return Money{Cents: cents, Currency: currency}, nil
+}
+
+// isDigits reports whether s only contains ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal with two places, e.g. "12.50"
+func (m Money) String() string {
+	sign := ""
+	cents := m.Cents
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Add returns m plus o, in m's currency
+func (m Money) Add(o Money) Money {
+	return Money{Cents: m.Cents + o.Cents, Currency: m.Currency}
+}
+
+// Sub returns m minus o, in m's currency
+func (m Money) Sub(o Money) Money {
+	return Money{Cents: m.Cents - o.Cents, Currency: m.Currency}
+}
+
+// Cmp compares two amounts of the same currency, returning -1, 0 or +1
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.Cents < o.Cents:
+		return -1
+	case m.Cents > o.Cents:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// LessThan reports whether m is lower than o
+func (m Money) LessThan(o Money) bool {
+	return m.Cents < o.Cents
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Cents == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Cents < 0
+}
+
+// IsPositive reports whether the amount is above zero
+func (m Money) IsPositive() bool {
+	return m.Cents > 0
+}
+
+// WithCurrency returns the same amount in another currency, without converting it
+func (m Money) WithCurrency(currency string) Money {
+	return Money{Cents: m.Cents, Currency: currency}
+}
+
+// MinMoney returns the lower of two amounts
+func MinMoney(a, b Money) Money {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
+
+// MaxMoney returns the higher of two amounts
+func MaxMoney(a, b Money) Money {
+	if a.LessThan(b) {
+		return b
+	}
+	return a
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes a decimal string or number. The currency is left
+// for the caller to fill in from the enclosing object. Amounts that don't fit
+// the amount columns are refused here, so requests binding them fail rather
+// than their inserts. Like other types, null leaves the amount unchanged.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(bytes.TrimSpace(data))
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(text, "eE") {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, text)
+	}
+
+	parsed, err := ParseMoney(text, m.Currency)
+	if err != nil {
+		return err
+	}
+	if parsed.Cents > MaxMoneyCents || parsed.Cents < -MaxMoneyCents {
+		return fmt.Errorf("%w: %q", ErrAmountTooLarge, text)
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount in a DECIMAL column
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads the amount from a DECIMAL column. The currency is read from
+// its own column by the repositories.
+func (m *Money) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', 2, 64)
+	default:
+		return fmt.Errorf("unsupported type %T for money", src)
+	}
+
+	parsed, err := ParseMoney(text, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
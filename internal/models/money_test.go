@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		json      string
+		wantCents int64
+		wantErr   error
+	}{
+		{"string", `"12.50"`, 1250, nil},
+		{"number", `12.5`, 1250, nil},
+		{"largest", `"99999999.99"`, MaxMoneyCents, nil},
+		{"largest negative", `-99999999.99`, -MaxMoneyCents, nil},
+		{"too large", `"100000000.00"`, 0, ErrAmountTooLarge},
+		{"too large negative", `-100000000`, 0, ErrAmountTooLarge},
+		{"overflowing", `"92233720368547758.07"`, 0, ErrInvalidAmount},
+		{"fractional cents", `"1.005"`, 0, ErrInvalidAmount},
+		{"exponent", `1e3`, 0, ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tt.json), &m)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.Cents != tt.wantCents {
+				t.Errorf("cents = %d, want %d", m.Cents, tt.wantCents)
+			}
+		})
+	}
+}
+
+func TestMoneyUnmarshalJSONNull(t *testing.T) {
+	var req CreateAuctionRequest
+	if err := json.Unmarshal([]byte(`{"start_price":"10.00","reserve_price":null}`), &req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.ReservePrice != nil {
+		t.Errorf("reserve price = %v, want nil", req.ReservePrice)
+	}
+
+	// null leaves an amount that isn't optional as it was, for validation to catch
+	bid := CreateBidRequest{Amount: NewMoney(500, "USD")}
+	if err := json.Unmarshal([]byte(`{"auction_id":1,"amount":null}`), &bid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bid.Amount != NewMoney(500, "USD") {
+		t.Errorf("amount = %v, want 5.00", bid.Amount)
+	}
+}
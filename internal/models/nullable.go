@@ -0,0 +1,30 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Nullable is an update request field that can be left out, set, or cleared
+// by sending null. Set reports whether the field was sent at all; Value is
+// nil when it was sent as null.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON records that the field was sent, and its value unless it is null
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		n.Value = nil
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	n.Value = &value
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNullableUpdateFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		json          string
+		wantSet       bool
+		wantReserve   *Money
+		wantExtension *int
+	}{
+		{"left out", `{}`, false, nil, nil},
+		{"cleared", `{"reserve_price":null,"soft_close_max_extensions":null}`, true, nil, nil},
+		{"set", `{"reserve_price":"25.00","soft_close_max_extensions":3}`, true, &Money{Cents: 2500}, intPtr(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateAuctionRequest
+			if err := json.Unmarshal([]byte(tt.json), &req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.ReservePrice.Set != tt.wantSet || req.SoftCloseMaxExtensions.Set != tt.wantSet {
+				t.Errorf("set = %v, %v, want %v", req.ReservePrice.Set, req.SoftCloseMaxExtensions.Set, tt.wantSet)
+			}
+			if (req.ReservePrice.Value == nil) != (tt.wantReserve == nil) ||
+				(tt.wantReserve != nil && *req.ReservePrice.Value != *tt.wantReserve) {
+				t.Errorf("reserve price = %v, want %v", req.ReservePrice.Value, tt.wantReserve)
+			}
+			if (req.SoftCloseMaxExtensions.Value == nil) != (tt.wantExtension == nil) ||
+				(tt.wantExtension != nil && *req.SoftCloseMaxExtensions.Value != *tt.wantExtension) {
+				t.Errorf("max extensions = %v, want %v", req.SoftCloseMaxExtensions.Value, tt.wantExtension)
+			}
+		})
+	}
+
+	var req UpdateAuctionRequest
+	if err := json.Unmarshal([]byte(`{"reserve_price":"1.005"}`), &req); err == nil {
+		t.Error("invalid reserve price accepted")
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
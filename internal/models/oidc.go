@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// OIDCProvidersResponse lists the enabled social login providers
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// OIDCAuthorizationResponse is the provider URL to send the user to
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCLinkPendingResponse is returned by the callback of a linking flow. The
+// link is only made once the user who started it confirms the code at
+// POST /auth/oidc/link/confirm, so a linking URL sent to someone else
+// can't attach their provider account to the sender's user.
+type OIDCLinkPendingResponse struct {
+	LinkCode  string    `json:"link_code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OIDCLinkConfirmRequest confirms a pending link
+type OIDCLinkConfirmRequest struct {
+	LinkCode string `json:"link_code" binding:"required"`
+}
+
+// ExternalIdentity is a provider account linked to a user
+type ExternalIdentity struct {
+	ID          int        `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
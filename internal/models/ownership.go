@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// TrackOwnership records the transfer of a track's rights to the winner of
+// its auction. It is created when the auction completes.
+type TrackOwnership struct {
+	ID         int       `json:"id" db:"id"`
+	TrackID    int       `json:"track_id" db:"track_id"`
+	AuctionID  int       `json:"auction_id" db:"auction_id"`
+	BidID      int       `json:"bid_id" db:"bid_id"` // The winning bid
+	OwnerID    int       `json:"owner_id" db:"owner_id"`
+	SellerID   int       `json:"seller_id" db:"seller_id"`
+	Price      Money     `json:"price" db:"price"`
+	Currency   string    `json:"currency" db:"currency"`
+	AcquiredAt time.Time `json:"acquired_at" db:"acquired_at"`
+
+	// Related entities (loaded via joins)
+	Track *Track `json:"track,omitempty"`
+}
+
+// PurchaseResponse represents a track the authenticated user owns
+type PurchaseResponse struct {
+	ID         int            `json:"id"`
+	TrackID    int            `json:"track_id"`
+	AuctionID  int            `json:"auction_id"`
+	BidID      int            `json:"bid_id"`
+	SellerID   int            `json:"seller_id"`
+	Price      Money          `json:"price"`
+	Currency   string         `json:"currency"`
+	AcquiredAt time.Time      `json:"acquired_at"`
+	Track      *TrackResponse `json:"track,omitempty"`
+}
+
+// ToResponse converts TrackOwnership to PurchaseResponse
+func (o *TrackOwnership) ToResponse() *PurchaseResponse {
+	response := &PurchaseResponse{
+		ID:         o.ID,
+		TrackID:    o.TrackID,
+		AuctionID:  o.AuctionID,
+		BidID:      o.BidID,
+		SellerID:   o.SellerID,
+		Price:      o.Price,
+		Currency:   o.Currency,
+		AcquiredAt: o.AcquiredAt,
+	}
+	if o.Track != nil {
+		response.Track = o.Track.ToResponse()
+	}
+	return response
+}
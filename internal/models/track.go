@@ -18,6 +18,12 @@ type Track struct {
 	Status      TrackStatus `json:"status" db:"status"`
 	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Audio metadata read from the uploaded file (nil for tracks created from a URL)
+	ContentType *string `json:"content_type,omitempty" db:"content_type"`
+	Bitrate     *int    `json:"bitrate,omitempty" db:"bitrate"` // Bits per second
+	SampleRate  *int    `json:"sample_rate,omitempty" db:"sample_rate"`
+	Channels    *int    `json:"channels,omitempty" db:"channels"`
 	
 	// Related entities (loaded via joins)
 	Artist   *User     `json:"artist,omitempty"`
@@ -32,8 +38,14 @@ const (
 	TrackStatusActive    TrackStatus = "active"
 	TrackStatusInactive  TrackStatus = "inactive"
 	TrackStatusDeleted   TrackStatus = "deleted"
+	TrackStatusSold      TrackStatus = "sold" // Rights transferred to an auction winner
 )
 
+// IsSellable reports whether the track's rights can still be sold at auction
+func (t *Track) IsSellable() bool {
+	return t.Status != TrackStatusSold && t.Status != TrackStatusDeleted
+}
+
 // CreateTrackRequest represents the request payload for creating a track
 type CreateTrackRequest struct {
 	Title       string      `json:"title" binding:"required,min=1,max=200"`
@@ -44,6 +56,16 @@ type CreateTrackRequest struct {
 	Description *string     `json:"description,omitempty" binding:"omitempty,max=1000"`
 }
 
+// UploadTrackRequest represents the form fields sent with a track's audio file,
+// or the JSON body completing a direct track audio upload.
+// Title and genre default to the file's tags; a declared duration must match the file's.
+type UploadTrackRequest struct {
+	Title       string  `form:"title" json:"title,omitempty" binding:"omitempty,min=1,max=200"`
+	Genre       string  `form:"genre" json:"genre,omitempty" binding:"omitempty,min=1,max=100"`
+	Duration    int     `form:"duration" json:"duration,omitempty" binding:"omitempty,min=1"`
+	Description *string `form:"description" json:"description,omitempty" binding:"omitempty,max=1000"`
+}
+
 // UpdateTrackRequest represents the request payload for updating a track
 type UpdateTrackRequest struct {
 	Title       *string      `json:"title,omitempty" binding:"omitempty,min=1,max=200"`
@@ -68,6 +90,11 @@ type TrackResponse struct {
 	Status      TrackStatus `json:"status"`
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
+
+	ContentType *string `json:"content_type,omitempty"`
+	Bitrate     *int    `json:"bitrate,omitempty"`
+	SampleRate  *int    `json:"sample_rate,omitempty"`
+	Channels    *int    `json:"channels,omitempty"`
 }
 
 // ToResponse converts Track to TrackResponse
@@ -84,6 +111,36 @@ func (t *Track) ToResponse() *TrackResponse {
 		Status:      t.Status,
 		CreatedAt:   t.CreatedAt,
 		UpdatedAt:   t.UpdatedAt,
+
+		ContentType: t.ContentType,
+		Bitrate:     t.Bitrate,
+		SampleRate:  t.SampleRate,
+		Channels:    t.Channels,
+	}
+}
+
+// TrackSearchFilter narrows a track search
+type TrackSearchFilter struct {
+	Genres []string // Match any of these genres (case-insensitive); empty means all
+}
+
+// TrackSearchResult is a track matched by a search with its relevance score
+type TrackSearchResult struct {
+	Track *Track
+	Score float64 // Relevance from 0 to 1
+}
+
+// TrackSearchResponse represents the response payload for a track search hit
+type TrackSearchResponse struct {
+	*TrackResponse
+	Relevance float64 `json:"relevance"`
+}
+
+// ToResponse converts TrackSearchResult to TrackSearchResponse
+func (r *TrackSearchResult) ToResponse() *TrackSearchResponse {
+	return &TrackSearchResponse{
+		TrackResponse: r.Track.ToResponse(),
+		Relevance:     r.Score,
 	}
 }
 
@@ -104,6 +104,10 @@ type AuthResponse struct {
 	RefreshToken string        `json:"refresh_token"`
 	ExpiresAt    time.Time     `json:"expires_at"`
 	User         *UserResponse `json:"user"`
+
+	// RecoveryCodes are only set when logging in finished a required TOTP
+	// enrollment; they are shown this once
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 // LoginRequest represents the request payload for user login
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+)
+
+// Binary WebAuthn values (challenges, credential and user IDs, authenticator
+// responses) travel as unpadded base64url strings, as in the WebAuthn JSON
+// serialization browsers use with PublicKeyCredential.parseCreationOptionsFromJSON.
+
+// PasskeyRelyingParty identifies this service to the authenticator
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PasskeyUser is the account a new passkey is created for
+type PasskeyUser struct {
+	ID          string `json:"id"` // Random user handle, not the user's database ID
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// PasskeyCredentialParameter is a key algorithm the server accepts
+type PasskeyCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"` // COSE algorithm identifier
+}
+
+// PasskeyCredentialDescriptor names an existing credential
+type PasskeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// PasskeyAuthenticatorSelection states what authenticators may be used
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// PasskeyCreationOptions are the options for navigator.credentials.create
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"` // Milliseconds
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                        `json:"attestation"`
+}
+
+// PasskeyRequestOptions are the options for navigator.credentials.get
+type PasskeyRequestOptions struct {
+	Challenge        string                        `json:"challenge"`
+	RPID             string                        `json:"rpId"`
+	Timeout          int64                         `json:"timeout"` // Milliseconds
+	AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                        `json:"userVerification"`
+}
+
+// PasskeyAttestationResponse is the authenticator's answer to create()
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AttestationObject string `json:"attestationObject" binding:"required"`
+}
+
+// PasskeyRegistrationCredential is the credential returned by create()
+type PasskeyRegistrationCredential struct {
+	ID       string                     `json:"id" binding:"required"`
+	RawID    string                     `json:"rawId"`
+	Type     string                     `json:"type" binding:"required,eq=public-key"`
+	Response PasskeyAttestationResponse `json:"response" binding:"required"`
+}
+
+// PasskeyRegisterRequest finishes registering a passkey
+type PasskeyRegisterRequest struct {
+	Name       string                        `json:"name" binding:"omitempty,max=100"`
+	Credential PasskeyRegistrationCredential `json:"credential" binding:"required"`
+}
+
+// PasskeyLoginBeginRequest starts a passkey login. Without an email any
+// discoverable passkey for this site can be used.
+type PasskeyLoginBeginRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// PasskeyAssertionResponse is the authenticator's answer to get()
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+	AuthenticatorData string `json:"authenticatorData" binding:"required"`
+	Signature         string `json:"signature" binding:"required"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// PasskeyLoginRequest finishes a passkey login with the credential returned by get()
+type PasskeyLoginRequest struct {
+	ID       string                   `json:"id" binding:"required"`
+	RawID    string                   `json:"rawId"`
+	Type     string                   `json:"type" binding:"required,eq=public-key"`
+	Response PasskeyAssertionResponse `json:"response" binding:"required"`
+}
+
+// Passkey is a registered passkey as shown to its owner
+type Passkey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
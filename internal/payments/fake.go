@@ -0,0 +1,202 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FakeSignatureHeader carries the signature of fake provider webhooks
+const FakeSignatureHeader = "Fake-Signature"
+
+// fakeDeclinePrefix marks payment methods the fake provider declines
+const fakeDeclinePrefix = "pm_fake_decline"
+
+// FakeProvider is an in-memory payment provider for development and tests.
+// Every payment method is accepted except those starting with
+// "pm_fake_decline". Webhooks are JSON WebhookEvents signed with SignWebhook.
+type FakeProvider struct {
+	webhookSecret []byte
+
+	mu       sync.Mutex
+	payments map[string]*FakePayment
+	results  map[string]interface{} // Results by idempotency key
+	nextID   int
+}
+
+// FakePayment is the state of a payment held by the fake provider
+type FakePayment struct {
+	ID            string
+	PaymentMethod string
+	Currency      string
+	Authorized    int64
+	Captured      int64
+	Refunded      int64
+	Voided        bool
+}
+
+// NewFakeProvider creates an in-memory provider whose webhooks are signed with webhookSecret
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		webhookSecret: []byte(webhookSecret),
+		payments:      make(map[string]*FakePayment),
+		results:       make(map[string]interface{}),
+	}
+}
+
+// Name identifies the provider
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// Authorize holds the amount unless the payment method is a declining one
+func (p *FakeProvider) Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.results[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return result.(*Authorization), nil
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("invalid amount %d", req.Amount)
+	}
+	if req.PaymentMethod == "" || strings.HasPrefix(req.PaymentMethod, fakeDeclinePrefix) {
+		return nil, fmt.Errorf("%w: card declined", ErrDeclined)
+	}
+
+	p.nextID++
+	payment := &FakePayment{
+		ID:            fmt.Sprintf("fake_pay_%d", p.nextID),
+		PaymentMethod: req.PaymentMethod,
+		Currency:      req.Currency,
+		Authorized:    req.Amount,
+	}
+	p.payments[payment.ID] = payment
+
+	authorization := &Authorization{ID: payment.ID, Amount: req.Amount, Currency: req.Currency}
+	p.remember(req.IdempotencyKey, authorization)
+	return authorization, nil
+}
+
+// Capture takes up to the authorized amount
+func (p *FakeProvider) Capture(ctx context.Context, paymentID string, amount int64, idempotencyKey string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.results[idempotencyKey]; ok && idempotencyKey != "" {
+		return nil
+	}
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return fmt.Errorf("payment %s not found", paymentID)
+	}
+	if payment.Voided || payment.Captured > 0 {
+		return fmt.Errorf("payment %s cannot be captured", paymentID)
+	}
+	if amount <= 0 || amount > payment.Authorized {
+		return fmt.Errorf("invalid capture amount %d", amount)
+	}
+
+	payment.Captured = amount
+	p.remember(idempotencyKey, true)
+	return nil
+}
+
+// Void releases an uncaptured hold
+func (p *FakeProvider) Void(ctx context.Context, paymentID string, idempotencyKey string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return fmt.Errorf("payment %s not found", paymentID)
+	}
+	if payment.Captured > 0 {
+		return fmt.Errorf("payment %s has been captured", paymentID)
+	}
+
+	payment.Voided = true
+	return nil
+}
+
+// Refund returns up to the captured amount that has not been refunded yet
+func (p *FakeProvider) Refund(ctx context.Context, paymentID string, amount int64, idempotencyKey string) (*Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, ok := p.results[idempotencyKey]; ok && idempotencyKey != "" {
+		return result.(*Refund), nil
+	}
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return nil, fmt.Errorf("payment %s not found", paymentID)
+	}
+	if amount <= 0 || amount > payment.Captured-payment.Refunded {
+		return nil, fmt.Errorf("invalid refund amount %d", amount)
+	}
+
+	payment.Refunded += amount
+	p.nextID++
+	refund := &Refund{ID: fmt.Sprintf("fake_refund_%d", p.nextID), Amount: amount}
+	p.remember(idempotencyKey, refund)
+	return refund, nil
+}
+
+// VerifyWebhook checks the hex HMAC-SHA256 of the payload in the
+// Fake-Signature header and decodes the payload as a WebhookEvent
+func (p *FakeProvider) VerifyWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if !hmac.Equal([]byte(p.SignWebhook(payload)), []byte(header.Get(FakeSignatureHeader))) {
+		return nil, ErrInvalidWebhook
+	}
+
+	var event struct {
+		ID        string    `json:"id"`
+		Type      EventType `json:"type"`
+		PaymentID string    `json:"payment_id"`
+		Amount    int64     `json:"amount"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" {
+		return nil, fmt.Errorf("%w: malformed event", ErrInvalidWebhook)
+	}
+
+	return &WebhookEvent{
+		ID:        event.ID,
+		Type:      event.Type,
+		PaymentID: event.PaymentID,
+		Amount:    event.Amount,
+	}, nil
+}
+
+// SignWebhook returns the signature of a webhook payload, to send in the
+// Fake-Signature header when simulating provider events
+func (p *FakeProvider) SignWebhook(payload []byte) string {
+	mac := hmac.New(sha256.New, p.webhookSecret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Payment returns a copy of a payment's state, or nil if it does not exist
+func (p *FakeProvider) Payment(paymentID string) *FakePayment {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return nil
+	}
+	copied := *payment
+	return &copied
+}
+
+// remember stores the result of a call under its idempotency key
+func (p *FakeProvider) remember(idempotencyKey string, result interface{}) {
+	if idempotencyKey != "" {
+		p.results[idempotencyKey] = result
+	}
+}
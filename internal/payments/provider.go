@@ -0,0 +1,87 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Payment errors
+var (
+	// ErrDeclined is returned when the payer's bank or the provider refuses a
+	// payment. Retrying with the same payment method will not help.
+	ErrDeclined = errors.New("payment declined")
+
+	// ErrInvalidWebhook is returned for webhooks whose signature does not verify
+	ErrInvalidWebhook = errors.New("invalid webhook signature")
+)
+
+// PaymentProvider moves money through a payment processor. The fake provider
+// keeps payments in memory for development and tests; the Stripe provider
+// uses Stripe PaymentIntents with manual capture.
+//
+// Amounts are in minor units (cents). Every call that moves money takes an
+// idempotency key, so a call retried after a timeout or crash is applied once.
+type PaymentProvider interface {
+	// Name identifies the provider in stored payment references
+	Name() string
+
+	// Authorize places a hold for an amount on a payment method without taking it.
+	// The returned authorization's ID identifies the payment in later calls.
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error)
+
+	// Capture takes up to the authorized amount of a held payment
+	Capture(ctx context.Context, paymentID string, amount int64, idempotencyKey string) error
+
+	// Void releases an uncaptured hold
+	Void(ctx context.Context, paymentID string, idempotencyKey string) error
+
+	// Refund returns an amount of a captured payment to the payer
+	Refund(ctx context.Context, paymentID string, amount int64, idempotencyKey string) (*Refund, error)
+
+	// VerifyWebhook checks the signature of a webhook request from the
+	// provider and parses the event it carries
+	VerifyWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+// AuthorizeRequest describes a payment to hold
+type AuthorizeRequest struct {
+	Amount         int64  // Minor units
+	Currency       string // ISO 4217 code
+	PaymentMethod  string // Provider reference of the payer's payment method
+	Description    string
+	IdempotencyKey string
+}
+
+// Authorization is a hold placed on a payment method
+type Authorization struct {
+	ID       string
+	Amount   int64
+	Currency string
+}
+
+// Refund is money returned to a payer
+type Refund struct {
+	ID     string
+	Amount int64
+}
+
+// EventType identifies the kind of payment webhook event
+type EventType string
+
+const (
+	EventPaymentCaptured EventType = "payment.captured"
+	EventPaymentFailed   EventType = "payment.failed"
+	EventPaymentRefunded EventType = "payment.refunded"
+	EventPaymentDisputed EventType = "payment.disputed"
+	EventOther           EventType = "other" // Events the escrow does not act on
+)
+
+// WebhookEvent is a provider notification about a payment, normalized
+// across providers
+type WebhookEvent struct {
+	ID        string // Provider event ID, unique per provider
+	Type      EventType
+	PaymentID string // The payment the event concerns
+	Amount    int64  // Minor units, where the event carries an amount
+}
@@ -0,0 +1,229 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stripeAPIURL is the base URL of the Stripe API
+const stripeAPIURL = "https://api.stripe.com"
+
+// stripeWebhookTolerance is how old a signed webhook may be before it is
+// rejected as a possible replay
+const stripeWebhookTolerance = 5 * time.Minute
+
+// StripeProvider takes payments through Stripe PaymentIntents. Authorizing
+// confirms an intent with manual capture, which holds the funds on the card
+// until the intent is captured or cancelled.
+type StripeProvider struct {
+	secretKey     string
+	webhookSecret string
+	baseURL       string
+	client        *http.Client
+}
+
+// NewStripeProvider creates a Stripe provider. webhookSecret is the signing
+// secret of the webhook endpoint configured in the Stripe dashboard.
+func NewStripeProvider(secretKey, webhookSecret string) (*StripeProvider, error) {
+	if secretKey == "" || webhookSecret == "" {
+		return nil, fmt.Errorf("stripe provider requires a secret key and a webhook secret")
+	}
+
+	return &StripeProvider{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		baseURL:       stripeAPIURL,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name identifies the provider
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// stripeIntent is the part of a PaymentIntent the provider reads
+type stripeIntent struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Authorize creates and confirms a PaymentIntent with manual capture
+func (p *StripeProvider) Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error) {
+	form := url.Values{
+		"amount":         {strconv.FormatInt(req.Amount, 10)},
+		"currency":       {strings.ToLower(req.Currency)},
+		"payment_method": {req.PaymentMethod},
+		"capture_method": {"manual"},
+		"confirm":        {"true"},
+		"off_session":    {"true"},
+		"description":    {req.Description},
+	}
+
+	var intent stripeIntent
+	if err := p.post(ctx, "/v1/payment_intents", form, req.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+	if intent.Status != "requires_capture" {
+		// The card needs the payer present (e.g. 3-D Secure) or was refused
+		return nil, fmt.Errorf("%w: payment intent %s is %s", ErrDeclined, intent.ID, intent.Status)
+	}
+
+	return &Authorization{ID: intent.ID, Amount: intent.Amount, Currency: intent.Currency}, nil
+}
+
+// Capture captures an amount of an authorized PaymentIntent
+func (p *StripeProvider) Capture(ctx context.Context, paymentID string, amount int64, idempotencyKey string) error {
+	form := url.Values{"amount_to_capture": {strconv.FormatInt(amount, 10)}}
+	return p.post(ctx, "/v1/payment_intents/"+url.PathEscape(paymentID)+"/capture", form, idempotencyKey, nil)
+}
+
+// Void cancels an uncaptured PaymentIntent, releasing its hold
+func (p *StripeProvider) Void(ctx context.Context, paymentID string, idempotencyKey string) error {
+	return p.post(ctx, "/v1/payment_intents/"+url.PathEscape(paymentID)+"/cancel", url.Values{}, idempotencyKey, nil)
+}
+
+// Refund refunds an amount of a captured PaymentIntent
+func (p *StripeProvider) Refund(ctx context.Context, paymentID string, amount int64, idempotencyKey string) (*Refund, error) {
+	form := url.Values{
+		"payment_intent": {paymentID},
+		"amount":         {strconv.FormatInt(amount, 10)},
+	}
+
+	var refund struct {
+		ID     string `json:"id"`
+		Amount int64  `json:"amount"`
+	}
+	if err := p.post(ctx, "/v1/refunds", form, idempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+
+	return &Refund{ID: refund.ID, Amount: refund.Amount}, nil
+}
+
+// VerifyWebhook checks the Stripe-Signature header, an HMAC-SHA256 of
+// "{timestamp}.{payload}", and maps the events the escrow acts on
+func (p *StripeProvider) VerifyWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(signedAt, 0)).Abs() > stripeWebhookTolerance {
+		return nil, ErrInvalidWebhook
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	verified := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(expected), []byte(signature)) {
+			verified = true
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidWebhook
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID             string `json:"id"`
+				PaymentIntent  string `json:"payment_intent"`
+				AmountReceived int64  `json:"amount_received"`
+				AmountRefunded int64  `json:"amount_refunded"`
+				Amount         int64  `json:"amount"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" {
+		return nil, fmt.Errorf("%w: malformed event", ErrInvalidWebhook)
+	}
+
+	object := event.Data.Object
+	result := &WebhookEvent{ID: event.ID, Type: EventOther}
+	switch event.Type {
+	case "payment_intent.succeeded":
+		result.Type, result.PaymentID, result.Amount = EventPaymentCaptured, object.ID, object.AmountReceived
+	case "payment_intent.payment_failed":
+		result.Type, result.PaymentID = EventPaymentFailed, object.ID
+	case "charge.refunded":
+		result.Type, result.PaymentID, result.Amount = EventPaymentRefunded, object.PaymentIntent, object.AmountRefunded
+	case "charge.dispute.created":
+		result.Type, result.PaymentID, result.Amount = EventPaymentDisputed, object.PaymentIntent, object.Amount
+	}
+
+	return result, nil
+}
+
+// post sends a form-encoded request to the Stripe API and decodes the
+// response into out. Card errors are reported as ErrDeclined.
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create stripe request: %w", err)
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read stripe response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Type    string `json:"type"`
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(body, &apiErr)
+		if apiErr.Error.Type == "card_error" {
+			return fmt.Errorf("%w: %s", ErrDeclined, apiErr.Error.Message)
+		}
+		return fmt.Errorf("stripe request failed with status %d: %s %s", resp.StatusCode, apiErr.Error.Code, apiErr.Error.Message)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to decode stripe response: %w", err)
+		}
+	}
+	return nil
+}
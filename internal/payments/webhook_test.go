@@ -0,0 +1,126 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFakeVerifyWebhook(t *testing.T) {
+	provider := NewFakeProvider("webhook-secret")
+	payload := []byte(fmt.Sprintf(`{"id":"evt_1","type":%q,"payment_id":"pay_1","amount":1500}`, EventPaymentCaptured))
+
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, provider.SignWebhook(payload))
+	event, err := provider.VerifyWebhook(payload, header)
+	if err != nil {
+		t.Fatalf("valid webhook rejected: %v", err)
+	}
+	want := WebhookEvent{ID: "evt_1", Type: EventPaymentCaptured, PaymentID: "pay_1", Amount: 1500}
+	if *event != want {
+		t.Errorf("event = %+v, want %+v", *event, want)
+	}
+
+	tampered := []byte(fmt.Sprintf(`{"id":"evt_1","type":%q,"payment_id":"pay_1","amount":150000}`, EventPaymentCaptured))
+	otherSecret := http.Header{}
+	otherSecret.Set(FakeSignatureHeader, NewFakeProvider("other-secret").SignWebhook(payload))
+	malformed := []byte(`{"type":"payment.captured"}`)
+	malformedHeader := http.Header{}
+	malformedHeader.Set(FakeSignatureHeader, provider.SignWebhook(malformed))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  http.Header
+	}{
+		{"tampered payload", tampered, header},
+		{"other secret", payload, otherSecret},
+		{"unsigned", payload, http.Header{}},
+		{"signed without id", malformed, malformedHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.VerifyWebhook(tt.payload, tt.header); !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("got %v, want ErrInvalidWebhook", err)
+			}
+		})
+	}
+}
+
+// stripeSignature builds a Stripe-Signature header for payload signed at signedAt
+func stripeSignature(secret string, signedAt time.Time, payload []byte) string {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + stripeV1(secret, timestamp, payload)
+}
+
+// stripeV1 is the v1 signature of payload signed at timestamp
+func stripeV1(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestStripeVerifyWebhook(t *testing.T) {
+	provider, err := NewStripeProvider("sk_test", "whsec_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","amount_received":1500}}}`)
+
+	header := http.Header{}
+	header.Set("Stripe-Signature", stripeSignature("whsec_test", time.Now(), payload))
+	event, err := provider.VerifyWebhook(payload, header)
+	if err != nil {
+		t.Fatalf("valid webhook rejected: %v", err)
+	}
+	want := WebhookEvent{ID: "evt_1", Type: EventPaymentCaptured, PaymentID: "pi_1", Amount: 1500}
+	if *event != want {
+		t.Errorf("event = %+v, want %+v", *event, want)
+	}
+
+	// Stripe sends a signature per active secret while one is being rolled
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	rolled := http.Header{}
+	rolled.Set("Stripe-Signature", "t="+now+",v1="+stripeV1("whsec_old", now, payload)+",v1="+stripeV1("whsec_test", now, payload))
+	if _, err := provider.VerifyWebhook(payload, rolled); err != nil {
+		t.Errorf("webhook with a signature per secret rejected: %v", err)
+	}
+
+	tampered := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1","amount_received":150000}}}`)
+	signature := func(secret string, signedAt time.Time) http.Header {
+		header := http.Header{}
+		header.Set("Stripe-Signature", stripeSignature(secret, signedAt, payload))
+		return header
+	}
+	noTimestamp := http.Header{}
+	noTimestamp.Set("Stripe-Signature", "v1="+stripeV1("whsec_test", now, payload))
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  http.Header
+	}{
+		{"tampered payload", tampered, header},
+		{"other secret", payload, signature("whsec_other", time.Now())},
+		{"replayed", payload, signature("whsec_test", time.Now().Add(-stripeWebhookTolerance-time.Minute))},
+		{"from the future", payload, signature("whsec_test", time.Now().Add(stripeWebhookTolerance+time.Minute))},
+		{"no timestamp", payload, noTimestamp},
+		{"unsigned", payload, http.Header{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.VerifyWebhook(tt.payload, tt.header); !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("got %v, want ErrInvalidWebhook", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"bagr-backend/internal/utils"
+
+	"github.com/lib/pq"
+)
+
+// Broker transports auction events between publishers and hubs.
+// The local broker only reaches the current process; the Postgres broker
+// reaches every replica connected to the same database.
+type Broker interface {
+	// Start begins delivering published events to handler
+	Start(handler func(Event)) error
+	// Publish sends an event to every started handler
+	Publish(ctx context.Context, event Event) error
+	// Close stops delivering events
+	Close() error
+}
+
+// localBroker delivers events within the current process
+type localBroker struct {
+	mu      sync.RWMutex
+	handler func(Event)
+}
+
+// NewLocalBroker creates an in-process broker
+func NewLocalBroker() Broker {
+	return &localBroker{}
+}
+
+// Start registers the handler that receives published events
+func (b *localBroker) Start(handler func(Event)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handler = handler
+	return nil
+}
+
+// Publish delivers the event to the handler directly
+func (b *localBroker) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handler := b.handler
+	b.mu.RUnlock()
+
+	if handler != nil {
+		handler(event)
+	}
+	return nil
+}
+
+// Close unregisters the handler
+func (b *localBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handler = nil
+	return nil
+}
+
+// postgresChannel is the LISTEN/NOTIFY channel auction events travel on
+const postgresChannel = "auction_events"
+
+// postgresBroker fans events out across replicas with Postgres LISTEN/NOTIFY
+type postgresBroker struct {
+	db       *sql.DB
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+// NewPostgresBroker creates a broker that publishes with pg_notify on db and
+// receives on a dedicated listener connection to databaseURL
+func NewPostgresBroker(db *sql.DB, databaseURL string) Broker {
+	logger := utils.GetLogger()
+	listener := pq.NewListener(databaseURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.WithError(err).Warn("Auction event listener connection problem")
+		}
+	})
+
+	return &postgresBroker{
+		db:       db,
+		listener: listener,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start listens on the events channel and delivers notifications to handler
+func (b *postgresBroker) Start(handler func(Event)) error {
+	if err := b.listener.Listen(postgresChannel); err != nil {
+		return fmt.Errorf("failed to listen for auction events: %w", err)
+	}
+
+	go func() {
+		logger := utils.GetLogger()
+		for {
+			select {
+			case <-b.done:
+				return
+			case notification, ok := <-b.listener.Notify:
+				if !ok {
+					return
+				}
+				// A nil notification signals a reconnect; events sent meanwhile are lost
+				if notification == nil {
+					continue
+				}
+
+				var event Event
+				if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+					logger.WithError(err).Error("Failed to decode auction event")
+					continue
+				}
+				handler(event)
+			case <-time.After(90 * time.Second):
+				// Check the connection is still alive when no events arrive
+				go b.listener.Ping()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Publish sends the event with pg_notify.
+// Notifications sent inside a transaction are only delivered on commit, so
+// callers publish after committing.
+func (b *postgresBroker) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode auction event: %w", err)
+	}
+
+	if _, err := b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", postgresChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify auction event: %w", err)
+	}
+	return nil
+}
+
+// Close stops the listener
+func (b *postgresBroker) Close() error {
+	close(b.done)
+	return b.listener.Close()
+}
@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"bagr-backend/internal/models"
+)
+
+// EventType identifies the kind of auction event
+type EventType string
+
+const (
+	EventSnapshot        EventType = "snapshot" // Current auction state, sent when a client connects
+	EventBidPlaced       EventType = "bid_placed"
+	EventOutbid          EventType = "outbid"
+	EventAuctionExtended EventType = "auction_extended"
+	EventAuctionClosed   EventType = "auction_closed"
+)
+
+// Event is a single auction update pushed to live subscribers
+type Event struct {
+	Type      EventType       `json:"type"`
+	AuctionID int             `json:"auction_id"`
+	UserID    int             `json:"user_id,omitempty"` // Set on events addressed to a single user
+	Data      json.RawMessage `json:"data"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// NewEvent creates an event for an auction with data encoded as JSON
+func NewEvent(eventType EventType, auctionID int, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		Type:      eventType,
+		AuctionID: auctionID,
+		Data:      payload,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// BidPlacedData is the payload of a bid_placed event
+type BidPlacedData struct {
+	BidID          int          `json:"bid_id"`
+	BidderID       int          `json:"bidder_id"`
+	Amount         models.Money `json:"amount"`
+	Currency       string       `json:"currency"`
+	IsAutomatic    bool         `json:"is_automatic"`
+	BidCount       int          `json:"bid_count"`
+	NextMinimumBid models.Money `json:"next_minimum_bid"`
+}
+
+// OutbidData is the payload of an outbid event, addressed to the outbid bidder
+type OutbidData struct {
+	BidID     int          `json:"bid_id"`
+	BidderID  int          `json:"bidder_id"`
+	Amount    models.Money `json:"amount"`
+	NewAmount models.Money `json:"new_amount"`
+	Currency  string       `json:"currency"`
+}
+
+// AuctionExtendedData is the payload of an auction_extended event
+type AuctionExtendedData struct {
+	EndTime        time.Time `json:"end_time"`
+	ExtensionCount int       `json:"extension_count"`
+}
+
+// AuctionClosedData is the payload of an auction_closed event
+type AuctionClosedData struct {
+	Status       string        `json:"status"`
+	WinningBidID *int          `json:"winning_bid_id,omitempty"`
+	WinnerID     *int          `json:"winner_id,omitempty"`
+	FinalAmount  *models.Money `json:"final_amount,omitempty"`
+	Currency     string        `json:"currency"`
+}
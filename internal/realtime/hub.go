@@ -0,0 +1,122 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bagr-backend/internal/utils"
+)
+
+// subscriberBuffer is the number of events queued per subscriber before new
+// events are dropped for it
+const subscriberBuffer = 32
+
+// Publisher publishes auction events
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// Hub fans auction events out to the subscribers connected to this process.
+// Events are published through a Broker, so with a shared broker every
+// replica's hub receives every event.
+type Hub struct {
+	broker    Broker
+	heartbeat time.Duration
+
+	mu          sync.RWMutex
+	subscribers map[int]map[*Subscription]struct{}
+}
+
+// Subscription receives the events of a single auction
+type Subscription struct {
+	AuctionID int
+	Events    <-chan Event
+
+	hub    *Hub
+	events chan Event
+	once   sync.Once
+}
+
+// NewHub creates a new hub and starts receiving events from the broker.
+// heartbeat is how often live connections send keep-alive messages.
+func NewHub(broker Broker, heartbeat time.Duration) (*Hub, error) {
+	hub := &Hub{
+		broker:      broker,
+		heartbeat:   heartbeat,
+		subscribers: make(map[int]map[*Subscription]struct{}),
+	}
+	if err := broker.Start(hub.deliver); err != nil {
+		return nil, err
+	}
+	return hub, nil
+}
+
+// Publish sends an event to every subscriber of its auction, on every replica.
+// Failures are logged rather than returned: live updates are best effort and
+// must never fail the operation that produced them.
+func (h *Hub) Publish(ctx context.Context, event Event) {
+	if err := h.broker.Publish(ctx, event); err != nil {
+		utils.GetLogger().WithError(err).WithField("auction_id", event.AuctionID).Error("Failed to publish auction event")
+	}
+}
+
+// Subscribe registers a subscriber for an auction's events.
+// The subscription must be closed when the subscriber goes away.
+func (h *Hub) Subscribe(auctionID int) *Subscription {
+	events := make(chan Event, subscriberBuffer)
+	sub := &Subscription{
+		AuctionID: auctionID,
+		Events:    events,
+		hub:       h,
+		events:    events,
+	}
+
+	h.mu.Lock()
+	if h.subscribers[auctionID] == nil {
+		h.subscribers[auctionID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[auctionID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Heartbeat returns the interval between keep-alive messages on live connections
+func (h *Hub) Heartbeat() time.Duration {
+	return h.heartbeat
+}
+
+// Close stops the broker; open subscriptions stop receiving events
+func (h *Hub) Close() error {
+	return h.broker.Close()
+}
+
+// Close unregisters the subscription and closes its event channel
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subscribers[s.AuctionID], s)
+		if len(s.hub.subscribers[s.AuctionID]) == 0 {
+			delete(s.hub.subscribers, s.AuctionID)
+		}
+		s.hub.mu.Unlock()
+
+		close(s.events)
+	})
+}
+
+// deliver hands an event received from the broker to the local subscribers.
+// A subscriber that is not keeping up misses the event instead of blocking the others.
+func (h *Hub) deliver(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers[event.AuctionID] {
+		select {
+		case sub.events <- event:
+		default:
+			utils.GetLogger().WithField("auction_id", event.AuctionID).Warn("Dropping auction event for slow subscriber")
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// auctionColumns is the column list shared by every auction SELECT
+const auctionColumns = `id, COALESCE(track_id, 0), seller_id, title, COALESCE(description, ''),
+		start_price, reserve_price, current_bid, COALESCE(bid_count, 0), status,
+		start_time, end_time, COALESCE(soft_close_window_minutes, 0),
+		COALESCE(soft_close_extension_minutes, 0), soft_close_max_extensions,
+		COALESCE(extension_count, 0), bid_increments, currency, created_at, updated_at`
+
+// auctionRepository implements AuctionRepository interface
+type auctionRepository struct {
+	db DBTX
+}
+
+// NewAuctionRepository creates a new auction repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewAuctionRepository(db DBTX) AuctionRepository {
+	return &auctionRepository{db: db}
+}
+
+// scanAuction scans a single auction row
+func scanAuction(row rowScanner) (*models.Auction, error) {
+	auction := &models.Auction{}
+	var currentBid *models.Money
+	var maxExtensions sql.NullInt64
+
+	err := row.Scan(
+		&auction.ID,
+		&auction.TrackID,
+		&auction.SellerID,
+		&auction.Title,
+		&auction.Description,
+		&auction.StartPrice,
+		&auction.ReservePrice,
+		&currentBid,
+		&auction.BidCount,
+		&auction.Status,
+		&auction.StartTime,
+		&auction.EndTime,
+		&auction.SoftCloseWindow,
+		&auction.SoftCloseExtension,
+		&maxExtensions,
+		&auction.ExtensionCount,
+		&auction.BidIncrements,
+		&auction.Currency,
+		&auction.CreatedAt,
+		&auction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// current_bid defaults to 0 in the schema, so only treat it as set once bids exist
+	if auction.BidCount > 0 {
+		auction.CurrentBid = currentBid
+	}
+	if maxExtensions.Valid {
+		max := int(maxExtensions.Int64)
+		auction.SoftCloseMaxExtensions = &max
+	}
+	auction.SetCurrency(auction.Currency)
+
+	return auction, nil
+}
+
+// Create creates a new auction
+func (r *auctionRepository) Create(ctx context.Context, auction *models.Auction) error {
+	query := `
+		INSERT INTO auctions (track_id, seller_id, title, description, start_price, reserve_price,
+		                      current_bid, bid_count, status, start_time, end_time,
+		                      soft_close_window_minutes, soft_close_extension_minutes,
+		                      soft_close_max_extensions, extension_count, bid_increments,
+		                      currency, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULL, 0, $7, $8, $9, $10, $11, $12, 0, $13, $14, $15, $16)
+		RETURNING id`
+
+	now := time.Now()
+	auction.CreatedAt = now
+	auction.UpdatedAt = now
+	auction.BidCount = 0
+	auction.CurrentBid = nil
+	auction.ExtensionCount = 0
+	if auction.Status == "" {
+		auction.Status = models.AuctionStatusDraft
+	}
+	if auction.Currency == "" {
+		auction.Currency = models.DefaultCurrency
+	}
+	auction.SetCurrency(auction.Currency)
+
+	err := r.db.QueryRowContext(ctx, query,
+		auction.TrackID,
+		auction.SellerID,
+		auction.Title,
+		auction.Description,
+		auction.StartPrice,
+		auction.ReservePrice,
+		auction.Status,
+		auction.StartTime,
+		auction.EndTime,
+		auction.SoftCloseWindow,
+		auction.SoftCloseExtension,
+		auction.SoftCloseMaxExtensions,
+		auction.BidIncrements,
+		auction.Currency,
+		auction.CreatedAt,
+		auction.UpdatedAt,
+	).Scan(&auction.ID)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create auction")
+		return fmt.Errorf("failed to create auction: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an auction by ID
+func (r *auctionRepository) GetByID(ctx context.Context, id int) (*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE id = $1`
+
+	auction, err := scanAuction(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get auction by ID")
+		return nil, fmt.Errorf("failed to get auction by ID: %w", err)
+	}
+
+	return auction, nil
+}
+
+// GetByIDForUpdate retrieves an auction by ID and locks its row until the
+// surrounding transaction ends. It must be used with a repository built on a *sql.Tx.
+func (r *auctionRepository) GetByIDForUpdate(ctx context.Context, id int) (*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE id = $1
+		FOR UPDATE`
+
+	auction, err := scanAuction(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to lock auction")
+		return nil, fmt.Errorf("failed to lock auction: %w", err)
+	}
+
+	return auction, nil
+}
+
+// Update updates an auction
+func (r *auctionRepository) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	// Build dynamic query
+	setParts := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)+1)
+	argIndex := 1
+
+	for field, value := range updates {
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argIndex))
+		args = append(args, value)
+		argIndex++
+	}
+
+	// Add updated_at
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argIndex))
+	args = append(args, time.Now())
+	argIndex++
+
+	// Add ID for WHERE clause
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE auctions
+		SET %s
+		WHERE id = $%d`,
+		strings.Join(setParts, ", "),
+		argIndex,
+	)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update auction")
+		return fmt.Errorf("failed to update auction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("auction not found")
+	}
+
+	return nil
+}
+
+// Delete deletes an auction (soft delete by setting status to cancelled)
+func (r *auctionRepository) Delete(ctx context.Context, id int) error {
+	query := `
+		UPDATE auctions
+		SET status = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, models.AuctionStatusCancelled, time.Now(), id)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to delete auction")
+		return fmt.Errorf("failed to delete auction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("auction not found")
+	}
+
+	return nil
+}
+
+// List retrieves a list of auctions with pagination
+func (r *auctionRepository) List(ctx context.Context, limit, offset int) ([]*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE status != $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.queryAuctions(ctx, "list auctions", query, models.AuctionStatusCancelled, limit, offset)
+}
+
+// GetBySellerID retrieves a seller's auctions with pagination
+func (r *auctionRepository) GetBySellerID(ctx context.Context, sellerID int, limit, offset int) ([]*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE seller_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.queryAuctions(ctx, "get auctions by seller ID", query, sellerID, limit, offset)
+}
+
+// GetActiveAuctions retrieves auctions that are currently open for bidding, ending soonest first
+func (r *auctionRepository) GetActiveAuctions(ctx context.Context, limit, offset int) ([]*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE status = $1 AND start_time <= $2 AND end_time > $2
+		ORDER BY end_time ASC
+		LIMIT $3 OFFSET $4`
+
+	return r.queryAuctions(ctx, "get active auctions", query, models.AuctionStatusActive, time.Now(), limit, offset)
+}
+
+// UpdateCurrentBid records a new highest bid on an auction and increments its bid count
+func (r *auctionRepository) UpdateCurrentBid(ctx context.Context, auctionID int, bidAmount models.Money) error {
+	query := `
+		UPDATE auctions
+		SET current_bid = $1, bid_count = COALESCE(bid_count, 0) + 1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, bidAmount, time.Now(), auctionID)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update auction current bid")
+		return fmt.Errorf("failed to update auction current bid: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("auction not found")
+	}
+
+	return nil
+}
+
+// ExtendEndTime moves an auction's end time out after a soft close and counts the extension
+func (r *auctionRepository) ExtendEndTime(ctx context.Context, auctionID int, endTime time.Time) error {
+	query := `
+		UPDATE auctions
+		SET end_time = $1, extension_count = COALESCE(extension_count, 0) + 1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, endTime, time.Now(), auctionID)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to extend auction end time")
+		return fmt.Errorf("failed to extend auction end time: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("auction not found")
+	}
+
+	return nil
+}
+
+// ActivateScheduled opens every draft auction whose start time has passed
+// and returns how many auctions were activated
+func (r *auctionRepository) ActivateScheduled(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+		UPDATE auctions
+		SET status = $1, updated_at = $2
+		WHERE status = $3 AND start_time <= $2 AND end_time > $2`
+
+	result, err := r.db.ExecContext(ctx, query, models.AuctionStatusActive, now, models.AuctionStatusDraft)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to activate scheduled auctions")
+		return 0, fmt.Errorf("failed to activate scheduled auctions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// LockEndedAuctions locks up to limit open auctions whose end time has passed.
+// Auctions that failed to close come last, so they can't hold up the others.
+// Rows already locked by a bid in progress are skipped and picked up on a later run.
+// It must be used with a repository built on a *sql.Tx.
+func (r *auctionRepository) LockEndedAuctions(ctx context.Context, now time.Time, limit int) ([]*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE status IN ($1, $2) AND end_time <= $3
+		ORDER BY close_failed_at ASC NULLS FIRST, end_time ASC
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED`
+
+	return r.queryAuctions(ctx, "lock ended auctions", query,
+		models.AuctionStatusActive, models.AuctionStatusDraft, now, limit)
+}
+
+// GetOpenByTrackID retrieves the latest draft or active auction of a track
+func (r *auctionRepository) GetOpenByTrackID(ctx context.Context, trackID int) (*models.Auction, error) {
+	query := `
+		SELECT ` + auctionColumns + `
+		FROM auctions
+		WHERE track_id = $1 AND status IN ($2, $3)
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	auction, err := scanAuction(r.db.QueryRowContext(ctx, query, trackID, models.AuctionStatusActive, models.AuctionStatusDraft))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get open auction by track ID")
+		return nil, fmt.Errorf("failed to get open auction by track ID: %w", err)
+	}
+
+	return auction, nil
+}
+
+// queryAuctions runs a multi-row auction query and scans the results
+func (r *auctionRepository) queryAuctions(ctx context.Context, action, query string, args ...interface{}) ([]*models.Auction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to " + action)
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer rows.Close()
+
+	var auctions []*models.Auction
+	for rows.Next() {
+		auction, err := scanAuction(rows)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan auction row")
+			return nil, fmt.Errorf("failed to scan auction row: %w", err)
+		}
+		auctions = append(auctions, auction)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating auction rows")
+		return nil, fmt.Errorf("error iterating auction rows: %w", err)
+	}
+
+	return auctions, nil
+}
@@ -0,0 +1,325 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// bidColumns is the column list shared by every bid SELECT
+const bidColumns = `id, auction_id, bidder_id, amount, currency, status, COALESCE(is_automatic, FALSE),
+		created_at, COALESCE(updated_at, created_at)`
+
+// bidRepository implements BidRepository interface
+type bidRepository struct {
+	db DBTX
+}
+
+// NewBidRepository creates a new bid repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewBidRepository(db DBTX) BidRepository {
+	return &bidRepository{db: db}
+}
+
+// scanBid scans a single bid row
+func scanBid(row rowScanner) (*models.Bid, error) {
+	bid := &models.Bid{}
+	err := row.Scan(
+		&bid.ID,
+		&bid.AuctionID,
+		&bid.BidderID,
+		&bid.Amount,
+		&bid.Currency,
+		&bid.Status,
+		&bid.IsAutomatic,
+		&bid.CreatedAt,
+		&bid.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	bid.Amount.Currency = bid.Currency
+	return bid, nil
+}
+
+// Create creates a new bid
+func (r *bidRepository) Create(ctx context.Context, bid *models.Bid) error {
+	query := `
+		INSERT INTO bids (auction_id, bidder_id, amount, currency, status, is_automatic, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	now := time.Now()
+	bid.CreatedAt = now
+	bid.UpdatedAt = now
+	if bid.Status == "" {
+		bid.Status = models.BidStatusActive
+	}
+	if bid.Currency == "" {
+		bid.Currency = models.DefaultCurrency
+	}
+	bid.Amount.Currency = bid.Currency
+
+	err := r.db.QueryRowContext(ctx, query,
+		bid.AuctionID,
+		bid.BidderID,
+		bid.Amount,
+		bid.Currency,
+		bid.Status,
+		bid.IsAutomatic,
+		bid.CreatedAt,
+		bid.UpdatedAt,
+	).Scan(&bid.ID)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create bid")
+		return fmt.Errorf("failed to create bid: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a bid by ID
+func (r *bidRepository) GetByID(ctx context.Context, id int) (*models.Bid, error) {
+	query := `
+		SELECT ` + bidColumns + `
+		FROM bids
+		WHERE id = $1`
+
+	bid, err := scanBid(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get bid by ID")
+		return nil, fmt.Errorf("failed to get bid by ID: %w", err)
+	}
+
+	return bid, nil
+}
+
+// Update updates a bid
+func (r *bidRepository) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	// Build dynamic query
+	setParts := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)+1)
+	argIndex := 1
+
+	for field, value := range updates {
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argIndex))
+		args = append(args, value)
+		argIndex++
+	}
+
+	// Add updated_at
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argIndex))
+	args = append(args, time.Now())
+	argIndex++
+
+	// Add ID for WHERE clause
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE bids
+		SET %s
+		WHERE id = $%d`,
+		strings.Join(setParts, ", "),
+		argIndex,
+	)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update bid")
+		return fmt.Errorf("failed to update bid: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("bid not found")
+	}
+
+	return nil
+}
+
+// Delete deletes a bid (soft delete by setting status to cancelled)
+func (r *bidRepository) Delete(ctx context.Context, id int) error {
+	query := `
+		UPDATE bids
+		SET status = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, models.BidStatusCancelled, time.Now(), id)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to delete bid")
+		return fmt.Errorf("failed to delete bid: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("bid not found")
+	}
+
+	return nil
+}
+
+// GetByAuctionID retrieves an auction's bids, highest first
+func (r *bidRepository) GetByAuctionID(ctx context.Context, auctionID int, limit, offset int) ([]*models.Bid, error) {
+	query := `
+		SELECT ` + bidColumns + `
+		FROM bids
+		WHERE auction_id = $1 AND status != $2
+		ORDER BY amount DESC, created_at ASC
+		LIMIT $3 OFFSET $4`
+
+	return r.queryBids(ctx, "get bids by auction ID", query, auctionID, models.BidStatusCancelled, limit, offset)
+}
+
+// GetByBidderID retrieves a bidder's bids, newest first
+func (r *bidRepository) GetByBidderID(ctx context.Context, bidderID int, limit, offset int) ([]*models.Bid, error) {
+	query := `
+		SELECT ` + bidColumns + `
+		FROM bids
+		WHERE bidder_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.queryBids(ctx, "get bids by bidder ID", query, bidderID, limit, offset)
+}
+
+// GetHighestBidForAuction retrieves the leading bid of an auction.
+// Outbid bids are ignored, so a proxy that wins a tie keeps the lead
+// over an equal bid placed before it.
+func (r *bidRepository) GetHighestBidForAuction(ctx context.Context, auctionID int) (*models.Bid, error) {
+	query := `
+		SELECT ` + bidColumns + `
+		FROM bids
+		WHERE auction_id = $1 AND status NOT IN ($2, $3)
+		ORDER BY amount DESC, created_at ASC, id ASC
+		LIMIT 1`
+
+	bid, err := scanBid(r.db.QueryRowContext(ctx, query, auctionID, models.BidStatusCancelled, models.BidStatusOutbid))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get highest bid for auction")
+		return nil, fmt.Errorf("failed to get highest bid for auction: %w", err)
+	}
+
+	return bid, nil
+}
+
+// GetBidHistory retrieves every bid placed on an auction in the order they were placed
+func (r *bidRepository) GetBidHistory(ctx context.Context, auctionID int) ([]*models.Bid, error) {
+	query := `
+		SELECT ` + bidColumns + `
+		FROM bids
+		WHERE auction_id = $1
+		ORDER BY created_at ASC, id ASC`
+
+	return r.queryBids(ctx, "get bid history", query, auctionID)
+}
+
+// GetProxy retrieves a bidder's proxy ceiling on an auction
+func (r *bidRepository) GetProxy(ctx context.Context, auctionID, bidderID int) (*models.ProxyBid, error) {
+	query := `
+		SELECT id, auction_id, bidder_id, max_amount, currency, created_at, updated_at
+		FROM proxy_bids
+		WHERE auction_id = $1 AND bidder_id = $2`
+
+	proxy := &models.ProxyBid{}
+	err := r.db.QueryRowContext(ctx, query, auctionID, bidderID).Scan(
+		&proxy.ID,
+		&proxy.AuctionID,
+		&proxy.BidderID,
+		&proxy.MaxAmount,
+		&proxy.Currency,
+		&proxy.CreatedAt,
+		&proxy.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get proxy bid")
+		return nil, fmt.Errorf("failed to get proxy bid: %w", err)
+	}
+	proxy.MaxAmount.Currency = proxy.Currency
+
+	return proxy, nil
+}
+
+// UpsertProxy creates or replaces a bidder's proxy ceiling on an auction
+func (r *bidRepository) UpsertProxy(ctx context.Context, proxy *models.ProxyBid) error {
+	query := `
+		INSERT INTO proxy_bids (auction_id, bidder_id, max_amount, currency, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (auction_id, bidder_id)
+		DO UPDATE SET max_amount = EXCLUDED.max_amount, currency = EXCLUDED.currency,
+		              updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at`
+
+	if proxy.Currency == "" {
+		proxy.Currency = models.DefaultCurrency
+	}
+	proxy.MaxAmount.Currency = proxy.Currency
+
+	err := r.db.QueryRowContext(ctx, query,
+		proxy.AuctionID,
+		proxy.BidderID,
+		proxy.MaxAmount,
+		proxy.Currency,
+		time.Now(),
+	).Scan(&proxy.ID, &proxy.CreatedAt, &proxy.UpdatedAt)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to save proxy bid")
+		return fmt.Errorf("failed to save proxy bid: %w", err)
+	}
+
+	return nil
+}
+
+// queryBids runs a multi-row bid query and scans the results
+func (r *bidRepository) queryBids(ctx context.Context, action, query string, args ...interface{}) ([]*models.Bid, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to " + action)
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer rows.Close()
+
+	var bids []*models.Bid
+	for rows.Next() {
+		bid, err := scanBid(rows)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan bid row")
+			return nil, fmt.Errorf("failed to scan bid row: %w", err)
+		}
+		bids = append(bids, bid)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating bid rows")
+		return nil, fmt.Errorf("error iterating bid rows: %w", err)
+	}
+
+	return bids, nil
+}
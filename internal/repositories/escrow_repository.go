@@ -0,0 +1,304 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+
+	"github.com/lib/pq"
+)
+
+// escrowColumns is the column list shared by every escrow SELECT
+const escrowColumns = `id, auction_id, bid_id, buyer_id, seller_id, amount, currency, status,
+		provider, payment_ref, failure_reason, attempts, captured_at, released_at, refunded_at,
+		created_at, updated_at`
+
+// escrowRepository implements EscrowRepository interface
+type escrowRepository struct {
+	db DBTX
+}
+
+// NewEscrowRepository creates a new escrow repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewEscrowRepository(db DBTX) EscrowRepository {
+	return &escrowRepository{db: db}
+}
+
+// scanEscrow scans a single escrow row
+func scanEscrow(row rowScanner) (*models.Escrow, error) {
+	escrow := &models.Escrow{}
+	var provider, paymentRef, failureReason sql.NullString
+	var capturedAt, releasedAt, refundedAt sql.NullTime
+
+	err := row.Scan(
+		&escrow.ID,
+		&escrow.AuctionID,
+		&escrow.BidID,
+		&escrow.BuyerID,
+		&escrow.SellerID,
+		&escrow.Amount,
+		&escrow.Currency,
+		&escrow.Status,
+		&provider,
+		&paymentRef,
+		&failureReason,
+		&escrow.Attempts,
+		&capturedAt,
+		&releasedAt,
+		&refundedAt,
+		&escrow.CreatedAt,
+		&escrow.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	escrow.Amount.Currency = escrow.Currency
+	escrow.Provider = nullStringPtr(provider)
+	escrow.PaymentRef = nullStringPtr(paymentRef)
+	escrow.FailureReason = nullStringPtr(failureReason)
+	escrow.CapturedAt = nullTimePtr(capturedAt)
+	escrow.ReleasedAt = nullTimePtr(releasedAt)
+	escrow.RefundedAt = nullTimePtr(refundedAt)
+
+	return escrow, nil
+}
+
+// Create opens an escrow for a winning bid
+func (r *escrowRepository) Create(ctx context.Context, escrow *models.Escrow) error {
+	query := `
+		INSERT INTO escrows (auction_id, bid_id, buyer_id, seller_id, amount, currency, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	escrow.CreatedAt = now
+	escrow.UpdatedAt = now
+	if escrow.Status == "" {
+		escrow.Status = models.EscrowStatusPending
+	}
+	if escrow.Currency == "" {
+		escrow.Currency = models.DefaultCurrency
+	}
+	escrow.Amount.Currency = escrow.Currency
+
+	err := r.db.QueryRowContext(ctx, query,
+		escrow.AuctionID,
+		escrow.BidID,
+		escrow.BuyerID,
+		escrow.SellerID,
+		escrow.Amount,
+		escrow.Currency,
+		escrow.Status,
+		escrow.CreatedAt,
+		escrow.UpdatedAt,
+	).Scan(&escrow.ID)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create escrow")
+		return fmt.Errorf("failed to create escrow: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an escrow by ID
+func (r *escrowRepository) GetByID(ctx context.Context, id int) (*models.Escrow, error) {
+	return r.getOne(ctx, "get escrow by ID", `
+		SELECT `+escrowColumns+`
+		FROM escrows
+		WHERE id = $1`, id)
+}
+
+// GetByIDForUpdate retrieves an escrow by ID and locks its row until the
+// surrounding transaction ends. It must be used with a repository built on a *sql.Tx.
+func (r *escrowRepository) GetByIDForUpdate(ctx context.Context, id int) (*models.Escrow, error) {
+	return r.getOne(ctx, "lock escrow", `
+		SELECT `+escrowColumns+`
+		FROM escrows
+		WHERE id = $1
+		FOR UPDATE`, id)
+}
+
+// GetByAuctionID retrieves the escrow of an auction
+func (r *escrowRepository) GetByAuctionID(ctx context.Context, auctionID int) (*models.Escrow, error) {
+	return r.getOne(ctx, "get escrow by auction ID", `
+		SELECT `+escrowColumns+`
+		FROM escrows
+		WHERE auction_id = $1`, auctionID)
+}
+
+// GetByPaymentRef retrieves the escrow of a provider payment
+func (r *escrowRepository) GetByPaymentRef(ctx context.Context, provider, paymentRef string) (*models.Escrow, error) {
+	return r.getOne(ctx, "get escrow by payment", `
+		SELECT `+escrowColumns+`
+		FROM escrows
+		WHERE provider = $1 AND payment_ref = $2`, provider, paymentRef)
+}
+
+// ListByUser retrieves the escrows a user is the buyer or seller of, newest first
+func (r *escrowRepository) ListByUser(ctx context.Context, userID int, limit, offset int) ([]*models.Escrow, error) {
+	query := `
+		SELECT ` + escrowColumns + `
+		FROM escrows
+		WHERE buyer_id = $1 OR seller_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.queryEscrows(ctx, "list escrows", query, userID, limit, offset)
+}
+
+// ClaimPending moves up to limit pending escrows, and escrows left processing
+// since before staleBefore by a crashed worker, to processing and counts the
+// attempt. Rows claimed by another worker are skipped.
+func (r *escrowRepository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]*models.Escrow, error) {
+	query := `
+		UPDATE escrows
+		SET status = $1, attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id
+			FROM escrows
+			WHERE status = $2 OR (status = $1 AND updated_at < $3)
+			ORDER BY created_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + escrowColumns
+
+	return r.queryEscrows(ctx, "claim pending escrows", query,
+		models.EscrowStatusProcessing, models.EscrowStatusPending, staleBefore, limit)
+}
+
+// SetPayment records the provider payment authorized for an escrow, or
+// clears it when provider and paymentRef are empty
+func (r *escrowRepository) SetPayment(ctx context.Context, id int, provider, paymentRef string) error {
+	query := `
+		UPDATE escrows
+		SET provider = NULLIF($1, ''), payment_ref = NULLIF($2, ''), updated_at = $3
+		WHERE id = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, provider, paymentRef, time.Now(), id); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to set escrow payment")
+		return fmt.Errorf("failed to set escrow payment: %w", err)
+	}
+
+	return nil
+}
+
+// Transition moves an escrow to status to if it is in one of the from
+// statuses, recording reason (cleared when empty) and the time the funds
+// were captured, released or refunded. A failed escrow moved back to pending
+// starts counting its attempts again. It reports whether the escrow moved.
+func (r *escrowRepository) Transition(ctx context.Context, id int, from []models.EscrowStatus, to models.EscrowStatus, reason string) (bool, error) {
+	query := `
+		UPDATE escrows
+		SET status = $1::VARCHAR,
+			failure_reason = NULLIF($2, ''),
+			attempts = CASE WHEN status = 'failed' AND $1::VARCHAR = 'pending' THEN 0 ELSE attempts END,
+			captured_at = CASE WHEN $1::VARCHAR = 'captured' THEN NOW() ELSE captured_at END,
+			released_at = CASE WHEN $1::VARCHAR = 'released' THEN NOW() ELSE released_at END,
+			refunded_at = CASE WHEN $1::VARCHAR = 'refunded' THEN NOW() ELSE refunded_at END,
+			updated_at = NOW()
+		WHERE id = $3 AND status = ANY($4)`
+
+	statuses := make([]string, len(from))
+	for i, status := range from {
+		statuses[i] = string(status)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, to, reason, id, pq.Array(statuses))
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update escrow status")
+		return false, fmt.Errorf("failed to update escrow status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// RecordWebhookEvent records a provider webhook event and reports whether
+// it is new, so redelivered events are only handled once
+func (r *escrowRepository) RecordWebhookEvent(ctx context.Context, provider, eventID, eventType string) (bool, error) {
+	query := `
+		INSERT INTO payment_webhook_events (provider, event_id, type, received_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, event_id) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, provider, eventID, eventType, time.Now())
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to record webhook event")
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// getOne runs a single-row escrow query, returning nil if there is no row
+func (r *escrowRepository) getOne(ctx context.Context, action, query string, args ...interface{}) (*models.Escrow, error) {
+	escrow, err := scanEscrow(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to " + action)
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	return escrow, nil
+}
+
+// queryEscrows runs a multi-row escrow query and scans the results
+func (r *escrowRepository) queryEscrows(ctx context.Context, action, query string, args ...interface{}) ([]*models.Escrow, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to " + action)
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer rows.Close()
+
+	var escrows []*models.Escrow
+	for rows.Next() {
+		escrow, err := scanEscrow(rows)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan escrow row")
+			return nil, fmt.Errorf("failed to scan escrow row: %w", err)
+		}
+		escrows = append(escrows, escrow)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating escrow rows")
+		return nil, fmt.Errorf("error iterating escrow rows: %w", err)
+	}
+
+	return escrows, nil
+}
+
+// nullStringPtr converts a nullable text column to a *string
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// nullTimePtr converts a nullable timestamp column to a *time.Time
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// exchangeRateRepository implements ExchangeRateRepository interface
+type exchangeRateRepository struct {
+	db DBTX
+}
+
+// NewExchangeRateRepository creates a new exchange rate repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewExchangeRateRepository(db DBTX) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+// List retrieves every exchange rate, ordered by currency
+func (r *exchangeRateRepository) List(ctx context.Context) ([]*models.ExchangeRate, error) {
+	query := `
+		SELECT currency, base_currency, rate::TEXT, updated_at
+		FROM exchange_rates
+		ORDER BY currency`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to list exchange rates")
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*models.ExchangeRate
+	for rows.Next() {
+		rate := &models.ExchangeRate{}
+		if err := rows.Scan(&rate.Currency, &rate.BaseCurrency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan exchange rate row")
+			return nil, fmt.Errorf("failed to scan exchange rate row: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating exchange rate rows")
+		return nil, fmt.Errorf("error iterating exchange rate rows: %w", err)
+	}
+
+	return rates, nil
+}
+
+// ReplaceAll swaps the whole rate table for rates. It must be used with a
+// repository built on a *sql.Tx so readers never see a partial table.
+func (r *exchangeRateRepository) ReplaceAll(ctx context.Context, rates []*models.ExchangeRate) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM exchange_rates"); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to clear exchange rates")
+		return fmt.Errorf("failed to clear exchange rates: %w", err)
+	}
+
+	for _, rate := range rates {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO exchange_rates (currency, base_currency, rate, updated_at)
+			VALUES ($1, $2, $3, $4)`,
+			rate.Currency,
+			rate.BaseCurrency,
+			rate.Rate,
+			rate.UpdatedAt,
+		)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to save exchange rate")
+			return fmt.Errorf("failed to save exchange rate: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -2,9 +2,25 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
 	"bagr-backend/internal/models"
 )
 
+// DBTX is implemented by both *sql.DB and *sql.Tx, so repositories built on it
+// can take part in a caller-managed transaction
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
@@ -20,12 +36,17 @@ type UserRepository interface {
 type AuctionRepository interface {
 	Create(ctx context.Context, auction *models.Auction) error
 	GetByID(ctx context.Context, id int) (*models.Auction, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*models.Auction, error)
 	Update(ctx context.Context, id int, updates map[string]interface{}) error
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, limit, offset int) ([]*models.Auction, error)
 	GetBySellerID(ctx context.Context, sellerID int, limit, offset int) ([]*models.Auction, error)
 	GetActiveAuctions(ctx context.Context, limit, offset int) ([]*models.Auction, error)
-	UpdateCurrentBid(ctx context.Context, auctionID int, bidAmount float64) error
+	UpdateCurrentBid(ctx context.Context, auctionID int, bidAmount models.Money) error
+	ExtendEndTime(ctx context.Context, auctionID int, endTime time.Time) error
+	ActivateScheduled(ctx context.Context, now time.Time) (int64, error)
+	LockEndedAuctions(ctx context.Context, now time.Time, limit int) ([]*models.Auction, error)
+	GetOpenByTrackID(ctx context.Context, trackID int) (*models.Auction, error)
 }
 
 // BidRepository defines the interface for bid data access
@@ -38,6 +59,8 @@ type BidRepository interface {
 	GetByBidderID(ctx context.Context, bidderID int, limit, offset int) ([]*models.Bid, error)
 	GetHighestBidForAuction(ctx context.Context, auctionID int) (*models.Bid, error)
 	GetBidHistory(ctx context.Context, auctionID int) ([]*models.Bid, error)
+	GetProxy(ctx context.Context, auctionID, bidderID int) (*models.ProxyBid, error)
+	UpsertProxy(ctx context.Context, proxy *models.ProxyBid) error
 }
 
 // TrackRepository defines the interface for track data access
@@ -46,15 +69,77 @@ type TrackRepository interface {
 	GetByID(ctx context.Context, id int) (*models.Track, error)
 	Update(ctx context.Context, id int, updates map[string]interface{}) error
 	Delete(ctx context.Context, id int) error
+	Transition(ctx context.Context, id int, from, to models.TrackStatus) (bool, error)
 	List(ctx context.Context, limit, offset int) ([]*models.Track, error)
 	GetByArtistID(ctx context.Context, artistID int, limit, offset int) ([]*models.Track, error)
-	Search(ctx context.Context, query string, limit, offset int) ([]*models.Track, error)
+	Search(ctx context.Context, query string, filter models.TrackSearchFilter, limit, offset int) ([]*models.TrackSearchResult, error)
+}
+
+// MediaUploadRepository defines the interface for direct media upload data access
+type MediaUploadRepository interface {
+	Create(ctx context.Context, upload *models.MediaUpload) error
+	GetByID(ctx context.Context, id int) (*models.MediaUpload, error)
+	Transition(ctx context.Context, id int, from, to models.MediaUploadStatus) (bool, error)
+	MarkCompleted(ctx context.Context, id int, trackID *int) error
+}
+
+// OwnershipRepository defines the interface for track ownership data access
+type OwnershipRepository interface {
+	Create(ctx context.Context, ownership *models.TrackOwnership) error
+	GetByID(ctx context.Context, id int) (*models.TrackOwnership, error)
+	GetByTrackAndOwner(ctx context.Context, trackID, ownerID int) (*models.TrackOwnership, error)
+	ListByOwner(ctx context.Context, ownerID int, limit, offset int) ([]*models.TrackOwnership, error)
+	CreateDownload(ctx context.Context, ownershipID int, tokenHash string, expiresAt time.Time) error
+	RedeemDownload(ctx context.Context, tokenHash string, now time.Time) (*models.TrackOwnership, error)
+}
+
+// EscrowRepository defines the interface for payment escrow data access
+type EscrowRepository interface {
+	Create(ctx context.Context, escrow *models.Escrow) error
+	GetByID(ctx context.Context, id int) (*models.Escrow, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*models.Escrow, error)
+	GetByAuctionID(ctx context.Context, auctionID int) (*models.Escrow, error)
+	GetByPaymentRef(ctx context.Context, provider, paymentRef string) (*models.Escrow, error)
+	ListByUser(ctx context.Context, userID int, limit, offset int) ([]*models.Escrow, error)
+	ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]*models.Escrow, error)
+	SetPayment(ctx context.Context, id int, provider, paymentRef string) error
+	Transition(ctx context.Context, id int, from []models.EscrowStatus, to models.EscrowStatus, reason string) (bool, error)
+	RecordWebhookEvent(ctx context.Context, provider, eventID, eventType string) (bool, error)
+}
+
+// PaymentMethodRepository defines the interface for saved payment method data access
+type PaymentMethodRepository interface {
+	Upsert(ctx context.Context, method *models.PaymentMethod) error
+	GetByUserID(ctx context.Context, userID int) (*models.PaymentMethod, error)
+}
+
+// LedgerRepository defines the interface for double-entry ledger data access
+type LedgerRepository interface {
+	GetOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error
+	LockAccount(ctx context.Context, accountID int) error
+	GetBalance(ctx context.Context, accountID int) (int64, error)
+	CreateEntry(ctx context.Context, entry *models.LedgerEntry) error
+	ListEntriesByEscrow(ctx context.Context, escrowID int) ([]*models.LedgerEntry, error)
+	ListUserBalances(ctx context.Context, userID int, code string) ([]*models.WalletBalance, error)
+	ListUserTransactions(ctx context.Context, userID int, code string, limit, offset int) ([]*models.WalletTransaction, error)
+}
+
+// ExchangeRateRepository defines the interface for display exchange rate data operations
+type ExchangeRateRepository interface {
+	List(ctx context.Context) ([]*models.ExchangeRate, error)
+	ReplaceAll(ctx context.Context, rates []*models.ExchangeRate) error
 }
 
 // Repositories holds all repository interfaces
 type Repositories struct {
-	User    UserRepository
-	Auction AuctionRepository
-	Bid     BidRepository
-	Track   TrackRepository
+	User          UserRepository
+	Auction       AuctionRepository
+	Bid           BidRepository
+	Track         TrackRepository
+	MediaUpload   MediaUploadRepository
+	Ownership     OwnershipRepository
+	Escrow        EscrowRepository
+	PaymentMethod PaymentMethodRepository
+	Ledger        LedgerRepository
+	ExchangeRate  ExchangeRateRepository
 }
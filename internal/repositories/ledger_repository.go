@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// ledgerRepository implements LedgerRepository interface
+type ledgerRepository struct {
+	db DBTX
+}
+
+// NewLedgerRepository creates a new ledger repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewLedgerRepository(db DBTX) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+// GetOrCreateAccount fills in the ID of the account with the given code,
+// user and currency, creating the account on first use
+func (r *ledgerRepository) GetOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error {
+	query := `
+		INSERT INTO ledger_accounts (code, type, user_id, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (code, COALESCE(user_id, 0), currency) DO UPDATE SET code = EXCLUDED.code
+		RETURNING id, type, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		account.Code,
+		account.Type,
+		account.UserID,
+		account.Currency,
+		time.Now(),
+	).Scan(&account.ID, &account.Type, &account.CreatedAt)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to get ledger account")
+		return fmt.Errorf("failed to get ledger account: %w", err)
+	}
+
+	return nil
+}
+
+// LockAccount locks an account's row until the surrounding transaction
+// ends, so its balance cannot change between reading and posting to it.
+// It must be used with a repository built on a *sql.Tx.
+func (r *ledgerRepository) LockAccount(ctx context.Context, accountID int) error {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM ledger_accounts WHERE id = $1 FOR UPDATE", accountID).Scan(&id)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to lock ledger account")
+		return fmt.Errorf("failed to lock ledger account: %w", err)
+	}
+
+	return nil
+}
+
+// GetBalance returns the sum of an account's postings: positive for a
+// debit balance, negative for a credit balance
+func (r *ledgerRepository) GetBalance(ctx context.Context, accountID int) (int64, error) {
+	var balance int64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM ledger_postings
+		WHERE account_id = $1`, accountID).Scan(&balance)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to get ledger balance")
+		return 0, fmt.Errorf("failed to get ledger balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// CreateEntry records a journal entry and its postings. The database
+// rejects the transaction at commit if the postings do not balance.
+func (r *ledgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) error {
+	query := `
+		INSERT INTO ledger_entries (kind, reference, description, escrow_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	entry.CreatedAt = time.Now()
+
+	err := r.db.QueryRowContext(ctx, query,
+		entry.Kind,
+		entry.Reference,
+		entry.Description,
+		entry.EscrowID,
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create ledger entry")
+		return fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+
+	for _, posting := range entry.Postings {
+		posting.EntryID = entry.ID
+		err := r.db.QueryRowContext(ctx, `
+			INSERT INTO ledger_postings (entry_id, account_id, amount, currency)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id`,
+			posting.EntryID,
+			posting.AccountID,
+			posting.Amount.Cents,
+			posting.Currency,
+		).Scan(&posting.ID)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to create ledger posting")
+			return fmt.Errorf("failed to create ledger posting: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListEntriesByEscrow retrieves the entries recorded for an escrow, with
+// their postings, oldest first
+func (r *ledgerRepository) ListEntriesByEscrow(ctx context.Context, escrowID int) ([]*models.LedgerEntry, error) {
+	query := `
+		SELECT e.id, e.kind, e.reference, e.description, e.escrow_id, e.created_at,
+			p.id, p.account_id, p.amount, p.currency
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.entry_id = e.id
+		WHERE e.escrow_id = $1
+		ORDER BY e.id, p.id`
+
+	rows, err := r.db.QueryContext(ctx, query, escrowID)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to list ledger entries")
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.LedgerEntry
+	for rows.Next() {
+		entry := &models.LedgerEntry{}
+		posting := &models.LedgerPosting{}
+		var entryEscrowID sql.NullInt64
+		var amount int64
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Kind,
+			&entry.Reference,
+			&entry.Description,
+			&entryEscrowID,
+			&entry.CreatedAt,
+			&posting.ID,
+			&posting.AccountID,
+			&amount,
+			&posting.Currency,
+		)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan ledger entry row")
+			return nil, fmt.Errorf("failed to scan ledger entry row: %w", err)
+		}
+
+		posting.Amount = models.NewMoney(amount, posting.Currency)
+
+		if len(entries) == 0 || entries[len(entries)-1].ID != entry.ID {
+			entry.EscrowID = nullIntPtr(entryEscrowID)
+			entries = append(entries, entry)
+		}
+		current := entries[len(entries)-1]
+		posting.EntryID = current.ID
+		current.Postings = append(current.Postings, posting)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating ledger entry rows")
+		return nil, fmt.Errorf("error iterating ledger entry rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ListUserBalances returns the credit balance of each of a user's accounts
+// with the given code, one per currency
+func (r *ledgerRepository) ListUserBalances(ctx context.Context, userID int, code string) ([]*models.WalletBalance, error) {
+	query := `
+		SELECT a.currency, COALESCE(-SUM(p.amount), 0)
+		FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		WHERE a.user_id = $1 AND a.code = $2
+		GROUP BY a.currency
+		ORDER BY a.currency`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, code)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to list ledger balances")
+		return nil, fmt.Errorf("failed to list ledger balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []*models.WalletBalance
+	for rows.Next() {
+		balance := &models.WalletBalance{}
+		var cents int64
+		if err := rows.Scan(&balance.Currency, &cents); err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan ledger balance row")
+			return nil, fmt.Errorf("failed to scan ledger balance row: %w", err)
+		}
+		balance.Balance = models.NewMoney(cents, balance.Currency)
+		balances = append(balances, balance)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating ledger balance rows")
+		return nil, fmt.Errorf("error iterating ledger balance rows: %w", err)
+	}
+
+	return balances, nil
+}
+
+// ListUserTransactions returns the postings to a user's accounts with the
+// given code, newest first, as credits to the user
+func (r *ledgerRepository) ListUserTransactions(ctx context.Context, userID int, code string, limit, offset int) ([]*models.WalletTransaction, error) {
+	query := `
+		SELECT e.id, e.kind, e.description, e.escrow_id, -p.amount, p.currency, e.created_at
+		FROM ledger_postings p
+		JOIN ledger_accounts a ON a.id = p.account_id
+		JOIN ledger_entries e ON e.id = p.entry_id
+		WHERE a.user_id = $1 AND a.code = $2
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, code, limit, offset)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to list ledger transactions")
+		return nil, fmt.Errorf("failed to list ledger transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*models.WalletTransaction
+	for rows.Next() {
+		transaction := &models.WalletTransaction{}
+		var escrowID sql.NullInt64
+		var amount int64
+		err := rows.Scan(
+			&transaction.EntryID,
+			&transaction.Kind,
+			&transaction.Description,
+			&escrowID,
+			&amount,
+			&transaction.Currency,
+			&transaction.CreatedAt,
+		)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan ledger transaction row")
+			return nil, fmt.Errorf("failed to scan ledger transaction row: %w", err)
+		}
+		transaction.EscrowID = nullIntPtr(escrowID)
+		transaction.Amount = models.NewMoney(amount, transaction.Currency)
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating ledger transaction rows")
+		return nil, fmt.Errorf("error iterating ledger transaction rows: %w", err)
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"bagr-backend/internal/models"
+	_ "github.com/lib/pq"
+)
+
+// ledgerTestTx returns a transaction on the migrated database named by
+// TEST_DATABASE_URL, rolled back when the test ends. Tests needing it are
+// skipped when the variable is unset.
+func ledgerTestTx(t *testing.T) *sql.Tx {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		t.Fatalf("begin: %v", err)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+		db.Close()
+	})
+	return tx
+}
+
+// createTestEntry records a charge of cents between platform cash and escrow
+// holding, without the service's balance check. Adding imbalance to the
+// holding posting makes the entry unbalanced.
+func createTestEntry(t *testing.T, repo LedgerRepository, reference string, cents, imbalance int64) *models.LedgerEntry {
+	t.Helper()
+	ctx := context.Background()
+	cash := &models.LedgerAccount{Code: models.LedgerAccountPlatformCash, Type: models.LedgerAccountTypeAsset, Currency: "USD"}
+	holding := &models.LedgerAccount{Code: models.LedgerAccountEscrowHolding, Type: models.LedgerAccountTypeLiability, Currency: "USD"}
+	for _, account := range []*models.LedgerAccount{cash, holding} {
+		if err := repo.GetOrCreateAccount(ctx, account); err != nil {
+			t.Fatalf("GetOrCreateAccount: %v", err)
+		}
+	}
+
+	entry := &models.LedgerEntry{
+		Kind:        models.LedgerEntryKindCharge,
+		Reference:   reference,
+		Description: "Ledger test charge",
+		Postings: []*models.LedgerPosting{
+			{AccountID: cash.ID, Amount: models.NewMoney(cents, "USD"), Currency: "USD"},
+			{AccountID: holding.ID, Amount: models.NewMoney(-cents+imbalance, "USD"), Currency: "USD"},
+		},
+	}
+	if err := repo.CreateEntry(ctx, entry); err != nil {
+		t.Fatalf("CreateEntry: %v", err)
+	}
+	return entry
+}
+
+// execRefused runs query in a savepoint, so the transaction survives the
+// failure, and fails the test unless the database refuses it with an error
+// containing want
+func execRefused(t *testing.T, tx *sql.Tx, want, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := tx.Exec("SAVEPOINT refused"); err != nil {
+		t.Fatalf("savepoint: %v", err)
+	}
+	_, err := tx.Exec(query, args...)
+	if _, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT refused"); rollbackErr != nil {
+		t.Fatalf("rollback to savepoint: %v", rollbackErr)
+	}
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("%s: got %v, want an error containing %q", query, err, want)
+	}
+}
+
+func TestLedgerIsAppendOnly(t *testing.T) {
+	tx := ledgerTestTx(t)
+	repo := NewLedgerRepository(tx)
+	entry := createTestEntry(t, repo, "test:append-only", 500, 0)
+
+	statements := []string{
+		"UPDATE ledger_entries SET description = 'changed' WHERE id = $1",
+		"DELETE FROM ledger_entries WHERE id = $1",
+		"UPDATE ledger_postings SET amount = amount * 2 WHERE entry_id = $1",
+		"DELETE FROM ledger_postings WHERE entry_id = $1",
+	}
+	for _, statement := range statements {
+		execRefused(t, tx, "append-only", statement, entry.ID)
+	}
+
+	// The entry is untouched
+	balance, err := repo.GetBalance(context.Background(), entry.Postings[0].AccountID)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance < 500 {
+		t.Errorf("platform cash balance = %d, want the 500 charged still there", balance)
+	}
+}
+
+func TestLedgerEntryMustBalance(t *testing.T) {
+	tx := ledgerTestTx(t)
+	repo := NewLedgerRepository(tx)
+
+	// The check is deferred to commit; running it now checks both entries
+	createTestEntry(t, repo, "test:balanced", 500, 0)
+	if _, err := tx.Exec("SET CONSTRAINTS ledger_entry_balanced IMMEDIATE"); err != nil {
+		t.Fatalf("balanced entry refused: %v", err)
+	}
+	if _, err := tx.Exec("SET CONSTRAINTS ledger_entry_balanced DEFERRED"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.Exec("SAVEPOINT unbalanced"); err != nil {
+		t.Fatal(err)
+	}
+	createTestEntry(t, repo, "test:unbalanced", 500, 1)
+	_, err := tx.Exec("SET CONSTRAINTS ledger_entry_balanced IMMEDIATE")
+	if err == nil || !strings.Contains(err.Error(), "does not balance") {
+		t.Errorf("unbalanced entry: got %v, want it refused", err)
+	}
+	if _, err := tx.Exec("ROLLBACK TO SAVEPOINT unbalanced"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLedgerUserBalancesAreMoney(t *testing.T) {
+	tx := ledgerTestTx(t)
+	repo := NewLedgerRepository(tx)
+	ctx := context.Background()
+
+	var userID int
+	err := tx.QueryRow(`
+		INSERT INTO users (email, username, first_name, last_name, password_hash, role)
+		VALUES ('ledger-test@example.com', 'ledgertest', 'Ledger', 'Test', 'x', 'artist')
+		RETURNING id`).Scan(&userID)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	cash := &models.LedgerAccount{Code: models.LedgerAccountPlatformCash, Type: models.LedgerAccountTypeAsset, Currency: "USD"}
+	payable := &models.LedgerAccount{Code: models.LedgerAccountSellerPayable, Type: models.LedgerAccountTypeLiability, UserID: &userID, Currency: "USD"}
+	for _, account := range []*models.LedgerAccount{cash, payable} {
+		if err := repo.GetOrCreateAccount(ctx, account); err != nil {
+			t.Fatalf("GetOrCreateAccount: %v", err)
+		}
+	}
+	entry := &models.LedgerEntry{
+		Kind:        models.LedgerEntryKindRelease,
+		Reference:   "test:earnings",
+		Description: "Ledger test earnings",
+		Postings: []*models.LedgerPosting{
+			{AccountID: cash.ID, Amount: models.NewMoney(1250, "USD"), Currency: "USD"},
+			{AccountID: payable.ID, Amount: models.NewMoney(-1250, "USD"), Currency: "USD"},
+		},
+	}
+	if err := repo.CreateEntry(ctx, entry); err != nil {
+		t.Fatalf("CreateEntry: %v", err)
+	}
+
+	balances, err := repo.ListUserBalances(ctx, userID, models.LedgerAccountSellerPayable)
+	if err != nil {
+		t.Fatalf("ListUserBalances: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Balance != models.NewMoney(1250, "USD") {
+		t.Errorf("balances = %+v, want 12.50 USD", balances)
+	}
+
+	transactions, err := repo.ListUserTransactions(ctx, userID, models.LedgerAccountSellerPayable, 10, 0)
+	if err != nil {
+		t.Fatalf("ListUserTransactions: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Amount != models.NewMoney(1250, "USD") {
+		t.Errorf("transactions = %+v, want 12.50 USD earned", transactions)
+	}
+}
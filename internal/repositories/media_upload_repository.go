@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// mediaUploadRepository implements MediaUploadRepository interface
+type mediaUploadRepository struct {
+	db DBTX
+}
+
+// NewMediaUploadRepository creates a new media upload repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewMediaUploadRepository(db DBTX) MediaUploadRepository {
+	return &mediaUploadRepository{db: db}
+}
+
+// Create records a newly issued upload
+func (r *mediaUploadRepository) Create(ctx context.Context, upload *models.MediaUpload) error {
+	query := `
+		INSERT INTO media_uploads (user_id, kind, object_key, content_type, size_bytes, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	now := time.Now()
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+	if upload.Status == "" {
+		upload.Status = models.MediaUploadStatusPending
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		upload.UserID,
+		upload.Kind,
+		upload.ObjectKey,
+		upload.ContentType,
+		upload.Size,
+		upload.Status,
+		upload.ExpiresAt,
+		upload.CreatedAt,
+		upload.UpdatedAt,
+	).Scan(&upload.ID)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create media upload")
+		return fmt.Errorf("failed to create media upload: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an upload by ID
+func (r *mediaUploadRepository) GetByID(ctx context.Context, id int) (*models.MediaUpload, error) {
+	query := `
+		SELECT id, user_id, kind, object_key, content_type, size_bytes, status, track_id,
+		       expires_at, completed_at, created_at, updated_at
+		FROM media_uploads
+		WHERE id = $1`
+
+	upload := &models.MediaUpload{}
+	var trackID sql.NullInt64
+	var completedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&upload.ID,
+		&upload.UserID,
+		&upload.Kind,
+		&upload.ObjectKey,
+		&upload.ContentType,
+		&upload.Size,
+		&upload.Status,
+		&trackID,
+		&upload.ExpiresAt,
+		&completedAt,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get media upload by ID")
+		return nil, fmt.Errorf("failed to get media upload by ID: %w", err)
+	}
+
+	upload.TrackID = nullIntPtr(trackID)
+	if completedAt.Valid {
+		upload.CompletedAt = &completedAt.Time
+	}
+
+	return upload, nil
+}
+
+// Transition moves an upload from one status to another and reports whether
+// it was in the expected status. Concurrent completions of the same upload
+// race on this, so only one of them proceeds.
+func (r *mediaUploadRepository) Transition(ctx context.Context, id int, from, to models.MediaUploadStatus) (bool, error) {
+	query := `
+		UPDATE media_uploads
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	result, err := r.db.ExecContext(ctx, query, to, time.Now(), id, from)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update media upload status")
+		return false, fmt.Errorf("failed to update media upload status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// MarkCompleted marks a processing upload completed, recording the track
+// created from it if any
+func (r *mediaUploadRepository) MarkCompleted(ctx context.Context, id int, trackID *int) error {
+	query := `
+		UPDATE media_uploads
+		SET status = $1, track_id = $2, completed_at = $3, updated_at = $3
+		WHERE id = $4 AND status = $5`
+
+	result, err := r.db.ExecContext(ctx, query,
+		models.MediaUploadStatusCompleted, trackID, time.Now(), id, models.MediaUploadStatusProcessing)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to complete media upload")
+		return fmt.Errorf("failed to complete media upload: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("media upload not processing")
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+
+	"github.com/lib/pq"
+)
+
+// ownershipColumns is the column list shared by every track ownership SELECT
+const ownershipColumns = `id, track_id, auction_id, bid_id, owner_id, seller_id, price, currency, acquired_at`
+
+// ownershipRepository implements OwnershipRepository interface
+type ownershipRepository struct {
+	db DBTX
+}
+
+// NewOwnershipRepository creates a new track ownership repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewOwnershipRepository(db DBTX) OwnershipRepository {
+	return &ownershipRepository{db: db}
+}
+
+// scanOwnership scans a single track ownership row
+func scanOwnership(row rowScanner) (*models.TrackOwnership, error) {
+	ownership := &models.TrackOwnership{}
+	err := row.Scan(
+		&ownership.ID,
+		&ownership.TrackID,
+		&ownership.AuctionID,
+		&ownership.BidID,
+		&ownership.OwnerID,
+		&ownership.SellerID,
+		&ownership.Price,
+		&ownership.Currency,
+		&ownership.AcquiredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ownership.Price.Currency = ownership.Currency
+	return ownership, nil
+}
+
+// Create records a track's transfer to an auction winner
+func (r *ownershipRepository) Create(ctx context.Context, ownership *models.TrackOwnership) error {
+	query := `
+		INSERT INTO track_ownerships (track_id, auction_id, bid_id, owner_id, seller_id, price, currency, acquired_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	if ownership.AcquiredAt.IsZero() {
+		ownership.AcquiredAt = time.Now()
+	}
+	if ownership.Currency == "" {
+		ownership.Currency = models.DefaultCurrency
+	}
+	ownership.Price.Currency = ownership.Currency
+
+	err := r.db.QueryRowContext(ctx, query,
+		ownership.TrackID,
+		ownership.AuctionID,
+		ownership.BidID,
+		ownership.OwnerID,
+		ownership.SellerID,
+		ownership.Price,
+		ownership.Currency,
+		ownership.AcquiredAt,
+	).Scan(&ownership.ID)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create track ownership")
+		return fmt.Errorf("failed to create track ownership: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a track ownership by ID
+func (r *ownershipRepository) GetByID(ctx context.Context, id int) (*models.TrackOwnership, error) {
+	query := `
+		SELECT ` + ownershipColumns + `
+		FROM track_ownerships
+		WHERE id = $1`
+
+	ownership, err := scanOwnership(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get track ownership by ID")
+		return nil, fmt.Errorf("failed to get track ownership by ID: %w", err)
+	}
+
+	return ownership, nil
+}
+
+// GetByTrackAndOwner retrieves the latest ownership of a track held by a user
+func (r *ownershipRepository) GetByTrackAndOwner(ctx context.Context, trackID, ownerID int) (*models.TrackOwnership, error) {
+	query := `
+		SELECT ` + ownershipColumns + `
+		FROM track_ownerships
+		WHERE track_id = $1 AND owner_id = $2
+		ORDER BY acquired_at DESC
+		LIMIT 1`
+
+	ownership, err := scanOwnership(r.db.QueryRowContext(ctx, query, trackID, ownerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get track ownership by owner")
+		return nil, fmt.Errorf("failed to get track ownership by owner: %w", err)
+	}
+
+	return ownership, nil
+}
+
+// ListByOwner retrieves a user's track ownerships with their tracks, most recent first
+func (r *ownershipRepository) ListByOwner(ctx context.Context, ownerID int, limit, offset int) ([]*models.TrackOwnership, error) {
+	query := `
+		SELECT ` + ownershipColumns + `
+		FROM track_ownerships
+		WHERE owner_id = $1
+		ORDER BY acquired_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, ownerID, limit, offset)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to list track ownerships")
+		return nil, fmt.Errorf("failed to list track ownerships: %w", err)
+	}
+	defer rows.Close()
+
+	var ownerships []*models.TrackOwnership
+	var trackIDs []int64
+	for rows.Next() {
+		ownership, err := scanOwnership(rows)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan track ownership row")
+			return nil, fmt.Errorf("failed to scan track ownership row: %w", err)
+		}
+		ownerships = append(ownerships, ownership)
+		trackIDs = append(trackIDs, int64(ownership.TrackID))
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating track ownership rows")
+		return nil, fmt.Errorf("error iterating track ownership rows: %w", err)
+	}
+
+	if len(ownerships) == 0 {
+		return ownerships, nil
+	}
+
+	trackRepo := &trackRepository{db: r.db}
+	tracks, err := trackRepo.queryTracks(ctx, "get purchased tracks", `
+		SELECT `+trackColumns+`
+		FROM tracks
+		WHERE id = ANY($1)`, pq.Array(trackIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Track, len(tracks))
+	for _, track := range tracks {
+		byID[track.ID] = track
+	}
+	for _, ownership := range ownerships {
+		ownership.Track = byID[ownership.TrackID]
+	}
+
+	return ownerships, nil
+}
+
+// CreateDownload records a one-time download link by the hash of its token
+func (r *ownershipRepository) CreateDownload(ctx context.Context, ownershipID int, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO track_downloads (ownership_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)`
+
+	if _, err := r.db.ExecContext(ctx, query, ownershipID, tokenHash, expiresAt, time.Now()); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create track download")
+		return fmt.Errorf("failed to create track download: %w", err)
+	}
+
+	return nil
+}
+
+// RedeemDownload marks an unused, unexpired download link as used and
+// returns the ownership it was issued for, or nil if the link cannot be
+// redeemed. A link is only ever redeemed once, even by concurrent requests.
+func (r *ownershipRepository) RedeemDownload(ctx context.Context, tokenHash string, now time.Time) (*models.TrackOwnership, error) {
+	query := `
+		WITH redeemed AS (
+			UPDATE track_downloads
+			SET used_at = $2
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+			RETURNING ownership_id
+		)
+		SELECT ` + ownershipColumns + `
+		FROM track_ownerships
+		WHERE id = (SELECT ownership_id FROM redeemed)`
+
+	ownership, err := scanOwnership(r.db.QueryRowContext(ctx, query, tokenHash, now))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to redeem track download")
+		return nil, fmt.Errorf("failed to redeem track download: %w", err)
+	}
+
+	return ownership, nil
+}
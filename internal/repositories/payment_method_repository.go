@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+)
+
+// paymentMethodRepository implements PaymentMethodRepository interface
+type paymentMethodRepository struct {
+	db DBTX
+}
+
+// NewPaymentMethodRepository creates a new payment method repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewPaymentMethodRepository(db DBTX) PaymentMethodRepository {
+	return &paymentMethodRepository{db: db}
+}
+
+// Upsert saves a user's payment method, replacing any previous one
+func (r *paymentMethodRepository) Upsert(ctx context.Context, method *models.PaymentMethod) error {
+	query := `
+		INSERT INTO payment_methods (user_id, provider, method_ref, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET provider = EXCLUDED.provider, method_ref = EXCLUDED.method_ref, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, method.UserID, method.Provider, method.MethodRef, time.Now()).
+		Scan(&method.CreatedAt, &method.UpdatedAt)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to save payment method")
+		return fmt.Errorf("failed to save payment method: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID retrieves a user's payment method
+func (r *paymentMethodRepository) GetByUserID(ctx context.Context, userID int) (*models.PaymentMethod, error) {
+	query := `
+		SELECT user_id, provider, method_ref, created_at, updated_at
+		FROM payment_methods
+		WHERE user_id = $1`
+
+	method := &models.PaymentMethod{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&method.UserID,
+		&method.Provider,
+		&method.MethodRef,
+		&method.CreatedAt,
+		&method.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get payment method")
+		return nil, fmt.Errorf("failed to get payment method: %w", err)
+	}
+
+	return method, nil
+}
@@ -0,0 +1,361 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/utils"
+
+	"github.com/lib/pq"
+)
+
+// trackColumns is the column list shared by every track SELECT
+const trackColumns = `id, artist_id, title, COALESCE(genre, ''), COALESCE(duration, 0),
+		COALESCE(file_url, ''), cover_art_url, description, status, created_at, updated_at,
+		content_type, bitrate, sample_rate, channels`
+
+// trackRepository implements TrackRepository interface
+type trackRepository struct {
+	db DBTX
+}
+
+// NewTrackRepository creates a new track repository.
+// Pass a *sql.Tx instead of the *sql.DB to run its queries inside a transaction.
+func NewTrackRepository(db DBTX) TrackRepository {
+	return &trackRepository{db: db}
+}
+
+// scanTrack scans a single track row, followed by any extra destinations
+func scanTrack(row rowScanner, extra ...interface{}) (*models.Track, error) {
+	track := &models.Track{}
+	var coverArtURL, description, contentType sql.NullString
+	var bitrate, sampleRate, channels sql.NullInt64
+
+	dest := []interface{}{
+		&track.ID,
+		&track.ArtistID,
+		&track.Title,
+		&track.Genre,
+		&track.Duration,
+		&track.FileURL,
+		&coverArtURL,
+		&description,
+		&track.Status,
+		&track.CreatedAt,
+		&track.UpdatedAt,
+		&contentType,
+		&bitrate,
+		&sampleRate,
+		&channels,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if coverArtURL.Valid {
+		track.CoverArtURL = &coverArtURL.String
+	}
+	if description.Valid {
+		track.Description = &description.String
+	}
+	if contentType.Valid {
+		track.ContentType = &contentType.String
+	}
+	track.Bitrate = nullIntPtr(bitrate)
+	track.SampleRate = nullIntPtr(sampleRate)
+	track.Channels = nullIntPtr(channels)
+
+	return track, nil
+}
+
+// nullIntPtr converts a nullable integer column to an *int
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+// Create creates a new track
+func (r *trackRepository) Create(ctx context.Context, track *models.Track) error {
+	query := `
+		INSERT INTO tracks (artist_id, title, genre, duration, file_url, cover_art_url, description, status,
+		                    content_type, bitrate, sample_rate, channels, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id`
+
+	now := time.Now()
+	track.CreatedAt = now
+	track.UpdatedAt = now
+	if track.Status == "" {
+		track.Status = models.TrackStatusDraft
+	}
+
+	err := r.db.QueryRowContext(ctx, query,
+		track.ArtistID,
+		track.Title,
+		track.Genre,
+		track.Duration,
+		track.FileURL,
+		track.CoverArtURL,
+		track.Description,
+		track.Status,
+		track.ContentType,
+		track.Bitrate,
+		track.SampleRate,
+		track.Channels,
+		track.CreatedAt,
+		track.UpdatedAt,
+	).Scan(&track.ID)
+
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create track")
+		return fmt.Errorf("failed to create track: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a track by ID
+func (r *trackRepository) GetByID(ctx context.Context, id int) (*models.Track, error) {
+	query := `
+		SELECT ` + trackColumns + `
+		FROM tracks
+		WHERE id = $1`
+
+	track, err := scanTrack(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		utils.GetLogger().WithError(err).Error("Failed to get track by ID")
+		return nil, fmt.Errorf("failed to get track by ID: %w", err)
+	}
+
+	return track, nil
+}
+
+// Update updates a track
+func (r *trackRepository) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	// Build dynamic query
+	setParts := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)+1)
+	argIndex := 1
+
+	for field, value := range updates {
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argIndex))
+		args = append(args, value)
+		argIndex++
+	}
+
+	// Add updated_at
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argIndex))
+	args = append(args, time.Now())
+	argIndex++
+
+	// Add ID for WHERE clause
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE tracks
+		SET %s
+		WHERE id = $%d`,
+		strings.Join(setParts, ", "),
+		argIndex,
+	)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update track")
+		return fmt.Errorf("failed to update track: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("track not found")
+	}
+
+	return nil
+}
+
+// Delete deletes a track (soft delete by setting status to deleted)
+func (r *trackRepository) Delete(ctx context.Context, id int) error {
+	query := `
+		UPDATE tracks
+		SET status = $1, updated_at = $2
+		WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, models.TrackStatusDeleted, time.Now(), id)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to delete track")
+		return fmt.Errorf("failed to delete track: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("track not found")
+	}
+
+	return nil
+}
+
+// Transition moves a track from one status to another and reports whether
+// it was in the expected status, so a concurrent sale or delete wins over it
+func (r *trackRepository) Transition(ctx context.Context, id int, from, to models.TrackStatus) (bool, error) {
+	query := `
+		UPDATE tracks
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4`
+
+	result, err := r.db.ExecContext(ctx, query, to, time.Now(), id, from)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to update track status")
+		return false, fmt.Errorf("failed to update track status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// List retrieves a list of tracks with pagination
+func (r *trackRepository) List(ctx context.Context, limit, offset int) ([]*models.Track, error) {
+	query := `
+		SELECT ` + trackColumns + `
+		FROM tracks
+		WHERE status != $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.queryTracks(ctx, "list tracks", query, models.TrackStatusDeleted, limit, offset)
+}
+
+// GetByArtistID retrieves an artist's tracks with pagination
+func (r *trackRepository) GetByArtistID(ctx context.Context, artistID int, limit, offset int) ([]*models.Track, error) {
+	query := `
+		SELECT ` + trackColumns + `
+		FROM tracks
+		WHERE artist_id = $1 AND status != $2
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	return r.queryTracks(ctx, "get tracks by artist ID", query, artistID, models.TrackStatusDeleted, limit, offset)
+}
+
+// Search runs a full-text search over active tracks' title, genre and description.
+// Every word of the query must match as a prefix, so partially typed words
+// still find results. Results are ordered by relevance, scored from 0 to 1
+// with title matches weighted above genre and description matches.
+func (r *trackRepository) Search(ctx context.Context, query string, filter models.TrackSearchFilter, limit, offset int) ([]*models.TrackSearchResult, error) {
+	tsQuery := buildPrefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, nil
+	}
+
+	genres := make([]string, 0, len(filter.Genres))
+	for _, genre := range filter.Genres {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			genres = append(genres, strings.ToLower(genre))
+		}
+	}
+
+	sqlQuery := `
+		SELECT ` + trackColumns + `, ts_rank_cd(search_vector, query, 32) AS score
+		FROM tracks, to_tsquery('english', $1) query
+		WHERE status = $2
+		  AND search_vector @@ query
+		  AND (cardinality($3::text[]) = 0 OR lower(genre) = ANY($3))
+		ORDER BY score DESC, created_at DESC, id DESC
+		LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, tsQuery, models.TrackStatusActive, pq.Array(genres), limit, offset)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to search tracks")
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*models.TrackSearchResult
+	for rows.Next() {
+		var score float64
+		track, err := scanTrack(rows, &score)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan track search row")
+			return nil, fmt.Errorf("failed to scan track search row: %w", err)
+		}
+		results = append(results, &models.TrackSearchResult{Track: track, Score: score})
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating track search rows")
+		return nil, fmt.Errorf("error iterating track search rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// buildPrefixTSQuery turns free text into a to_tsquery expression that ANDs
+// together a prefix match of every word. Anything but letters and digits
+// is treated as a separator, so user input cannot inject tsquery operators.
+func buildPrefixTSQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// queryTracks runs a multi-row track query and scans the results
+func (r *trackRepository) queryTracks(ctx context.Context, action, query string, args ...interface{}) ([]*models.Track, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to " + action)
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer rows.Close()
+
+	var tracks []*models.Track
+	for rows.Next() {
+		track, err := scanTrack(rows)
+		if err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to scan track row")
+			return nil, fmt.Errorf("failed to scan track row: %w", err)
+		}
+		tracks = append(tracks, track)
+	}
+
+	if err = rows.Err(); err != nil {
+		utils.GetLogger().WithError(err).Error("Error iterating track rows")
+		return nil, fmt.Errorf("error iterating track rows: %w", err)
+	}
+
+	return tracks, nil
+}
@@ -1,12 +1,15 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"bagr-backend/internal/auth"
+	"bagr-backend/internal/controllers"
 	"bagr-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -41,6 +44,11 @@ func JWTMiddleware() gin.HandlerFunc {
 
 		// Validate token
 		claims, err := jwtService.(*auth.JWTService).ValidateAccessToken(tokenString)
+		if errors.Is(err, auth.ErrTokenRevoked) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "TOKEN_REVOKED", "Token has been revoked", "Log in again to continue")
+			c.Abort()
+			return
+		}
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired token", err.Error())
 			c.Abort()
@@ -50,7 +58,8 @@ func JWTMiddleware() gin.HandlerFunc {
 		// Set user information in context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		c.Set("user_role", string(claims.Role))
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
@@ -73,27 +82,51 @@ func OptionalJWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Get JWT service from context
-		jwtService, exists := c.Get("jwt_service")
-		if !exists {
-			c.Next()
-			return
-		}
+		setOptionalClaims(c, tokenString)
+		c.Next()
+	}
+}
 
-		// Validate token
-		claims, err := jwtService.(*auth.JWTService).ValidateAccessToken(tokenString)
-		if err != nil {
-			c.Next()
-			return
+// LiveFeedJWTMiddleware is OptionalJWTMiddleware for the live auction feed.
+// Browsers can't set headers on EventSource or WebSocket requests, so the
+// access token may also come in the access_token query parameter or, for
+// WebSockets, as a "bearer.<token>" subprotocol.
+func LiveFeedJWTMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			tokenString = c.Query(controllers.LiveTokenQueryParam)
 		}
+		if tokenString == "" {
+			tokenString = controllers.LiveSubprotocolToken(c.Request)
+		}
+		if tokenString != "" {
+			setOptionalClaims(c, tokenString)
+		}
+		c.Next()
+	}
+}
 
-		// Set user information in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+// setOptionalClaims sets the user of a valid access token in the context,
+// leaving the request anonymous when the token is invalid
+func setOptionalClaims(c *gin.Context, tokenString string) {
+	// Get JWT service from context
+	jwtService, exists := c.Get("jwt_service")
+	if !exists {
+		return
+	}
 
-		c.Next()
+	// Validate token
+	claims, err := jwtService.(*auth.JWTService).ValidateAccessToken(tokenString)
+	if err != nil {
+		return
 	}
+
+	// Set user information in context
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_role", string(claims.Role))
+	c.Set("session_id", claims.SessionID)
 }
 
 // RoleMiddleware checks if user has required role
@@ -164,14 +197,15 @@ func MultipleRoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
 	}
 }
 
-// LoggerMiddleware logs HTTP requests
+// LoggerMiddleware logs HTTP requests. Access tokens passed in the query
+// string are redacted.
 func LoggerMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
 			param.ClientIP,
 			param.TimeStamp.Format(time.RFC1123),
 			param.Method,
-			param.Path,
+			redactQueryToken(param.Path),
 			param.Request.Proto,
 			param.StatusCode,
 			param.Latency,
@@ -181,15 +215,37 @@ func LoggerMiddleware() gin.HandlerFunc {
 	})
 }
 
+// redactQueryToken hides the access token of a logged path and query
+func redactQueryToken(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil || !query.Has(controllers.LiveTokenQueryParam) {
+		return path
+	}
+	query.Set(controllers.LiveTokenQueryParam, "REDACTED")
+	return base + "?" + query.Encode()
+}
+
 // RecoveryMiddleware recovers from panics
 func RecoveryMiddleware() gin.HandlerFunc {
 	return gin.Recovery()
 }
 
-// CORSMiddleware handles CORS
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware handles CORS. Requests from allowedOrigins are allowed, or
+// from any origin when there are none.
+func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
+		if len(allowedOrigins) == 0 {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); controllers.OriginAllowed(origin, allowedOrigins) {
+				c.Header("Access-Control-Allow-Origin", origin)
+			}
+		}
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 		c.Header("Access-Control-Allow-Credentials", "true")
@@ -4,6 +4,7 @@ import (
 	"bagr-backend/internal/auth"
 	"bagr-backend/internal/controllers"
 	"bagr-backend/internal/handlers"
+	"bagr-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +15,25 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 	router.GET("/health", controllers.Health.Health)
 	router.GET("/ready", controllers.Health.Ready)
 
+	// Public keys other services verify access tokens with
+	router.GET("/.well-known/jwks.json", controllers.Auth.JWKS)
+
+	// Local disk storage (only with the "local" storage driver). Public
+	// objects are static files; uploads and private downloads need a signed URL.
+	if controllers.Storage != nil {
+		storage := router.Group("/storage")
+		{
+			storage.Static("/public", controllers.Storage.PublicDir())
+			storage.PUT("/upload/*key", controllers.Storage.Upload)
+			storage.GET("/private/*key", controllers.Storage.Download)
+		}
+	}
+
+	// Mock login provider (only with oidc.mock)
+	if controllers.MockOIDC != nil {
+		router.Any("/mock-oidc/*path", gin.WrapH(controllers.MockOIDC))
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
@@ -27,9 +47,44 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 			auth.GET("/reset-password", controllers.Auth.ResetPasswordPage)
 			auth.POST("/reset-password", controllers.Auth.ResetPassword)
 			auth.POST("/refresh", controllers.Auth.RefreshToken)
+			auth.POST("/mfa/verify", controllers.Auth.VerifyMFA)
+			auth.POST("/mfa/setup", controllers.Auth.SetupMFAForLogin)
+			auth.POST("/webauthn/login/begin", controllers.Auth.BeginPasskeyLogin)
+			auth.POST("/webauthn/login/finish", controllers.Auth.FinishPasskeyLogin)
+			auth.GET("/oidc/providers", controllers.Auth.ListOIDCProviders)
+			auth.GET("/oidc/:provider/authorize", controllers.Auth.BeginOIDCLogin)
+			auth.GET("/oidc/:provider/callback", controllers.Auth.OIDCCallback)
+			auth.POST("/oidc/:provider/callback", controllers.Auth.OIDCCallback)
 			auth.GET("/roles", controllers.Auth.GetRoles)
 		}
 
+		// Live auction feed (public; a token only enables personal outbid notices).
+		// Browsers cannot set headers on EventSource or WebSocket requests, so
+		// the token may also be sent in the query or as a WebSocket subprotocol.
+		live := v1.Group("/auctions")
+		live.Use(LiveFeedJWTMiddleware())
+		{
+			live.GET("/:id/live", controllers.Live.AuctionFeed)
+		}
+
+		// Track previews (public; the signed token in the link is the credential,
+		// so it can be used directly as an <audio> source)
+		previews := v1.Group("/previews")
+		{
+			previews.GET("/:token", controllers.Preview.StreamPreview)
+			previews.HEAD("/:token", controllers.Preview.StreamPreview)
+		}
+
+		// One-time master downloads (public; the token is the credential and
+		// is used up by the first request)
+		v1.GET("/downloads/:token", controllers.Purchase.RedeemDownload)
+
+		// Payment provider webhooks (public; authenticated by the provider's signature)
+		v1.POST("/payments/webhook", controllers.Payment.HandleWebhook)
+
+		// Display exchange rates (public)
+		v1.GET("/fx/rates", controllers.FX.GetRates)
+
 		// Protected routes (require authentication)
 		protected := v1.Group("/")
 		protected.Use(JWTMiddleware())
@@ -40,6 +95,22 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 				authProtected.GET("/profile", controllers.Auth.GetProfile)
 				authProtected.PUT("/profile", controllers.Auth.UpdateProfile)
 				authProtected.POST("/logout", controllers.Auth.Logout)
+				authProtected.POST("/logout-all", controllers.Auth.LogoutAll)
+				authProtected.GET("/mfa", controllers.Auth.GetMFAStatus)
+				authProtected.POST("/mfa/totp", controllers.Auth.SetupTOTP)
+				authProtected.POST("/mfa/totp/confirm", controllers.Auth.ConfirmTOTP)
+				authProtected.POST("/mfa/totp/disable", controllers.Auth.DisableTOTP)
+				authProtected.POST("/mfa/recovery-codes", controllers.Auth.RegenerateRecoveryCodes)
+				authProtected.GET("/mfa/required-roles", RoleMiddleware("admin"), controllers.Auth.GetMFARequiredRoles)
+				authProtected.PUT("/mfa/required-roles", RoleMiddleware("admin"), controllers.Auth.SetMFARequiredRoles)
+				authProtected.POST("/webauthn/register/begin", controllers.Auth.BeginPasskeyRegistration)
+				authProtected.POST("/webauthn/register/finish", controllers.Auth.FinishPasskeyRegistration)
+				authProtected.GET("/webauthn/credentials", controllers.Auth.ListPasskeys)
+				authProtected.DELETE("/webauthn/credentials/:id", controllers.Auth.DeletePasskey)
+				authProtected.POST("/oidc/:provider/link", controllers.Auth.BeginOIDCLink)
+				authProtected.POST("/oidc/link/confirm", controllers.Auth.ConfirmOIDCLink)
+				authProtected.GET("/oidc/identities", controllers.Auth.ListOIDCIdentities)
+				authProtected.DELETE("/oidc/identities/:id", controllers.Auth.UnlinkOIDCIdentity)
 			}
 
 			// User routes (protected)
@@ -60,28 +131,123 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 				profile.POST("/image", controllers.Profile.UploadProfileImage)
 			}
 
-			// Future protected routes can be added here:
-			// auctions := protected.Group("/auctions")
-			// bids := protected.Group("/bids")
-			// tracks := protected.Group("/tracks")
+			// Auction routes (protected)
+			auctions := protected.Group("/auctions")
+			{
+				auctions.POST("", controllers.Auction.CreateAuction)
+				auctions.GET("", controllers.Auction.ListAuctions)
+				auctions.GET("/:id", controllers.Auction.GetAuction)
+				auctions.PUT("/:id", controllers.Auction.UpdateAuction)
+				auctions.DELETE("/:id", controllers.Auction.DeleteAuction)
+				auctions.GET("/:id/bids", controllers.Bid.ListAuctionBids)
+			}
+
+			// Bid routes (protected)
+			bids := protected.Group("/bids")
+			{
+				bids.POST("", controllers.Bid.PlaceBid)
+				bids.GET("", controllers.Bid.ListMyBids)
+			}
+
+			// Track routes (protected)
+			tracks := protected.Group("/tracks")
+			{
+				tracks.GET("/search", controllers.Track.SearchTracks)
+				tracks.POST("/upload", MultipleRoleMiddleware("artist", "producer", "admin"), controllers.Track.UploadTrack)
+				tracks.GET("/:id/audio", controllers.Track.GetAudioURL)
+				tracks.POST("/:id/publish", MultipleRoleMiddleware("artist", "producer", "admin"), controllers.Track.PublishTrack)
+				tracks.POST("/:id/preview", controllers.Preview.CreatePreview)
+			}
+
+			// Purchase routes (protected)
+			purchases := protected.Group("/purchases")
+			{
+				purchases.GET("", controllers.Purchase.ListPurchases)
+				purchases.POST("/:id/download", controllers.Purchase.CreateDownload)
+			}
+
+			// Payment routes (protected)
+			payments := protected.Group("/payments")
+			{
+				payments.GET("/method", controllers.Payment.GetPaymentMethod)
+				payments.PUT("/method", controllers.Payment.SetPaymentMethod)
+			}
+
+			// Escrow routes (protected)
+			escrows := protected.Group("/escrows")
+			{
+				escrows.GET("", controllers.Payment.ListEscrows)
+				escrows.GET("/:id", controllers.Payment.GetEscrow)
+				escrows.POST("/:id/retry", controllers.Payment.RetryPayment)
+				escrows.POST("/:id/confirm", controllers.Payment.ConfirmDelivery)
+				escrows.POST("/:id/refund", RoleMiddleware("admin"), controllers.Payment.RefundEscrow)
+			}
+
+			// Wallet routes (protected)
+			protected.GET("/wallet", controllers.Wallet.GetWallet)
+			protected.POST("/payouts", RoleMiddleware("admin"), controllers.Wallet.RecordPayout)
+
+			// Exchange rate routes (protected)
+			protected.PUT("/fx/rates", RoleMiddleware("admin"), controllers.FX.SetRates)
+
+			// Direct-to-S3 media upload routes (protected)
+			media := protected.Group("/media")
+			{
+				media.POST("/uploads", controllers.Media.CreateUpload)
+				media.POST("/uploads/:id/complete", controllers.Media.CompleteUpload)
+			}
 		}
 	}
 }
 
 // Controllers holds all controller instances
 type Controllers struct {
-	Health  *controllers.HealthController
-	User    *controllers.UserController
-	Auth    *auth.AuthHandlers
-	Profile *handlers.ProfileHandlers
+	Health   *controllers.HealthController
+	User     *controllers.UserController
+	Auth     *auth.AuthHandlers
+	Profile  *handlers.ProfileHandlers
+	Auction  *controllers.AuctionController
+	Bid      *controllers.BidController
+	Live     *controllers.LiveController
+	Track    *controllers.TrackController
+	Media    *controllers.MediaController
+	Preview  *controllers.PreviewController
+	Purchase *controllers.PurchaseController
+	Payment  *controllers.PaymentController
+	Wallet   *controllers.WalletController
+	FX       *controllers.FXController
+	Storage  *controllers.StorageController // nil unless using local disk storage
+	MockOIDC *auth.MockOIDCProvider         // nil unless oidc.mock is set
 }
 
-// NewControllers creates and returns all controller instances
-func NewControllers(services *Services) *Controllers {
+// NewControllers creates and returns all controller instances. Live feed
+// WebSockets are accepted from allowedOrigins.
+func NewControllers(services *Services, allowedOrigins []string) *Controllers {
 	return &Controllers{
-		Health:  controllers.NewHealthController(),
-		User:    controllers.NewUserController(services.User),
-		Auth:    auth.NewAuthHandlers(services.Auth),
-		Profile: handlers.NewProfileHandlers(services.Profile, services.S3, services.Logger),
+		Health:   controllers.NewHealthController(),
+		User:     controllers.NewUserController(services.User),
+		Auth:     auth.NewAuthHandlers(services.Auth),
+		Profile:  handlers.NewProfileHandlers(services.Profile, services.Storage, services.Logger),
+		Auction:  controllers.NewAuctionController(services.Auction, services.FX),
+		Bid:      controllers.NewBidController(services.Bid, services.FX),
+		Live:     controllers.NewLiveController(services.Auction, services.Realtime, allowedOrigins),
+		Track:    controllers.NewTrackController(services.Track),
+		Media:    controllers.NewMediaController(services.Media),
+		Preview:  controllers.NewPreviewController(services.Preview),
+		Purchase: controllers.NewPurchaseController(services.Purchase),
+		Payment:  controllers.NewPaymentController(services.Escrow),
+		Wallet:   controllers.NewWalletController(services.Ledger),
+		FX:       controllers.NewFXController(services.FX),
+		Storage:  newStorageController(services.Storage),
+		MockOIDC: services.MockOIDC,
+	}
+}
+
+// newStorageController returns the controller serving local disk storage,
+// or nil when objects are stored elsewhere
+func newStorageController(storage services.Storage) *controllers.StorageController {
+	if localStorage, ok := storage.(*services.LocalStorage); ok {
+		return controllers.NewStorageController(localStorage)
 	}
+	return nil
 }
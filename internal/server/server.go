@@ -2,13 +2,18 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"bagr-backend/internal/auth"
 	"bagr-backend/internal/config"
+	"bagr-backend/internal/payments"
+	"bagr-backend/internal/realtime"
 	"bagr-backend/internal/repositories"
 	"bagr-backend/internal/services"
 	"bagr-backend/internal/utils"
@@ -18,20 +23,42 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// workerStopTimeout bounds how long stopping the workers waits for their
+// current run when the server fails to start
+const workerStopTimeout = 30 * time.Second
+
 // Server represents the HTTP server
 type Server struct {
 	config     *config.Config
 	httpServer *http.Server
 	db         *sql.DB
+	lifecycle  *services.AuctionLifecycleWorker
+	escrows    *services.EscrowWorker
+	hub        *realtime.Hub
+
+	accessKeys  *auth.KeySet
+	revocations *auth.PostgresRevocationStore
+	mockOIDC    *auth.MockOIDCProvider
 }
 
 // Services holds all service instances
 type Services struct {
-	User    *services.UserService
-	Auth    *auth.AuthService
-	Profile *services.ProfileService
-	S3      *services.S3Service
-	Logger  *logrus.Logger
+	User     *services.UserService
+	Auth     *auth.AuthService
+	Profile  *services.ProfileService
+	Storage  services.Storage
+	Auction  *services.AuctionService
+	Bid      *services.BidService
+	Track    *services.TrackService
+	Media    *services.MediaService
+	Preview  *services.PreviewService
+	Purchase *services.PurchaseService
+	Escrow   *services.EscrowService
+	Ledger   *services.LedgerService
+	FX       *services.FXService
+	Realtime *realtime.Hub
+	Logger   *logrus.Logger
+	MockOIDC *auth.MockOIDCProvider // nil unless oidc.mock is set
 }
 
 // NewServer creates a new server instance
@@ -57,25 +84,76 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	// Initialize real-time auction feed
+	if err := s.initRealtime(); err != nil {
+		return fmt.Errorf("failed to initialize realtime hub: %w", err)
+	}
+
+	// Fill in the signing keys left unset in development
+	if err := s.initSigningKeys(); err != nil {
+		return fmt.Errorf("failed to initialize signing keys: %w", err)
+	}
+
+	// Initialize object storage
+	storage, err := s.initStorage()
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	// Initialize payment provider
+	paymentProvider, err := s.initPayments()
+	if err != nil {
+		return fmt.Errorf("failed to initialize payments: %w", err)
+	}
+
+	// Set up the social login providers
+	oidcProviders, err := s.initOIDC()
+	if err != nil {
+		return fmt.Errorf("failed to initialize login providers: %w", err)
+	}
+
+	// Load the access token signing keys
+	accessKeys, err := auth.LoadKeySet(s.config.JWT.SigningKeyFile, s.config.JWT.VerificationKeyFiles)
+	if err != nil {
+		return fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+	s.accessKeys = accessKeys
+
+	// Load the access token denylist
+	if err := s.initRevocations(); err != nil {
+		return fmt.Errorf("failed to initialize token revocations: %w", err)
+	}
+
 	// Initialize repositories
 	repos := s.initRepositories()
 
 	// Initialize services
-	services := s.initServices(repos)
+	services := s.initServices(repos, storage, paymentProvider, oidcProviders)
+
+	// Load display exchange rates
+	if err := s.loadExchangeRates(services.FX); err != nil {
+		return fmt.Errorf("failed to load exchange rates: %w", err)
+	}
 
 	// Initialize controllers
-	controllers := NewControllers(services)
+	controllers := NewControllers(services, s.config.Server.AllowedOrigins)
 
 	// Create Gin router
 	router := gin.New()
 
+	// Only configured proxies may set the client IP, which login throttling
+	// counts failures by; gin trusts every proxy otherwise
+	if err := router.SetTrustedProxies(s.config.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
 	// Load HTML templates
 	router.LoadHTMLGlob("templates/*")
 
 	// Add middleware
 	router.Use(LoggerMiddleware())
 	router.Use(RecoveryMiddleware())
-	router.Use(CORSMiddleware())
+	router.Use(CORSMiddleware(s.config.Server.AllowedOrigins))
 	router.Use(RequestIDMiddleware())
 	router.Use(TimeoutMiddleware(30 * time.Second))
 
@@ -96,10 +174,15 @@ func (s *Server) Start() error {
 		WriteTimeout: time.Duration(s.config.Server.WriteTimeout) * time.Second,
 	}
 
+	// Start the background workers last, so none is left running when an
+	// earlier step fails
+	s.startWorkers(services.Escrow)
+
 	logger.WithField("address", s.config.GetServerAddr()).Info("Starting HTTP server")
 
 	// Start server
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		s.stopWorkers()
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 
@@ -117,6 +200,37 @@ func (s *Server) Stop(ctx context.Context) error {
 		return err
 	}
 
+	// Stop auction lifecycle worker before the database goes away
+	if s.lifecycle != nil {
+		if err := s.lifecycle.Stop(ctx); err != nil {
+			logger.WithError(err).Error("Failed to stop auction lifecycle worker")
+			return err
+		}
+	}
+
+	// Stop escrow payment worker
+	if s.escrows != nil {
+		if err := s.escrows.Stop(ctx); err != nil {
+			logger.WithError(err).Error("Failed to stop escrow worker")
+			return err
+		}
+	}
+
+	// Stop syncing token revocations
+	if s.revocations != nil {
+		if err := s.revocations.Stop(ctx); err != nil {
+			logger.WithError(err).Error("Failed to stop token revocation sync")
+			return err
+		}
+	}
+
+	// Stop receiving auction events
+	if s.hub != nil {
+		if err := s.hub.Close(); err != nil {
+			logger.WithError(err).Error("Failed to close realtime hub")
+		}
+	}
+
 	// Close database connection
 	if s.db != nil {
 		if err := s.db.Close(); err != nil {
@@ -150,21 +264,245 @@ func (s *Server) initDatabase() error {
 	return nil
 }
 
+// initRealtime initializes the hub behind the live auction feed
+func (s *Server) initRealtime() error {
+	var broker realtime.Broker
+	switch s.config.Realtime.Broker {
+	case "local":
+		broker = realtime.NewLocalBroker()
+	case "postgres":
+		broker = realtime.NewPostgresBroker(s.db, s.config.GetDatabaseURL())
+	default:
+		return fmt.Errorf("unknown realtime broker %q", s.config.Realtime.Broker)
+	}
+
+	hub, err := realtime.NewHub(broker, time.Duration(s.config.Realtime.HeartbeatInterval)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	s.hub = hub
+	utils.GetLogger().WithField("broker", s.config.Realtime.Broker).Info("Realtime hub initialized")
+
+	return nil
+}
+
+// initRevocations loads the access token denylist and keeps it in sync
+func (s *Server) initRevocations() error {
+	store := auth.NewPostgresRevocationStore(s.db, time.Duration(s.config.JWT.RevocationSyncInterval)*time.Second)
+	if err := store.Start(); err != nil {
+		return err
+	}
+
+	s.revocations = store
+	return nil
+}
+
+// initStorage initializes the object storage driver selected in the S3 config
+func (s *Server) initStorage() (services.Storage, error) {
+	logger := utils.GetLogger()
+
+	switch s.config.S3.Driver {
+	case "s3":
+		s3Service, err := services.NewS3Service(
+			s.config.S3.Region,
+			s.config.S3.Bucket,
+			s.config.S3.AccessKeyID,
+			s.config.S3.SecretAccessKey,
+			s.config.S3.BaseURL,
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return s3Service, nil
+	case "local":
+		localStorage, err := services.NewLocalStorage(
+			s.config.S3.LocalPath,
+			s.config.S3.LocalBaseURL,
+			s.config.S3.LocalSigningKey,
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+		logger.WithField("path", s.config.S3.LocalPath).Info("Using local disk storage")
+		return localStorage, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", s.config.S3.Driver)
+	}
+}
+
+// initPayments initializes the payment provider selected in the payments config
+func (s *Server) initPayments() (payments.PaymentProvider, error) {
+	switch s.config.Payments.Provider {
+	case "fake":
+		webhookSecret := s.config.Payments.WebhookSecret
+		if webhookSecret == "" {
+			var err error
+			if webhookSecret, err = processSecret("payments.webhook_secret"); err != nil {
+				return nil, err
+			}
+		}
+		return payments.NewFakeProvider(webhookSecret), nil
+	case "stripe":
+		return payments.NewStripeProvider(s.config.Payments.StripeSecretKey, s.config.Payments.WebhookSecret)
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", s.config.Payments.Provider)
+	}
+}
+
+// initSigningKeys generates a per-process key for each signed link setting
+// left unset. Configuration validation requires them in production.
+func (s *Server) initSigningKeys() error {
+	if s.config.Media.PreviewSigningKey == "" {
+		key, err := processSecret("media.preview_signing_key")
+		if err != nil {
+			return err
+		}
+		s.config.Media.PreviewSigningKey = key
+	}
+	if s.config.S3.Driver == "local" && s.config.S3.LocalSigningKey == "" {
+		key, err := processSecret("s3.local_signing_key")
+		if err != nil {
+			return err
+		}
+		s.config.S3.LocalSigningKey = key
+	}
+	return nil
+}
+
+// processSecret generates a random secret for a setting left unset in
+// development. It only lives as long as the process, so whatever it signs
+// stops verifying on restart and on other replicas.
+func processSecret(setting string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate %s: %w", setting, err)
+	}
+	utils.GetLogger().WithField("setting", setting).Warn("Setting is not configured; using a random secret for this process")
+	return hex.EncodeToString(secret), nil
+}
+
+// initOIDC builds the client registrations of the configured login
+// providers, starting the mock provider when it is enabled
+func (s *Server) initOIDC() ([]auth.OIDCProviderConfig, error) {
+	logger := utils.GetLogger()
+	callbackBase := s.config.OIDC.CallbackBaseURL + "/api/v1/auth/oidc/"
+
+	names := make([]string, 0, len(s.config.OIDC.Providers))
+	for name := range s.config.OIDC.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var providers []auth.OIDCProviderConfig
+	for _, name := range names {
+		provider := s.config.OIDC.Providers[name]
+		if provider.ClientID == "" {
+			continue
+		}
+		if provider.Issuer == "" {
+			return nil, fmt.Errorf("login provider %q has no issuer", name)
+		}
+		providers = append(providers, auth.OIDCProviderConfig{
+			Name:         name,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			Scopes:       provider.Scopes,
+			ResponseMode: provider.ResponseMode,
+			RedirectURL:  callbackBase + name + "/callback",
+		})
+	}
+
+	if s.config.OIDC.Mock {
+		issuer := s.config.OIDC.CallbackBaseURL + "/mock-oidc"
+		mock, err := auth.NewMockOIDCProvider(issuer)
+		if err != nil {
+			return nil, err
+		}
+		s.mockOIDC = mock
+		providers = append(providers, auth.OIDCProviderConfig{
+			Name:        "mock",
+			Issuer:      issuer,
+			ClientID:    "bagr-mock",
+			Scopes:      []string{"openid", "email", "profile"},
+			RedirectURL: callbackBase + "mock/callback",
+		})
+	}
+
+	for _, provider := range providers {
+		logger.WithField("provider", provider.Name).Info("Login provider enabled")
+	}
+	return providers, nil
+}
+
+// startWorkers starts the auction lifecycle worker, which opens and closes
+// auctions on schedule, and the escrow payment worker
+func (s *Server) startWorkers(escrowService *services.EscrowService) {
+	s.lifecycle = services.NewAuctionLifecycleWorker(
+		s.db,
+		time.Duration(s.config.Auction.SchedulerInterval)*time.Second,
+		s.config.Auction.SchedulerBatchSize,
+		s.hub,
+	)
+	s.lifecycle.Start()
+
+	s.escrows = services.NewEscrowWorker(
+		escrowService,
+		time.Duration(s.config.Payments.WorkerInterval)*time.Second,
+		s.config.Payments.WorkerBatchSize,
+	)
+	s.escrows.Start()
+}
+
+// stopWorkers stops the workers after the server failed to start
+func (s *Server) stopWorkers() {
+	ctx, cancel := context.WithTimeout(context.Background(), workerStopTimeout)
+	defer cancel()
+
+	if err := s.lifecycle.Stop(ctx); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to stop auction lifecycle worker")
+	}
+	if err := s.escrows.Stop(ctx); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to stop escrow worker")
+	}
+}
+
+// loadExchangeRates replaces the exchange rate table with the configured
+// rates file, if any, overwriting rates set through the API before the restart
+func (s *Server) loadExchangeRates(fxService *services.FXService) error {
+	if s.config.Currency.RatesFile == "" {
+		return nil
+	}
+	return fxService.LoadRatesFile(context.Background(), s.config.Currency.RatesFile)
+}
+
 // initRepositories initializes all repositories
 func (s *Server) initRepositories() *repositories.Repositories {
 	return &repositories.Repositories{
-		User: repositories.NewUserRepository(s.db),
+		User:          repositories.NewUserRepository(s.db),
+		Auction:       repositories.NewAuctionRepository(s.db),
+		Bid:           repositories.NewBidRepository(s.db),
+		Track:         repositories.NewTrackRepository(s.db),
+		MediaUpload:   repositories.NewMediaUploadRepository(s.db),
+		Ownership:     repositories.NewOwnershipRepository(s.db),
+		Escrow:        repositories.NewEscrowRepository(s.db),
+		PaymentMethod: repositories.NewPaymentMethodRepository(s.db),
+		Ledger:        repositories.NewLedgerRepository(s.db),
+		ExchangeRate:  repositories.NewExchangeRateRepository(s.db),
 		// Add other repositories here when implemented
 	}
 }
 
 // initServices initializes all services
-func (s *Server) initServices(repos *repositories.Repositories) *Services {
+func (s *Server) initServices(repos *repositories.Repositories, storage services.Storage, paymentProvider payments.PaymentProvider, oidcProviders []auth.OIDCProviderConfig) *Services {
 	// Initialize logger
 	logger := utils.GetLogger()
 
 	// Initialize auth services
-	jwtService := auth.NewJWTService(s.config.JWT.AccessSecret, s.config.JWT.RefreshSecret)
+	jwtService := auth.NewJWTService(s.config.JWT.AccessSecret, s.config.JWT.RefreshSecret, s.accessKeys, s.revocations)
 	passwordService := auth.NewPasswordService()
 	emailService := auth.NewEmailService(auth.EmailConfig{
 		ClientID:     s.config.Email.ClientID,
@@ -174,29 +512,41 @@ func (s *Server) initServices(repos *repositories.Repositories) *Services {
 		FromName:     s.config.Email.FromName,
 		TestMode:     s.config.Email.TestMode, // Use config value
 	})
-	authService := auth.NewAuthService(s.db, jwtService, passwordService, emailService)
-
-	// Initialize S3 service
-	s3Service, err := services.NewS3Service(
-		s.config.S3.Region,
-		s.config.S3.Bucket,
-		s.config.S3.AccessKeyID,
-		s.config.S3.SecretAccessKey,
-		s.config.S3.BaseURL,
-		logger,
-	)
-	if err != nil {
-		logger.WithError(err).Fatal("Failed to initialize S3 service")
-	}
+	authService := auth.NewAuthService(s.db, jwtService, passwordService, emailService, auth.WebAuthnConfig{
+		RPID:    s.config.WebAuthn.RPID,
+		RPName:  s.config.WebAuthn.RPName,
+		Origins: s.config.WebAuthn.Origins,
+	}, oidcProviders, auth.LoginThrottleConfig{
+		MaxFailures:        s.config.Login.MaxFailures,
+		IPMaxFailures:      s.config.Login.IPMaxFailures,
+		FailureWindow:      time.Duration(s.config.Login.FailureWindow) * time.Second,
+		LockoutDuration:    time.Duration(s.config.Login.LockoutDuration) * time.Second,
+		MaxLockoutDuration: time.Duration(s.config.Login.MaxLockoutDuration) * time.Second,
+	})
 
 	// Initialize profile service
 	profileService := services.NewProfileService(s.db, logger)
 
+	ledgerService := services.NewLedgerService(s.db, repos.Ledger, s.config.Payments)
+
+	trackService := services.NewTrackService(repos.Track, repos.Ownership, repos.Escrow, storage, s.config.Media)
+
 	return &Services{
-		User:    services.NewUserService(repos.User),
-		Auth:    authService,
-		Profile: profileService,
-		S3:      s3Service,
-		Logger:  logger,
+		User:     services.NewUserService(repos.User, authService),
+		Auth:     authService,
+		Profile:  profileService,
+		Storage:  storage,
+		Auction:  services.NewAuctionService(s.db, repos.Auction, repos.Track, s.config.Auction, s.config.Currency),
+		Bid:      services.NewBidService(s.db, repos.Bid, s.config.Auction, s.hub),
+		Track:    trackService,
+		Media:    services.NewMediaService(repos.MediaUpload, trackService, profileService, storage, s.config.Media),
+		Preview:  services.NewPreviewService(trackService, repos.Auction, storage, s.config.Media),
+		Purchase: services.NewPurchaseService(repos.Ownership, trackService, s.config.Media),
+		Escrow:   services.NewEscrowService(s.db, repos.Escrow, repos.PaymentMethod, ledgerService, paymentProvider, s.config.Payments),
+		Ledger:   ledgerService,
+		FX:       services.NewFXService(s.db, repos.ExchangeRate, s.config.Currency),
+		Realtime: s.hub,
+		Logger:   logger,
+		MockOIDC: s.mockOIDC,
 	}
 }
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/realtime"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// auctionLifecycleLockKey is the Postgres advisory lock held for the duration of
+// a lifecycle run, so only one replica transitions auctions at a time
+const auctionLifecycleLockKey int64 = 0x42414752_0001
+
+// AuctionLifecycleWorker periodically moves auctions through their lifecycle:
+// drafts are activated at their start time, and open auctions are closed at
+// their end time as completed (winning bid recorded, payment escrow opened and
+// the track transferred to the winner) or expired.
+// The result of every closed auction is published to its live feed.
+type AuctionLifecycleWorker struct {
+	db        *sql.DB
+	interval  time.Duration
+	batchSize int
+	publisher realtime.Publisher
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAuctionLifecycleWorker creates a new auction lifecycle worker
+func NewAuctionLifecycleWorker(db *sql.DB, interval time.Duration, batchSize int, publisher realtime.Publisher) *AuctionLifecycleWorker {
+	return &AuctionLifecycleWorker{
+		db:        db,
+		interval:  interval,
+		batchSize: batchSize,
+		publisher: publisher,
+	}
+}
+
+// Start runs the worker in the background until Stop is called
+func (w *AuctionLifecycleWorker) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		return // Already running
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go w.loop(ctx, w.done)
+
+	utils.GetLogger().WithField("interval", w.interval.String()).Info("Auction lifecycle worker started")
+}
+
+// Stop signals the worker to finish and waits for the current run to end
+// or for ctx to expire, whichever comes first
+func (w *AuctionLifecycleWorker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		utils.GetLogger().Info("Auction lifecycle worker stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("auction lifecycle worker did not stop in time: %w", ctx.Err())
+	}
+}
+
+// loop runs the lifecycle on every tick until ctx is cancelled
+func (w *AuctionLifecycleWorker) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			utils.GetLogger().WithError(err).Error("Auction lifecycle run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single lifecycle pass inside one transaction.
+// Auctions that fail to close are recorded and retried on a later run.
+// If another replica holds the lifecycle lock the pass is skipped.
+func (w *AuctionLifecycleWorker) RunOnce(ctx context.Context) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var acquired bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", auctionLifecycleLockKey).Scan(&acquired); err != nil {
+		return fmt.Errorf("failed to acquire lifecycle lock: %w", err)
+	}
+	if !acquired {
+		return nil // Another replica is running the lifecycle
+	}
+
+	now := time.Now()
+	repos := &repositories.Repositories{
+		Auction:   repositories.NewAuctionRepository(tx),
+		Bid:       repositories.NewBidRepository(tx),
+		Track:     repositories.NewTrackRepository(tx),
+		Ownership: repositories.NewOwnershipRepository(tx),
+		Escrow:    repositories.NewEscrowRepository(tx),
+	}
+
+	activated, err := repos.Auction.ActivateScheduled(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	ended, err := repos.Auction.LockEndedAuctions(ctx, now, w.batchSize)
+	if err != nil {
+		return err
+	}
+
+	// Each auction closes under a savepoint, so one that fails only rolls
+	// back its own changes and the rest of the batch still closes
+	var events []realtime.Event
+	failed := 0
+	for _, auction := range ended {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT close_auction"); err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		result, closeErr := w.closeAuction(ctx, repos, auction)
+		if closeErr != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT close_auction"); err != nil {
+				return fmt.Errorf("failed to roll back auction %d: %w", auction.ID, err)
+			}
+			failed++
+			utils.GetLogger().WithError(closeErr).WithField("auction_id", auction.ID).Error("Failed to close auction, retrying on a later run")
+			if err := repos.Auction.Update(ctx, auction.ID, map[string]interface{}{
+				"close_failed_at": now,
+				"close_error":     closeErr.Error(),
+			}); err != nil {
+				return err
+			}
+		} else {
+			events = appendEvent(events, realtime.EventAuctionClosed, auction.ID, 0, result)
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT close_auction"); err != nil {
+			return fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit lifecycle run: %w", err)
+	}
+
+	publishEvents(ctx, w.publisher, events)
+
+	if activated > 0 || len(ended) > 0 {
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"activated": activated,
+			"closed":    len(ended) - failed,
+			"failed":    failed,
+		}).Info("Auction lifecycle run completed")
+	}
+
+	return nil
+}
+
+// closeAuction settles a single ended auction with repositories bound to the
+// lifecycle transaction. The highest bid wins if the reserve price was met:
+// the auctioned track is sold to its bidder and an escrow is opened to take
+// their payment. Otherwise the auction expires without a winner. An auction
+// whose track can no longer be sold by its seller is cancelled instead.
+func (w *AuctionLifecycleWorker) closeAuction(ctx context.Context, repos *repositories.Repositories, auction *models.Auction) (*realtime.AuctionClosedData, error) {
+	highest, err := repos.Bid.GetHighestBidForAuction(ctx, auction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.AuctionStatusExpired
+	if highest != nil && auction.HasReserveMet() {
+		err := transferTrack(ctx, repos.Track, repos.Ownership, auction, highest)
+		switch {
+		case errors.Is(err, ErrTrackNotSellable):
+			status = models.AuctionStatusCancelled
+		case err != nil:
+			return nil, err
+		default:
+			status = models.AuctionStatusCompleted
+		}
+	}
+
+	if status == models.AuctionStatusCompleted {
+		if err := repos.Bid.Update(ctx, highest.ID, map[string]interface{}{"status": models.BidStatusWinning}); err != nil {
+			return nil, err
+		}
+		escrow := &models.Escrow{
+			AuctionID: auction.ID,
+			BidID:     highest.ID,
+			BuyerID:   highest.BidderID,
+			SellerID:  auction.SellerID,
+			Amount:    highest.Amount,
+			Currency:  highest.Currency,
+		}
+		if err := repos.Escrow.Create(ctx, escrow); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repos.Auction.Update(ctx, auction.ID, map[string]interface{}{
+		"status":          status,
+		"close_failed_at": nil,
+		"close_error":     nil,
+	}); err != nil {
+		return nil, err
+	}
+
+	result := &realtime.AuctionClosedData{Status: string(status), Currency: auction.Currency}
+
+	logger := utils.GetLogger().WithFields(map[string]interface{}{
+		"auction_id": auction.ID,
+		"status":     status,
+	})
+	if status == models.AuctionStatusCompleted {
+		result.WinningBidID = &highest.ID
+		result.WinnerID = &highest.BidderID
+		result.FinalAmount = &highest.Amount
+
+		logger.WithFields(map[string]interface{}{
+			"winning_bid_id": highest.ID,
+			"winner_id":      highest.BidderID,
+			"amount":         highest.Amount,
+		}).Info("Auction completed")
+	} else if status == models.AuctionStatusCancelled {
+		logger.Warn("Auction cancelled, its track can no longer be sold by the seller")
+	} else {
+		logger.Info("Auction expired")
+	}
+
+	return result, nil
+}
+
+// transferTrack records the winning bidder as the owner of an auction's track
+// and marks the track sold. Auctions without a track have nothing to transfer.
+// Returns ErrTrackNotSellable when the track is gone, already sold or no
+// longer the seller's.
+func transferTrack(ctx context.Context, trackRepo repositories.TrackRepository, ownershipRepo repositories.OwnershipRepository, auction *models.Auction, winning *models.Bid) error {
+	if auction.TrackID == 0 {
+		return nil
+	}
+	track, err := trackRepo.GetByID(ctx, auction.TrackID)
+	if err != nil {
+		return err
+	}
+	if track == nil || !track.IsSellable() || track.ArtistID != auction.SellerID {
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"auction_id": auction.ID,
+			"track_id":   auction.TrackID,
+		}).Warn("Auctioned track can no longer be sold by the seller, nothing to transfer")
+		return ErrTrackNotSellable
+	}
+
+	ownership := &models.TrackOwnership{
+		TrackID:   auction.TrackID,
+		AuctionID: auction.ID,
+		BidID:     winning.ID,
+		OwnerID:   winning.BidderID,
+		SellerID:  auction.SellerID,
+		Price:     winning.Amount,
+		Currency:  winning.Currency,
+	}
+	if err := ownershipRepo.Create(ctx, ownership); err != nil {
+		return err
+	}
+
+	return trackRepo.Update(ctx, auction.TrackID, map[string]interface{}{"status": models.TrackStatusSold})
+}
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Auction service errors
+var (
+	ErrAuctionNotFound      = errors.New("auction not found")
+	ErrNotAuctionSeller     = errors.New("only the seller can modify this auction")
+	ErrAuctionNotEditable   = errors.New("auction can no longer be modified")
+	ErrInvalidAuctionWindow = errors.New("end time must be after start time and in the future")
+	ErrInvalidStartPrice    = errors.New("start price must be positive")
+	ErrInvalidReservePrice  = errors.New("reserve price must not be lower than the start price")
+	ErrInvalidMaxExtensions = errors.New("soft close max extensions must not be negative")
+	ErrInvalidStatusChange  = errors.New("status can only be changed to cancelled")
+	ErrInvalidBidIncrements = errors.New("invalid bid increment table")
+	ErrUnsupportedCurrency  = errors.New("auctions cannot be priced in this currency")
+	ErrNotTrackArtist       = errors.New("only the track's artist can auction it")
+	ErrTrackNotSellable     = errors.New("track has already been sold or deleted")
+)
+
+// AuctionService handles auction business logic
+type AuctionService struct {
+	db          *sql.DB
+	auctionRepo repositories.AuctionRepository
+	trackRepo   repositories.TrackRepository
+	config      config.AuctionConfig
+	currencies  config.CurrencyConfig
+	increments  models.BidIncrementTable
+}
+
+// NewAuctionService creates a new auction service
+func NewAuctionService(db *sql.DB, auctionRepo repositories.AuctionRepository, trackRepo repositories.TrackRepository, cfg config.AuctionConfig, currencies config.CurrencyConfig) *AuctionService {
+	return &AuctionService{
+		db:          db,
+		auctionRepo: auctionRepo,
+		trackRepo:   trackRepo,
+		config:      cfg,
+		currencies:  currencies,
+		increments:  cfg.BidIncrementTable(),
+	}
+}
+
+// CreateAuction creates a new auction for the given seller, who must be the
+// artist of the auctioned track.
+// Auctions starting in the future are created as drafts; the rest open immediately.
+func (s *AuctionService) CreateAuction(ctx context.Context, sellerID int, req *models.CreateAuctionRequest) (*models.Auction, error) {
+	now := time.Now()
+	if !req.EndTime.After(req.StartTime) || !req.EndTime.After(now) {
+		return nil, ErrInvalidAuctionWindow
+	}
+	if !req.StartPrice.IsPositive() {
+		return nil, ErrInvalidStartPrice
+	}
+	if req.ReservePrice != nil && req.ReservePrice.LessThan(req.StartPrice) {
+		return nil, ErrInvalidReservePrice
+	}
+	if err := req.BidIncrements.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBidIncrements, err)
+	}
+	currency := s.currencies.Default
+	if req.Currency != "" {
+		currency = strings.ToUpper(req.Currency)
+	}
+	if !s.isSupportedCurrency(currency) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	// Only the artist can sell a track's rights, and only once
+	track, err := s.trackRepo.GetByID(ctx, req.TrackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+	if track == nil || track.Status == models.TrackStatusDeleted {
+		return nil, ErrTrackNotFound
+	}
+	if track.ArtistID != sellerID {
+		return nil, ErrNotTrackArtist
+	}
+	if !track.IsSellable() {
+		return nil, ErrTrackNotSellable
+	}
+
+	status := models.AuctionStatusActive
+	if req.StartTime.After(now) {
+		status = models.AuctionStatusDraft
+	}
+
+	auction := &models.Auction{
+		TrackID:      req.TrackID,
+		SellerID:     sellerID,
+		Title:        req.Title,
+		Description:  req.Description,
+		Currency:     currency,
+		StartPrice:   req.StartPrice,
+		ReservePrice: req.ReservePrice,
+		Status:       status,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+
+		BidIncrements: req.BidIncrements,
+
+		SoftCloseWindow:    s.config.SoftCloseWindow,
+		SoftCloseExtension: s.config.SoftCloseExtension,
+	}
+	if s.config.SoftCloseMaxExtensions > 0 {
+		maxExtensions := s.config.SoftCloseMaxExtensions
+		auction.SoftCloseMaxExtensions = &maxExtensions
+	}
+	if req.SoftCloseWindow != nil {
+		auction.SoftCloseWindow = *req.SoftCloseWindow
+	}
+	if req.SoftCloseExtension != nil {
+		auction.SoftCloseExtension = *req.SoftCloseExtension
+	}
+	if req.SoftCloseMaxExtensions != nil {
+		auction.SoftCloseMaxExtensions = req.SoftCloseMaxExtensions
+	}
+
+	if err := s.auctionRepo.Create(ctx, auction); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to create auction")
+		return nil, fmt.Errorf("failed to create auction: %w", err)
+	}
+	auction.NextMinimumBid = auction.MinimumBid(s.increments)
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"auction_id": auction.ID,
+		"seller_id":  sellerID,
+		"status":     auction.Status,
+	}).Info("Auction created successfully")
+	return auction, nil
+}
+
+// GetAuction retrieves an auction by ID
+func (s *AuctionService) GetAuction(ctx context.Context, id int) (*models.Auction, error) {
+	auction, err := s.auctionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction: %w", err)
+	}
+	if auction == nil {
+		return nil, ErrAuctionNotFound
+	}
+	auction.NextMinimumBid = auction.MinimumBid(s.increments)
+	return auction, nil
+}
+
+// UpdateAuction updates an auction owned by sellerID.
+// Once an auction has received bids its terms are frozen. The auction row is
+// locked while it is checked and updated, so a bid can't land in between.
+func (s *AuctionService) UpdateAuction(ctx context.Context, id, sellerID int, req *models.UpdateAuctionRequest) (*models.Auction, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	auctionRepo := repositories.NewAuctionRepository(tx)
+	auction, err := lockEditableAuction(ctx, auctionRepo, id, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Currency != nil {
+		currency := strings.ToUpper(*req.Currency)
+		if !s.isSupportedCurrency(currency) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+		updates["currency"] = currency
+	}
+
+	startPrice := auction.StartPrice
+	if req.StartPrice != nil {
+		startPrice = *req.StartPrice
+		if !startPrice.IsPositive() {
+			return nil, ErrInvalidStartPrice
+		}
+		updates["start_price"] = startPrice
+	}
+	reservePrice := auction.ReservePrice
+	if req.ReservePrice.Set {
+		reservePrice = req.ReservePrice.Value
+		if reservePrice != nil {
+			updates["reserve_price"] = *reservePrice
+		} else {
+			updates["reserve_price"] = nil
+		}
+	}
+	if reservePrice != nil && reservePrice.LessThan(startPrice) {
+		return nil, ErrInvalidReservePrice
+	}
+
+	startTime, endTime := auction.StartTime, auction.EndTime
+	if req.StartTime != nil {
+		startTime = *req.StartTime
+		updates["start_time"] = startTime
+	}
+	if req.EndTime != nil {
+		endTime = *req.EndTime
+		updates["end_time"] = endTime
+	}
+	if req.StartTime != nil || req.EndTime != nil {
+		now := time.Now()
+		if !endTime.After(startTime) || !endTime.After(now) {
+			return nil, ErrInvalidAuctionWindow
+		}
+		// Rescheduling decides whether the auction is open yet; the lifecycle
+		// worker activates drafts once their start time arrives
+		if startTime.After(now) {
+			updates["status"] = models.AuctionStatusDraft
+		} else {
+			updates["status"] = models.AuctionStatusActive
+		}
+	}
+
+	if req.SoftCloseWindow != nil {
+		updates["soft_close_window_minutes"] = *req.SoftCloseWindow
+	}
+	if req.SoftCloseExtension != nil {
+		updates["soft_close_extension_minutes"] = *req.SoftCloseExtension
+	}
+	if req.SoftCloseMaxExtensions.Set {
+		if maxExtensions := req.SoftCloseMaxExtensions.Value; maxExtensions != nil {
+			if *maxExtensions < 0 {
+				return nil, ErrInvalidMaxExtensions
+			}
+			updates["soft_close_max_extensions"] = *maxExtensions
+		} else {
+			updates["soft_close_max_extensions"] = nil
+		}
+	}
+	if req.BidIncrements != nil {
+		if err := req.BidIncrements.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBidIncrements, err)
+		}
+		updates["bid_increments"] = req.BidIncrements
+	}
+
+	// Draft and active are driven by the schedule, sellers may only cancel
+	if req.Status != nil {
+		if *req.Status != models.AuctionStatusCancelled {
+			return nil, ErrInvalidStatusChange
+		}
+		updates["status"] = *req.Status
+	}
+
+	if len(updates) > 0 {
+		if err := auctionRepo.Update(ctx, id, updates); err != nil {
+			utils.GetLogger().WithError(err).Error("Failed to update auction")
+			return nil, fmt.Errorf("failed to update auction: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit auction update: %w", err)
+	}
+
+	utils.GetLogger().WithField("auction_id", id).Info("Auction updated successfully")
+	return s.GetAuction(ctx, id)
+}
+
+// DeleteAuction cancels an auction owned by sellerID, under the same lock as
+// UpdateAuction
+func (s *AuctionService) DeleteAuction(ctx context.Context, id, sellerID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	auctionRepo := repositories.NewAuctionRepository(tx)
+	if _, err := lockEditableAuction(ctx, auctionRepo, id, sellerID); err != nil {
+		return err
+	}
+
+	if err := auctionRepo.Delete(ctx, id); err != nil {
+		utils.GetLogger().WithError(err).Error("Failed to delete auction")
+		return fmt.Errorf("failed to delete auction: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit auction cancellation: %w", err)
+	}
+
+	utils.GetLogger().WithField("auction_id", id).Info("Auction cancelled successfully")
+	return nil
+}
+
+// lockEditableAuction locks an auction's row for the rest of the transaction
+// and checks that sellerID may still change it. Bids take the same lock, so
+// none can arrive until the change is committed.
+func lockEditableAuction(ctx context.Context, auctionRepo repositories.AuctionRepository, id, sellerID int) (*models.Auction, error) {
+	auction, err := auctionRepo.GetByIDForUpdate(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction: %w", err)
+	}
+	if auction == nil {
+		return nil, ErrAuctionNotFound
+	}
+	if auction.SellerID != sellerID {
+		return nil, ErrNotAuctionSeller
+	}
+	if !isEditable(auction) {
+		return nil, ErrAuctionNotEditable
+	}
+	return auction, nil
+}
+
+// ListAuctions retrieves a list of auctions with pagination
+func (s *AuctionService) ListAuctions(ctx context.Context, limit, offset int) ([]*models.Auction, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	auctions, err := s.auctionRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auctions: %w", err)
+	}
+	s.setNextMinimumBids(auctions)
+	return auctions, nil
+}
+
+// ListActiveAuctions retrieves auctions currently open for bidding
+func (s *AuctionService) ListActiveAuctions(ctx context.Context, limit, offset int) ([]*models.Auction, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	auctions, err := s.auctionRepo.GetActiveAuctions(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active auctions: %w", err)
+	}
+	s.setNextMinimumBids(auctions)
+	return auctions, nil
+}
+
+// ListSellerAuctions retrieves the auctions created by a seller
+func (s *AuctionService) ListSellerAuctions(ctx context.Context, sellerID, limit, offset int) ([]*models.Auction, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	auctions, err := s.auctionRepo.GetBySellerID(ctx, sellerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seller auctions: %w", err)
+	}
+	s.setNextMinimumBids(auctions)
+	return auctions, nil
+}
+
+// setNextMinimumBids fills in the next minimum bid of each auction
+func (s *AuctionService) setNextMinimumBids(auctions []*models.Auction) {
+	for _, auction := range auctions {
+		auction.NextMinimumBid = auction.MinimumBid(s.increments)
+	}
+}
+
+// isSupportedCurrency reports whether auctions may be priced in currency
+func (s *AuctionService) isSupportedCurrency(currency string) bool {
+	for _, supported := range s.currencies.Supported {
+		if currency == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// isEditable reports whether the seller may still change an auction
+func isEditable(auction *models.Auction) bool {
+	if auction.BidCount > 0 {
+		return false
+	}
+	return auction.Status == models.AuctionStatusDraft || auction.Status == models.AuctionStatusActive
+}
+
+// normalizePagination clamps limit and offset to sane bounds
+func normalizePagination(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
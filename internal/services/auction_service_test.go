@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+)
+
+func TestCreateAuctionRefusesInvalidBidIncrements(t *testing.T) {
+	// The ladder is checked before anything is read or stored
+	service := NewAuctionService(nil, nil, nil, config.AuctionConfig{}, config.CurrencyConfig{Default: "USD", Supported: []string{"USD"}})
+
+	tests := []struct {
+		name   string
+		ladder models.BidIncrementTable
+	}{
+		{"zero increment", models.BidIncrementTable{{Increment: *usd(0)}}},
+		{"unsorted", models.BidIncrementTable{{UpTo: usd(10000), Increment: *usd(500)}, {UpTo: usd(2000), Increment: *usd(100)}}},
+		{"open band before the last", models.BidIncrementTable{{Increment: *usd(100)}, {UpTo: usd(2000), Increment: *usd(500)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.CreateAuctionRequest{
+				TrackID:       1,
+				StartPrice:    *usd(1000),
+				StartTime:     time.Now(),
+				EndTime:       time.Now().Add(24 * time.Hour),
+				BidIncrements: tt.ladder,
+			}
+			if _, err := service.CreateAuction(context.Background(), 1, req); !errors.Is(err, ErrInvalidBidIncrements) {
+				t.Fatalf("got %v, want ErrInvalidBidIncrements", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/realtime"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Bid service errors
+var (
+	ErrAuctionNotActive = errors.New("auction is not open for bidding")
+	ErrSellerCannotBid  = errors.New("sellers cannot bid on their own auctions")
+	ErrBidTooLow        = errors.New("bid amount is too low")
+	ErrInvalidMaxAmount = errors.New("max amount must not be lower than the bid amount")
+)
+
+// BidService handles bid business logic
+type BidService struct {
+	db         *sql.DB
+	bidRepo    repositories.BidRepository
+	increments models.BidIncrementTable
+	publisher  realtime.Publisher
+}
+
+// NewBidService creates a new bid service
+func NewBidService(db *sql.DB, bidRepo repositories.BidRepository, cfg config.AuctionConfig, publisher realtime.Publisher) *BidService {
+	return &BidService{
+		db:         db,
+		bidRepo:    bidRepo,
+		increments: cfg.BidIncrementTable(),
+		publisher:  publisher,
+	}
+}
+
+// PlaceBid places a bid on an auction.
+// The auction row is locked with SELECT ... FOR UPDATE for the whole transaction,
+// so concurrent bidders on the same auction are serialised and every bid is
+// validated against the price left by the previous one. The returned bid carries
+// the updated auction, including any soft close extension of its end time.
+//
+// When the request carries a max amount it is stored as the bidder's proxy, and
+// competing proxies are resolved eBay-style: the higher ceiling wins at one
+// increment above the lower one, and the earlier proxy wins a tie. Every
+// automatic raise is recorded as its own bid.
+//
+// Once committed, the new bids, outbid notices and any extension are published
+// to the auction's live feed.
+func (s *BidService) PlaceBid(ctx context.Context, bidderID int, req *models.CreateBidRequest) (*models.Bid, error) {
+	if req.MaxAmount != nil && req.MaxAmount.LessThan(req.Amount) {
+		return nil, ErrInvalidMaxAmount
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	auctionRepo := repositories.NewAuctionRepository(tx)
+	bidRepo := repositories.NewBidRepository(tx)
+
+	auction, err := auctionRepo.GetByIDForUpdate(ctx, req.AuctionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction: %w", err)
+	}
+	if auction == nil {
+		return nil, ErrAuctionNotFound
+	}
+	if auction.SellerID == bidderID {
+		return nil, ErrSellerCannotBid
+	}
+	if !auction.IsActive() {
+		return nil, ErrAuctionNotActive
+	}
+
+	// Bids are always in the auction's currency
+	amount := req.Amount.WithCurrency(auction.Currency)
+	var maxAmount *models.Money
+	if req.MaxAmount != nil {
+		ceiling := req.MaxAmount.WithCurrency(auction.Currency)
+		maxAmount = &ceiling
+	}
+
+	placement := &bidPlacement{
+		auctionRepo: auctionRepo,
+		bidRepo:     bidRepo,
+		auction:     auction,
+		increments:  s.increments,
+	}
+
+	bid, placed, err := placement.place(ctx, bidderID, amount, maxAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if !placed {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit proxy bid: %w", err)
+		}
+		auction.NextMinimumBid = auction.MinimumBid(s.increments)
+		bid.Auction = auction
+
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"auction_id": auction.ID,
+			"bidder_id":  bidderID,
+		}).Info("Proxy bid ceiling raised")
+
+		return bid, nil
+	}
+
+	// Anti-sniping: late bids push the end time out
+	if err := placement.softClose(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bid: %w", err)
+	}
+	auction.NextMinimumBid = auction.MinimumBid(s.increments)
+	bid.Auction = auction
+
+	publishEvents(ctx, s.publisher, placement.events)
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"auction_id": auction.ID,
+		"bid_id":     bid.ID,
+		"bidder_id":  bidderID,
+		"amount":     bid.Amount,
+		"status":     bid.Status,
+	}).Info("Bid placed successfully")
+
+	return bid, nil
+}
+
+// bidPlacement tracks the leading bid while one placement records its bids,
+// collecting the live events to publish once the transaction commits
+type bidPlacement struct {
+	auctionRepo repositories.AuctionRepository
+	bidRepo     repositories.BidRepository
+	auction     *models.Auction
+	leader      *models.Bid
+	increments  models.BidIncrementTable
+	events      []realtime.Event
+}
+
+// place checks a bid against the auction's minimum, stores the bidder's proxy
+// when maxAmount is set and resolves it against the leader's. A leader sending
+// a new ceiling only moves their proxy, they never bid against themselves: their
+// leading bid is returned and placed is false.
+func (p *bidPlacement) place(ctx context.Context, bidderID int, amount models.Money, maxAmount *models.Money) (bid *models.Bid, placed bool, err error) {
+	ceiling := amount
+	if maxAmount != nil {
+		ceiling = *maxAmount
+	}
+
+	leader, err := p.bidRepo.GetHighestBidForAuction(ctx, p.auction.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get leading bid: %w", err)
+	}
+	p.leader = leader
+
+	raisingCeiling := leader != nil && leader.BidderID == bidderID && maxAmount != nil
+
+	minimum := p.auction.MinimumBid(p.increments)
+	if raisingCeiling {
+		if ceiling.LessThan(minimum) {
+			return nil, false, fmt.Errorf("%w: must be at least %s %s", ErrBidTooLow, minimum, p.auction.Currency)
+		}
+	} else if amount.LessThan(minimum) {
+		return nil, false, fmt.Errorf("%w: must be at least %s %s", ErrBidTooLow, minimum, p.auction.Currency)
+	}
+
+	if maxAmount != nil {
+		proxy := &models.ProxyBid{AuctionID: p.auction.ID, BidderID: bidderID, MaxAmount: ceiling, Currency: p.auction.Currency}
+		if err := p.bidRepo.UpsertProxy(ctx, proxy); err != nil {
+			return nil, false, fmt.Errorf("failed to save proxy bid: %w", err)
+		}
+	}
+
+	if raisingCeiling {
+		return leader, false, nil
+	}
+
+	if leader == nil || leader.BidderID == bidderID {
+		bid, err = p.record(ctx, bidderID, amount, false)
+		if err != nil {
+			return nil, false, err
+		}
+		return bid, true, nil
+	}
+
+	leaderCeiling := leader.Amount
+	proxy, err := p.bidRepo.GetProxy(ctx, p.auction.ID, leader.BidderID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get leading proxy bid: %w", err)
+	}
+	if proxy != nil {
+		leaderCeiling = models.MaxMoney(leaderCeiling, proxy.MaxAmount)
+	}
+	leaderID := leader.BidderID
+
+	if leaderCeiling.LessThan(ceiling) {
+		// The new bidder wins: the leader's proxy is spent, then the new
+		// bidder takes the lead one increment above it
+		if leader.Amount.LessThan(leaderCeiling) {
+			if _, err := p.record(ctx, leaderID, leaderCeiling, true); err != nil {
+				return nil, false, err
+			}
+		}
+		increment := p.auction.BidIncrement(leaderCeiling, p.increments)
+		raise := models.MinMoney(ceiling, leaderCeiling.Add(increment))
+		bid, err = p.record(ctx, bidderID, models.MaxMoney(amount, raise), false)
+		if err != nil {
+			return nil, false, err
+		}
+		return bid, true, nil
+	}
+
+	// The leader's proxy holds: the new bidder is pushed to their ceiling
+	// and the leader answers one increment above it, winning ties
+	bid, err = p.record(ctx, bidderID, ceiling, false)
+	if err != nil {
+		return nil, false, err
+	}
+	increment := p.auction.BidIncrement(ceiling, p.increments)
+	answer := models.MinMoney(leaderCeiling, ceiling.Add(increment))
+	if _, err := p.record(ctx, leaderID, answer, true); err != nil {
+		return nil, false, err
+	}
+	return bid, true, nil
+}
+
+// softClose pushes the auction's end time out when the leading bid came in
+// within its soft close window, up to its maximum number of extensions
+func (p *bidPlacement) softClose(ctx context.Context) error {
+	endTime, extended := p.auction.SoftCloseEndTime(p.leader.CreatedAt)
+	if !extended {
+		return nil
+	}
+	if err := p.auctionRepo.ExtendEndTime(ctx, p.auction.ID, endTime); err != nil {
+		return fmt.Errorf("failed to extend auction: %w", err)
+	}
+	p.auction.EndTime = endTime
+	p.auction.ExtensionCount++
+	p.events = appendEvent(p.events, realtime.EventAuctionExtended, p.auction.ID, 0, realtime.AuctionExtendedData{
+		EndTime:        endTime,
+		ExtensionCount: p.auction.ExtensionCount,
+	})
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"auction_id": p.auction.ID,
+		"end_time":   endTime,
+		"extensions": p.auction.ExtensionCount,
+	}).Info("Auction extended by soft close")
+
+	return nil
+}
+
+// record inserts a bid that takes the lead, marks the previous leader as outbid
+// and moves the auction's current bid
+func (p *bidPlacement) record(ctx context.Context, bidderID int, amount models.Money, automatic bool) (*models.Bid, error) {
+	bid := &models.Bid{
+		AuctionID:   p.auction.ID,
+		BidderID:    bidderID,
+		Amount:      amount,
+		Currency:    p.auction.Currency,
+		Status:      models.BidStatusActive,
+		IsAutomatic: automatic,
+	}
+	if err := p.bidRepo.Create(ctx, bid); err != nil {
+		return nil, fmt.Errorf("failed to create bid: %w", err)
+	}
+
+	if p.leader != nil && p.leader.Status == models.BidStatusActive {
+		if err := p.bidRepo.Update(ctx, p.leader.ID, map[string]interface{}{"status": models.BidStatusOutbid}); err != nil {
+			return nil, fmt.Errorf("failed to mark previous bid as outbid: %w", err)
+		}
+		p.leader.Status = models.BidStatusOutbid
+		p.events = appendEvent(p.events, realtime.EventOutbid, p.auction.ID, p.leader.BidderID, realtime.OutbidData{
+			BidID:     p.leader.ID,
+			BidderID:  p.leader.BidderID,
+			Amount:    p.leader.Amount,
+			NewAmount: bid.Amount,
+			Currency:  bid.Currency,
+		})
+	}
+
+	if err := p.auctionRepo.UpdateCurrentBid(ctx, p.auction.ID, bid.Amount); err != nil {
+		return nil, fmt.Errorf("failed to update auction current bid: %w", err)
+	}
+	p.auction.CurrentBid = &bid.Amount
+	p.auction.BidCount++
+	p.leader = bid
+	p.events = appendEvent(p.events, realtime.EventBidPlaced, p.auction.ID, 0, realtime.BidPlacedData{
+		BidID:          bid.ID,
+		BidderID:       bid.BidderID,
+		Amount:         bid.Amount,
+		Currency:       bid.Currency,
+		IsAutomatic:    bid.IsAutomatic,
+		BidCount:       p.auction.BidCount,
+		NextMinimumBid: p.auction.MinimumBid(p.increments),
+	})
+
+	if automatic {
+		utils.GetLogger().WithFields(map[string]interface{}{
+			"auction_id": p.auction.ID,
+			"bid_id":     bid.ID,
+			"bidder_id":  bidderID,
+			"amount":     bid.Amount,
+		}).Info("Proxy bid placed automatically")
+	}
+
+	return bid, nil
+}
+
+// GetAuctionBids retrieves an auction's bids, highest first
+func (s *BidService) GetAuctionBids(ctx context.Context, auctionID, limit, offset int) ([]*models.Bid, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	bids, err := s.bidRepo.GetByAuctionID(ctx, auctionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auction bids: %w", err)
+	}
+	return bids, nil
+}
+
+// GetBidderBids retrieves the bids placed by a user, newest first
+func (s *BidService) GetBidderBids(ctx context.Context, bidderID, limit, offset int) ([]*models.Bid, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	bids, err := s.bidRepo.GetByBidderID(ctx, bidderID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bidder bids: %w", err)
+	}
+	return bids, nil
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/realtime"
+	"bagr-backend/internal/repositories"
+)
+
+// fakeBidRepository keeps an auction's bids and proxies in memory. Methods
+// the placement doesn't use panic through the nil embedded interface.
+type fakeBidRepository struct {
+	repositories.BidRepository
+	bids    []*models.Bid
+	proxies map[int]*models.ProxyBid
+	now     time.Time
+}
+
+func newFakeBidRepository(now time.Time) *fakeBidRepository {
+	return &fakeBidRepository{proxies: map[int]*models.ProxyBid{}, now: now}
+}
+
+func (r *fakeBidRepository) Create(ctx context.Context, bid *models.Bid) error {
+	stored := *bid
+	stored.ID = len(r.bids) + 1
+	stored.CreatedAt = r.now
+	r.bids = append(r.bids, &stored)
+	bid.ID, bid.CreatedAt = stored.ID, stored.CreatedAt
+	return nil
+}
+
+func (r *fakeBidRepository) Update(ctx context.Context, id int, updates map[string]interface{}) error {
+	if status, ok := updates["status"].(models.BidStatus); ok {
+		r.bids[id-1].Status = status
+	}
+	return nil
+}
+
+func (r *fakeBidRepository) GetHighestBidForAuction(ctx context.Context, auctionID int) (*models.Bid, error) {
+	var highest *models.Bid
+	for _, bid := range r.bids {
+		if highest == nil || highest.Amount.LessThan(bid.Amount) {
+			highest = bid
+		}
+	}
+	if highest == nil {
+		return nil, nil
+	}
+	leader := *highest
+	return &leader, nil
+}
+
+func (r *fakeBidRepository) GetProxy(ctx context.Context, auctionID, bidderID int) (*models.ProxyBid, error) {
+	return r.proxies[bidderID], nil
+}
+
+func (r *fakeBidRepository) UpsertProxy(ctx context.Context, proxy *models.ProxyBid) error {
+	r.proxies[proxy.BidderID] = proxy
+	return nil
+}
+
+// fakeAuctionRepository records the auction updates a placement makes
+type fakeAuctionRepository struct {
+	repositories.AuctionRepository
+	currentBid *models.Money
+	endTime    time.Time
+}
+
+func (r *fakeAuctionRepository) UpdateCurrentBid(ctx context.Context, auctionID int, bidAmount models.Money) error {
+	r.currentBid = &bidAmount
+	return nil
+}
+
+func (r *fakeAuctionRepository) ExtendEndTime(ctx context.Context, auctionID int, endTime time.Time) error {
+	r.endTime = endTime
+	return nil
+}
+
+var testIncrements = models.BidIncrementTable{
+	{UpTo: usd(2000), Increment: *usd(100)},
+	{UpTo: usd(10000), Increment: *usd(500)},
+	{Increment: *usd(1000)},
+}
+
+func usd(cents int64) *models.Money {
+	amount := models.NewMoney(cents, "USD")
+	return &amount
+}
+
+// testBid is a bid already on the auction; a positive maxAmount gives the
+// bidder a proxy
+type testBid struct {
+	bidderID  int
+	amount    int64
+	maxAmount int64
+}
+
+// newTestPlacement builds a placement on an active auction with the existing
+// bids, as PlaceBid would have left them
+func newTestPlacement(existing []testBid) (*bidPlacement, *fakeBidRepository) {
+	now := time.Now()
+	bidRepo := newFakeBidRepository(now)
+	auction := &models.Auction{
+		ID:         1,
+		SellerID:   99,
+		StartPrice: *usd(1000),
+		Currency:   "USD",
+		Status:     models.AuctionStatusActive,
+		EndTime:    now.Add(24 * time.Hour),
+	}
+
+	for i, bid := range existing {
+		status := models.BidStatusOutbid
+		if i == len(existing)-1 {
+			status = models.BidStatusActive
+		}
+		bidRepo.bids = append(bidRepo.bids, &models.Bid{
+			ID:        i + 1,
+			AuctionID: auction.ID,
+			BidderID:  bid.bidderID,
+			Amount:    *usd(bid.amount),
+			Currency:  "USD",
+			Status:    status,
+			CreatedAt: now,
+		})
+		if bid.maxAmount > 0 {
+			bidRepo.proxies[bid.bidderID] = &models.ProxyBid{AuctionID: auction.ID, BidderID: bid.bidderID, MaxAmount: *usd(bid.maxAmount), Currency: "USD"}
+		}
+		auction.CurrentBid = usd(bid.amount)
+		auction.BidCount++
+	}
+
+	return &bidPlacement{
+		auctionRepo: &fakeAuctionRepository{},
+		bidRepo:     bidRepo,
+		auction:     auction,
+		increments:  testIncrements,
+	}, bidRepo
+}
+
+// placedBid is the bidder and amount of a recorded bid
+type placedBid struct {
+	bidderID  int
+	amount    int64
+	automatic bool
+}
+
+func TestBidPlacementProxyResolution(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []testBid
+		bidderID  int
+		amount    int64
+		maxAmount int64
+		// wantBids are the bids the placement records, in order
+		wantBids   []placedBid
+		wantLeader int
+		wantPrice  int64
+	}{
+		{
+			name:       "first bid",
+			bidderID:   1,
+			amount:     1000,
+			wantBids:   []placedBid{{1, 1000, false}},
+			wantLeader: 1,
+			wantPrice:  1000,
+		},
+		{
+			name:       "first bid with a proxy opens at the amount",
+			bidderID:   1,
+			amount:     1000,
+			maxAmount:  5000,
+			wantBids:   []placedBid{{1, 1000, false}},
+			wantLeader: 1,
+			wantPrice:  1000,
+		},
+		{
+			name:       "outbids a leader without a proxy",
+			existing:   []testBid{{1, 1000, 0}},
+			bidderID:   2,
+			amount:     1100,
+			wantBids:   []placedBid{{2, 1100, false}},
+			wantLeader: 2,
+			wantPrice:  1100,
+		},
+		{
+			name:       "ceiling below the leader's",
+			existing:   []testBid{{1, 1000, 3000}},
+			bidderID:   2,
+			amount:     1100,
+			maxAmount:  2000,
+			wantBids:   []placedBid{{2, 2000, false}, {1, 2500, true}},
+			wantLeader: 1,
+			wantPrice:  2500,
+		},
+		{
+			name:       "leader answer is capped at their ceiling",
+			existing:   []testBid{{1, 1000, 2050}},
+			bidderID:   2,
+			amount:     1100,
+			maxAmount:  2000,
+			wantBids:   []placedBid{{2, 2000, false}, {1, 2050, true}},
+			wantLeader: 1,
+			wantPrice:  2050,
+		},
+		{
+			name:       "ceiling equal to the leader's: the earlier proxy wins",
+			existing:   []testBid{{1, 1000, 3000}},
+			bidderID:   2,
+			amount:     1100,
+			maxAmount:  3000,
+			wantBids:   []placedBid{{2, 3000, false}, {1, 3000, true}},
+			wantLeader: 1,
+			wantPrice:  3000,
+		},
+		{
+			name:       "ceiling above the leader's",
+			existing:   []testBid{{1, 1000, 3000}},
+			bidderID:   2,
+			amount:     1100,
+			maxAmount:  8000,
+			wantBids:   []placedBid{{1, 3000, true}, {2, 3500, false}},
+			wantLeader: 2,
+			wantPrice:  3500,
+		},
+		{
+			name:       "increment step past the ceiling is capped at it",
+			existing:   []testBid{{1, 1000, 3000}},
+			bidderID:   2,
+			amount:     1100,
+			maxAmount:  3200,
+			wantBids:   []placedBid{{1, 3000, true}, {2, 3200, false}},
+			wantLeader: 2,
+			wantPrice:  3200,
+		},
+		{
+			name:       "amount above the leader's ceiling is bid in full",
+			existing:   []testBid{{1, 1000, 3000}},
+			bidderID:   2,
+			amount:     4000,
+			wantBids:   []placedBid{{1, 3000, true}, {2, 4000, false}},
+			wantLeader: 2,
+			wantPrice:  4000,
+		},
+		{
+			name:       "spent proxy isn't bid again",
+			existing:   []testBid{{1, 3000, 3000}},
+			bidderID:   2,
+			amount:     3500,
+			wantBids:   []placedBid{{2, 3500, false}},
+			wantLeader: 2,
+			wantPrice:  3500,
+		},
+		{
+			name:       "leader bidding again without a proxy raises their own bid",
+			existing:   []testBid{{1, 1000, 0}},
+			bidderID:   1,
+			amount:     1500,
+			wantBids:   []placedBid{{1, 1500, false}},
+			wantLeader: 1,
+			wantPrice:  1500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placement, bidRepo := newTestPlacement(tt.existing)
+			var maxAmount *models.Money
+			if tt.maxAmount > 0 {
+				maxAmount = usd(tt.maxAmount)
+			}
+
+			bid, placed, err := placement.place(context.Background(), tt.bidderID, *usd(tt.amount), maxAmount)
+			if err != nil {
+				t.Fatalf("place: %v", err)
+			}
+			if !placed {
+				t.Fatal("placed = false, want a bid recorded")
+			}
+			if bid.BidderID != tt.bidderID || bid.IsAutomatic {
+				t.Errorf("returned bid = %+v, want the bidder's own bid", bid)
+			}
+
+			recorded := bidRepo.bids[len(tt.existing):]
+			if len(recorded) != len(tt.wantBids) {
+				t.Fatalf("recorded %d bids, want %d", len(recorded), len(tt.wantBids))
+			}
+			for i, want := range tt.wantBids {
+				got := placedBid{recorded[i].BidderID, recorded[i].Amount.Cents, recorded[i].IsAutomatic}
+				if got != want {
+					t.Errorf("bid %d = %+v, want %+v", i+1, got, want)
+				}
+			}
+
+			if placement.leader.BidderID != tt.wantLeader || placement.leader.Amount.Cents != tt.wantPrice {
+				t.Errorf("leader = bidder %d at %s, want bidder %d at %d cents", placement.leader.BidderID, placement.leader.Amount, tt.wantLeader, tt.wantPrice)
+			}
+			if placement.auction.CurrentBid == nil || placement.auction.CurrentBid.Cents != tt.wantPrice {
+				t.Errorf("current bid = %v, want %d cents", placement.auction.CurrentBid, tt.wantPrice)
+			}
+
+			// Only the last bid leads; every bid it displaced is outbid
+			for i, bid := range bidRepo.bids {
+				want := models.BidStatusOutbid
+				if i == len(bidRepo.bids)-1 {
+					want = models.BidStatusActive
+				}
+				if bid.Status != want {
+					t.Errorf("bid %d by bidder %d is %s, want %s", bid.ID, bid.BidderID, bid.Status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBidPlacementMarksPreviousLeaderOutbid(t *testing.T) {
+	placement, bidRepo := newTestPlacement([]testBid{{1, 1000, 0}})
+
+	if _, _, err := placement.place(context.Background(), 2, *usd(1500), nil); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if bidRepo.bids[0].Status != models.BidStatusOutbid {
+		t.Errorf("previous leader's bid is %s, want outbid", bidRepo.bids[0].Status)
+	}
+
+	var outbid []int
+	for _, event := range placement.events {
+		if event.Type == realtime.EventOutbid {
+			outbid = append(outbid, event.UserID)
+		}
+	}
+	if len(outbid) != 1 || outbid[0] != 1 {
+		t.Errorf("outbid notices went to %v, want [1]", outbid)
+	}
+}
+
+func TestBidPlacementLeaderRaisesCeiling(t *testing.T) {
+	placement, bidRepo := newTestPlacement([]testBid{{2, 1000, 0}, {1, 1100, 2000}})
+
+	bid, placed, err := placement.place(context.Background(), 1, *usd(1200), usd(5000))
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if placed {
+		t.Error("placed = true, want only the proxy moved")
+	}
+	if bid.ID != 2 || bid.Amount.Cents != 1100 {
+		t.Errorf("returned bid = %+v, want the leading bid", bid)
+	}
+	if len(bidRepo.bids) != 2 || len(placement.events) != 0 {
+		t.Errorf("leader bid against themselves: %d bids, %d events", len(bidRepo.bids), len(placement.events))
+	}
+	if proxy := bidRepo.proxies[1]; proxy == nil || proxy.MaxAmount.Cents != 5000 {
+		t.Errorf("proxy = %+v, want a 50.00 ceiling", proxy)
+	}
+}
+
+func TestBidPlacementTooLow(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []testBid
+		bidderID  int
+		amount    int64
+		maxAmount int64
+	}{
+		{"below the start price", nil, 1, 900, 0},
+		{"below one increment over the current bid", []testBid{{1, 1000, 0}}, 2, 1050, 0},
+		{"leader's new ceiling below the minimum", []testBid{{1, 1000, 0}}, 1, 1000, 1050},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placement, bidRepo := newTestPlacement(tt.existing)
+			var maxAmount *models.Money
+			if tt.maxAmount > 0 {
+				maxAmount = usd(tt.maxAmount)
+			}
+
+			if _, _, err := placement.place(context.Background(), tt.bidderID, *usd(tt.amount), maxAmount); !errors.Is(err, ErrBidTooLow) {
+				t.Fatalf("got %v, want ErrBidTooLow", err)
+			}
+			if len(bidRepo.bids) != len(tt.existing) || len(bidRepo.proxies) != 0 {
+				t.Error("a refused bid was recorded")
+			}
+		})
+	}
+}
+
+func TestBidPlacementSoftClose(t *testing.T) {
+	placement, _ := newTestPlacement(nil)
+	auctionRepo := placement.auctionRepo.(*fakeAuctionRepository)
+	maxExtensions := 2
+	placement.auction.SoftCloseWindow = 5
+	placement.auction.SoftCloseExtension = 2
+	placement.auction.SoftCloseMaxExtensions = &maxExtensions
+	placement.auction.EndTime = time.Now().Add(time.Minute)
+	end := placement.auction.EndTime
+
+	// Each late bid pushes the end out until the cap is reached
+	for i, amount := range []int64{1000, 1100, 1200} {
+		if _, _, err := placement.place(context.Background(), 1+i%2, *usd(amount), nil); err != nil {
+			t.Fatalf("bid %d: %v", i+1, err)
+		}
+		if err := placement.softClose(context.Background()); err != nil {
+			t.Fatalf("bid %d: softClose: %v", i+1, err)
+		}
+	}
+
+	if placement.auction.ExtensionCount != maxExtensions {
+		t.Errorf("extension count = %d, want %d", placement.auction.ExtensionCount, maxExtensions)
+	}
+	want := end.Add(4 * time.Minute)
+	if !placement.auction.EndTime.Equal(want) || !auctionRepo.endTime.Equal(want) {
+		t.Errorf("end time = %v (stored %v), want %v", placement.auction.EndTime, auctionRepo.endTime, want)
+	}
+
+	var extensions int
+	for _, event := range placement.events {
+		if event.Type == realtime.EventAuctionExtended {
+			extensions++
+		}
+	}
+	if extensions != maxExtensions {
+		t.Errorf("published %d extensions, want %d", extensions, maxExtensions)
+	}
+}
+
+func TestBidPlacementSoftCloseEarlyBid(t *testing.T) {
+	placement, _ := newTestPlacement(nil)
+	placement.auction.SoftCloseWindow = 5
+	placement.auction.SoftCloseExtension = 2
+	end := placement.auction.EndTime
+
+	if _, _, err := placement.place(context.Background(), 1, *usd(1000), nil); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if err := placement.softClose(context.Background()); err != nil {
+		t.Fatalf("softClose: %v", err)
+	}
+	if placement.auction.ExtensionCount != 0 || !placement.auction.EndTime.Equal(end) {
+		t.Errorf("bid a day before the end extended the auction to %v", placement.auction.EndTime)
+	}
+}
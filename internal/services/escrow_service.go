@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/payments"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Escrow errors
+var (
+	ErrEscrowNotFound      = errors.New("escrow not found")
+	ErrEscrowNotCaptured   = errors.New("payment has not been captured")
+	ErrEscrowNotFailed     = errors.New("only failed payments can be retried")
+	ErrEscrowNotRefundable = errors.New("only captured or disputed payments can be refunded")
+	ErrNoPaymentMethod     = errors.New("no payment method on file")
+)
+
+// escrowProcessingTimeout is how long an escrow may stay processing before
+// it is assumed abandoned by a crashed worker and claimed again
+const escrowProcessingTimeout = 5 * time.Minute
+
+// EscrowService holds auction payments in escrow. The winning bid of every
+// completed auction opens an escrow; the buyer's saved payment method is
+// authorized and captured in the background, and the funds are released to
+// the seller once the buyer confirms delivery. Every transition that moves
+// money is recorded in the ledger in the same transaction.
+type EscrowService struct {
+	db         *sql.DB
+	escrowRepo repositories.EscrowRepository
+	methodRepo repositories.PaymentMethodRepository
+	ledger     *LedgerService
+	provider   payments.PaymentProvider
+	config     config.PaymentsConfig
+}
+
+// NewEscrowService creates a new escrow service
+func NewEscrowService(db *sql.DB, escrowRepo repositories.EscrowRepository, methodRepo repositories.PaymentMethodRepository, ledger *LedgerService, provider payments.PaymentProvider, cfg config.PaymentsConfig) *EscrowService {
+	return &EscrowService{
+		db:         db,
+		escrowRepo: escrowRepo,
+		methodRepo: methodRepo,
+		ledger:     ledger,
+		provider:   provider,
+		config:     cfg,
+	}
+}
+
+// SetPaymentMethod saves the payment method charged when the user wins an auction
+func (s *EscrowService) SetPaymentMethod(ctx context.Context, userID int, methodRef string) (*models.PaymentMethod, error) {
+	method := &models.PaymentMethod{
+		UserID:    userID,
+		Provider:  s.provider.Name(),
+		MethodRef: methodRef,
+	}
+	if err := s.methodRepo.Upsert(ctx, method); err != nil {
+		return nil, fmt.Errorf("failed to save payment method: %w", err)
+	}
+	return method, nil
+}
+
+// GetPaymentMethod retrieves the user's payment method on file
+func (s *EscrowService) GetPaymentMethod(ctx context.Context, userID int) (*models.PaymentMethod, error) {
+	method, err := s.methodRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment method: %w", err)
+	}
+	if method == nil || method.Provider != s.provider.Name() {
+		return nil, ErrNoPaymentMethod
+	}
+	return method, nil
+}
+
+// ListEscrows retrieves the escrows a user is the buyer or seller of
+func (s *EscrowService) ListEscrows(ctx context.Context, userID int, limit, offset int) ([]*models.Escrow, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	escrows, err := s.escrowRepo.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escrows: %w", err)
+	}
+	return escrows, nil
+}
+
+// GetEscrow retrieves an escrow visible to the user: its buyer, its seller or an admin
+func (s *EscrowService) GetEscrow(ctx context.Context, userID int, role string, escrowID int) (*models.Escrow, error) {
+	escrow, err := s.escrowRepo.GetByID(ctx, escrowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escrow: %w", err)
+	}
+	if escrow == nil || (escrow.BuyerID != userID && escrow.SellerID != userID && role != string(models.UserRoleAdmin)) {
+		return nil, ErrEscrowNotFound
+	}
+	return escrow, nil
+}
+
+// RetryPayment queues a failed escrow for another capture attempt, after
+// the buyer has saved a new payment method
+func (s *EscrowService) RetryPayment(ctx context.Context, userID, escrowID int) (*models.Escrow, error) {
+	escrow, err := s.buyerEscrow(ctx, userID, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetPaymentMethod(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	moved, err := s.escrowRepo.Transition(ctx, escrow.ID, []models.EscrowStatus{models.EscrowStatusFailed}, models.EscrowStatusPending, "")
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, ErrEscrowNotFailed
+	}
+
+	return s.escrowRepo.GetByID(ctx, escrow.ID)
+}
+
+// ConfirmDelivery releases a captured escrow to the seller on the buyer's word
+// that the track has been delivered
+func (s *EscrowService) ConfirmDelivery(ctx context.Context, userID, escrowID int) (*models.Escrow, error) {
+	escrow, err := s.buyerEscrow(ctx, userID, escrowID)
+	if err != nil {
+		return nil, err
+	}
+
+	if escrow.Status != models.EscrowStatusCaptured {
+		return nil, ErrEscrowNotCaptured
+	}
+
+	moved, err := s.settleNow(ctx, escrow, models.EscrowStatusReleased)
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, ErrEscrowNotCaptured
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"escrow_id": escrow.ID,
+		"seller_id": escrow.SellerID,
+		"amount":    escrow.Amount,
+	}).Info("Escrow released to seller")
+
+	return s.escrowRepo.GetByID(ctx, escrow.ID)
+}
+
+// Refund returns a captured or disputed escrow's payment to the buyer.
+// The escrow stays locked during the provider call, so a concurrent
+// delivery confirmation cannot release money that is being refunded.
+func (s *EscrowService) Refund(ctx context.Context, escrowID int) (*models.Escrow, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	escrowRepo := repositories.NewEscrowRepository(tx)
+	escrow, err := escrowRepo.GetByIDForUpdate(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow == nil {
+		return nil, ErrEscrowNotFound
+	}
+	if (escrow.Status != models.EscrowStatusCaptured && escrow.Status != models.EscrowStatusDisputed) || escrow.PaymentRef == nil {
+		return nil, ErrEscrowNotRefundable
+	}
+
+	amount := escrow.Amount.Cents
+	if _, err := s.provider.Refund(ctx, *escrow.PaymentRef, amount, fmt.Sprintf("escrow-%d-refund", escrow.ID)); err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	if _, err := s.settle(ctx, tx, escrow, models.EscrowStatusRefunded, ""); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit refund: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"escrow_id": escrow.ID,
+		"buyer_id":  escrow.BuyerID,
+		"amount":    escrow.Amount,
+	}).Info("Escrow refunded to buyer")
+
+	return s.escrowRepo.GetByID(ctx, escrow.ID)
+}
+
+// HandleWebhook applies a provider webhook to the escrow of its payment.
+// Refunds and disputes made at the provider are mirrored; each event is
+// handled once, however often it is delivered.
+func (s *EscrowService) HandleWebhook(ctx context.Context, payload []byte, header http.Header) error {
+	event, err := s.provider.VerifyWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	escrowRepo := repositories.NewEscrowRepository(tx)
+	isNew, err := escrowRepo.RecordWebhookEvent(ctx, s.provider.Name(), event.ID, string(event.Type))
+	if err != nil {
+		return err
+	}
+	if !isNew || event.PaymentID == "" {
+		return nil
+	}
+
+	escrow, err := escrowRepo.GetByPaymentRef(ctx, s.provider.Name(), event.PaymentID)
+	if err == nil && escrow != nil {
+		escrow, err = escrowRepo.GetByIDForUpdate(ctx, escrow.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if escrow != nil {
+		switch {
+		case event.Type == payments.EventPaymentRefunded && (escrow.Status == models.EscrowStatusCaptured ||
+			escrow.Status == models.EscrowStatusReleased || escrow.Status == models.EscrowStatusDisputed):
+			_, err = s.settle(ctx, tx, escrow, models.EscrowStatusRefunded, "")
+		case event.Type == payments.EventPaymentDisputed && (escrow.Status == models.EscrowStatusCaptured ||
+			escrow.Status == models.EscrowStatusReleased):
+			_, err = s.settle(ctx, tx, escrow, models.EscrowStatusDisputed, "payment disputed by the buyer")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"payment_id": event.PaymentID,
+	}).Info("Payment webhook handled")
+
+	return nil
+}
+
+// CapturePending claims up to limit pending escrows and captures their
+// payments, returning how many were claimed
+func (s *EscrowService) CapturePending(ctx context.Context, limit int) (int, error) {
+	escrows, err := s.escrowRepo.ClaimPending(ctx, time.Now().Add(-escrowProcessingTimeout), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, escrow := range escrows {
+		s.capture(ctx, escrow)
+	}
+	return len(escrows), nil
+}
+
+// capture authorizes and captures a claimed escrow's payment. The payment
+// is recorded as soon as it is authorized, so a retry after a crash captures
+// the existing hold rather than placing a second one.
+func (s *EscrowService) capture(ctx context.Context, escrow *models.Escrow) {
+	amount := escrow.Amount.Cents
+
+	paymentRef := ""
+	if escrow.PaymentRef != nil && escrow.Provider != nil && *escrow.Provider == s.provider.Name() {
+		paymentRef = *escrow.PaymentRef
+	}
+
+	if paymentRef == "" {
+		method, err := s.methodRepo.GetByUserID(ctx, escrow.BuyerID)
+		if err != nil {
+			s.retryLater(ctx, escrow, err)
+			return
+		}
+		if method == nil || method.Provider != s.provider.Name() {
+			s.fail(ctx, escrow, "", ErrNoPaymentMethod)
+			return
+		}
+
+		authorization, err := s.provider.Authorize(ctx, payments.AuthorizeRequest{
+			Amount:         amount,
+			Currency:       escrow.Currency,
+			PaymentMethod:  method.MethodRef,
+			Description:    fmt.Sprintf("BAGR auction %d", escrow.AuctionID),
+			IdempotencyKey: fmt.Sprintf("escrow-%d-authorize-%d", escrow.ID, escrow.Attempts),
+		})
+		if err != nil {
+			if errors.Is(err, payments.ErrDeclined) {
+				s.fail(ctx, escrow, "", err)
+			} else {
+				s.retryLater(ctx, escrow, err)
+			}
+			return
+		}
+
+		paymentRef = authorization.ID
+		if err := s.escrowRepo.SetPayment(ctx, escrow.ID, s.provider.Name(), paymentRef); err != nil {
+			s.void(ctx, escrow, paymentRef)
+			s.retryLater(ctx, escrow, err)
+			return
+		}
+	}
+
+	// The key names the payment, so a retry of the same capture is a no-op
+	// while a capture of a payment authorized again after a failure is not
+	// refused as a reuse of the key with different parameters
+	if err := s.provider.Capture(ctx, paymentRef, amount, fmt.Sprintf("escrow-%d-capture-%s", escrow.ID, paymentRef)); err != nil {
+		if errors.Is(err, payments.ErrDeclined) {
+			s.fail(ctx, escrow, paymentRef, err)
+		} else {
+			s.retryLater(ctx, escrow, err)
+		}
+		return
+	}
+
+	if _, err := s.settleNow(ctx, escrow, models.EscrowStatusCaptured); err != nil {
+		// The money is taken; the next claim of this stale escrow captures
+		// the same payment again, which the idempotency key turns into a no-op
+		utils.GetLogger().WithError(err).WithField("escrow_id", escrow.ID).Error("Failed to record captured payment")
+		return
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"escrow_id":  escrow.ID,
+		"auction_id": escrow.AuctionID,
+		"buyer_id":   escrow.BuyerID,
+		"amount":     escrow.Amount,
+	}).Info("Escrow payment captured")
+}
+
+// settle moves an escrow from the status it was read in to status to and
+// records the money moved in the ledger, both within tx. It reports whether
+// the escrow moved; it does not if its status changed since it was read.
+func (s *EscrowService) settle(ctx context.Context, tx *sql.Tx, escrow *models.Escrow, to models.EscrowStatus, reason string) (bool, error) {
+	moved, err := repositories.NewEscrowRepository(tx).Transition(ctx, escrow.ID, []models.EscrowStatus{escrow.Status}, to, reason)
+	if err != nil || !moved {
+		return moved, err
+	}
+
+	if err := s.ledger.RecordEscrow(ctx, repositories.NewLedgerRepository(tx), escrow, to); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// settleNow settles an escrow in a transaction of its own
+func (s *EscrowService) settleNow(ctx context.Context, escrow *models.Escrow, to models.EscrowStatus) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	moved, err := s.settle(ctx, tx, escrow, to, "")
+	if err != nil || !moved {
+		return moved, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit escrow settlement: %w", err)
+	}
+	return true, nil
+}
+
+// retryLater returns a claimed escrow to pending after a transient error,
+// or fails it once it has used up its attempts
+func (s *EscrowService) retryLater(ctx context.Context, escrow *models.Escrow, cause error) {
+	logger := utils.GetLogger().WithError(cause).WithField("escrow_id", escrow.ID)
+	if escrow.Attempts >= s.config.MaxAttempts {
+		logger.Error("Escrow capture failed, giving up")
+		paymentRef := ""
+		if escrow.PaymentRef != nil {
+			paymentRef = *escrow.PaymentRef
+		}
+		s.fail(ctx, escrow, paymentRef, cause)
+		return
+	}
+
+	logger.Warn("Escrow capture failed, will retry")
+	if _, err := s.escrowRepo.Transition(ctx, escrow.ID, []models.EscrowStatus{models.EscrowStatusProcessing}, models.EscrowStatusPending, cause.Error()); err != nil {
+		utils.GetLogger().WithError(err).WithField("escrow_id", escrow.ID).Error("Failed to requeue escrow")
+	}
+}
+
+// fail marks a claimed escrow failed, releasing any hold on the buyer's
+// payment method, so the buyer can retry with another one
+func (s *EscrowService) fail(ctx context.Context, escrow *models.Escrow, paymentRef string, cause error) {
+	if paymentRef != "" {
+		s.void(ctx, escrow, paymentRef)
+		if err := s.escrowRepo.SetPayment(ctx, escrow.ID, "", ""); err != nil {
+			utils.GetLogger().WithError(err).WithField("escrow_id", escrow.ID).Error("Failed to clear escrow payment")
+		}
+	}
+
+	if _, err := s.escrowRepo.Transition(ctx, escrow.ID, []models.EscrowStatus{models.EscrowStatusProcessing}, models.EscrowStatusFailed, cause.Error()); err != nil {
+		utils.GetLogger().WithError(err).WithField("escrow_id", escrow.ID).Error("Failed to mark escrow failed")
+		return
+	}
+
+	utils.GetLogger().WithError(cause).WithFields(map[string]interface{}{
+		"escrow_id": escrow.ID,
+		"buyer_id":  escrow.BuyerID,
+	}).Warn("Escrow payment failed")
+}
+
+// void releases a payment hold, logging failures: an unreleased hold
+// expires on its own at the provider
+func (s *EscrowService) void(ctx context.Context, escrow *models.Escrow, paymentRef string) {
+	if err := s.provider.Void(ctx, paymentRef, fmt.Sprintf("escrow-%d-void-%s", escrow.ID, paymentRef)); err != nil {
+		utils.GetLogger().WithError(err).WithField("escrow_id", escrow.ID).Error("Failed to void payment hold")
+	}
+}
+
+// buyerEscrow retrieves an escrow the user is the buyer of
+func (s *EscrowService) buyerEscrow(ctx context.Context, userID, escrowID int) (*models.Escrow, error) {
+	escrow, err := s.escrowRepo.GetByID(ctx, escrowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escrow: %w", err)
+	}
+	if escrow == nil || escrow.BuyerID != userID {
+		return nil, ErrEscrowNotFound
+	}
+	return escrow, nil
+}
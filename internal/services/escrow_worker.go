@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"bagr-backend/internal/utils"
+)
+
+// EscrowWorker periodically captures the payments of pending escrows.
+// Escrows are claimed with row locks that skip rows held by others, so
+// several replicas can run the worker side by side.
+type EscrowWorker struct {
+	escrowService *EscrowService
+	interval      time.Duration
+	batchSize     int
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewEscrowWorker creates a new escrow worker
+func NewEscrowWorker(escrowService *EscrowService, interval time.Duration, batchSize int) *EscrowWorker {
+	return &EscrowWorker{
+		escrowService: escrowService,
+		interval:      interval,
+		batchSize:     batchSize,
+	}
+}
+
+// Start runs the worker in the background until Stop is called
+func (w *EscrowWorker) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		return // Already running
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go w.loop(ctx, w.done)
+
+	utils.GetLogger().WithField("interval", w.interval.String()).Info("Escrow worker started")
+}
+
+// Stop signals the worker to finish and waits for the current run to end
+// or for ctx to expire, whichever comes first
+func (w *EscrowWorker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		utils.GetLogger().Info("Escrow worker stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("escrow worker did not stop in time: %w", ctx.Err())
+	}
+}
+
+// loop captures pending payments on every tick until ctx is cancelled
+func (w *EscrowWorker) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			utils.GetLogger().WithError(err).Error("Escrow run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce captures one batch of pending escrows
+func (w *EscrowWorker) RunOnce(ctx context.Context) error {
+	claimed, err := w.escrowService.CapturePending(ctx, w.batchSize)
+	if err != nil {
+		return err
+	}
+
+	if claimed > 0 {
+		utils.GetLogger().WithField("claimed", claimed).Info("Escrow run completed")
+	}
+	return nil
+}
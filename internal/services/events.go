@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+
+	"bagr-backend/internal/realtime"
+	"bagr-backend/internal/utils"
+)
+
+// appendEvent builds a live auction event and appends it to events.
+// userID addresses the event to a single user; 0 sends it to every subscriber.
+func appendEvent(events []realtime.Event, eventType realtime.EventType, auctionID, userID int, data interface{}) []realtime.Event {
+	event, err := realtime.NewEvent(eventType, auctionID, data)
+	if err != nil {
+		utils.GetLogger().WithError(err).WithField("auction_id", auctionID).Error("Failed to build auction event")
+		return events
+	}
+	event.UserID = userID
+	return append(events, event)
+}
+
+// publishEvents publishes live auction events once the transaction that
+// produced them has committed. The caller's cancellation is ignored so a
+// client disconnecting right after its bid does not swallow the update.
+func publishEvents(ctx context.Context, publisher realtime.Publisher, events []realtime.Event) {
+	if publisher == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, event := range events {
+		publisher.Publish(ctx, event)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// FX service errors
+var (
+	ErrInvalidExchangeRates = errors.New("invalid exchange rate table")
+	ErrNoExchangeRate       = errors.New("no exchange rate for the requested currency")
+)
+
+// rateFormat matches a positive decimal rate with at most ten decimal places
+var rateFormat = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,10})?$`)
+
+// rateDecimals is the precision rates are stored and shown with
+const rateDecimals = 10
+
+// FXService keeps the exchange rate table used to show amounts in a
+// client's preferred currency. Conversions are for display only: bids,
+// escrows and the ledger always stay in the auction's currency.
+type FXService struct {
+	db       *sql.DB
+	rateRepo repositories.ExchangeRateRepository
+	config   config.CurrencyConfig
+}
+
+// NewFXService creates a new FX service
+func NewFXService(db *sql.DB, rateRepo repositories.ExchangeRateRepository, cfg config.CurrencyConfig) *FXService {
+	return &FXService{
+		db:       db,
+		rateRepo: rateRepo,
+		config:   cfg,
+	}
+}
+
+// GetRates retrieves the current exchange rate table
+func (s *FXService) GetRates(ctx context.Context) (*models.ExchangeRateTable, error) {
+	rates, err := s.rateRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	table := &models.ExchangeRateTable{Base: s.config.Default, Rates: make(map[string]string)}
+	for _, rate := range rates {
+		value, ok := new(big.Rat).SetString(rate.Rate)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse exchange rate for %s: %q", rate.Currency, rate.Rate)
+		}
+		table.Base = rate.BaseCurrency
+		table.Rates[rate.Currency] = formatRate(value)
+		if table.UpdatedAt == nil || rate.UpdatedAt.After(*table.UpdatedAt) {
+			updatedAt := rate.UpdatedAt
+			table.UpdatedAt = &updatedAt
+		}
+	}
+	return table, nil
+}
+
+// SetRates replaces the exchange rate table. The base currency is added
+// with a rate of 1 when the table leaves it out.
+func (s *FXService) SetRates(ctx context.Context, table *models.ExchangeRateTable) (*models.ExchangeRateTable, error) {
+	base := strings.ToUpper(strings.TrimSpace(table.Base))
+	if !isCurrencyCode(base) {
+		return nil, fmt.Errorf("%w: base %q is not an ISO 4217 currency code", ErrInvalidExchangeRates, table.Base)
+	}
+
+	now := time.Now()
+	rates := []*models.ExchangeRate{{Currency: base, BaseCurrency: base, Rate: "1", UpdatedAt: now}}
+	for currency, value := range table.Rates {
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		value = strings.TrimSpace(value)
+		if !isCurrencyCode(currency) {
+			return nil, fmt.Errorf("%w: %q is not an ISO 4217 currency code", ErrInvalidExchangeRates, currency)
+		}
+		rate, ok := new(big.Rat).SetString(value)
+		if !rateFormat.MatchString(value) || !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: rate for %s must be a positive decimal with at most %d decimal places", ErrInvalidExchangeRates, currency, rateDecimals)
+		}
+		if currency == base {
+			if rate.Cmp(big.NewRat(1, 1)) != 0 {
+				return nil, fmt.Errorf("%w: the base currency must have a rate of 1", ErrInvalidExchangeRates)
+			}
+			continue
+		}
+		rates = append(rates, &models.ExchangeRate{Currency: currency, BaseCurrency: base, Rate: value, UpdatedAt: now})
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := repositories.NewExchangeRateRepository(tx).ReplaceAll(ctx, rates); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit exchange rates: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"base":       base,
+		"currencies": len(rates),
+	}).Info("Exchange rates updated")
+
+	return s.GetRates(ctx)
+}
+
+// LoadRatesFile replaces the exchange rate table with the one in a JSON file
+func (s *FXService) LoadRatesFile(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read exchange rates file: %w", err)
+	}
+
+	var table models.ExchangeRateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("failed to parse exchange rates file: %w", err)
+	}
+	if _, err := s.SetRates(ctx, &table); err != nil {
+		return err
+	}
+
+	utils.GetLogger().WithField("path", path).Info("Exchange rates loaded from file")
+	return nil
+}
+
+// Converter returns a converter into currency using the current rates
+func (s *FXService) Converter(ctx context.Context, currency string) (*CurrencyConverter, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	rates, err := s.rateRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	converter := &CurrencyConverter{currency: currency, rates: make(map[string]*big.Rat, len(rates))}
+	for _, rate := range rates {
+		value, ok := new(big.Rat).SetString(rate.Rate)
+		if !ok || value.Sign() <= 0 {
+			continue
+		}
+		converter.rates[rate.Currency] = value
+		if rate.UpdatedAt.After(converter.asOf) {
+			converter.asOf = rate.UpdatedAt
+		}
+	}
+	if _, ok := converter.rates[currency]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoExchangeRate, currency)
+	}
+
+	return converter, nil
+}
+
+// CurrencyConverter converts amounts into one display currency. A nil
+// converter converts nothing, so callers can use it whether or not the
+// client asked for a display currency.
+type CurrencyConverter struct {
+	currency string
+	rates    map[string]*big.Rat
+	asOf     time.Time
+}
+
+// rate returns the units of the display currency worth one unit of from
+func (c *CurrencyConverter) rate(from string) (*big.Rat, bool) {
+	fromRate, ok := c.rates[from]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).Quo(c.rates[c.currency], fromRate), true
+}
+
+// convert converts an amount at rate, rounding half away from zero to whole cents
+func (c *CurrencyConverter) convert(amount models.Money, rate *big.Rat) models.Money {
+	exact := new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Cents), rate)
+
+	cents, remainder := new(big.Int).QuoRem(new(big.Int).Abs(exact.Num()), exact.Denom(), new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(exact.Denom()) >= 0 {
+		cents.Add(cents, big.NewInt(1))
+	}
+	if exact.Sign() < 0 {
+		cents.Neg(cents)
+	}
+
+	return models.NewMoney(cents.Int64(), c.currency)
+}
+
+// AuctionPrices converts an auction's amounts. It returns nil when there is
+// no converter or no rate for the auction's currency.
+func (c *CurrencyConverter) AuctionPrices(auction *models.Auction) *models.DisplayPrices {
+	if c == nil {
+		return nil
+	}
+	rate, ok := c.rate(auction.Currency)
+	if !ok {
+		return nil
+	}
+
+	prices := &models.DisplayPrices{
+		Currency:       c.currency,
+		Rate:           formatRate(rate),
+		StartPrice:     c.convert(auction.StartPrice, rate),
+		NextMinimumBid: c.convert(auction.NextMinimumBid, rate),
+		RatesAsOf:      c.asOf,
+	}
+	if auction.ReservePrice != nil {
+		reservePrice := c.convert(*auction.ReservePrice, rate)
+		prices.ReservePrice = &reservePrice
+	}
+	if auction.CurrentBid != nil {
+		currentBid := c.convert(*auction.CurrentBid, rate)
+		prices.CurrentBid = &currentBid
+	}
+	return prices
+}
+
+// Amount converts a single amount. It returns nil when there is no
+// converter or no rate for the amount's currency.
+func (c *CurrencyConverter) Amount(amount models.Money) *models.DisplayAmount {
+	if c == nil {
+		return nil
+	}
+	rate, ok := c.rate(amount.Currency)
+	if !ok {
+		return nil
+	}
+
+	return &models.DisplayAmount{
+		Currency:  c.currency,
+		Rate:      formatRate(rate),
+		Amount:    c.convert(amount, rate),
+		RatesAsOf: c.asOf,
+	}
+}
+
+// formatRate formats a rate as a decimal without trailing zeros
+func formatRate(rate *big.Rat) string {
+	formatted := rate.FloatString(rateDecimals)
+	formatted = strings.TrimRight(formatted, "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+// isCurrencyCode reports whether code looks like an ISO 4217 currency code
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Ledger errors
+var (
+	ErrUnbalancedEntry   = errors.New("ledger entry does not balance")
+	ErrInsufficientFunds = errors.New("payout exceeds the seller's balance")
+	ErrInvalidPayout     = errors.New("payout amount must be positive")
+)
+
+// LedgerService keeps the double-entry ledger of the money moving through
+// the platform. Amounts are integer minor units; a posting debits its
+// account when positive and credits it when negative, and the postings of
+// every entry sum to zero in each currency.
+//
+// A captured payment debits platform cash and credits escrow holding.
+// Releasing it moves the escrow to the seller's payable account, less the
+// platform commission. A refund reverses everything recorded for the escrow,
+// and a payout debits the seller's payable account against platform cash.
+type LedgerService struct {
+	db         *sql.DB
+	ledgerRepo repositories.LedgerRepository
+	config     config.PaymentsConfig
+}
+
+// NewLedgerService creates a new ledger service
+func NewLedgerService(db *sql.DB, ledgerRepo repositories.LedgerRepository, cfg config.PaymentsConfig) *LedgerService {
+	return &LedgerService{
+		db:         db,
+		ledgerRepo: ledgerRepo,
+		config:     cfg,
+	}
+}
+
+// GetWallet retrieves what the platform owes a user in each currency and
+// the recent movements of those balances
+func (s *LedgerService) GetWallet(ctx context.Context, userID int, limit, offset int) (*models.WalletResponse, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	balances, err := s.ledgerRepo.ListUserBalances(ctx, userID, models.LedgerAccountSellerPayable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet balances: %w", err)
+	}
+	transactions, err := s.ledgerRepo.ListUserTransactions(ctx, userID, models.LedgerAccountSellerPayable, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet transactions: %w", err)
+	}
+
+	if balances == nil {
+		balances = []*models.WalletBalance{}
+	}
+	if transactions == nil {
+		transactions = []*models.WalletTransaction{}
+	}
+	return &models.WalletResponse{Balances: balances, Transactions: transactions}, nil
+}
+
+// RecordPayout records that part of a seller's balance was paid out to them
+func (s *LedgerService) RecordPayout(ctx context.Context, userID int, payout models.Money) (*models.LedgerEntry, error) {
+	if !payout.IsPositive() {
+		return nil, ErrInvalidPayout
+	}
+	amount := payout.Cents
+	currency := strings.ToUpper(payout.Currency)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ledgerRepo := repositories.NewLedgerRepository(tx)
+	payable, err := sellerPayableAccount(ctx, ledgerRepo, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := ledgerRepo.LockAccount(ctx, payable.ID); err != nil {
+		return nil, err
+	}
+	balance, err := ledgerRepo.GetBalance(ctx, payable.ID)
+	if err != nil {
+		return nil, err
+	}
+	if amount > -balance {
+		return nil, ErrInsufficientFunds
+	}
+
+	cash, err := platformAccount(ctx, ledgerRepo, models.LedgerAccountPlatformCash, models.LedgerAccountTypeAsset, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate payout reference: %w", err)
+	}
+
+	entry := &models.LedgerEntry{
+		Kind:        models.LedgerEntryKindPayout,
+		Reference:   fmt.Sprintf("payout:%d:%s", userID, hex.EncodeToString(nonce)),
+		Description: "Payout to seller",
+		Postings: []*models.LedgerPosting{
+			{AccountID: payable.ID, Amount: models.NewMoney(amount, currency), Currency: currency},
+			{AccountID: cash.ID, Amount: models.NewMoney(-amount, currency), Currency: currency},
+		},
+	}
+	if err := postEntry(ctx, ledgerRepo, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit payout: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"user_id":  userID,
+		"amount":   amount,
+		"currency": currency,
+	}).Info("Payout recorded")
+
+	return entry, nil
+}
+
+// RecordEscrow records the money moved by an escrow reaching status to.
+// ledgerRepo must share the transaction that moves the escrow, so the ledger
+// and the escrow cannot disagree. Statuses that move no money record nothing.
+func (s *LedgerService) RecordEscrow(ctx context.Context, ledgerRepo repositories.LedgerRepository, escrow *models.Escrow, to models.EscrowStatus) error {
+	amount := escrow.Amount.Cents
+	currency := escrow.Currency
+
+	entry := &models.LedgerEntry{
+		Reference: fmt.Sprintf("escrow:%d:%s", escrow.ID, to),
+		EscrowID:  &escrow.ID,
+	}
+
+	switch to {
+	case models.EscrowStatusCaptured:
+		cash, err := platformAccount(ctx, ledgerRepo, models.LedgerAccountPlatformCash, models.LedgerAccountTypeAsset, currency)
+		if err != nil {
+			return err
+		}
+		holding, err := platformAccount(ctx, ledgerRepo, models.LedgerAccountEscrowHolding, models.LedgerAccountTypeLiability, currency)
+		if err != nil {
+			return err
+		}
+
+		entry.Kind = models.LedgerEntryKindCharge
+		entry.Description = fmt.Sprintf("Payment for auction %d", escrow.AuctionID)
+		entry.Postings = []*models.LedgerPosting{
+			{AccountID: cash.ID, Amount: models.NewMoney(amount, currency), Currency: currency},
+			{AccountID: holding.ID, Amount: models.NewMoney(-amount, currency), Currency: currency},
+		}
+
+	case models.EscrowStatusReleased:
+		holding, err := platformAccount(ctx, ledgerRepo, models.LedgerAccountEscrowHolding, models.LedgerAccountTypeLiability, currency)
+		if err != nil {
+			return err
+		}
+		commission, err := platformAccount(ctx, ledgerRepo, models.LedgerAccountPlatformCommission, models.LedgerAccountTypeRevenue, currency)
+		if err != nil {
+			return err
+		}
+		payable, err := sellerPayableAccount(ctx, ledgerRepo, escrow.SellerID, currency)
+		if err != nil {
+			return err
+		}
+
+		fee := s.commission(amount)
+		entry.Kind = models.LedgerEntryKindRelease
+		entry.Description = fmt.Sprintf("Sale of auction %d", escrow.AuctionID)
+		entry.Postings = []*models.LedgerPosting{
+			{AccountID: holding.ID, Amount: models.NewMoney(amount, currency), Currency: currency},
+			{AccountID: commission.ID, Amount: models.NewMoney(-fee, currency), Currency: currency},
+			{AccountID: payable.ID, Amount: models.NewMoney(-(amount - fee), currency), Currency: currency},
+		}
+
+	case models.EscrowStatusRefunded:
+		// Reversing every entry of the escrow undoes exactly what was
+		// recorded, whatever the commission rate is today
+		entries, err := ledgerRepo.ListEntriesByEscrow(ctx, escrow.ID)
+		if err != nil {
+			return err
+		}
+
+		entry.Kind = models.LedgerEntryKindRefund
+		entry.Description = fmt.Sprintf("Refund for auction %d", escrow.AuctionID)
+		entry.Postings = reversePostings(entries)
+		if len(entry.Postings) == 0 {
+			return nil // Nothing was charged
+		}
+
+	default:
+		return nil
+	}
+
+	return postEntry(ctx, ledgerRepo, entry)
+}
+
+// commission returns the platform's cut of a sale, rounded to the nearest minor unit
+func (s *LedgerService) commission(amount int64) int64 {
+	return (amount*int64(s.config.CommissionBps) + 5000) / 10000
+}
+
+// reversePostings returns postings undoing the net effect of entries on each account
+func reversePostings(entries []*models.LedgerEntry) []*models.LedgerPosting {
+	type accountCurrency struct {
+		accountID int
+		currency  string
+	}
+
+	var order []accountCurrency
+	net := make(map[accountCurrency]int64)
+	for _, entry := range entries {
+		for _, posting := range entry.Postings {
+			key := accountCurrency{posting.AccountID, posting.Currency}
+			if _, seen := net[key]; !seen {
+				order = append(order, key)
+			}
+			net[key] += posting.Amount.Cents
+		}
+	}
+
+	var postings []*models.LedgerPosting
+	for _, key := range order {
+		if net[key] != 0 {
+			postings = append(postings, &models.LedgerPosting{AccountID: key.accountID, Amount: models.NewMoney(-net[key], key.currency), Currency: key.currency})
+		}
+	}
+	return postings
+}
+
+// postEntry checks that an entry balances and records it. Zero postings
+// are dropped; the database enforces the same invariant at commit.
+func postEntry(ctx context.Context, ledgerRepo repositories.LedgerRepository, entry *models.LedgerEntry) error {
+	postings := entry.Postings[:0]
+	sums := make(map[string]int64)
+	for _, posting := range entry.Postings {
+		if posting.Amount.IsZero() {
+			continue
+		}
+		sums[posting.Currency] += posting.Amount.Cents
+		postings = append(postings, posting)
+	}
+	entry.Postings = postings
+
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("%w: %s has fewer than two postings", ErrUnbalancedEntry, entry.Reference)
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: %s is off by %d %s", ErrUnbalancedEntry, entry.Reference, sum, currency)
+		}
+	}
+
+	return ledgerRepo.CreateEntry(ctx, entry)
+}
+
+// platformAccount returns one of the platform's own accounts
+func platformAccount(ctx context.Context, ledgerRepo repositories.LedgerRepository, code string, accountType models.LedgerAccountType, currency string) (*models.LedgerAccount, error) {
+	account := &models.LedgerAccount{Code: code, Type: accountType, Currency: currency}
+	if err := ledgerRepo.GetOrCreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// sellerPayableAccount returns the account of what the platform owes a seller
+func sellerPayableAccount(ctx context.Context, ledgerRepo repositories.LedgerRepository, userID int, currency string) (*models.LedgerAccount, error) {
+	account := &models.LedgerAccount{
+		Code:     models.LedgerAccountSellerPayable,
+		Type:     models.LedgerAccountTypeLiability,
+		UserID:   &userID,
+		Currency: currency,
+	}
+	if err := ledgerRepo.GetOrCreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
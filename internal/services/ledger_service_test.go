@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+)
+
+// fakeLedgerRepository keeps accounts and entries in memory. Methods the
+// ledger service doesn't use panic through the nil embedded interface.
+type fakeLedgerRepository struct {
+	repositories.LedgerRepository
+	accounts []*models.LedgerAccount
+	entries  []*models.LedgerEntry
+}
+
+func (r *fakeLedgerRepository) GetOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error {
+	for _, existing := range r.accounts {
+		if existing.Code == account.Code && existing.Currency == account.Currency &&
+			(existing.UserID == nil) == (account.UserID == nil) && (existing.UserID == nil || *existing.UserID == *account.UserID) {
+			*account = *existing
+			return nil
+		}
+	}
+	stored := *account
+	stored.ID = len(r.accounts) + 1
+	r.accounts = append(r.accounts, &stored)
+	account.ID = stored.ID
+	return nil
+}
+
+func (r *fakeLedgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) error {
+	for _, existing := range r.entries {
+		if existing.Reference == entry.Reference {
+			return errors.New("duplicate ledger reference")
+		}
+	}
+	entry.ID = len(r.entries) + 1
+	for _, posting := range entry.Postings {
+		posting.EntryID = entry.ID
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *fakeLedgerRepository) ListEntriesByEscrow(ctx context.Context, escrowID int) ([]*models.LedgerEntry, error) {
+	var entries []*models.LedgerEntry
+	for _, entry := range r.entries {
+		if entry.EscrowID != nil && *entry.EscrowID == escrowID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// balance returns the sum of the postings to the account with code, and
+// for seller accounts userID
+func (r *fakeLedgerRepository) balance(t *testing.T, code string, userID *int) int64 {
+	t.Helper()
+	for _, account := range r.accounts {
+		if account.Code != code || (account.UserID == nil) != (userID == nil) || (userID != nil && *account.UserID != *userID) {
+			continue
+		}
+		var sum int64
+		for _, entry := range r.entries {
+			for _, posting := range entry.Postings {
+				if posting.AccountID == account.ID {
+					sum += posting.Amount.Cents
+				}
+			}
+		}
+		return sum
+	}
+	t.Fatalf("no %s account", code)
+	return 0
+}
+
+// assertBalanced fails the test unless every entry's postings sum to zero in
+// each currency, each in the currency of its amount
+func assertBalanced(t *testing.T, entries []*models.LedgerEntry) {
+	t.Helper()
+	for _, entry := range entries {
+		sums := map[string]int64{}
+		for _, posting := range entry.Postings {
+			if posting.Amount.Currency != posting.Currency {
+				t.Errorf("%s: %s posting in %s", entry.Reference, posting.Amount.Currency, posting.Currency)
+			}
+			sums[posting.Currency] += posting.Amount.Cents
+		}
+		for currency, sum := range sums {
+			if sum != 0 {
+				t.Errorf("%s: postings sum to %d %s", entry.Reference, sum, currency)
+			}
+		}
+	}
+}
+
+func newTestLedgerService() *LedgerService {
+	return NewLedgerService(nil, nil, config.PaymentsConfig{CommissionBps: 1000})
+}
+
+func TestLedgerEscrowLifecycle(t *testing.T) {
+	ctx := context.Background()
+	service := newTestLedgerService()
+	repo := &fakeLedgerRepository{}
+	sellerID := 7
+	escrow := &models.Escrow{ID: 3, AuctionID: 11, SellerID: sellerID, Amount: models.NewMoney(1999, "EUR"), Currency: "EUR"}
+
+	tests := []struct {
+		to             models.EscrowStatus
+		wantEntries    int
+		wantCash       int64
+		wantHolding    int64
+		wantCommission int64
+		wantPayable    int64
+	}{
+		{models.EscrowStatusCaptured, 1, 1999, -1999, 0, 0},
+		// 10% of 19.99 rounds to 2.00
+		{models.EscrowStatusReleased, 2, 1999, 0, -200, -1799},
+		{models.EscrowStatusRefunded, 3, 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if err := service.RecordEscrow(ctx, repo, escrow, tt.to); err != nil {
+			t.Fatalf("RecordEscrow(%s): %v", tt.to, err)
+		}
+		if len(repo.entries) != tt.wantEntries {
+			t.Fatalf("after %s: %d entries, want %d", tt.to, len(repo.entries), tt.wantEntries)
+		}
+		assertBalanced(t, repo.entries)
+
+		if got := repo.balance(t, models.LedgerAccountPlatformCash, nil); got != tt.wantCash {
+			t.Errorf("after %s: platform cash = %d, want %d", tt.to, got, tt.wantCash)
+		}
+		if got := repo.balance(t, models.LedgerAccountEscrowHolding, nil); got != tt.wantHolding {
+			t.Errorf("after %s: escrow holding = %d, want %d", tt.to, got, tt.wantHolding)
+		}
+		if tt.to == models.EscrowStatusCaptured {
+			continue // Commission and seller accounts are opened on release
+		}
+		if got := repo.balance(t, models.LedgerAccountPlatformCommission, nil); got != tt.wantCommission {
+			t.Errorf("after %s: commission = %d, want %d", tt.to, got, tt.wantCommission)
+		}
+		if got := repo.balance(t, models.LedgerAccountSellerPayable, &sellerID); got != tt.wantPayable {
+			t.Errorf("after %s: seller payable = %d, want %d", tt.to, got, tt.wantPayable)
+		}
+	}
+
+	// Once refunded the escrow nets to zero, so refunding again moves nothing
+	if err := service.RecordEscrow(ctx, repo, escrow, models.EscrowStatusRefunded); err != nil {
+		t.Fatalf("second refund: %v", err)
+	}
+	if len(repo.entries) != 3 {
+		t.Errorf("second refund recorded an entry")
+	}
+}
+
+func TestLedgerRefundBeforeRelease(t *testing.T) {
+	ctx := context.Background()
+	service := newTestLedgerService()
+	repo := &fakeLedgerRepository{}
+	escrow := &models.Escrow{ID: 3, AuctionID: 11, SellerID: 7, Amount: models.NewMoney(5000, "USD"), Currency: "USD"}
+
+	for _, to := range []models.EscrowStatus{models.EscrowStatusCaptured, models.EscrowStatusDisputed, models.EscrowStatusRefunded} {
+		if err := service.RecordEscrow(ctx, repo, escrow, to); err != nil {
+			t.Fatalf("RecordEscrow(%s): %v", to, err)
+		}
+	}
+	assertBalanced(t, repo.entries)
+
+	// A dispute moves no money; the refund returns the charge
+	if len(repo.entries) != 2 || repo.entries[1].Kind != models.LedgerEntryKindRefund {
+		t.Fatalf("entries = %+v, want a charge and a refund", repo.entries)
+	}
+	if got := repo.balance(t, models.LedgerAccountPlatformCash, nil); got != 0 {
+		t.Errorf("platform cash = %d, want 0", got)
+	}
+	if got := repo.balance(t, models.LedgerAccountEscrowHolding, nil); got != 0 {
+		t.Errorf("escrow holding = %d, want 0", got)
+	}
+}
+
+func TestLedgerRefundWithoutCharge(t *testing.T) {
+	repo := &fakeLedgerRepository{}
+	escrow := &models.Escrow{ID: 3, AuctionID: 11, SellerID: 7, Amount: models.NewMoney(5000, "USD"), Currency: "USD"}
+
+	if err := newTestLedgerService().RecordEscrow(context.Background(), repo, escrow, models.EscrowStatusRefunded); err != nil {
+		t.Fatalf("RecordEscrow: %v", err)
+	}
+	if len(repo.entries) != 0 {
+		t.Errorf("refund of an uncharged escrow recorded %d entries", len(repo.entries))
+	}
+}
+
+func TestPostEntry(t *testing.T) {
+	posting := func(accountID int, cents int64, currency string) *models.LedgerPosting {
+		return &models.LedgerPosting{AccountID: accountID, Amount: models.NewMoney(cents, currency), Currency: currency}
+	}
+
+	tests := []struct {
+		name         string
+		postings     []*models.LedgerPosting
+		wantErr      error
+		wantPostings int
+	}{
+		{"balanced", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, -500, "USD")}, nil, 2},
+		{"split", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, -50, "USD"), posting(3, -450, "USD")}, nil, 3},
+		{"zero postings dropped", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, 0, "USD"), posting(3, -500, "USD")}, nil, 2},
+		{"balanced in each currency", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, -500, "USD"), posting(1, 300, "EUR"), posting(2, -300, "EUR")}, nil, 4},
+		{"off by a cent", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, -499, "USD")}, ErrUnbalancedEntry, 0},
+		{"balanced only across currencies", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, -500, "EUR")}, ErrUnbalancedEntry, 0},
+		{"single posting", []*models.LedgerPosting{posting(1, 500, "USD"), posting(2, 0, "USD")}, ErrUnbalancedEntry, 0},
+		{"no postings", nil, ErrUnbalancedEntry, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeLedgerRepository{}
+			entry := &models.LedgerEntry{Reference: "test", Postings: tt.postings}
+			err := postEntry(context.Background(), repo, entry)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.entries) != 0 {
+					t.Error("unbalanced entry recorded")
+				}
+				return
+			}
+			if len(repo.entries) != 1 || len(repo.entries[0].Postings) != tt.wantPostings {
+				t.Errorf("recorded %+v, want one entry of %d postings", repo.entries, tt.wantPostings)
+			}
+		})
+	}
+}
+
+func TestRecordPayoutRefusesNonPositiveAmounts(t *testing.T) {
+	service := newTestLedgerService()
+	for _, cents := range []int64{0, -100} {
+		if _, err := service.RecordPayout(context.Background(), 7, models.NewMoney(cents, "USD")); !errors.Is(err, ErrInvalidPayout) {
+			t.Errorf("payout of %d: got %v, want ErrInvalidPayout", cents, err)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Local storage errors
+var (
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	ErrInvalidKey       = errors.New("invalid object key")
+)
+
+// Local storage directories for public and private objects
+const (
+	localPublicDir  = "public"
+	localPrivateDir = "private"
+)
+
+// LocalStorage stores objects on the local disk, for development without AWS.
+// Public objects are served as static files under {baseURL}/public/; private
+// objects and direct uploads go through HMAC-signed URLs that the storage
+// controller checks, standing in for S3 presigned URLs.
+type LocalStorage struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	logger     *logrus.Logger
+}
+
+// NewLocalStorage creates a local disk storage rooted at root. baseURL is
+// the external URL the storage routes are served under.
+func NewLocalStorage(root, baseURL, signingKey string, logger *logrus.Logger) (*LocalStorage, error) {
+	if signingKey == "" {
+		return nil, fmt.Errorf("local storage requires a signing key")
+	}
+
+	for _, dir := range []string{localPublicDir, localPrivateDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+	}
+
+	return &LocalStorage{
+		root:       root,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		signingKey: []byte(signingKey),
+		logger:     logger,
+	}, nil
+}
+
+// PublicDir returns the directory of public objects, served as static files
+func (s *LocalStorage) PublicDir() string {
+	return filepath.Join(s.root, localPublicDir)
+}
+
+// Put writes an object to disk. The file is written under a temporary name
+// and renamed, so readers never see a partial object.
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string, public bool) error {
+	dir := localPrivateDir
+	if public {
+		dir = localPublicDir
+	}
+	path, err := s.path(dir, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create object directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(body, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("key", key).Error("Failed to write object to disk")
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("failed to write object: expected %d bytes, got %d", size, written)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+
+	// An object only lives in one of the two directories
+	if other, err := s.path(otherDir(dir), key); err == nil {
+		os.Remove(other)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"key":  key,
+		"size": size,
+	}).Info("Object written to disk successfully")
+
+	return nil
+}
+
+// Delete removes an object. Deleting a missing object is not an error.
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.locate(key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.WithError(err).WithField("key", key).Error("Failed to delete object from disk")
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	s.logger.WithField("key", key).Info("Object deleted from disk successfully")
+	return nil
+}
+
+// Stat returns an object's size and the content type implied by its key
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := s.locate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return &ObjectInfo{
+		Size:        info.Size(),
+		ContentType: contentTypeForKey(key),
+	}, nil
+}
+
+// Open returns random access to an object. Each read opens the file, so the
+// reader holds no file handle between reads.
+func (s *LocalStorage) Open(ctx context.Context, key string) io.ReaderAt {
+	return &localObject{storage: s, key: key}
+}
+
+// URL returns the static URL of a public object
+func (s *LocalStorage) URL(key string) string {
+	return fmt.Sprintf("%s/%s/%s", s.baseURL, localPublicDir, key)
+}
+
+// Key returns the key of an object URL returned by URL.
+// It returns false for URLs that point elsewhere.
+func (s *LocalStorage) Key(objectURL string) (string, bool) {
+	prefix := fmt.Sprintf("%s/%s/", s.baseURL, localPublicDir)
+	key := strings.TrimPrefix(objectURL, prefix)
+	return key, key != objectURL && key != ""
+}
+
+// PresignPut issues a signed upload URL handled by the storage controller.
+// The content type, size and visibility are part of the signature.
+func (s *LocalStorage) PresignPut(ctx context.Context, key, contentType string, size int64, public bool, expires time.Duration) (*PresignedRequest, error) {
+	if _, err := s.path(localPublicDir, key); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(expires)
+	query := url.Values{
+		"content_type": {contentType},
+		"size":         {strconv.FormatInt(size, 10)},
+		"public":       {strconv.FormatBool(public)},
+		"expires":      {strconv.FormatInt(expiresAt.Unix(), 10)},
+	}
+	query.Set("signature", s.sign("PUT", key, query))
+
+	return &PresignedRequest{
+		URL:    fmt.Sprintf("%s/upload/%s?%s", s.baseURL, key, query.Encode()),
+		Method: "PUT",
+		Headers: map[string]string{
+			"Content-Type":   contentType,
+			"Content-Length": strconv.FormatInt(size, 10),
+		},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// PresignGet issues a signed download URL handled by the storage controller
+func (s *LocalStorage) PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error) {
+	if _, err := s.path(localPrivateDir, key); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(expires)
+	query := url.Values{
+		"expires": {strconv.FormatInt(expiresAt.Unix(), 10)},
+	}
+	query.Set("signature", s.sign("GET", key, query))
+
+	return &PresignedRequest{
+		URL:       fmt.Sprintf("%s/%s/%s?%s", s.baseURL, localPrivateDir, key, query.Encode()),
+		Method:    "GET",
+		Headers:   map[string]string{},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ReceiveUpload stores the body of a request made with a PresignPut URL,
+// after checking its signature and that the request matches what was signed
+func (s *LocalStorage) ReceiveUpload(ctx context.Context, key string, query url.Values, contentType string, size int64, body io.Reader) error {
+	if err := s.verify("PUT", key, query); err != nil {
+		return err
+	}
+	if contentType != query.Get("content_type") || strconv.FormatInt(size, 10) != query.Get("size") {
+		return ErrInvalidSignature
+	}
+
+	return s.Put(ctx, key, body, size, contentType, query.Get("public") == "true")
+}
+
+// ResolveDownload checks a PresignGet URL and returns the path and content
+// type of the object to serve
+func (s *LocalStorage) ResolveDownload(key string, query url.Values) (string, string, error) {
+	if err := s.verify("GET", key, query); err != nil {
+		return "", "", err
+	}
+
+	path, err := s.locate(key)
+	if err != nil {
+		return "", "", err
+	}
+	return path, contentTypeForKey(key), nil
+}
+
+// sign computes the signature of a request for key over the query parameters
+// (in a fixed order), excluding the signature itself
+func (s *LocalStorage) sign(method, key string, query url.Values) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s\n%s", method, key)
+	for _, name := range []string{"content_type", "size", "public", "expires"} {
+		fmt.Fprintf(mac, "\n%s=%s", name, query.Get(name))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a signed request's signature and expiry
+func (s *LocalStorage) verify(method, key string, query url.Values) error {
+	expected := s.sign(method, key, query)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return ErrInvalidSignature
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// path returns the file path of key in dir, rejecting keys that would
+// escape the storage root
+func (s *LocalStorage) path(dir, key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(s.root, dir, filepath.FromSlash(key)), nil
+}
+
+// locate returns the file path of an existing object, public or private
+func (s *LocalStorage) locate(key string) (string, error) {
+	for _, dir := range []string{localPublicDir, localPrivateDir} {
+		path, err := s.path(dir, key)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrObjectNotFound
+}
+
+// otherDir returns the directory an object of the other visibility lives in
+func otherDir(dir string) string {
+	if dir == localPublicDir {
+		return localPrivateDir
+	}
+	return localPublicDir
+}
+
+// localObject implements io.ReaderAt over an object on disk
+type localObject struct {
+	storage *LocalStorage
+	key     string
+}
+
+// ReadAt reads len(p) bytes starting at off
+func (o *localObject) ReadAt(p []byte, off int64) (int, error) {
+	path, err := o.storage.locate(o.key)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return file.ReadAt(p, off)
+}
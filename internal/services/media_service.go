@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Media upload errors
+var (
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadNotPending  = errors.New("upload has already been completed")
+	ErrUploadNotReceived = errors.New("file has not been uploaded yet")
+	ErrUploadMismatch    = errors.New("uploaded file does not match the requested upload")
+	ErrUploadForbidden   = errors.New("only artists, producers and admins can upload tracks")
+	ErrUnsupportedImage  = errors.New("unsupported image format, only JPEG, PNG, GIF and WebP are allowed")
+	ErrImageTooLarge     = errors.New("image file is too large")
+)
+
+// trackUploadRoles are the roles allowed to upload track audio
+var trackUploadRoles = map[models.UserRole]bool{
+	models.UserRoleArtist:   true,
+	models.UserRoleProducer: true,
+	models.UserRoleAdmin:    true,
+}
+
+// CompletedUpload is a verified upload and what it was attached to
+type CompletedUpload struct {
+	Upload   *models.MediaUpload
+	Track    *models.Track // Set for track audio
+	ImageURL string        // Set for profile images
+}
+
+// MediaService issues presigned URLs for uploading media straight to storage and
+// attaches the uploaded objects once they have been verified, so large files
+// never pass through the API servers
+type MediaService struct {
+	uploadRepo     repositories.MediaUploadRepository
+	trackService   *TrackService
+	profileService *ProfileService
+	storage        Storage
+	config         config.MediaConfig
+}
+
+// NewMediaService creates a new media service
+func NewMediaService(uploadRepo repositories.MediaUploadRepository, trackService *TrackService, profileService *ProfileService, storage Storage, cfg config.MediaConfig) *MediaService {
+	return &MediaService{
+		uploadRepo:     uploadRepo,
+		trackService:   trackService,
+		profileService: profileService,
+		storage:        storage,
+		config:         cfg,
+	}
+}
+
+// CreateUpload validates a requested upload and issues a presigned PUT URL for it.
+// The content type and size are signed into the URL, so storage refuses any other file.
+func (s *MediaService) CreateUpload(ctx context.Context, userID int, role string, req *models.CreateMediaUploadRequest) (*models.MediaUpload, *PresignedRequest, error) {
+	var key string
+	public := false
+
+	switch req.Kind {
+	case models.MediaUploadTrackAudio:
+		if !trackUploadRoles[models.UserRole(role)] {
+			return nil, nil, ErrUploadForbidden
+		}
+		if getAudioExtension(req.ContentType) == "" {
+			return nil, nil, ErrUnsupportedAudio
+		}
+		if req.Size > s.trackService.MaxAudioSize() {
+			return nil, nil, fmt.Errorf("%w: limit is %d MB", ErrAudioTooLarge, s.config.MaxAudioSizeMB)
+		}
+		key = TrackAudioKey(userID, req.ContentType)
+	case models.MediaUploadProfileImage:
+		if !ValidateImageType(req.ContentType) {
+			return nil, nil, ErrUnsupportedImage
+		}
+		if req.Size > int64(s.config.MaxImageSizeMB)<<20 {
+			return nil, nil, fmt.Errorf("%w: limit is %d MB", ErrImageTooLarge, s.config.MaxImageSizeMB)
+		}
+		key = ProfileImageKey(userID, req.ContentType)
+		public = true
+	default:
+		return nil, nil, fmt.Errorf("unknown upload kind %q", req.Kind)
+	}
+
+	expires := time.Duration(s.config.UploadURLExpiry) * time.Second
+	presigned, err := s.storage.PresignPut(ctx, key, req.ContentType, req.Size, public, expires)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upload := &models.MediaUpload{
+		UserID:      userID,
+		Kind:        req.Kind,
+		ObjectKey:   key,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		Status:      models.MediaUploadStatusPending,
+		ExpiresAt:   presigned.ExpiresAt,
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		return nil, nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"upload_id": upload.ID,
+		"user_id":   userID,
+		"kind":      req.Kind,
+		"size":      req.Size,
+	}).Info("Upload URL issued")
+
+	return upload, presigned, nil
+}
+
+// CompleteUpload verifies that a pending upload's object exists with the
+// declared size and content type, then attaches it: track audio becomes a
+// draft track described by req, a profile image replaces the user's image.
+//
+// If the file has not arrived, or the track details are wrong, the upload
+// stays pending and can be completed again. A file that fails verification
+// is deleted and the upload rejected.
+func (s *MediaService) CompleteUpload(ctx context.Context, userID, uploadID int, req *models.UploadTrackRequest) (*CompletedUpload, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+	if upload == nil || upload.UserID != userID {
+		return nil, ErrUploadNotFound
+	}
+	if upload.Status != models.MediaUploadStatusPending {
+		return nil, ErrUploadNotPending
+	}
+
+	claimed, err := s.uploadRepo.Transition(ctx, upload.ID, models.MediaUploadStatusPending, models.MediaUploadStatusProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim upload: %w", err)
+	}
+	if !claimed {
+		return nil, ErrUploadNotPending
+	}
+
+	info, err := s.storage.Stat(ctx, upload.ObjectKey)
+	if err != nil {
+		s.release(ctx, upload)
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, ErrUploadNotReceived
+		}
+		return nil, err
+	}
+	if info.Size != upload.Size || info.ContentType != upload.ContentType {
+		s.reject(ctx, upload)
+		return nil, fmt.Errorf("%w: expected %d bytes of %s, got %d bytes of %s",
+			ErrUploadMismatch, upload.Size, upload.ContentType, info.Size, info.ContentType)
+	}
+
+	result := &CompletedUpload{Upload: upload}
+	var trackID *int
+
+	switch upload.Kind {
+	case models.MediaUploadTrackAudio:
+		track, err := s.trackService.CreateTrackFromObject(ctx, userID, req, upload.ObjectKey, upload.ContentType, upload.Size)
+		if err != nil {
+			if isRejectedAudio(err) {
+				s.reject(ctx, upload)
+			} else {
+				s.release(ctx, upload)
+			}
+			return nil, err
+		}
+		result.Track = track
+		trackID = &track.ID
+	case models.MediaUploadProfileImage:
+		imageURL := s.storage.URL(upload.ObjectKey)
+		if err := s.profileService.UpdateProfileImage(userID, imageURL); err != nil {
+			s.release(ctx, upload)
+			return nil, err
+		}
+		result.ImageURL = imageURL
+	}
+
+	if err := s.uploadRepo.MarkCompleted(ctx, upload.ID, trackID); err != nil {
+		return nil, err
+	}
+	upload.Status = models.MediaUploadStatusCompleted
+	upload.TrackID = trackID
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"upload_id": upload.ID,
+		"user_id":   userID,
+		"kind":      upload.Kind,
+	}).Info("Upload completed successfully")
+
+	return result, nil
+}
+
+// release returns a claimed upload to pending so it can be completed again
+func (s *MediaService) release(ctx context.Context, upload *models.MediaUpload) {
+	ctx = context.WithoutCancel(ctx)
+	if _, err := s.uploadRepo.Transition(ctx, upload.ID, models.MediaUploadStatusProcessing, models.MediaUploadStatusPending); err != nil {
+		utils.GetLogger().WithError(err).WithField("upload_id", upload.ID).Error("Failed to release upload")
+	}
+}
+
+// reject deletes a claimed upload's object and marks the upload rejected
+func (s *MediaService) reject(ctx context.Context, upload *models.MediaUpload) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.storage.Delete(ctx, upload.ObjectKey); err != nil {
+		utils.GetLogger().WithError(err).WithField("upload_id", upload.ID).Error("Failed to delete rejected upload")
+	}
+	if _, err := s.uploadRepo.Transition(ctx, upload.ID, models.MediaUploadStatusProcessing, models.MediaUploadStatusRejected); err != nil {
+		utils.GetLogger().WithError(err).WithField("upload_id", upload.ID).Error("Failed to reject upload")
+	}
+}
+
+// isRejectedAudio reports whether a track creation error means the audio
+// file itself is unacceptable, rather than the details sent with it
+func isRejectedAudio(err error) bool {
+	return errors.Is(err, ErrUnsupportedAudio) ||
+		errors.Is(err, ErrInvalidAudio) ||
+		errors.Is(err, ErrAudioTypeMismatch) ||
+		errors.Is(err, ErrAudioTooLarge) ||
+		errors.Is(err, ErrEmptyAudio)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bagr-backend/internal/audio"
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Preview errors
+var (
+	ErrPreviewUnavailable  = errors.New("previews are only available for tracks in an open auction")
+	ErrInvalidPreviewToken = errors.New("invalid or expired preview link")
+)
+
+// PreviewToken is a signed link to stream a track's preview
+type PreviewToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// PreviewService lets bidders listen to the opening of an auctioned track
+// without releasing the master. Previews are cut from the stored audio on
+// the fly and served through short-lived signed tokens so they can be played
+// by <audio> elements that cannot send headers. Each preview carries a
+// metadata tag naming its listener. The tag is not an audio watermark: the
+// audio is left as is, and any tag editor removes it.
+type PreviewService struct {
+	trackService *TrackService
+	auctionRepo  repositories.AuctionRepository
+	storage      Storage
+	config       config.MediaConfig
+}
+
+// NewPreviewService creates a new preview service
+func NewPreviewService(trackService *TrackService, auctionRepo repositories.AuctionRepository, storage Storage, cfg config.MediaConfig) *PreviewService {
+	return &PreviewService{
+		trackService: trackService,
+		auctionRepo:  auctionRepo,
+		storage:      storage,
+		config:       cfg,
+	}
+}
+
+// IssueToken issues a preview token for a track. The track's artist and
+// admins may always preview it; other users only while it is in a draft or
+// active auction.
+func (s *PreviewService) IssueToken(ctx context.Context, userID int, role string, trackID int) (*PreviewToken, error) {
+	track, err := s.trackService.GetTrack(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.storage.Key(track.FileURL); !ok {
+		return nil, ErrTrackAudioUnavailable
+	}
+
+	if track.ArtistID != userID && role != string(models.UserRoleAdmin) {
+		auction, err := s.auctionRepo.GetOpenByTrackID(ctx, trackID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get track auction: %w", err)
+		}
+		if auction == nil {
+			return nil, ErrPreviewUnavailable
+		}
+	}
+
+	expiresAt := time.Now().Add(time.Duration(s.config.PreviewTokenTTL) * time.Second)
+	payload := fmt.Sprintf("%d.%d.%d", trackID, userID, expiresAt.Unix())
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sign(payload)
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"track_id": trackID,
+		"user_id":  userID,
+	}).Info("Preview token issued")
+
+	return &PreviewToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// OpenPreview checks a preview token and returns the preview clip it grants:
+// the opening seconds of the track, with a metadata tag naming the user the
+// token was issued to
+func (s *PreviewService) OpenPreview(ctx context.Context, token string) (*audio.Clip, error) {
+	trackID, userID, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	track, err := s.trackService.GetTrack(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := s.storage.Key(track.FileURL)
+	if !ok {
+		return nil, ErrTrackAudioUnavailable
+	}
+
+	info, err := s.storage.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, ErrTrackAudioUnavailable
+		}
+		return nil, err
+	}
+
+	listenerTag := fmt.Sprintf("BAGR preview of track %d for user %d", trackID, userID)
+	clip, err := audio.Excerpt(s.storage.Open(ctx, key), info.Size, float64(s.config.PreviewSeconds), listenerTag)
+	if err != nil {
+		utils.GetLogger().WithError(err).WithField("track_id", trackID).Error("Failed to cut track preview")
+		return nil, fmt.Errorf("failed to cut preview: %w", err)
+	}
+
+	return clip, nil
+}
+
+// sign computes the signature of a token payload
+func (s *PreviewService) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.PreviewSigningKey))
+	mac.Write([]byte("preview\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a token's signature and expiry and returns the track and user it was issued for
+func (s *PreviewService) verify(token string) (int, int, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, 0, ErrInvalidPreviewToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, 0, ErrInvalidPreviewToken
+	}
+	if !hmac.Equal([]byte(s.sign(string(payload))), []byte(signature)) {
+		return 0, 0, ErrInvalidPreviewToken
+	}
+
+	var trackID, userID int
+	var expires int64
+	if _, err := fmt.Sscanf(string(payload), "%d.%d.%d", &trackID, &userID, &expires); err != nil {
+		return 0, 0, ErrInvalidPreviewToken
+	}
+	if time.Now().Unix() > expires {
+		return 0, 0, ErrInvalidPreviewToken
+	}
+
+	return trackID, userID, nil
+}
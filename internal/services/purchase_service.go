@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Purchase errors
+var (
+	ErrPurchaseNotFound     = errors.New("purchase not found")
+	ErrInvalidDownloadToken = errors.New("download link is invalid, expired or already used")
+	ErrPaymentRequired      = errors.New("the purchase has not been paid for yet")
+)
+
+// DownloadLink is a one-time link to a purchased track's master file
+type DownloadLink struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// PurchaseService gives auction winners access to the tracks they bought.
+// Masters are delivered through one-time links: redeeming a link marks it
+// used and hands out a short-lived storage URL, so a leaked link is worthless
+// once the owner has downloaded the file. Links are only issued once the
+// winning bid's payment has been captured.
+type PurchaseService struct {
+	ownershipRepo repositories.OwnershipRepository
+	trackService  *TrackService
+	config        config.MediaConfig
+}
+
+// NewPurchaseService creates a new purchase service
+func NewPurchaseService(ownershipRepo repositories.OwnershipRepository, trackService *TrackService, cfg config.MediaConfig) *PurchaseService {
+	return &PurchaseService{
+		ownershipRepo: ownershipRepo,
+		trackService:  trackService,
+		config:        cfg,
+	}
+}
+
+// ListPurchases retrieves the tracks a user has bought, most recent first
+func (s *PurchaseService) ListPurchases(ctx context.Context, userID int, limit, offset int) ([]*models.TrackOwnership, error) {
+	limit, offset = normalizePagination(limit, offset)
+
+	purchases, err := s.ownershipRepo.ListByOwner(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchases: %w", err)
+	}
+	return purchases, nil
+}
+
+// CreateDownload issues a one-time download link for one of a user's purchases
+func (s *PurchaseService) CreateDownload(ctx context.Context, userID, purchaseID int) (*DownloadLink, error) {
+	ownership, err := s.ownershipRepo.GetByID(ctx, purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase: %w", err)
+	}
+	if ownership == nil || ownership.OwnerID != userID {
+		return nil, ErrPurchaseNotFound
+	}
+	if err := s.trackService.checkPaid(ctx, ownership); err != nil {
+		return nil, err
+	}
+
+	track, err := s.trackService.GetTrack(ctx, ownership.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.trackService.storage.Key(track.FileURL); !ok {
+		return nil, ErrTrackAudioUnavailable
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate download token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	expiresAt := time.Now().Add(time.Duration(s.config.DownloadURLExpiry) * time.Second)
+	if err := s.ownershipRepo.CreateDownload(ctx, ownership.ID, hashDownloadToken(token), expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to create download: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"purchase_id": ownership.ID,
+		"track_id":    ownership.TrackID,
+		"user_id":     userID,
+	}).Info("Download link issued")
+
+	return &DownloadLink{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// RedeemDownload uses up a download link and returns a short-lived presigned
+// URL for the purchased track's master
+func (s *PurchaseService) RedeemDownload(ctx context.Context, token string) (*PresignedRequest, error) {
+	ownership, err := s.ownershipRepo.RedeemDownload(ctx, hashDownloadToken(token), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem download: %w", err)
+	}
+	if ownership == nil {
+		return nil, ErrInvalidDownloadToken
+	}
+
+	track, err := s.trackService.GetTrack(ctx, ownership.TrackID)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"purchase_id": ownership.ID,
+		"track_id":    ownership.TrackID,
+		"user_id":     ownership.OwnerID,
+	}).Info("Download link redeemed")
+
+	return s.trackService.masterURL(ctx, track)
+}
+
+// hashDownloadToken returns the hash a download token is stored under
+func hashDownloadToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,8 +2,10 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 	"time"
 
@@ -11,12 +13,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/sirupsen/logrus"
 )
 
-// S3Service handles AWS S3 operations
+// S3Service stores objects in an AWS S3 bucket
 type S3Service struct {
 	client  *s3.Client
+	presign *s3.PresignClient
 	bucket  string
 	region  string
 	baseURL string
@@ -39,6 +43,7 @@ func NewS3Service(region, bucket, accessKeyID, secretAccessKey, baseURL string,
 
 	return &S3Service{
 		client:  client,
+		presign: s3.NewPresignClient(client),
 		bucket:  bucket,
 		region:  region,
 		baseURL: baseURL,
@@ -46,89 +51,203 @@ func NewS3Service(region, bucket, accessKeyID, secretAccessKey, baseURL string,
 	}, nil
 }
 
-// UploadProfileImage uploads a profile image to S3 and returns the URL
-func (s *S3Service) UploadProfileImage(ctx context.Context, userID int, imageData io.Reader, contentType string) (string, error) {
-	// Generate unique filename
-	timestamp := time.Now().Unix()
-	filename := fmt.Sprintf("profiles/%d/profile_%d_%d%s", userID, userID, timestamp, getFileExtension(contentType))
-
-	// Upload to S3
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(filename),
-		Body:        imageData,
-		ContentType: aws.String(contentType),
-		ACL:         "public-read", // Make the image publicly accessible
-	})
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to upload profile image to S3")
-		return "", fmt.Errorf("failed to upload image: %w", err)
+// Put uploads an object. Public objects get a public-read ACL.
+func (s *S3Service) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string, public bool) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	}
+	if public {
+		input.ACL = types.ObjectCannedACLPublicRead
 	}
 
-	// Generate public URL
-	imageURL := fmt.Sprintf("%s/%s", s.baseURL, filename)
+	_, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		s.logger.WithError(err).WithField("key", key).Error("Failed to upload object to S3")
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
 
 	s.logger.WithFields(logrus.Fields{
-		"user_id":  userID,
-		"filename": filename,
-		"url":      imageURL,
-	}).Info("Profile image uploaded successfully")
+		"key":  key,
+		"size": size,
+	}).Info("Object uploaded to S3 successfully")
+
+	return nil
+}
+
+// PresignPut issues a presigned PUT URL for key. The content type and
+// length are part of the signature, so S3 rejects an upload of any other
+// type or size. Public objects are uploaded with a public-read ACL.
+func (s *S3Service) PresignPut(ctx context.Context, key, contentType string, size int64, public bool, expires time.Duration) (*PresignedRequest, error) {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}
+	if public {
+		input.ACL = types.ObjectCannedACLPublicRead
+	}
+
+	req, err := s.presign.PresignPutObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
+		s.logger.WithError(err).WithField("key", key).Error("Failed to presign S3 upload")
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	return newPresignedRequest(req.URL, req.Method, req.SignedHeader, expires), nil
+}
+
+// PresignGet issues a short-lived presigned GET URL for a private object
+func (s *S3Service) PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		s.logger.WithError(err).WithField("key", key).Error("Failed to presign S3 download")
+		return nil, fmt.Errorf("failed to presign download: %w", err)
+	}
+
+	return newPresignedRequest(req.URL, req.Method, req.SignedHeader, expires), nil
+}
+
+// newPresignedRequest builds a PresignedRequest, leaving out the Host header
+// that HTTP clients set themselves
+func newPresignedRequest(url, method string, signed http.Header, expires time.Duration) *PresignedRequest {
+	headers := make(map[string]string, len(signed))
+	for name := range signed {
+		if name != "Host" {
+			headers[name] = signed.Get(name)
+		}
+	}
+
+	return &PresignedRequest{
+		URL:       url,
+		Method:    method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(expires),
+	}
+}
 
-	return imageURL, nil
+// Stat returns the size and content type of an object, or
+// ErrObjectNotFound if it has not been uploaded
+func (s *S3Service) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrObjectNotFound
+		}
+		s.logger.WithError(err).WithField("key", key).Error("Failed to stat S3 object")
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return &ObjectInfo{
+		Size:        aws.ToInt64(out.ContentLength),
+		ContentType: aws.ToString(out.ContentType),
+	}, nil
 }
 
-// DeleteProfileImage deletes a profile image from S3
-func (s *S3Service) DeleteProfileImage(ctx context.Context, imageURL string) error {
-	// Extract key from URL
-	key := strings.TrimPrefix(imageURL, s.baseURL+"/")
+// Open returns random access to an object through ranged GET requests,
+// so a file's headers can be inspected without downloading all of it
+func (s *S3Service) Open(ctx context.Context, key string) io.ReaderAt {
+	return &objectReader{ctx: ctx, service: s, key: key}
+}
 
+// Delete deletes an object by key
+func (s *S3Service) Delete(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		s.logger.WithError(err).WithField("key", key).Error("Failed to delete profile image from S3")
-		return fmt.Errorf("failed to delete image: %w", err)
+		s.logger.WithError(err).WithField("key", key).Error("Failed to delete S3 object")
+		return fmt.Errorf("failed to delete object: %w", err)
 	}
 
-	s.logger.WithField("key", key).Info("Profile image deleted successfully")
+	s.logger.WithField("key", key).Info("S3 object deleted successfully")
 	return nil
 }
 
-// getFileExtension returns the appropriate file extension based on content type
-func getFileExtension(contentType string) string {
-	switch contentType {
-	case "image/jpeg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/gif":
-		return ".gif"
-	case "image/webp":
-		return ".webp"
-	default:
-		return ".jpg" // Default to jpg
-	}
+// URL returns the URL of an object in this bucket
+func (s *S3Service) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, key)
 }
 
-// ValidateImageType checks if the content type is a valid image type
-func (s *S3Service) ValidateImageType(contentType string) bool {
-	validTypes := []string{
-		"image/jpeg",
-		"image/png",
-		"image/gif",
-		"image/webp",
-	}
+// Key returns the key of an object URL served from this bucket.
+// It returns false for URLs that point elsewhere.
+func (s *S3Service) Key(objectURL string) (string, bool) {
+	key := strings.TrimPrefix(objectURL, s.baseURL+"/")
+	return key, key != objectURL && key != ""
+}
+
+// objectReadAhead is the smallest range fetched per GET, so the many small
+// reads of header parsing are served from one request
+const objectReadAhead = 64 << 10
+
+// objectReader implements io.ReaderAt over an S3 object with ranged GETs.
+// It keeps the last range fetched and is not safe for concurrent use.
+type objectReader struct {
+	ctx     context.Context
+	service *S3Service
+	key     string
 
-	for _, validType := range validTypes {
-		if contentType == validType {
-			return true
+	buf    []byte
+	bufOff int64
+	eof    bool // buf runs to the end of the object
+}
+
+// ReadAt reads len(p) bytes starting at off
+func (r *objectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < r.bufOff || off+int64(len(p)) > r.bufOff+int64(len(r.buf)) {
+		if !r.eof || off < r.bufOff {
+			if err := r.fill(off, max(len(p), objectReadAhead)); err != nil {
+				return 0, err
+			}
 		}
 	}
-	return false
+
+	if off < r.bufOff || off >= r.bufOff+int64(len(r.buf)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.buf[off-r.bufOff:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
 }
 
-// GetImageURL generates the full URL for an image key
-func (s *S3Service) GetImageURL(key string) string {
-	return fmt.Sprintf("%s/%s", s.baseURL, key)
+// fill fetches up to n bytes of the object starting at off
+func (r *objectReader) fill(off int64, n int) error {
+	out, err := r.service.client.GetObject(r.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.service.bucket),
+		Key:    aws.String(r.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", off, off+int64(n)-1)),
+	})
+	if err != nil {
+		var apiErr interface{ ErrorCode() string }
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+			// Past the end of the object
+			r.buf, r.bufOff, r.eof = nil, off, true
+			return nil
+		}
+		return fmt.Errorf("failed to read object range: %w", err)
+	}
+	defer out.Body.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(out.Body, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("failed to read object range: %w", err)
+	}
+
+	r.buf, r.bufOff, r.eof = buf[:read], off, read < n
+	return nil
 }
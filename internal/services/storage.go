@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"time"
+
+	"bagr-backend/internal/audio"
+)
+
+// ErrObjectNotFound is returned when a stored object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage stores media objects by key. S3Service keeps them in an S3 bucket
+// and LocalStorage on the local disk for development.
+type Storage interface {
+	// Put stores an object. Public objects can be read by anyone at URL(key);
+	// private ones only through PresignGet.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string, public bool) error
+	Delete(ctx context.Context, key string) error
+
+	// Stat returns an object's size and content type, or ErrObjectNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// Open returns random access to an object's contents
+	Open(ctx context.Context, key string) io.ReaderAt
+
+	// URL returns the URL of an object, and Key turns such a URL back into its
+	// key, returning false for URLs that belong to another store
+	URL(key string) string
+	Key(objectURL string) (string, bool)
+
+	// PresignPut issues a URL a client can upload an object to directly. The
+	// content type and size are part of the signature, so any other file is refused.
+	PresignPut(ctx context.Context, key, contentType string, size int64, public bool, expires time.Duration) (*PresignedRequest, error)
+
+	// PresignGet issues a short-lived download URL for a private object
+	PresignGet(ctx context.Context, key string, expires time.Duration) (*PresignedRequest, error)
+}
+
+// PresignedRequest is a signed request a client can send straight to storage.
+// The client must send every header in Headers unchanged.
+type PresignedRequest struct {
+	URL       string
+	Method    string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// ProfileImageKey generates a unique object key for a user's profile image
+func ProfileImageKey(userID int, contentType string) string {
+	return fmt.Sprintf("profiles/%d/profile_%d_%d%s", userID, userID, time.Now().UnixNano(), getFileExtension(contentType))
+}
+
+// TrackAudioKey generates a unique object key for an artist's track audio
+func TrackAudioKey(artistID int, contentType string) string {
+	return fmt.Sprintf("tracks/%d/track_%d_%d%s", artistID, artistID, time.Now().UnixNano(), getAudioExtension(contentType))
+}
+
+// ValidateImageType checks if the content type is a valid image type
+func ValidateImageType(contentType string) bool {
+	validTypes := []string{
+		"image/jpeg",
+		"image/png",
+		"image/gif",
+		"image/webp",
+	}
+
+	for _, validType := range validTypes {
+		if contentType == validType {
+			return true
+		}
+	}
+	return false
+}
+
+// getFileExtension returns the appropriate file extension based on content type
+func getFileExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".jpg" // Default to jpg
+	}
+}
+
+// getAudioExtension returns the file extension for a sniffed audio content type
+func getAudioExtension(contentType string) string {
+	switch contentType {
+	case audio.TypeMP3:
+		return ".mp3"
+	case audio.TypeWAV:
+		return ".wav"
+	case audio.TypeFLAC:
+		return ".flac"
+	default:
+		return ""
+	}
+}
+
+// contentTypeForKey returns the content type of an object from its key's
+// extension, the reverse of the key helpers above
+func contentTypeForKey(key string) string {
+	switch ext := path.Ext(key); ext {
+	case ".jpg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".mp3":
+		return audio.TypeMP3
+	case ".wav":
+		return audio.TypeWAV
+	case ".flac":
+		return audio.TypeFLAC
+	default:
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"bagr-backend/internal/audio"
+	"bagr-backend/internal/config"
+	"bagr-backend/internal/models"
+	"bagr-backend/internal/repositories"
+	"bagr-backend/internal/utils"
+)
+
+// Track service errors
+var (
+	ErrTrackNotFound     = errors.New("track not found")
+	ErrEmptySearchQuery  = errors.New("search query must contain at least one word")
+	ErrUnsupportedAudio  = errors.New("unsupported audio format, only MP3, WAV and FLAC are allowed")
+	ErrAudioTooLarge     = errors.New("audio file is too large")
+	ErrEmptyAudio        = errors.New("audio file is empty")
+	ErrInvalidAudio      = errors.New("audio file is corrupt or truncated")
+	ErrDurationMismatch  = errors.New("declared duration does not match the audio file")
+	ErrTrackAccessDenied = errors.New("only the track's artist and its owner can access its audio")
+
+	ErrAudioTypeMismatch     = errors.New("audio file does not match its declared content type")
+	ErrTrackAudioUnavailable = errors.New("track audio is not stored by this service")
+
+	ErrMissingTrackDetails = errors.New("title and genre are required when the audio file has no tags for them")
+
+	ErrTrackPublishDenied = errors.New("only the track's artist can publish it")
+	ErrTrackNotDraft      = errors.New("only draft tracks can be published")
+)
+
+// TrackService handles track business logic
+type TrackService struct {
+	trackRepo     repositories.TrackRepository
+	ownershipRepo repositories.OwnershipRepository
+	escrowRepo    repositories.EscrowRepository
+	storage       Storage
+	config        config.MediaConfig
+}
+
+// NewTrackService creates a new track service
+func NewTrackService(trackRepo repositories.TrackRepository, ownershipRepo repositories.OwnershipRepository, escrowRepo repositories.EscrowRepository, storage Storage, cfg config.MediaConfig) *TrackService {
+	return &TrackService{
+		trackRepo:     trackRepo,
+		ownershipRepo: ownershipRepo,
+		escrowRepo:    escrowRepo,
+		storage:       storage,
+		config:        cfg,
+	}
+}
+
+// MaxAudioSize returns the largest accepted audio upload in bytes
+func (s *TrackService) MaxAudioSize() int64 {
+	return int64(s.config.MaxAudioSizeMB) << 20
+}
+
+// durationTolerance is how far, in seconds, a declared duration may be off from the file's
+const durationTolerance = 2
+
+// AudioFile is an uploaded audio file that supports random access for metadata parsing
+type AudioFile interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// UploadTrack stores an artist's audio file and creates its track as a draft.
+// The format, duration and stream details are read from the file itself; the
+// declared content type is ignored, a declared duration must agree with the
+// file's, and missing title and genre fall back to the file's tags.
+func (s *TrackService) UploadTrack(ctx context.Context, artistID int, req *models.UploadTrackRequest, file AudioFile, size int64) (*models.Track, error) {
+	track, err := s.newTrackFromAudio(artistID, req, file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind audio: %w", err)
+	}
+	// Track audio stays private; listeners get presigned URLs
+	key := TrackAudioKey(artistID, *track.ContentType)
+	if err := s.storage.Put(ctx, key, file, size, *track.ContentType, false); err != nil {
+		return nil, fmt.Errorf("failed to store audio: %w", err)
+	}
+
+	track.FileURL = s.storage.URL(key)
+	if err := s.trackRepo.Create(ctx, track); err != nil {
+		// Don't leave an orphaned object behind
+		if delErr := s.storage.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			utils.GetLogger().WithError(delErr).WithField("key", key).Error("Failed to clean up track audio")
+		}
+		return nil, fmt.Errorf("failed to create track: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"track_id":     track.ID,
+		"artist_id":    artistID,
+		"content_type": *track.ContentType,
+		"duration":     track.Duration,
+	}).Info("Track uploaded successfully")
+
+	return track, nil
+}
+
+// CreateTrackFromObject creates a draft track from audio the artist uploaded
+// straight to storage under key. The object is checked like an UploadTrack file,
+// and must also be in the format it was declared as when the upload URL was issued.
+// The caller owns the object and deletes it if the track is not created.
+func (s *TrackService) CreateTrackFromObject(ctx context.Context, artistID int, req *models.UploadTrackRequest, key, contentType string, size int64) (*models.Track, error) {
+	track, err := s.newTrackFromAudio(artistID, req, s.storage.Open(ctx, key), size)
+	if err != nil {
+		return nil, err
+	}
+	if *track.ContentType != contentType {
+		return nil, fmt.Errorf("%w: file is %s, declared as %s", ErrAudioTypeMismatch, *track.ContentType, contentType)
+	}
+
+	track.FileURL = s.storage.URL(key)
+	if err := s.trackRepo.Create(ctx, track); err != nil {
+		return nil, fmt.Errorf("failed to create track: %w", err)
+	}
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"track_id":     track.ID,
+		"artist_id":    artistID,
+		"content_type": contentType,
+		"duration":     track.Duration,
+	}).Info("Track created from direct upload")
+
+	return track, nil
+}
+
+// newTrackFromAudio reads an audio file's metadata and builds the draft track
+// for it, without a file URL
+func (s *TrackService) newTrackFromAudio(artistID int, req *models.UploadTrackRequest, file io.ReaderAt, size int64) (*models.Track, error) {
+	if size <= 0 {
+		return nil, ErrEmptyAudio
+	}
+	if size > s.MaxAudioSize() {
+		return nil, fmt.Errorf("%w: limit is %d MB", ErrAudioTooLarge, s.config.MaxAudioSizeMB)
+	}
+
+	meta, err := audio.Parse(file, size)
+	if err != nil {
+		switch {
+		case errors.Is(err, audio.ErrUnsupportedFormat):
+			return nil, ErrUnsupportedAudio
+		case errors.Is(err, audio.ErrMalformed):
+			return nil, ErrInvalidAudio
+		default:
+			return nil, fmt.Errorf("failed to read audio: %w", err)
+		}
+	}
+
+	duration := meta.DurationSeconds()
+	if req.Duration > 0 && math.Abs(float64(req.Duration)-meta.Duration) > durationTolerance {
+		return nil, fmt.Errorf("%w: declared %ds, file is %ds", ErrDurationMismatch, req.Duration, duration)
+	}
+
+	title, genre := req.Title, req.Genre
+	if title == "" {
+		title = meta.Title
+	}
+	if genre == "" {
+		genre = meta.Genre
+	}
+	if title == "" || genre == "" {
+		return nil, ErrMissingTrackDetails
+	}
+
+	return &models.Track{
+		ArtistID:    artistID,
+		Title:       title,
+		Genre:       genre,
+		Duration:    duration,
+		Description: req.Description,
+		Status:      models.TrackStatusDraft,
+		ContentType: &meta.ContentType,
+		Bitrate:     &meta.Bitrate,
+		SampleRate:  &meta.SampleRate,
+		Channels:    &meta.Channels,
+	}, nil
+}
+
+// AudioDownloadURL issues a short-lived presigned URL for a track's private
+// audio. Only the track's artist, admins and the user who bought it at
+// auction, once their payment is captured, may download the full master;
+// everyone else gets previews.
+func (s *TrackService) AudioDownloadURL(ctx context.Context, userID int, role string, trackID int) (*PresignedRequest, error) {
+	track, err := s.GetTrack(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+	if track.ArtistID != userID && role != string(models.UserRoleAdmin) {
+		ownership, err := s.ownershipRepo.GetByTrackAndOwner(ctx, trackID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check track ownership: %w", err)
+		}
+		if ownership == nil {
+			return nil, ErrTrackAccessDenied
+		}
+		if err := s.checkPaid(ctx, ownership); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.masterURL(ctx, track)
+}
+
+// checkPaid returns ErrPaymentRequired unless the payment escrowed for a
+// purchase has been captured
+func (s *TrackService) checkPaid(ctx context.Context, ownership *models.TrackOwnership) error {
+	escrow, err := s.escrowRepo.GetByAuctionID(ctx, ownership.AuctionID)
+	if err != nil {
+		return fmt.Errorf("failed to check purchase payment: %w", err)
+	}
+	if escrow == nil || !escrow.IsPaid() {
+		return ErrPaymentRequired
+	}
+	return nil
+}
+
+// masterURL issues a short-lived presigned URL for a track's master file
+func (s *TrackService) masterURL(ctx context.Context, track *models.Track) (*PresignedRequest, error) {
+	key, ok := s.storage.Key(track.FileURL)
+	if !ok {
+		return nil, ErrTrackAudioUnavailable
+	}
+
+	return s.storage.PresignGet(ctx, key, time.Duration(s.config.DownloadURLExpiry)*time.Second)
+}
+
+// GetTrack retrieves a track by ID
+func (s *TrackService) GetTrack(ctx context.Context, id int) (*models.Track, error) {
+	track, err := s.trackRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+	if track == nil || track.Status == models.TrackStatusDeleted {
+		return nil, ErrTrackNotFound
+	}
+	return track, nil
+}
+
+// PublishTrack makes an artist's draft track active, so it shows up in search.
+// Its audio must have been read when the track was created and still be in
+// storage; tracks whose audio lives elsewhere can't be published.
+func (s *TrackService) PublishTrack(ctx context.Context, artistID, trackID int) (*models.Track, error) {
+	track, err := s.GetTrack(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+	if track.ArtistID != artistID {
+		return nil, ErrTrackPublishDenied
+	}
+	if track.Status != models.TrackStatusDraft {
+		return nil, ErrTrackNotDraft
+	}
+
+	key, ok := s.storage.Key(track.FileURL)
+	if !ok || track.ContentType == nil {
+		return nil, ErrTrackAudioUnavailable
+	}
+	if _, err := s.storage.Stat(ctx, key); err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, ErrTrackAudioUnavailable
+		}
+		return nil, fmt.Errorf("failed to check track audio: %w", err)
+	}
+
+	published, err := s.trackRepo.Transition(ctx, trackID, models.TrackStatusDraft, models.TrackStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish track: %w", err)
+	}
+	if !published {
+		// Sold, deleted or published since we read it
+		return nil, ErrTrackNotDraft
+	}
+	track.Status = models.TrackStatusActive
+
+	utils.GetLogger().WithFields(map[string]interface{}{
+		"track_id":  trackID,
+		"artist_id": artistID,
+	}).Info("Track published")
+
+	return track, nil
+}
+
+// SearchTracks runs a full-text search over active tracks, most relevant first
+func (s *TrackService) SearchTracks(ctx context.Context, query string, filter models.TrackSearchFilter, limit, offset int) ([]*models.TrackSearchResult, error) {
+	if strings.IndexFunc(query, isSearchable) < 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	limit, offset = normalizePagination(limit, offset)
+
+	results, err := s.trackRepo.Search(ctx, query, filter, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+	return results, nil
+}
+
+// isSearchable reports whether a rune can be part of a search word
+func isSearchable(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
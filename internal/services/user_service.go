@@ -12,12 +12,20 @@ import (
 // UserService handles user business logic
 type UserService struct {
 	userRepo repositories.UserRepository
+	sessions TokenRevoker
 }
 
-// NewUserService creates a new user service
-func NewUserService(userRepo repositories.UserRepository) *UserService {
+// TokenRevoker ends every session of a user, such as auth.AuthService
+type TokenRevoker interface {
+	RevokeAllSessions(userID int, reason string) error
+}
+
+// NewUserService creates a new user service. Users who are suspended,
+// deactivated or deleted are logged out through sessions.
+func NewUserService(userRepo repositories.UserRepository, sessions TokenRevoker) *UserService {
 	return &UserService{
 		userRepo: userRepo,
+		sessions: sessions,
 	}
 }
 
@@ -145,6 +153,14 @@ func (s *UserService) UpdateUser(ctx context.Context, id int, req *models.Update
 		}
 	}
 
+	// Existing tokens of a user who can no longer log in must stop working too
+	if req.Status != nil && *req.Status != models.UserStatusActive {
+		if err := s.sessions.RevokeAllSessions(id, "status_"+string(*req.Status)); err != nil {
+			utils.GetLogger().WithError(err).WithField("user_id", id).Error("Failed to revoke sessions of deactivated user")
+			return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
 	// Return updated user
 	updatedUser, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
@@ -171,6 +187,11 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) error {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
+	if err := s.sessions.RevokeAllSessions(id, "user_deleted"); err != nil {
+		utils.GetLogger().WithError(err).WithField("user_id", id).Error("Failed to revoke sessions of deleted user")
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
 	utils.GetLogger().WithField("user_id", id).Info("User deleted successfully")
 	return nil
 }
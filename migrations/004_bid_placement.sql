@@ -0,0 +1,15 @@
+-- Migration: Support transactional bid placement
+-- Created: 2026-10-15
+-- Description: Adds bid update tracking and indexes used when resolving the leading bid
+
+ALTER TABLE bids ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT NOW();
+UPDATE bids SET updated_at = created_at WHERE updated_at IS NULL;
+
+-- Leading bid lookup: highest amount first, earliest bid wins ties
+CREATE INDEX IF NOT EXISTS idx_bids_auction_amount ON bids(auction_id, amount DESC, created_at ASC);
+
+-- Amounts must always be positive
+ALTER TABLE bids DROP CONSTRAINT IF EXISTS chk_bids_amount_positive;
+ALTER TABLE bids ADD CONSTRAINT chk_bids_amount_positive CHECK (amount > 0);
+
+COMMENT ON COLUMN bids.updated_at IS 'Last status change, e.g. when the bid was outbid';
@@ -0,0 +1,6 @@
+-- Migration: Support the auction lifecycle scheduler
+-- Created: 2026-10-15
+-- Description: Adds indexes used to find auctions due to open or close
+
+CREATE INDEX IF NOT EXISTS idx_auctions_status_start_time ON auctions(status, start_time);
+CREATE INDEX IF NOT EXISTS idx_auctions_status_end_time ON auctions(status, end_time);
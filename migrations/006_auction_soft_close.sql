@@ -0,0 +1,13 @@
+-- Migration: Anti-sniping soft close for auctions
+-- Created: 2026-10-15
+-- Description: Stores per-auction soft close settings and how often the end time was extended
+
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS soft_close_window_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS soft_close_extension_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS soft_close_max_extensions INTEGER;
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS extension_count INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN auctions.soft_close_window_minutes IS 'Bids within this many minutes of end_time extend the auction (0 disables soft close)';
+COMMENT ON COLUMN auctions.soft_close_extension_minutes IS 'Minutes added to end_time for each late bid';
+COMMENT ON COLUMN auctions.soft_close_max_extensions IS 'Maximum number of extensions, NULL for no cap';
+COMMENT ON COLUMN auctions.extension_count IS 'Number of times end_time has been extended';
@@ -0,0 +1,20 @@
+-- Migration: Proxy (maximum) bidding
+-- Created: 2026-10-15
+-- Description: Stores hidden bidder ceilings and flags bids placed automatically on their behalf
+
+CREATE TABLE IF NOT EXISTS proxy_bids (
+    id SERIAL PRIMARY KEY,
+    auction_id INTEGER NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+    bidder_id INTEGER NOT NULL REFERENCES users(id),
+    max_amount DECIMAL(10,2) NOT NULL CHECK (max_amount > 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(auction_id, bidder_id)
+);
+
+ALTER TABLE bids ADD COLUMN IF NOT EXISTS is_automatic BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_proxy_bids_auction_id ON proxy_bids(auction_id);
+
+COMMENT ON TABLE proxy_bids IS 'Hidden maximum bids used by the proxy bidding engine';
+COMMENT ON COLUMN bids.is_automatic IS 'Whether the bid was placed by the proxy bidding engine';
@@ -0,0 +1,7 @@
+-- Migration: Per-auction bid increment ladders
+-- Created: 2026-10-15
+-- Description: Lets an auction override the server's bid increment ladder
+
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS bid_increments JSONB;
+
+COMMENT ON COLUMN auctions.bid_increments IS 'Bid increment ladder as [{"up_to": 20, "increment": 1}, ...]; NULL uses the server default';
@@ -0,0 +1,16 @@
+-- Migration: Track full-text search
+-- Created: 2026-10-15
+-- Description: Adds a weighted tsvector over track title, genre and description for search
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(genre, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_search_vector ON tracks USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_tracks_genre_lower ON tracks(lower(genre));
+CREATE INDEX IF NOT EXISTS idx_tracks_status ON tracks(status);
+
+COMMENT ON COLUMN tracks.search_vector IS 'Full-text search vector: title (A), genre (B), description (C)';
@@ -0,0 +1,13 @@
+-- Migration: Track audio metadata
+-- Created: 2026-10-15
+-- Description: Stores technical metadata read from uploaded audio files
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS content_type VARCHAR(50);
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS bitrate INTEGER;
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS sample_rate INTEGER;
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS channels SMALLINT;
+
+COMMENT ON COLUMN tracks.content_type IS 'Detected audio format (audio/mpeg, audio/wav, audio/flac)';
+COMMENT ON COLUMN tracks.bitrate IS 'Average bitrate in bits per second';
+COMMENT ON COLUMN tracks.sample_rate IS 'Sample rate in Hz';
+COMMENT ON COLUMN tracks.channels IS 'Number of audio channels';
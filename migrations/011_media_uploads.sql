@@ -0,0 +1,26 @@
+-- Migration: Direct media uploads
+-- Created: 2026-10-15
+-- Description: Tracks presigned S3 uploads from the time a URL is issued until the object is attached
+
+CREATE TABLE IF NOT EXISTS media_uploads (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('track_audio', 'profile_image')),
+    object_key VARCHAR(500) NOT NULL UNIQUE,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'rejected')),
+    track_id INTEGER REFERENCES tracks(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_uploads_user_id ON media_uploads(user_id);
+CREATE INDEX IF NOT EXISTS idx_media_uploads_status ON media_uploads(status);
+
+COMMENT ON TABLE media_uploads IS 'Presigned direct-to-S3 uploads awaiting or past completion';
+COMMENT ON COLUMN media_uploads.size_bytes IS 'Declared size, signed into the upload URL';
+COMMENT ON COLUMN media_uploads.expires_at IS 'When the presigned upload URL stops working';
+COMMENT ON COLUMN media_uploads.track_id IS 'Track created from a completed audio upload';
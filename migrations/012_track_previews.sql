@@ -0,0 +1,6 @@
+-- Migration: Track previews
+-- Created: 2026-10-15
+-- Description: Indexes the lookups that decide who may preview or download a track's audio
+
+CREATE INDEX IF NOT EXISTS idx_auctions_track_status ON auctions(track_id, status);
+CREATE INDEX IF NOT EXISTS idx_bids_bidder_status ON bids(bidder_id, status);
@@ -0,0 +1,34 @@
+-- Migration: Track ownerships
+-- Created: 2026-10-15
+-- Description: Records who owns a track after winning its auction, and the one-time download links issued to them
+
+CREATE TABLE IF NOT EXISTS track_ownerships (
+    id SERIAL PRIMARY KEY,
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    auction_id INTEGER NOT NULL UNIQUE REFERENCES auctions(id) ON DELETE CASCADE,
+    bid_id INTEGER NOT NULL UNIQUE REFERENCES bids(id) ON DELETE CASCADE,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seller_id INTEGER NOT NULL REFERENCES users(id),
+    price DECIMAL(10,2) NOT NULL,
+    acquired_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_ownerships_owner_id ON track_ownerships(owner_id, acquired_at DESC);
+CREATE INDEX IF NOT EXISTS idx_track_ownerships_track_id ON track_ownerships(track_id);
+
+CREATE TABLE IF NOT EXISTS track_downloads (
+    id SERIAL PRIMARY KEY,
+    ownership_id INTEGER NOT NULL REFERENCES track_ownerships(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_downloads_ownership_id ON track_downloads(ownership_id);
+
+COMMENT ON TABLE track_ownerships IS 'Rights to a track transferred to the winner of its auction';
+COMMENT ON COLUMN track_ownerships.price IS 'Winning bid amount';
+COMMENT ON TABLE track_downloads IS 'One-time master download links issued to track owners';
+COMMENT ON COLUMN track_downloads.token_hash IS 'SHA-256 of the download token; the token itself is never stored';
+COMMENT ON COLUMN track_downloads.used_at IS 'When the link was redeemed; a link can only be redeemed once';
@@ -0,0 +1,52 @@
+-- Migration: Payment escrow
+-- Created: 2026-10-15
+-- Description: Holds the winning bid of every completed auction in escrow until the buyer confirms delivery
+
+CREATE TABLE IF NOT EXISTS payment_methods (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    method_ref VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS escrows (
+    id SERIAL PRIMARY KEY,
+    auction_id INTEGER NOT NULL UNIQUE REFERENCES auctions(id) ON DELETE CASCADE,
+    bid_id INTEGER NOT NULL UNIQUE REFERENCES bids(id) ON DELETE CASCADE,
+    buyer_id INTEGER NOT NULL REFERENCES users(id),
+    seller_id INTEGER NOT NULL REFERENCES users(id),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'captured', 'released', 'refunded', 'disputed', 'failed')),
+    provider VARCHAR(20),
+    payment_ref VARCHAR(255),
+    failure_reason TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    captured_at TIMESTAMP,
+    released_at TIMESTAMP,
+    refunded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrows_status ON escrows(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_escrows_buyer_id ON escrows(buyer_id);
+CREATE INDEX IF NOT EXISTS idx_escrows_seller_id ON escrows(seller_id);
+CREATE INDEX IF NOT EXISTS idx_escrows_payment_ref ON escrows(payment_ref);
+
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    provider VARCHAR(20) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    received_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (provider, event_id)
+);
+
+COMMENT ON TABLE payment_methods IS 'Payment method charged when the user wins an auction';
+COMMENT ON TABLE escrows IS 'Winning bid payments held by the platform until delivery is confirmed';
+COMMENT ON COLUMN escrows.status IS 'pending: awaiting capture; captured: funds held; released: owed to the seller; refunded: returned to the buyer';
+COMMENT ON COLUMN escrows.payment_ref IS 'Provider payment ID, set once the payment has been authorized';
+COMMENT ON COLUMN escrows.attempts IS 'Capture attempts made, including the one in progress';
+COMMENT ON TABLE payment_webhook_events IS 'Provider webhook events already handled, so redeliveries are ignored';
@@ -0,0 +1,88 @@
+-- Migration: Double-entry ledger
+-- Created: 2026-10-15
+-- Description: Records buyer charges, platform commission, seller earnings, refunds and payouts as balanced journal entries
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('asset', 'liability', 'revenue')),
+    user_id INTEGER REFERENCES users(id),
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_code
+    ON ledger_accounts(code, COALESCE(user_id, 0), currency);
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_user_id ON ledger_accounts(user_id);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('charge', 'release', 'refund', 'payout')),
+    reference VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    escrow_id INTEGER REFERENCES escrows(id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES ledger_entries(id),
+    account_id INTEGER NOT NULL REFERENCES ledger_accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    currency CHAR(3) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry_id ON ledger_postings(entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_id ON ledger_postings(account_id, entry_id);
+
+-- Every entry must balance to zero in each currency. The check is deferred
+-- to commit so an entry's postings can be inserted one at a time.
+CREATE OR REPLACE FUNCTION check_ledger_entry_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM ledger_postings
+        WHERE entry_id = NEW.entry_id
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger entry % does not balance', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS ledger_entry_balanced ON ledger_postings;
+CREATE CONSTRAINT TRIGGER ledger_entry_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    EXECUTE FUNCTION check_ledger_entry_balanced();
+
+-- The ledger is append-only: mistakes are corrected with new entries
+CREATE OR REPLACE FUNCTION reject_ledger_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS ledger_entries_append_only ON ledger_entries;
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_ledger_change();
+
+DROP TRIGGER IF EXISTS ledger_postings_append_only ON ledger_postings;
+CREATE TRIGGER ledger_postings_append_only
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW
+    EXECUTE FUNCTION reject_ledger_change();
+
+COMMENT ON TABLE ledger_accounts IS 'Ledger accounts: platform cash and commission, funds held in escrow and each seller''s earnings';
+COMMENT ON COLUMN ledger_accounts.code IS 'platform_cash, escrow_holding, platform_commission or seller_payable (with user_id)';
+COMMENT ON TABLE ledger_entries IS 'Journal entries; each one is a single money movement';
+COMMENT ON COLUMN ledger_entries.reference IS 'Identifies the event recorded, so it can only be recorded once';
+COMMENT ON TABLE ledger_postings IS 'Entry lines; the postings of an entry sum to zero in each currency';
+COMMENT ON COLUMN ledger_postings.amount IS 'Minor units (cents); positive debits, negative credits';
@@ -0,0 +1,18 @@
+-- Migration: Money currency
+-- Created: 2026-10-15
+-- Description: Records the currency of every stored amount. Amounts stay DECIMAL(10,2) and are read and written as exact cents; existing rows are USD
+
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE bids ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE proxy_bids ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE track_ownerships ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Bids are placed in their auction's currency
+UPDATE bids b SET currency = a.currency FROM auctions a WHERE a.id = b.auction_id AND b.currency <> a.currency;
+UPDATE proxy_bids p SET currency = a.currency FROM auctions a WHERE a.id = p.auction_id AND p.currency <> a.currency;
+UPDATE track_ownerships o SET currency = a.currency FROM auctions a WHERE a.id = o.auction_id AND o.currency <> a.currency;
+
+COMMENT ON COLUMN auctions.currency IS 'ISO 4217 code of the start price, reserve price, current bid and every bid';
+COMMENT ON COLUMN bids.currency IS 'ISO 4217 code of the amount, always the auction currency';
+COMMENT ON COLUMN proxy_bids.currency IS 'ISO 4217 code of the max amount, always the auction currency';
+COMMENT ON COLUMN track_ownerships.currency IS 'ISO 4217 code of the price paid';
@@ -0,0 +1,13 @@
+-- Migration: Exchange rates
+-- Created: 2026-10-15
+-- Description: Stores the exchange rate table used to show auction amounts in a buyer's preferred currency
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency CHAR(3) PRIMARY KEY,
+    base_currency CHAR(3) NOT NULL,
+    rate NUMERIC(24,10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE exchange_rates IS 'Display-only exchange rates; bids and payments are never converted';
+COMMENT ON COLUMN exchange_rates.rate IS 'Units of currency worth one unit of base_currency; the base currency itself has a rate of 1';
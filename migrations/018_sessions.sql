@@ -0,0 +1,22 @@
+-- Migration: Sessions
+-- Created: 2026-10-15
+-- Description: Tracks refresh tokens so they can be rotated on every refresh, revoked on reuse and deleted on logout
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+COMMENT ON TABLE sessions IS 'One row per refresh token; the rows of a family make up one login session';
+COMMENT ON COLUMN sessions.id IS 'The refresh token''s jti claim';
+COMMENT ON COLUMN sessions.family_id IS 'The sid claim shared by every token rotated from the same login';
+COMMENT ON COLUMN sessions.rotated_at IS 'Set when the token is exchanged; using it again revokes the whole family';
+COMMENT ON COLUMN sessions.revoked_at IS 'Set on every token of a family when reuse is detected';
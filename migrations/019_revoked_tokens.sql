@@ -0,0 +1,21 @@
+-- Migration: Revoked tokens
+-- Created: 2026-10-15
+-- Description: Denylist of revoked access tokens, checked on every authenticated request
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS access_token_id VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS access_expires_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    revoked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_revoked_at ON revoked_tokens(revoked_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+COMMENT ON COLUMN sessions.access_token_id IS 'jti of the access token issued with this refresh token, so it can be revoked with the session';
+COMMENT ON TABLE revoked_tokens IS 'Access tokens refused until they expire; rows are deleted once expired';
+COMMENT ON COLUMN revoked_tokens.reason IS 'Why the token was revoked, e.g. logout, logout_all, password_reset, status_suspended';
@@ -0,0 +1,38 @@
+-- Migration: Two-factor authentication
+-- Created: 2026-10-15
+-- Description: TOTP enrollment, hashed recovery codes, login challenges and per-role 2FA requirements
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS mfa_required_roles (
+    role VARCHAR(20) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_mfa_challenges_user_id ON mfa_challenges(user_id);
+
+COMMENT ON COLUMN users.totp_secret IS 'Base32 TOTP secret; set when setup starts and only used once totp_enabled';
+COMMENT ON COLUMN users.totp_last_step IS 'Newest TOTP time step accepted, so a code cannot be replayed';
+COMMENT ON TABLE recovery_codes IS 'Single-use 2FA recovery codes, bcrypt hashed';
+COMMENT ON TABLE mfa_challenges IS 'Logins waiting for a second factor; id is the SHA-256 of the challenge token';
+COMMENT ON TABLE mfa_required_roles IS 'Roles whose users must use two-factor authentication';
@@ -0,0 +1,33 @@
+-- Migration: Passkeys
+-- Created: 2026-10-16
+-- Description: WebAuthn credentials, single-use ceremony challenges and per-user WebAuthn user handles
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS webauthn_user_id BYTEA UNIQUE;
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    algorithm INTEGER NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+    challenge VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    ceremony VARCHAR(20) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_expires_at ON webauthn_challenges(expires_at);
+
+COMMENT ON COLUMN users.webauthn_user_id IS 'Random WebAuthn user handle given to authenticators instead of the user ID';
+COMMENT ON TABLE webauthn_credentials IS 'Registered passkeys: credential ID, COSE public key and signature counter';
+COMMENT ON COLUMN webauthn_credentials.algorithm IS 'COSE algorithm of the public key (-7 ES256, -8 EdDSA, -257 RS256)';
+COMMENT ON TABLE webauthn_challenges IS 'Outstanding registration and login challenges; deleted when answered';
@@ -0,0 +1,44 @@
+-- Migration: Social login
+-- Created: 2026-10-16
+-- Description: Provider accounts linked to users, pending OpenID Connect authorization requests and pending links
+
+CREATE TABLE IF NOT EXISTS external_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+CREATE TABLE IF NOT EXISTS oidc_states (
+    state_hash VARCHAR(64) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    code_verifier VARCHAR(64) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS oidc_pending_links (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_states_expires_at ON oidc_states(expires_at);
+CREATE INDEX IF NOT EXISTS idx_oidc_pending_links_expires_at ON oidc_pending_links(expires_at);
+
+COMMENT ON TABLE external_identities IS 'Login provider accounts linked to users; subject is the provider''s stable user ID';
+COMMENT ON TABLE oidc_states IS 'Authorization requests waiting for their callback; state_hash is the SHA-256 of the state parameter';
+COMMENT ON COLUMN oidc_states.user_id IS 'Set when a logged-in user is linking a provider rather than logging in';
+COMMENT ON TABLE oidc_pending_links IS 'Provider accounts waiting for the linking user to confirm the link code';
@@ -0,0 +1,20 @@
+-- Migration: Login throttling
+-- Created: 2026-10-16
+-- Description: Failed password logins per account and per IP address, for backoff and temporary lockouts
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('account', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    lockouts INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts(last_failure_at);
+
+COMMENT ON TABLE login_attempts IS 'Failed password logins; rows are deleted on a successful login to the account or once long quiet';
+COMMENT ON COLUMN login_attempts.key IS 'Lowercased email for account rows, whether or not a user has it, so lockouts do not reveal which accounts exist; client IP for ip rows';
+COMMENT ON COLUMN login_attempts.failures IS 'Failures since the last lockout within the failure window';
+COMMENT ON COLUMN login_attempts.lockouts IS 'Lockouts in a row; each one doubles the next lockout''s duration';
@@ -0,0 +1,9 @@
+-- Migration: Auction close failures
+-- Created: 2026-10-16
+-- Description: Records auctions the lifecycle worker failed to close, so they are retried after the others
+
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS close_failed_at TIMESTAMP;
+ALTER TABLE auctions ADD COLUMN IF NOT EXISTS close_error TEXT;
+
+COMMENT ON COLUMN auctions.close_failed_at IS 'Last time closing the ended auction failed; such auctions are closed after the ones that have not failed, NULL once closed';
+COMMENT ON COLUMN auctions.close_error IS 'Error of the last failed close, NULL once closed';